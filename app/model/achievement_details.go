@@ -0,0 +1,327 @@
+package model
+
+import (
+	"encoding/json"
+	"fmt"
+	"reflect"
+	"sort"
+	"strings"
+	"time"
+)
+
+// Kategori achievement yang punya schema details sendiri
+const (
+	AchievementTypeCompetition      = "competition"
+	AchievementTypePublication      = "publication"
+	AchievementTypeOrganization     = "organization"
+	AchievementTypeCertification    = "certification"
+	AchievementTypeCommunityService = "community_service"
+	AchievementTypeInternship       = "internship"
+)
+
+var AchievementTypes = []string{
+	AchievementTypeCompetition,
+	AchievementTypePublication,
+	AchievementTypeOrganization,
+	AchievementTypeCertification,
+	AchievementTypeCommunityService,
+	AchievementTypeInternship,
+}
+
+// Enum competitionLevel dan rank (dipakai juga sebagai key rubrik)
+var (
+	CompetitionLevels = []string{"international", "national", "regional", "local"}
+	CompetitionRanks  = []string{"first", "second", "third", "honorable_mention", "finalist", "participant"}
+	MedalTypes        = []string{"gold", "silver", "bronze"}
+	PublicationTypes  = []string{"journal", "conference", "book"}
+)
+
+const dateLayout = "2006-01-02"
+
+// ValidationErrors: pesan error per field, key = path field (contoh "details.year")
+type ValidationErrors map[string]string
+
+func (v ValidationErrors) Error() string {
+	keys := make([]string, 0, len(v))
+	for k := range v {
+		keys = append(keys, k)
+	}
+	sort.Strings(keys)
+	parts := make([]string, 0, len(keys))
+	for _, k := range keys {
+		parts = append(parts, k+": "+v[k])
+	}
+	return "validation failed: " + strings.Join(parts, "; ")
+}
+
+// AchievementDetails diimplementasikan oleh semua struct details bertipe
+type AchievementDetails interface {
+	Validate() ValidationErrors
+}
+
+// Field umum untuk semua kategori
+type CommonDetails struct {
+	Year      int    `json:"year" bson:"year"`
+	EventDate string `json:"eventDate,omitempty" bson:"eventDate,omitempty"`
+	Location  string `json:"location,omitempty" bson:"location,omitempty"`
+	Organizer string `json:"organizer,omitempty" bson:"organizer,omitempty"`
+}
+
+type CompetitionDetails struct {
+	CommonDetails    `bson:",inline"`
+	CompetitionName  string `json:"competitionName" bson:"competitionName"`
+	CompetitionLevel string `json:"competitionLevel" bson:"competitionLevel"`
+	Rank             string `json:"rank" bson:"rank"`
+	MedalType        string `json:"medalType,omitempty" bson:"medalType,omitempty"`
+}
+
+type PublicationDetails struct {
+	CommonDetails    `bson:",inline"`
+	PublicationType  string   `json:"publicationType" bson:"publicationType"`
+	PublicationTitle string   `json:"publicationTitle" bson:"publicationTitle"`
+	Authors          []string `json:"authors" bson:"authors"`
+	Publisher        string   `json:"publisher" bson:"publisher"`
+	ISSN             string   `json:"issn,omitempty" bson:"issn,omitempty"`
+}
+
+type OrganizationDetails struct {
+	CommonDetails    `bson:",inline"`
+	OrganizationName string `json:"organizationName" bson:"organizationName"`
+	Position         string `json:"position" bson:"position"`
+	PeriodStart      string `json:"periodStart" bson:"periodStart"`
+	PeriodEnd        string `json:"periodEnd,omitempty" bson:"periodEnd,omitempty"`
+}
+
+type CertificationDetails struct {
+	CommonDetails       `bson:",inline"`
+	CertificationName   string `json:"certificationName" bson:"certificationName"`
+	IssuedBy            string `json:"issuedBy" bson:"issuedBy"`
+	CertificationNumber string `json:"certificationNumber,omitempty" bson:"certificationNumber,omitempty"`
+	ValidUntil          string `json:"validUntil,omitempty" bson:"validUntil,omitempty"`
+}
+
+type CommunityServiceDetails struct {
+	CommonDetails `bson:",inline"`
+	ActivityName  string `json:"activityName" bson:"activityName"`
+	Role          string `json:"role" bson:"role"`
+	Hours         int    `json:"hours" bson:"hours"`
+}
+
+type InternshipDetails struct {
+	CommonDetails `bson:",inline"`
+	CompanyName   string `json:"companyName" bson:"companyName"`
+	Position      string `json:"position" bson:"position"`
+	StartDate     string `json:"startDate" bson:"startDate"`
+	EndDate       string `json:"endDate" bson:"endDate"`
+}
+
+// NewAchievementDetails mengembalikan struct kosong untuk tipe tertentu,
+// nil jika tipe tidak dikenal.
+func NewAchievementDetails(achievementType string) AchievementDetails {
+	switch achievementType {
+	case AchievementTypeCompetition:
+		return &CompetitionDetails{}
+	case AchievementTypePublication:
+		return &PublicationDetails{}
+	case AchievementTypeOrganization:
+		return &OrganizationDetails{}
+	case AchievementTypeCertification:
+		return &CertificationDetails{}
+	case AchievementTypeCommunityService:
+		return &CommunityServiceDetails{}
+	case AchievementTypeInternship:
+		return &InternshipDetails{}
+	}
+	return nil
+}
+
+// ParseAchievementDetails men-decode details mentah ke struct bertipe dan
+// memvalidasinya. Semua error dikumpulkan per field, bukan berhenti di yang pertama.
+func ParseAchievementDetails(achievementType string, raw map[string]interface{}) (AchievementDetails, ValidationErrors) {
+	details := NewAchievementDetails(achievementType)
+	if details == nil {
+		return nil, ValidationErrors{
+			"achievement_type": "must be one of " + strings.Join(AchievementTypes, ", "),
+		}
+	}
+	errs := ValidationErrors{}
+	decodeFields(raw, reflect.ValueOf(details).Elem(), errs)
+	for field, msg := range details.Validate() {
+		if _, exists := errs[field]; !exists {
+			errs[field] = msg
+		}
+	}
+	if len(errs) > 0 {
+		return nil, errs
+	}
+	return details, nil
+}
+
+// DetailsToMap mengubah struct details ke map untuk disimpan di Mongo / rubrik
+func DetailsToMap(details AchievementDetails) map[string]interface{} {
+	b, _ := json.Marshal(details)
+	out := map[string]interface{}{}
+	_ = json.Unmarshal(b, &out)
+	return out
+}
+
+// Decode per field supaya "year": "2024" jadi error di details.year
+func decodeFields(raw map[string]interface{}, dst reflect.Value, errs ValidationErrors) {
+	known := map[string]bool{}
+	collectFields(raw, dst, known, errs)
+	for k := range raw {
+		if !known[k] {
+			errs["details."+k] = "unknown field"
+		}
+	}
+}
+
+func collectFields(raw map[string]interface{}, dst reflect.Value, known map[string]bool, errs ValidationErrors) {
+	t := dst.Type()
+	for i := 0; i < t.NumField(); i++ {
+		f := t.Field(i)
+		if f.Anonymous {
+			collectFields(raw, dst.Field(i), known, errs)
+			continue
+		}
+		name := strings.Split(f.Tag.Get("json"), ",")[0]
+		known[name] = true
+		v, ok := raw[name]
+		if !ok || v == nil {
+			continue
+		}
+		b, _ := json.Marshal(v)
+		if err := json.Unmarshal(b, dst.Field(i).Addr().Interface()); err != nil {
+			errs["details."+name] = "must be " + kindName(f.Type)
+		}
+	}
+}
+
+func kindName(t reflect.Type) string {
+	switch t.Kind() {
+	case reflect.Int, reflect.Int64:
+		return "an integer"
+	case reflect.Slice:
+		return "a list of " + strings.TrimPrefix(kindName(t.Elem()), "a ") + "s"
+	}
+	return "a string"
+}
+
+func (d *CommonDetails) validate(errs ValidationErrors) {
+	if d.Year == 0 {
+		errs["details.year"] = "is required"
+	} else if d.Year < 1900 || d.Year > time.Now().Year()+1 {
+		errs["details.year"] = "is out of range"
+	}
+	checkDate(errs, "eventDate", d.EventDate)
+}
+
+func (d *CompetitionDetails) Validate() ValidationErrors {
+	errs := ValidationErrors{}
+	d.CommonDetails.validate(errs)
+	required(errs, "competitionName", d.CompetitionName)
+	oneOf(errs, "competitionLevel", d.CompetitionLevel, CompetitionLevels, true)
+	oneOf(errs, "rank", d.Rank, CompetitionRanks, true)
+	oneOf(errs, "medalType", d.MedalType, MedalTypes, false)
+	return errs
+}
+
+func (d *PublicationDetails) Validate() ValidationErrors {
+	errs := ValidationErrors{}
+	d.CommonDetails.validate(errs)
+	oneOf(errs, "publicationType", d.PublicationType, PublicationTypes, true)
+	required(errs, "publicationTitle", d.PublicationTitle)
+	required(errs, "publisher", d.Publisher)
+	if len(d.Authors) == 0 {
+		errs["details.authors"] = "is required"
+	}
+	return errs
+}
+
+func (d *OrganizationDetails) Validate() ValidationErrors {
+	errs := ValidationErrors{}
+	d.CommonDetails.validate(errs)
+	required(errs, "organizationName", d.OrganizationName)
+	required(errs, "position", d.Position)
+	required(errs, "periodStart", d.PeriodStart)
+	checkDate(errs, "periodStart", d.PeriodStart)
+	checkDate(errs, "periodEnd", d.PeriodEnd)
+	checkOrder(errs, "periodEnd", d.PeriodStart, d.PeriodEnd)
+	return errs
+}
+
+func (d *CertificationDetails) Validate() ValidationErrors {
+	errs := ValidationErrors{}
+	d.CommonDetails.validate(errs)
+	required(errs, "certificationName", d.CertificationName)
+	required(errs, "issuedBy", d.IssuedBy)
+	checkDate(errs, "validUntil", d.ValidUntil)
+	return errs
+}
+
+func (d *CommunityServiceDetails) Validate() ValidationErrors {
+	errs := ValidationErrors{}
+	d.CommonDetails.validate(errs)
+	required(errs, "activityName", d.ActivityName)
+	required(errs, "role", d.Role)
+	if d.Hours < 0 {
+		errs["details.hours"] = "must not be negative"
+	}
+	return errs
+}
+
+func (d *InternshipDetails) Validate() ValidationErrors {
+	errs := ValidationErrors{}
+	d.CommonDetails.validate(errs)
+	required(errs, "companyName", d.CompanyName)
+	required(errs, "position", d.Position)
+	required(errs, "startDate", d.StartDate)
+	required(errs, "endDate", d.EndDate)
+	checkDate(errs, "startDate", d.StartDate)
+	checkDate(errs, "endDate", d.EndDate)
+	checkOrder(errs, "endDate", d.StartDate, d.EndDate)
+	return errs
+}
+
+func required(errs ValidationErrors, field, value string) {
+	if strings.TrimSpace(value) == "" {
+		errs["details."+field] = "is required"
+	}
+}
+
+func oneOf(errs ValidationErrors, field, value string, allowed []string, isRequired bool) {
+	if value == "" {
+		if isRequired {
+			errs["details."+field] = "is required"
+		}
+		return
+	}
+	for _, a := range allowed {
+		if value == a {
+			return
+		}
+	}
+	errs["details."+field] = fmt.Sprintf("must be one of %s", strings.Join(allowed, ", "))
+}
+
+func checkDate(errs ValidationErrors, field, value string) {
+	if value == "" {
+		return
+	}
+	if _, err := time.Parse(dateLayout, value); err != nil {
+		errs["details."+field] = "must be a date (YYYY-MM-DD)"
+	}
+}
+
+// end tidak boleh sebelum start (format YYYY-MM-DD bisa dibandingkan sebagai string)
+func checkOrder(errs ValidationErrors, endField, start, end string) {
+	if start == "" || end == "" {
+		return
+	}
+	if _, exists := errs["details."+endField]; exists {
+		return
+	}
+	if end < start {
+		errs["details."+endField] = "must not be before the start date"
+	}
+}
@@ -9,6 +9,7 @@ const (
 	AchievementStatusSubmitted AchievementStatus = "submitted"
 	AchievementStatusVerified  AchievementStatus = "verified"
 	AchievementStatusRejected  AchievementStatus = "rejected"
+	AchievementStatusRevoked   AchievementStatus = "revoked"
 )
 
 type AchievementReference struct {
@@ -21,6 +22,13 @@ type AchievementReference struct {
 	VerifiedAt         *time.Time        `json:"verified_at,omitempty"`
 	VerifiedBy         *string           `gorm:"type:uuid" json:"verified_by,omitempty"`
 	RejectionNote      *string           `json:"rejection_note,omitempty"`
+	RevisionRound      int               `gorm:"not null;default:1" json:"revision_round"`
+	ClaimedPoints      *float64          `json:"claimed_points,omitempty"`
+	ApprovedPoints     *float64          `json:"approved_points,omitempty"`
+	VerificationRemark *string           `json:"verification_remarks,omitempty"`
+	RevokedAt          *time.Time        `json:"revoked_at,omitempty"`
+	RevokedBy          *int64            `json:"revoked_by,omitempty"`
+	RevocationReason   *string           `json:"revocation_reason,omitempty"`
 	CreatedAt          time.Time         `json:"created_at"`
 	UpdatedAt          time.Time         `json:"updated_at"`
-}
\ No newline at end of file
+}
@@ -0,0 +1,17 @@
+package model
+
+import "time"
+
+// AchievementStatusEvent adalah satu baris log transisi status (append-only)
+type AchievementStatusEvent struct {
+	ID                 int64     `json:"id"`
+	AchievementRefID   string    `json:"achievement_ref_id"`
+	MongoAchievementID string    `json:"mongo_achievement_id"`
+	Round              int       `json:"round"`
+	FromStatus         *string   `json:"from_status"`
+	ToStatus           string    `json:"to_status"`
+	ActorUserID        *int64    `json:"actor_user_id"`
+	ActorRole          string    `json:"actor_role"`
+	Note               *string   `json:"note"`
+	CreatedAt          time.Time `json:"created_at"`
+}
@@ -0,0 +1,36 @@
+package model
+
+import (
+	"encoding/json"
+	"time"
+)
+
+// Operasi dual-write Mongo + PostgreSQL yang dicatat di achievement_sync_ops
+const (
+	SyncOpCreate = "create"
+	SyncOpUpdate = "update"
+	SyncOpDelete = "delete"
+	SyncOpAttach = "attach"
+)
+
+// pending: sedang berjalan / gagal di tengah, done: sudah ada di kedua store,
+// compensated: perubahan di Mongo sudah dibatalkan (tidak ada di keduanya)
+const (
+	SyncStatusPending     = "pending"
+	SyncStatusDone        = "done"
+	SyncStatusCompensated = "compensated"
+)
+
+// AchievementSyncOp adalah catatan outbox satu operasi dual-write.
+// Payload berisi data yang dibutuhkan untuk membatalkan perubahan di Mongo.
+type AchievementSyncOp struct {
+	ID                 int64           `json:"id"`
+	Op                 string          `json:"op"`
+	MongoAchievementID string          `json:"mongo_achievement_id"`
+	Payload            json.RawMessage `json:"payload"`
+	Status             string          `json:"status"`
+	Attempts           int             `json:"attempts"`
+	LastError          *string         `json:"last_error"`
+	CreatedAt          time.Time       `json:"created_at"`
+	UpdatedAt          time.Time       `json:"updated_at"`
+}
@@ -0,0 +1,67 @@
+package model
+
+import (
+	"embed"
+	"encoding/json"
+	"time"
+)
+
+// AchievementType adalah satu entri katalog kategori achievement.
+// DetailsSchema berisi JSON Schema untuk field details.
+type AchievementType struct {
+	Key                 string             `json:"key"`
+	LabelID             string             `json:"label_id"`
+	LabelEN             string             `json:"label_en"`
+	DetailsSchema       json.RawMessage    `json:"details_schema"`
+	RequiredAttachments []string           `json:"required_attachments"`
+	DefaultRubric       []PointRubricEntry `json:"default_rubric"`
+	IsActive            bool               `json:"is_active"`
+	IsBuiltin           bool               `json:"is_builtin"`
+	CreatedAt           *time.Time         `json:"created_at,omitempty"`
+	UpdatedAt           *time.Time         `json:"updated_at,omitempty"`
+}
+
+type AchievementTypeRequest struct {
+	Key                 string             `json:"key"`
+	LabelID             string             `json:"label_id"`
+	LabelEN             string             `json:"label_en"`
+	DetailsSchema       json.RawMessage    `json:"details_schema"`
+	RequiredAttachments []string           `json:"required_attachments"`
+	DefaultRubric       []PointRubricEntry `json:"default_rubric"`
+	IsActive            *bool              `json:"is_active"`
+}
+
+//go:embed schemas/*.json
+var builtinSchemas embed.FS
+
+var builtinLabels = map[string][2]string{
+	AchievementTypeCompetition:      {"Kompetisi", "Competition"},
+	AchievementTypePublication:      {"Publikasi", "Publication"},
+	AchievementTypeOrganization:     {"Organisasi", "Organization"},
+	AchievementTypeCertification:    {"Sertifikasi", "Certification"},
+	AchievementTypeCommunityService: {"Pengabdian Masyarakat", "Community Service"},
+	AchievementTypeInternship:       {"Magang", "Internship"},
+}
+
+// BuiltinAchievementTypes: kategori bawaan (lihat achievement_details.go).
+// Baris di tabel achievement_types dengan key yang sama menimpa default ini.
+func BuiltinAchievementTypes() []AchievementType {
+	types := make([]AchievementType, 0, len(AchievementTypes))
+	for _, key := range AchievementTypes {
+		schema, err := builtinSchemas.ReadFile("schemas/" + key + ".json")
+		if err != nil {
+			panic("missing builtin schema for " + key)
+		}
+		types = append(types, AchievementType{
+			Key:                 key,
+			LabelID:             builtinLabels[key][0],
+			LabelEN:             builtinLabels[key][1],
+			DetailsSchema:       schema,
+			RequiredAttachments: []string{},
+			DefaultRubric:       []PointRubricEntry{},
+			IsActive:            true,
+			IsBuiltin:           true,
+		})
+	}
+	return types
+}
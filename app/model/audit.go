@@ -0,0 +1,23 @@
+package model
+
+import "time"
+
+// Event audit_logs
+const (
+	AuditAccountLocked   = "account_locked"
+	AuditAccountUnlocked = "account_unlocked"
+	AuditIPLocked        = "ip_locked"
+	AuditSSOLinked       = "sso_identity_linked"
+	AuditSSOProvisioned  = "sso_user_provisioned"
+)
+
+// AuditLog: satu kejadian keamanan akun (append-only)
+type AuditLog struct {
+	ID        int64                  `json:"id"`
+	Event     string                 `json:"event"`
+	UserID    *int64                 `json:"user_id"`
+	ActorID   *int64                 `json:"actor_id"`
+	IPAddress string                 `json:"ip_address"`
+	Details   map[string]interface{} `json:"details"`
+	CreatedAt time.Time              `json:"created_at"`
+}
@@ -2,14 +2,57 @@ package model
 
 import "time"
 
+// RefreshToken: satu baris refresh_tokens. Token asli tidak pernah disimpan,
+// hanya hash-nya. Semua token hasil rotasi dari satu login berbagi FamilyID.
 type RefreshToken struct {
-	ID        int64     `json:"id"`
-	UserID    int64     `json:"user_id"`
-	Token     string    `json:"token"`
-	ExpiresAt time.Time `json:"expires_at"`
-	CreatedAt time.Time `json:"created_at"`
+	ID        int64      `json:"id"`
+	UserID    int64      `json:"user_id"`
+	TokenHash string     `json:"-"`
+	FamilyID  string     `json:"family_id"`
+	ExpiresAt time.Time  `json:"expires_at"`
+	CreatedAt time.Time  `json:"created_at"`
+	RotatedAt *time.Time `json:"rotated_at,omitempty"`
+	RevokedAt *time.Time `json:"revoked_at,omitempty"`
 }
 
 type RefreshTokenRequest struct {
 	RefreshToken string `json:"refresh_token"`
 }
+
+// AuthSession: satu login di satu perangkat (auth_sessions, id = FamilyID)
+type AuthSession struct {
+	ID         string    `json:"id"`
+	UserID     int64     `json:"user_id"`
+	UserAgent  string    `json:"user_agent"`
+	IPAddress  string    `json:"ip_address"`
+	CreatedAt  time.Time `json:"created_at"`
+	LastUsedAt time.Time `json:"last_used_at"`
+	Current    bool      `json:"current"`
+}
+
+// AccessTokenState: kondisi terkini user untuk memvalidasi satu access token
+// (dicek middleware setiap request, karena JWT sendiri tidak bisa dicabut)
+type AccessTokenState struct {
+	TokenVersion   int64
+	IsActive       bool
+	Revoked        bool // jti ada di revoked_access_tokens
+	SessionRevoked bool // sesi asal token sudah di-logout
+}
+
+type UserStatusRequest struct {
+	IsActive *bool `json:"is_active"`
+}
+
+type ChangePasswordRequest struct {
+	CurrentPassword string `json:"current_password"`
+	NewPassword     string `json:"new_password"`
+}
+
+type ForgotPasswordRequest struct {
+	Email string `json:"email"`
+}
+
+type ResetPasswordRequest struct {
+	Token       string `json:"token"`
+	NewPassword string `json:"new_password"`
+}
@@ -0,0 +1,37 @@
+package model
+
+// OIDCIdentity: claim ID token IdP kampus yang sudah dipetakan
+// (nama claim diatur lewat konfigurasi OIDC_CLAIM_*)
+type OIDCIdentity struct {
+	Issuer       string
+	Subject      string
+	Email        string // kosong jika IdP tidak menyatakan email terverifikasi
+	Username     string
+	FullName     string
+	NIM          string
+	NIP          string
+	ProgramStudy string
+	Department   string
+}
+
+// OIDCLoginState: PKCE verifier dan nonce satu login SSO (kunci: hash state)
+type OIDCLoginState struct {
+	CodeVerifier string
+	Nonce        string
+}
+
+// OIDCProvision: user baru dari login SSO pertama (just-in-time). Profil
+// mahasiswa / dosen wali ikut dibuat sesuai Role.
+type OIDCProvision struct {
+	Identity     OIDCIdentity
+	Username     string
+	RoleID       string
+	Role         string
+	PasswordHash string
+}
+
+// OIDCCallbackRequest: code + state dari redirect IdP (query atau body JSON)
+type OIDCCallbackRequest struct {
+	Code  string `json:"code" query:"code"`
+	State string `json:"state" query:"state"`
+}
@@ -7,3 +7,103 @@ type Permission struct {
 	Action      string `gorm:"size:50;not null" json:"action"`
 	Description string `json:"description"`
 }
+
+// Nama role bawaan (harus persis sama dengan roles.name)
+const (
+	RoleAdmin    = "admin"
+	RoleStudent  = "mahasiswa"
+	RoleLecturer = "dosen wali"
+)
+
+// Nama permission berformat "resource:action"
+const (
+	PermUserManage            = "user:manage"
+	PermRoleManage            = "role:manage"
+	PermStudentRead           = "student:read"
+	PermStudentManage         = "student:manage"
+	PermLecturerRead          = "lecturer:read"
+	PermLecturerAdvisees      = "lecturer:advisees"
+	PermAchievementCreate     = "achievement:create"
+	PermAchievementRead       = "achievement:read"
+	PermAchievementUpdate     = "achievement:update"
+	PermAchievementDelete     = "achievement:delete"
+	PermAchievementSubmit     = "achievement:submit"
+	PermAchievementSupervise  = "achievement:supervise"
+	PermAchievementVerify     = "achievement:verify"
+	PermAchievementRevoke     = "achievement:revoke"
+	PermAchievementReadAll    = "achievement:read_all"
+	PermAchievementReconcile  = "achievement:reconcile"
+	PermAchievementTypeRead   = "achievement_type:read"
+	PermAchievementTypeManage = "achievement_type:manage"
+	PermRubricManage          = "rubric:manage"
+	PermPointRead             = "point:read"
+	PermPointRecompute        = "point:recompute"
+	PermReportStatistics      = "report:statistics"
+	PermReportStudent         = "report:student"
+)
+
+// DefaultPermissions: semua permission yang dikenal aplikasi (dipakai seed)
+var DefaultPermissions = []Permission{
+	{Name: PermUserManage, Resource: "user", Action: "manage", Description: "Create, update and delete users"},
+	{Name: PermRoleManage, Resource: "role", Action: "manage", Description: "Manage roles and their permissions"},
+	{Name: PermStudentRead, Resource: "student", Action: "read", Description: "List and view student profiles"},
+	{Name: PermStudentManage, Resource: "student", Action: "manage", Description: "Assign advisors to students"},
+	{Name: PermLecturerRead, Resource: "lecturer", Action: "read", Description: "List lecturers"},
+	{Name: PermLecturerAdvisees, Resource: "lecturer", Action: "advisees", Description: "View a lecturer's advisees"},
+	{Name: PermAchievementCreate, Resource: "achievement", Action: "create", Description: "Create own achievements"},
+	{Name: PermAchievementRead, Resource: "achievement", Action: "read", Description: "View achievements within own scope"},
+	{Name: PermAchievementUpdate, Resource: "achievement", Action: "update", Description: "Edit drafts, revise and upload attachments"},
+	{Name: PermAchievementDelete, Resource: "achievement", Action: "delete", Description: "Delete own draft achievements"},
+	{Name: PermAchievementSubmit, Resource: "achievement", Action: "submit", Description: "Submit achievements for verification"},
+	{Name: PermAchievementSupervise, Resource: "achievement", Action: "supervise", Description: "List advisees' achievements"},
+	{Name: PermAchievementVerify, Resource: "achievement", Action: "verify", Description: "Verify or reject submitted achievements"},
+	{Name: PermAchievementRevoke, Resource: "achievement", Action: "revoke", Description: "Revoke verified achievements"},
+	{Name: PermAchievementReadAll, Resource: "achievement", Action: "read_all", Description: "List all achievements"},
+	{Name: PermAchievementReconcile, Resource: "achievement", Action: "reconcile", Description: "Reconcile MongoDB and PostgreSQL"},
+	{Name: PermAchievementTypeRead, Resource: "achievement_type", Action: "read", Description: "View the achievement type catalog"},
+	{Name: PermAchievementTypeManage, Resource: "achievement_type", Action: "manage", Description: "Manage the achievement type catalog"},
+	{Name: PermRubricManage, Resource: "rubric", Action: "manage", Description: "Manage point rubrics"},
+	{Name: PermPointRead, Resource: "point", Action: "read", Description: "View point balances within own scope"},
+	{Name: PermPointRecompute, Resource: "point", Action: "recompute", Description: "Recompute cached point balances"},
+	{Name: PermReportStatistics, Resource: "report", Action: "statistics", Description: "View global achievement statistics"},
+	{Name: PermReportStudent, Resource: "report", Action: "student", Description: "View a student's achievement report"},
+}
+
+// DefaultRoles: role bawaan beserta matriks permission-nya
+var DefaultRoles = []struct {
+	Name        string
+	Description string
+	Require2FA  bool // hanya berlaku saat role pertama kali dibuat
+	Permissions []string
+}{
+	{
+		Name:        RoleAdmin,
+		Description: "Administrator sistem",
+		Require2FA:  true,
+		Permissions: []string{
+			PermUserManage, PermRoleManage,
+			PermStudentRead, PermStudentManage, PermLecturerRead, PermLecturerAdvisees,
+			PermAchievementRead, PermAchievementRevoke, PermAchievementReadAll, PermAchievementReconcile,
+			PermAchievementTypeRead, PermAchievementTypeManage, PermRubricManage,
+			PermPointRead, PermPointRecompute, PermReportStatistics, PermReportStudent,
+		},
+	},
+	{
+		Name:        RoleStudent,
+		Description: "Mahasiswa",
+		Permissions: []string{
+			PermAchievementCreate, PermAchievementRead, PermAchievementUpdate,
+			PermAchievementDelete, PermAchievementSubmit,
+			PermAchievementTypeRead, PermPointRead,
+		},
+	},
+	{
+		Name:        RoleLecturer,
+		Description: "Dosen wali",
+		Permissions: []string{
+			PermLecturerAdvisees,
+			PermAchievementRead, PermAchievementSupervise, PermAchievementVerify, PermAchievementRevoke,
+			PermAchievementTypeRead, PermPointRead, PermReportStudent,
+		},
+	},
+}
@@ -0,0 +1,41 @@
+package model
+
+import (
+	"fmt"
+	"time"
+)
+
+// PointRubric adalah tabel skor resmi fakultas untuk satu tahun akademik.
+// Satu tahun akademik bisa punya beberapa versi; hanya satu rubrik aktif.
+type PointRubric struct {
+	ID           string             `json:"id"`
+	AcademicYear string             `json:"academic_year"`
+	Version      int                `json:"version"`
+	Description  string             `json:"description"`
+	IsActive     bool               `json:"is_active"`
+	Entries      []PointRubricEntry `json:"entries"`
+	CreatedAt    time.Time          `json:"created_at"`
+	UpdatedAt    time.Time          `json:"updated_at"`
+}
+
+// PointRubricEntry: poin untuk kombinasi tipe × level × rank.
+// Level/Rank kosong berarti berlaku untuk semua nilai.
+type PointRubricEntry struct {
+	ID              int64   `json:"id"`
+	AchievementType string  `json:"achievement_type"`
+	Level           string  `json:"level"`
+	Rank            string  `json:"rank"`
+	Points          float64 `json:"points"`
+}
+
+type PointRubricRequest struct {
+	AcademicYear string             `json:"academic_year"`
+	Description  string             `json:"description"`
+	IsActive     bool               `json:"is_active"`
+	Entries      []PointRubricEntry `json:"entries"`
+}
+
+// Label versi yang disimpan di dokumen achievement, contoh "2025/2026#2"
+func (r *PointRubric) Label() string {
+	return fmt.Sprintf("%s#%d", r.AcademicYear, r.Version)
+}
@@ -0,0 +1,31 @@
+package model
+
+import "time"
+
+type PointReason string
+
+const (
+	PointReasonGrant  PointReason = "grant"
+	PointReasonRevoke PointReason = "revoke"
+)
+
+// PointTransaction adalah satu baris ledger poin mahasiswa
+type PointTransaction struct {
+	ID                 int64       `json:"id"`
+	AchievementRefID   *string     `json:"achievement_ref_id"`
+	MongoAchievementID *string     `json:"mongo_achievement_id"`
+	StudentID          string      `json:"student_id"`
+	Delta              float64     `json:"delta"`
+	Reason             PointReason `json:"reason"`
+	ActorUserID        *int64      `json:"actor_user_id"`
+	Semester           string      `json:"semester"`
+	CreatedAt          time.Time   `json:"created_at"`
+}
+
+// PointDrift: selisih students.points (cache) dengan saldo ledger
+type PointDrift struct {
+	StudentID    string  `json:"student_id"`
+	CachedPoints float64 `json:"cached_points"`
+	LedgerPoints float64 `json:"ledger_points"`
+	Drift        float64 `json:"drift"`
+}
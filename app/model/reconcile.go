@@ -0,0 +1,60 @@
+package model
+
+import "time"
+
+// Jenis temuan reconcile antara achievement_references dan dokumen Mongo
+const (
+	ReconcileDanglingReference = "dangling_reference" // reference aktif, dokumen Mongo tidak ada
+	ReconcileOrphanDocument    = "orphan_document"    // dokumen Mongo tanpa reference
+	ReconcileStudentMismatch   = "student_mismatch"   // studentId Mongo != student_uuid
+	ReconcileDeletedDivergence = "deleted_divergence" // deletedAt Mongo != is_deleted
+)
+
+// Aksi perbaikan; PostgreSQL dianggap sumber kebenaran
+const (
+	ReconcileActionSoftDeleteReference = "soft_delete_reference"
+	ReconcileActionSoftDeleteDocument  = "soft_delete_document"
+	ReconcileActionSetDocumentStudent  = "set_document_student"
+	ReconcileActionMarkDocumentDeleted = "mark_document_deleted"
+	ReconcileActionClearDocumentDelete = "clear_document_deleted"
+	ReconcileActionManualReview        = "manual_review"
+)
+
+// Status reference yang dibutuhkan untuk reconcile (termasuk yang sudah dihapus)
+type ReferenceState struct {
+	ID        string
+	MongoID   string
+	StudentID string
+	Status    string
+	IsDeleted bool
+}
+
+// Ringkasan dokumen Mongo yang dibutuhkan untuk reconcile
+type DocumentState struct {
+	MongoID   string
+	StudentID string
+	DeletedAt *time.Time
+}
+
+type ReconcileFinding struct {
+	Kind        string  `json:"kind"`
+	MongoID     string  `json:"mongo_id"`
+	ReferenceID *string `json:"reference_id,omitempty"`
+	StudentID   string  `json:"student_id,omitempty"` // student_uuid di PostgreSQL
+	Detail      string  `json:"detail"`
+	Action      string  `json:"action"`
+	Repaired    bool    `json:"repaired"`
+	Error       string  `json:"error,omitempty"`
+}
+
+type ReconcileReport struct {
+	Repair            bool               `json:"repair"`
+	DryRun            bool               `json:"dry_run"`
+	ScannedReferences int                `json:"scanned_references"`
+	ScannedDocuments  int                `json:"scanned_documents"`
+	SkippedInFlight   int                `json:"skipped_in_flight"`
+	SkippedRecent     int                `json:"skipped_recent"` // dokumen baru, reference mungkin belum ter-commit
+	Counts            map[string]int     `json:"counts"`
+	Repaired          int                `json:"repaired"`
+	Findings          []ReconcileFinding `json:"findings"`
+}
@@ -7,4 +7,54 @@ type Role struct {
 	Name        string    `gorm:"size:50;unique;not null" json:"name"`
 	Description string    `json:"description"`
 	CreatedAt   time.Time `json:"created_at"`
-}
\ No newline at end of file
+}
+
+// RoleDetail: role beserta permission dan jumlah user yang memakainya
+type RoleDetail struct {
+	Role
+	Permissions []string `json:"permissions"`
+	UserCount   int64    `json:"user_count"`
+	IsBuiltin   bool     `json:"is_builtin"`
+	Require2FA  bool     `json:"require_2fa"`
+}
+
+type RoleRequest struct {
+	Name        string `json:"name"`
+	Description string `json:"description"`
+}
+
+// RolePermissionsRequest: daftar lengkap nama permission (menggantikan yang lama)
+type RolePermissionsRequest struct {
+	Permissions []string `json:"permissions"`
+}
+
+// RoleTwoFactorRequest: wajibkan / lepaskan 2FA untuk semua user di role
+type RoleTwoFactorRequest struct {
+	Required *bool `json:"required"`
+}
+
+type PermissionRequest struct {
+	Name        string `json:"name"`
+	Description string `json:"description"`
+}
+
+// Role bawaan dipakai kode (profil mahasiswa / dosen wali), tidak boleh
+// diganti nama atau dihapus
+func IsBuiltinRole(name string) bool {
+	for _, r := range DefaultRoles {
+		if r.Name == name {
+			return true
+		}
+	}
+	return false
+}
+
+// Permission bawaan dicek oleh route, tidak boleh diganti nama atau dihapus
+func IsBuiltinPermission(name string) bool {
+	for _, p := range DefaultPermissions {
+		if p.Name == name {
+			return true
+		}
+	}
+	return false
+}
@@ -0,0 +1,47 @@
+package model
+
+import "time"
+
+// TwoFactorStatus: kondisi 2FA satu user. Required berasal dari role
+// (roles.require_2fa); user seperti ini wajib enroll saat login.
+type TwoFactorStatus struct {
+	Enabled           bool `json:"enabled"`
+	Pending           bool `json:"pending"` // secret sudah dibuat, belum dikonfirmasi
+	Required          bool `json:"required"`
+	RecoveryCodesLeft int  `json:"recovery_codes_left"`
+}
+
+// TOTPSecret: satu baris user_totp
+type TOTPSecret struct {
+	UserID       int64
+	Secret       string
+	Confirmed    bool
+	LastUsedStep int64
+}
+
+// LoginChallenge: tahap kedua login (password sudah benar, menunggu kode)
+type LoginChallenge struct {
+	UserID    int64
+	Attempts  int
+	ExpiresAt time.Time
+}
+
+// TwoFactorEnrollment: secret baru untuk di-scan authenticator app
+type TwoFactorEnrollment struct {
+	Secret     string `json:"secret"`
+	OTPAuthURI string `json:"otpauth_uri"`
+}
+
+type TwoFactorVerifyRequest struct {
+	ChallengeToken string `json:"challenge_token"`
+	Code           string `json:"code"`
+	RecoveryCode   string `json:"recovery_code"`
+}
+
+type TwoFactorSetupRequest struct {
+	ChallengeToken string `json:"challenge_token"`
+}
+
+type TwoFactorCodeRequest struct {
+	Code string `json:"code"`
+}
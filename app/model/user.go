@@ -11,6 +11,7 @@ type User struct {
 	RoleID       string    `json:"role_id"`
 	Role         string    `json:"role"`
 	IsActive     bool      `json:"is_active"`
+	TokenVersion int64     `json:"-"`
 	CreatedAt    time.Time `json:"created_at"`
 	UpdatedAt    time.Time `json:"updated_at"`
 }
@@ -45,11 +46,13 @@ type LoginResponse struct {
 }
 
 type UserResponse struct {
-	ID          int64    `json:"id"`
-	Username    string   `json:"username"`
-	FullName    string   `json:"full_name"`
-	Role        string   `json:"role"`
-	Permissions []string `json:"permissions"`
+	ID           int64    `json:"id"`
+	Username     string   `json:"username"`
+	FullName     string   `json:"full_name"`
+	Role         string   `json:"role"`
+	IsActive     bool     `json:"is_active"`
+	TokenVersion int64    `json:"-"` // disalin ke klaim ver access token
+	Permissions  []string `json:"permissions"`
 }
 
 type AdminUpdateUserRequest struct {
@@ -0,0 +1,77 @@
+package repository
+
+import (
+	"context"
+	"database/sql"
+	"time"
+	"uas/app/model"
+)
+
+// AccessTokenRepository: pencabutan access token (JWT) sebelum kedaluwarsa.
+// Satu token dicabut lewat jti (denylist), semua token user dicabut dengan
+// menaikkan users.token_version.
+type AccessTokenRepository interface {
+	State(ctx context.Context, userID int64, jti, sessionID string) (*model.AccessTokenState, error)
+	Revoke(ctx context.Context, jti string, userID int64, expiresAt time.Time) error
+	RevokeAll(ctx context.Context, userID int64) error
+}
+
+type AccessTokenRepositoryImpl struct {
+	DB *sql.DB
+}
+
+func NewAccessTokenRepository(db *sql.DB) AccessTokenRepository {
+	return &AccessTokenRepositoryImpl{DB: db}
+}
+
+// State: satu query per request. sql.ErrNoRows berarti user sudah dihapus.
+func (r *AccessTokenRepositoryImpl) State(
+	ctx context.Context,
+	userID int64,
+	jti, sessionID string,
+) (*model.AccessTokenState, error) {
+	query := `
+		SELECT u.token_version, u.is_active,
+		       EXISTS (SELECT 1 FROM revoked_access_tokens WHERE jti = $2::uuid),
+		       EXISTS (SELECT 1 FROM auth_sessions
+		               WHERE id = NULLIF($3, '')::uuid AND revoked_at IS NOT NULL)
+		FROM users u
+		WHERE u.id = $1
+	`
+	var s model.AccessTokenState
+	err := r.DB.QueryRowContext(ctx, query, userID, jti, sessionID).
+		Scan(&s.TokenVersion, &s.IsActive, &s.Revoked, &s.SessionRevoked)
+	if err != nil {
+		return nil, err
+	}
+	return &s, nil
+}
+
+// Revoke memasukkan jti ke denylist sampai token kedaluwarsa; baris yang
+// sudah lewat expires_at dibersihkan sekalian.
+func (r *AccessTokenRepositoryImpl) Revoke(
+	ctx context.Context,
+	jti string,
+	userID int64,
+	expiresAt time.Time,
+) error {
+	_, err := r.DB.ExecContext(ctx, `DELETE FROM revoked_access_tokens WHERE expires_at < NOW()`)
+	if err != nil {
+		return err
+	}
+	query := `
+		INSERT INTO revoked_access_tokens (jti, user_id, expires_at)
+		VALUES ($1, $2, $3)
+		ON CONFLICT (jti) DO NOTHING
+	`
+	_, err = r.DB.ExecContext(ctx, query, jti, userID, expiresAt)
+	return err
+}
+
+// RevokeAll menaikkan token_version: semua access token user yang sudah
+// terbit ditolak, token berikutnya membawa versi baru
+func (r *AccessTokenRepositoryImpl) RevokeAll(ctx context.Context, userID int64) error {
+	query := `UPDATE users SET token_version = token_version + 1 WHERE id = $1`
+	_, err := r.DB.ExecContext(ctx, query, userID)
+	return err
+}
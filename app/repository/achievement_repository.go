@@ -8,9 +8,40 @@ import (
 	"strings"
 	"time"
 
+	"uas/app/model"
+
 	"github.com/lib/pq"
 )
 
+// AchievementReferenceRepository: data achievement di PostgreSQL
+// (achievement_references, status events, poin, relasi dosen wali)
+type AchievementReferenceRepository interface {
+	GetStudentID(ctx context.Context, userID int64) (string, error)
+	InsertReference(ctx context.Context, studentID, mongoID string, actor AchievementActor, syncOpID int64) error
+	Submit(ctx context.Context, achievementID, studentID string, actor AchievementActor) error
+	GetByStudentID(ctx context.Context, studentID string, includeDeleted bool) ([]map[string]interface{}, error)
+	AdminGetAll(ctx context.Context, f AchievementAdminFilter) ([]map[string]interface{}, int64, error)
+	GetStudentsByAdvisor(ctx context.Context, advisorID int64) ([]string, error)
+	GetReferencesByStudentList(ctx context.Context, studentIDs []string) ([]map[string]interface{}, error)
+	GetLecturerID(ctx context.Context, userID int64) (int64, error)
+	Verify(ctx context.Context, achievementID, studentID string, lecturerID int64, claimedPoints, approvedPoints float64, remarks string, actor AchievementActor) error
+	GetStudentIDByAchievement(ctx context.Context, achievementID string) (string, error)
+	IsStudentSupervised(ctx context.Context, lecturerID int64, studentID string) (bool, error)
+	Reject(ctx context.Context, achievementID, studentID string, lecturerID int64, note string, actor AchievementActor) error
+	Revoke(ctx context.Context, achievementID, reason string, actor AchievementActor) (float64, error)
+	Revise(ctx context.Context, achievementID, studentID, note string, actor AchievementActor) (int, error)
+	SoftDelete(ctx context.Context, achievementID string, userID int64, actor AchievementActor, syncOpID int64) error
+	ListReferenceStates(ctx context.Context) ([]model.ReferenceState, error)
+	GetReferenceState(ctx context.Context, mongoID string) (*model.ReferenceState, error)
+	SoftDeleteDangling(ctx context.Context, refID string, actor AchievementActor) error
+	GetReferenceByMongoID(ctx context.Context, mongoID string) (map[string]interface{}, error)
+	GetHistoryByMongoID(ctx context.Context, mongoID string) ([]model.AchievementStatusEvent, error)
+	GetVerifiedAchievementRefs(ctx context.Context) ([]map[string]interface{}, error)
+	GetTopStudents(ctx context.Context) ([]map[string]interface{}, error)
+	CountRevoked(ctx context.Context) (int64, error)
+	GetByStudentUUID(ctx context.Context, studentUUID string) ([]map[string]interface{}, error)
+}
+
 type AchievementRepository struct {
 	DB *sql.DB
 }
@@ -24,7 +55,13 @@ type AchievementAdminFilter struct {
 	Offset    int
 }
 
-func NewAchievementRepository(db *sql.DB) *AchievementRepository {
+// AchievementActor: user yang melakukan transisi status (dicatat di event log)
+type AchievementActor struct {
+	UserID int64
+	Role   string
+}
+
+func NewAchievementRepository(db *sql.DB) AchievementReferenceRepository {
 	return &AchievementRepository{DB: db}
 }
 
@@ -40,26 +77,58 @@ func (r *AchievementRepository) GetStudentID(ctx context.Context, userID int64)
 	return studentID, err
 }
 
-// Insert reference ke PostgreSQL setelah Mongo success
+// Insert reference ke PostgreSQL setelah Mongo success.
+// syncOpID > 0: operasi outbox ditandai done di tx yang sama.
 func (r *AchievementRepository) InsertReference(
 	ctx context.Context,
 	studentID string,
 	mongoID string,
+	actor AchievementActor,
+	syncOpID int64,
 ) error {
-	query := `
+	tx, err := r.DB.BeginTx(ctx, nil)
+	if err != nil {
+		return err
+	}
+	var refID string
+	err = tx.QueryRowContext(ctx, `
         INSERT INTO achievement_references (
             id, student_uuid, mongo_achievement_id, status, created_at, updated_at
         ) VALUES (
             gen_random_uuid(), $1, $2, 'draft', NOW(), NOW()
         )
-    `
-	_, err := r.DB.ExecContext(ctx, query, studentID, mongoID)
-	return err
+        RETURNING id
+    `, studentID, mongoID).Scan(&refID)
+	if err != nil {
+		tx.Rollback()
+		return err
+	}
+	if err := insertStatusEvent(ctx, tx, refID, mongoID, "", "draft", actor, ""); err != nil {
+		tx.Rollback()
+		return err
+	}
+	if syncOpID > 0 {
+		if err := completeSyncOp(ctx, tx, syncOpID); err != nil {
+			tx.Rollback()
+			return err
+		}
+	}
+	return tx.Commit()
 }
 
 // Update status dari draft menjadi submitted
-func (r *AchievementRepository) Submit(ctx context.Context, achievementID string, studentID string) error {
-	query := `
+func (r *AchievementRepository) Submit(
+	ctx context.Context,
+	achievementID string,
+	studentID string,
+	actor AchievementActor,
+) error {
+	tx, err := r.DB.BeginTx(ctx, nil)
+	if err != nil {
+		return err
+	}
+	var refID string
+	err = tx.QueryRowContext(ctx, `
         UPDATE achievement_references
         SET status = 'submitted',
             submitted_at = NOW(),
@@ -67,25 +136,31 @@ func (r *AchievementRepository) Submit(ctx context.Context, achievementID string
         WHERE mongo_achievement_id = $1
 		AND student_uuid = $2
 		AND status = 'draft'
-    `
-	result, err := r.DB.ExecContext(ctx, query, achievementID, studentID)
+		AND is_deleted = FALSE
+        RETURNING id
+    `, achievementID, studentID).Scan(&refID)
 	if err != nil {
+		tx.Rollback()
+		if errors.Is(err, sql.ErrNoRows) {
+			return errors.New("cannot submit: not found or not in draft status")
+		}
 		return err
 	}
-	rows, _ := result.RowsAffected()
-	if rows == 0 {
-		return errors.New("cannot submit: not found or not in draft status")
+	if err := insertStatusEvent(ctx, tx, refID, achievementID, "draft", "submitted", actor, ""); err != nil {
+		tx.Rollback()
+		return err
 	}
-	return nil
+	return tx.Commit()
 }
 
 // Ambil semua achievement milik student (di PostgreSQL)
 func (r *AchievementRepository) GetByStudentID(ctx context.Context, studentID string, includeDeleted bool) ([]map[string]interface{}, error) {
 	query := `
-        SELECT mongo_achievement_id, status, submitted_at, verified_at, rejection_note
+        SELECT mongo_achievement_id, status, submitted_at, verified_at, rejection_note, revision_round
         FROM achievement_references
         WHERE student_uuid = $1
-        AND ($2 OR is_deleted = false);
+        AND ($2 OR is_deleted = false)
+        ORDER BY created_at DESC;
     `
 	rows, err := r.DB.QueryContext(ctx, query, studentID, includeDeleted)
 	if err != nil {
@@ -96,7 +171,8 @@ func (r *AchievementRepository) GetByStudentID(ctx context.Context, studentID st
 	for rows.Next() {
 		var mongoID, status string
 		var submittedAt, verifiedAt, rejectionNote sql.NullString
-		if err := rows.Scan(&mongoID, &status, &submittedAt, &verifiedAt, &rejectionNote); err != nil {
+		var revisionRound int
+		if err := rows.Scan(&mongoID, &status, &submittedAt, &verifiedAt, &rejectionNote, &revisionRound); err != nil {
 			return nil, err
 		}
 		result = append(result, map[string]interface{}{
@@ -105,6 +181,7 @@ func (r *AchievementRepository) GetByStudentID(ctx context.Context, studentID st
 			"submitted_at":   submittedAt.String,
 			"verified_at":    verifiedAt.String,
 			"rejection_note": rejectionNote.String,
+			"revision_round": revisionRound,
 		})
 	}
 	return result, nil
@@ -281,6 +358,9 @@ func (r *AchievementRepository) GetReferencesByStudentList(ctx context.Context,
             verified_at,
             verified_by,
             rejection_note,
+            claimed_points,
+            approved_points,
+            verification_remarks,
             created_at,
             updated_at
         FROM achievement_references
@@ -302,6 +382,9 @@ func (r *AchievementRepository) GetReferencesByStudentList(ctx context.Context,
 			verifiedAt    *time.Time
 			verifiedBy    *int64
 			rejectionNote *string
+			claimed       *float64
+			approved      *float64
+			remarks       *string
 			createdAt     time.Time
 			updatedAt     time.Time
 		)
@@ -313,21 +396,27 @@ func (r *AchievementRepository) GetReferencesByStudentList(ctx context.Context,
 			&verifiedAt,
 			&verifiedBy,
 			&rejectionNote,
+			&claimed,
+			&approved,
+			&remarks,
 			&createdAt,
 			&updatedAt,
 		); err != nil {
 			return nil, err
 		}
 		results = append(results, map[string]interface{}{
-			"student_uuid":   studentID,
-			"mongo_id":       mongoID,
-			"status":         status,
-			"submitted_at":   submittedAt,
-			"verified_at":    verifiedAt,
-			"verified_by":    verifiedBy,
-			"rejection_note": rejectionNote,
-			"created_at":     createdAt,
-			"updated_at":     updatedAt,
+			"student_uuid":         studentID,
+			"mongo_id":             mongoID,
+			"status":               status,
+			"submitted_at":         submittedAt,
+			"verified_at":          verifiedAt,
+			"verified_by":          verifiedBy,
+			"rejection_note":       rejectionNote,
+			"claimed_points":       claimed,
+			"approved_points":      approved,
+			"verification_remarks": remarks,
+			"created_at":           createdAt,
+			"updated_at":           updatedAt,
 		})
 	}
 	return results, nil
@@ -355,7 +444,10 @@ func (r *AchievementRepository) Verify(
 	achievementID string,
 	studentID string,
 	lecturerID int64,
-	points float64,
+	claimedPoints float64,
+	approvedPoints float64,
+	remarks string,
+	actor AchievementActor,
 ) error {
 
 	tx, err := r.DB.BeginTx(ctx, nil)
@@ -364,31 +456,52 @@ func (r *AchievementRepository) Verify(
 	}
 
 	// 1. Update status → verified
-	_, err = tx.ExecContext(ctx, `
+	var refID string
+	err = tx.QueryRowContext(ctx, `
         UPDATE achievement_references
         SET status = 'verified',
             verified_at = NOW(),
             verified_by = $1,
+            claimed_points = $4,
+            approved_points = $5,
+            verification_remarks = NULLIF($6, ''),
             updated_at = NOW()
         WHERE mongo_achievement_id = $2
         AND student_uuid = $3
         AND status = 'submitted'
-    `, lecturerID, achievementID, studentID)
+        AND is_deleted = FALSE
+        RETURNING id
+    `, lecturerID, achievementID, studentID, claimedPoints, approvedPoints, remarks).Scan(&refID)
 	if err != nil {
 		tx.Rollback()
+		if errors.Is(err, sql.ErrNoRows) {
+			return errors.New("cannot verify: not found or not in submitted status")
+		}
 		return err
 	}
 
-	// 2. Tambah poin mahasiswa
-	_, err = tx.ExecContext(ctx, `
-        UPDATE students
-        SET points = points + $1
-        WHERE id = $2
-    `, points, studentID)
+	// 2. Catat grant (approved points) di ledger (unik per achievement, retry tidak dobel)
+	inserted, err := insertPointTransaction(ctx, tx, refID, achievementID, studentID, approvedPoints, model.PointReasonGrant, actor)
 	if err != nil {
 		tx.Rollback()
 		return err
 	}
+	if !inserted {
+		tx.Rollback()
+		return errors.New("cannot verify: points already granted for this achievement")
+	}
+
+	// 3. Hitung ulang saldo mahasiswa dari ledger
+	if err := refreshStudentPoints(ctx, tx, studentID); err != nil {
+		tx.Rollback()
+		return err
+	}
+
+	// 4. Catat event
+	if err := insertStatusEvent(ctx, tx, refID, achievementID, "submitted", "verified", actor, remarks); err != nil {
+		tx.Rollback()
+		return err
+	}
 	return tx.Commit()
 }
 
@@ -422,8 +535,14 @@ func (r *AchievementRepository) Reject(
 	studentID string,
 	lecturerID int64,
 	note string,
+	actor AchievementActor,
 ) error {
-	query := `
+	tx, err := r.DB.BeginTx(ctx, nil)
+	if err != nil {
+		return err
+	}
+	var refID string
+	err = tx.QueryRowContext(ctx, `
         UPDATE achievement_references
         SET status = 'rejected',
             rejection_note = $1,
@@ -432,23 +551,269 @@ func (r *AchievementRepository) Reject(
         WHERE mongo_achievement_id = $3
           AND student_uuid = $4
           AND status = 'submitted'
-    `
-	_, err := r.DB.ExecContext(ctx, query, note, lecturerID, achievementID, studentID)
-	return err
+          AND is_deleted = FALSE
+        RETURNING id
+    `, note, lecturerID, achievementID, studentID).Scan(&refID)
+	if err != nil {
+		tx.Rollback()
+		if errors.Is(err, sql.ErrNoRows) {
+			return errors.New("cannot reject: not found or not in submitted status")
+		}
+		return err
+	}
+	if err := insertStatusEvent(ctx, tx, refID, achievementID, "submitted", "rejected", actor, note); err != nil {
+		tx.Rollback()
+		return err
+	}
+	return tx.Commit()
 }
 
-// Soft delete: update status menjadi deleted
-func (r *AchievementRepository) SoftDelete(ctx context.Context, achievementID string, userID int64) error {
-	query := `
+// Revoke: cabut achievement verified dan kurangi poin sebesar approved_points
+// (poin yang diberikan saat verifikasi), dalam satu transaksi.
+func (r *AchievementRepository) Revoke(
+	ctx context.Context,
+	achievementID string,
+	reason string,
+	actor AchievementActor,
+) (float64, error) {
+	tx, err := r.DB.BeginTx(ctx, nil)
+	if err != nil {
+		return 0, err
+	}
+
+	// 1. Update status → revoked
+	var (
+		refID          string
+		studentID      string
+		approvedPoints float64
+	)
+	err = tx.QueryRowContext(ctx, `
+        UPDATE achievement_references
+        SET status = 'revoked',
+            revoked_at = NOW(),
+            revoked_by = NULLIF($1, 0),
+            revocation_reason = $2,
+            updated_at = NOW()
+        WHERE mongo_achievement_id = $3
+          AND status = 'verified'
+          AND is_deleted = FALSE
+        RETURNING id, student_uuid, COALESCE(approved_points, 0)
+    `, actor.UserID, reason, achievementID).Scan(&refID, &studentID, &approvedPoints)
+	if err != nil {
+		tx.Rollback()
+		if errors.Is(err, sql.ErrNoRows) {
+			return 0, errors.New("cannot revoke: not found or not in verified status")
+		}
+		return 0, err
+	}
+
+	// 2. Catat pembalikan di ledger
+	inserted, err := insertPointTransaction(ctx, tx, refID, achievementID, studentID, -approvedPoints, model.PointReasonRevoke, actor)
+	if err != nil {
+		tx.Rollback()
+		return 0, err
+	}
+	if !inserted {
+		tx.Rollback()
+		return 0, errors.New("cannot revoke: points already reverted for this achievement")
+	}
+
+	// 3. Hitung ulang saldo mahasiswa dari ledger
+	if err := refreshStudentPoints(ctx, tx, studentID); err != nil {
+		tx.Rollback()
+		return 0, err
+	}
+
+	// 4. Catat event
+	if err := insertStatusEvent(ctx, tx, refID, achievementID, "verified", "revoked", actor, reason); err != nil {
+		tx.Rollback()
+		return 0, err
+	}
+	return approvedPoints, tx.Commit()
+}
+
+// Revise: kembalikan achievement rejected ke draft untuk diperbaiki.
+// rejection_note tetap disimpan dan revision_round naik satu.
+func (r *AchievementRepository) Revise(
+	ctx context.Context,
+	achievementID string,
+	studentID string,
+	note string,
+	actor AchievementActor,
+) (int, error) {
+	tx, err := r.DB.BeginTx(ctx, nil)
+	if err != nil {
+		return 0, err
+	}
+	var (
+		refID string
+		round int
+	)
+	err = tx.QueryRowContext(ctx, `
+        UPDATE achievement_references
+        SET status = 'draft',
+            revision_round = revision_round + 1,
+            submitted_at = NULL,
+            updated_at = NOW()
+        WHERE mongo_achievement_id = $1
+          AND student_uuid = $2
+          AND status = 'rejected'
+          AND is_deleted = FALSE
+        RETURNING id, revision_round
+    `, achievementID, studentID).Scan(&refID, &round)
+	if err != nil {
+		tx.Rollback()
+		if errors.Is(err, sql.ErrNoRows) {
+			return 0, errors.New("cannot revise: not found or not in rejected status")
+		}
+		return 0, err
+	}
+	if err := insertStatusEvent(ctx, tx, refID, achievementID, "rejected", "draft", actor, note); err != nil {
+		tx.Rollback()
+		return 0, err
+	}
+	return round, tx.Commit()
+}
+
+// Soft delete: tandai reference sebagai deleted (hanya draft)
+func (r *AchievementRepository) SoftDelete(
+	ctx context.Context,
+	achievementID string,
+	userID int64,
+	actor AchievementActor,
+	syncOpID int64,
+) error {
+	tx, err := r.DB.BeginTx(ctx, nil)
+	if err != nil {
+		return err
+	}
+	var refID string
+	err = tx.QueryRowContext(ctx, `
         UPDATE achievement_references
         SET is_deleted = true, updated_at = NOW()
         WHERE mongo_achievement_id = $1
-        AND student_id = (
+        AND student_uuid = (
             SELECT id FROM students WHERE user_id = $2
         )
-        AND status = 'draft';
-    `
-	_, err := r.DB.ExecContext(ctx, query, achievementID, userID)
+        AND status = 'draft'
+        AND is_deleted = FALSE
+        RETURNING id
+    `, achievementID, userID).Scan(&refID)
+	if err != nil {
+		tx.Rollback()
+		if errors.Is(err, sql.ErrNoRows) {
+			return errors.New("cannot delete: not found or not in draft status")
+		}
+		return err
+	}
+	if err := insertStatusEvent(ctx, tx, refID, achievementID, "draft", "deleted", actor, ""); err != nil {
+		tx.Rollback()
+		return err
+	}
+	if syncOpID > 0 {
+		if err := completeSyncOp(ctx, tx, syncOpID); err != nil {
+			tx.Rollback()
+			return err
+		}
+	}
+	return tx.Commit()
+}
+
+// Semua reference (termasuk yang sudah dihapus) untuk reconcile
+func (r *AchievementRepository) ListReferenceStates(ctx context.Context) ([]model.ReferenceState, error) {
+	rows, err := r.DB.QueryContext(ctx, `
+        SELECT id, mongo_achievement_id, student_uuid, status, is_deleted
+        FROM achievement_references
+        ORDER BY created_at
+    `)
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+
+	var results []model.ReferenceState
+	for rows.Next() {
+		var st model.ReferenceState
+		if err := rows.Scan(&st.ID, &st.MongoID, &st.StudentID, &st.Status, &st.IsDeleted); err != nil {
+			return nil, err
+		}
+		results = append(results, st)
+	}
+	return results, rows.Err()
+}
+
+// GetReferenceState: state terkini satu reference (termasuk yang sudah
+// dihapus); sql.ErrNoRows jika tidak ada
+func (r *AchievementRepository) GetReferenceState(ctx context.Context, mongoID string) (*model.ReferenceState, error) {
+	var st model.ReferenceState
+	err := r.DB.QueryRowContext(ctx, `
+        SELECT id, mongo_achievement_id, student_uuid, status, is_deleted
+        FROM achievement_references
+        WHERE mongo_achievement_id = $1
+    `, mongoID).Scan(&st.ID, &st.MongoID, &st.StudentID, &st.Status, &st.IsDeleted)
+	if err != nil {
+		return nil, err
+	}
+	return &st, nil
+}
+
+// Soft delete reference yang dokumen Mongo-nya hilang (hanya draft / rejected;
+// status lain punya poin / riwayat verifikasi dan perlu dicek manual)
+func (r *AchievementRepository) SoftDeleteDangling(
+	ctx context.Context,
+	refID string,
+	actor AchievementActor,
+) error {
+	tx, err := r.DB.BeginTx(ctx, nil)
+	if err != nil {
+		return err
+	}
+	var mongoID, status string
+	err = tx.QueryRowContext(ctx, `
+        UPDATE achievement_references
+        SET is_deleted = true, updated_at = NOW()
+        WHERE id = $1
+        AND is_deleted = FALSE
+        AND status IN ('draft', 'rejected')
+        RETURNING mongo_achievement_id, status
+    `, refID).Scan(&mongoID, &status)
+	if err != nil {
+		tx.Rollback()
+		if errors.Is(err, sql.ErrNoRows) {
+			return errors.New("cannot delete reference: not found, already deleted or not in draft/rejected status")
+		}
+		return err
+	}
+	if err := insertStatusEvent(ctx, tx, refID, mongoID, status, "deleted", actor, "reconcile: mongo document missing"); err != nil {
+		tx.Rollback()
+		return err
+	}
+	return tx.Commit()
+}
+
+// Tulis satu baris ke achievement_status_events di dalam transaksi yang sama
+// dengan perubahan status. fromStatus kosong berarti reference baru dibuat.
+// Round diambil dari revision_round reference setelah UPDATE di tx ini.
+func insertStatusEvent(
+	ctx context.Context,
+	tx *sql.Tx,
+	refID string,
+	mongoID string,
+	fromStatus string,
+	toStatus string,
+	actor AchievementActor,
+	note string,
+) error {
+	_, err := tx.ExecContext(ctx, `
+        INSERT INTO achievement_status_events (
+            achievement_ref_id, mongo_achievement_id, round, from_status, to_status,
+            actor_user_id, actor_role, note, created_at
+        ) VALUES (
+            $1, $2,
+            (SELECT revision_round FROM achievement_references WHERE id = $1),
+            NULLIF($3, ''), $4, NULLIF($5, 0), $6, NULLIF($7, ''), NOW()
+        )
+    `, refID, mongoID, fromStatus, toStatus, actor.UserID, actor.Role, note)
 	return err
 }
 
@@ -467,6 +832,12 @@ func (r *AchievementRepository) GetReferenceByMongoID(
 			verified_at,
 			verified_by,
 			rejection_note,
+			revision_round,
+			claimed_points,
+			approved_points,
+			verification_remarks,
+			revoked_at,
+			revocation_reason,
 			created_at,
 			updated_at
 		FROM achievement_references
@@ -476,15 +847,21 @@ func (r *AchievementRepository) GetReferenceByMongoID(
 	`
 	row := r.DB.QueryRowContext(ctx, query, mongoID)
 	var (
-		id            string
-		studentUUID   sql.NullString
-		status        string
-		submittedAt   sql.NullTime
-		verifiedAt    sql.NullTime
-		verifiedBy    sql.NullInt64
-		rejectionNote sql.NullString
-		createdAt     time.Time
-		updatedAt     time.Time
+		id             string
+		studentUUID    sql.NullString
+		status         string
+		submittedAt    sql.NullTime
+		verifiedAt     sql.NullTime
+		verifiedBy     sql.NullInt64
+		rejectionNote  sql.NullString
+		revisionRound  int
+		claimedPoints  sql.NullFloat64
+		approvedPoints sql.NullFloat64
+		remarks        sql.NullString
+		revokedAt      sql.NullTime
+		revokeReason   sql.NullString
+		createdAt      time.Time
+		updatedAt      time.Time
 	)
 	if err := row.Scan(
 		&id,
@@ -495,22 +872,34 @@ func (r *AchievementRepository) GetReferenceByMongoID(
 		&verifiedAt,
 		&verifiedBy,
 		&rejectionNote,
+		&revisionRound,
+		&claimedPoints,
+		&approvedPoints,
+		&remarks,
+		&revokedAt,
+		&revokeReason,
 		&createdAt,
 		&updatedAt,
 	); err != nil {
 		return nil, err
 	}
 	ref := map[string]interface{}{
-		"id":             id,
-		"mongo_id":       mongoID,
-		"status":         status,
-		"student_uuid":   nil,
-		"submitted_at":   nil,
-		"verified_at":    nil,
-		"verified_by":    nil,
-		"rejection_note": nil,
-		"created_at":     createdAt,
-		"updated_at":     updatedAt,
+		"id":                   id,
+		"mongo_id":             mongoID,
+		"status":               status,
+		"student_uuid":         nil,
+		"submitted_at":         nil,
+		"verified_at":          nil,
+		"verified_by":          nil,
+		"rejection_note":       nil,
+		"revision_round":       revisionRound,
+		"claimed_points":       nil,
+		"approved_points":      nil,
+		"verification_remarks": nil,
+		"revoked_at":           nil,
+		"revoke_reason":        nil,
+		"created_at":           createdAt,
+		"updated_at":           updatedAt,
 	}
 	if studentUUID.Valid {
 		ref["student_uuid"] = studentUUID.String
@@ -527,84 +916,76 @@ func (r *AchievementRepository) GetReferenceByMongoID(
 	if rejectionNote.Valid {
 		ref["rejection_note"] = rejectionNote.String
 	}
+	if claimedPoints.Valid {
+		ref["claimed_points"] = claimedPoints.Float64
+	}
+	if approvedPoints.Valid {
+		ref["approved_points"] = approvedPoints.Float64
+	}
+	if remarks.Valid {
+		ref["verification_remarks"] = remarks.String
+	}
+	if revokedAt.Valid {
+		ref["revoked_at"] = revokedAt.Time
+	}
+	if revokeReason.Valid {
+		ref["revoke_reason"] = revokeReason.String
+	}
 	return ref, nil
 }
 
-// GET achievement history by mongo_achievement_id
+// GET achievement history by mongo_achievement_id (urut kronologis)
 func (r *AchievementRepository) GetHistoryByMongoID(
 	ctx context.Context,
 	mongoID string,
-) ([]map[string]interface{}, error) {
+) ([]model.AchievementStatusEvent, error) {
 	query := `
-		SELECT 
-			status,
-			created_at,
-			submitted_at,
-			verified_at,
-			verified_by,
-			rejection_note
-		FROM achievement_references
+		SELECT
+			id,
+			achievement_ref_id,
+			mongo_achievement_id,
+			round,
+			from_status,
+			to_status,
+			actor_user_id,
+			actor_role,
+			note,
+			created_at
+		FROM achievement_status_events
 		WHERE mongo_achievement_id = $1
-		  AND is_deleted = FALSE
-		LIMIT 1
+		ORDER BY created_at ASC, id ASC
 	`
-	var (
-		status        string
-		createdAt     time.Time
-		submittedAt   sql.NullTime
-		verifiedAt    sql.NullTime
-		verifiedBy    sql.NullInt64
-		rejectionNote sql.NullString
-	)
-	err := r.DB.QueryRowContext(ctx, query, mongoID).Scan(
-		&status,
-		&createdAt,
-		&submittedAt,
-		&verifiedAt,
-		&verifiedBy,
-		&rejectionNote,
-	)
+	rows, err := r.DB.QueryContext(ctx, query, mongoID)
 	if err != nil {
 		return nil, err
 	}
-	var history []map[string]interface{}
-
-	// Draft
-	history = append(history, map[string]interface{}{
-		"status": "draft",
-		"at":     createdAt,
-		"by":     nil,
-	})
-
-	// Submitted
-	if submittedAt.Valid {
-		history = append(history, map[string]interface{}{
-			"status": "submitted",
-			"at":     submittedAt.Time,
-			"by":     "student",
-		})
-	}
-
-	// Verified / Rejected
-	if status == "verified" && verifiedAt.Valid {
-		history = append(history, map[string]interface{}{
-			"status": "verified",
-			"at":     verifiedAt.Time,
-			"by":     verifiedBy.Int64,
-		})
+	defer rows.Close()
+	history := []model.AchievementStatusEvent{}
+	for rows.Next() {
+		var ev model.AchievementStatusEvent
+		if err := rows.Scan(
+			&ev.ID,
+			&ev.AchievementRefID,
+			&ev.MongoAchievementID,
+			&ev.Round,
+			&ev.FromStatus,
+			&ev.ToStatus,
+			&ev.ActorUserID,
+			&ev.ActorRole,
+			&ev.Note,
+			&ev.CreatedAt,
+		); err != nil {
+			return nil, err
+		}
+		history = append(history, ev)
 	}
-	if status == "rejected" && verifiedAt.Valid {
-		history = append(history, map[string]interface{}{
-			"status": "rejected",
-			"at":     verifiedAt.Time,
-			"by":     verifiedBy.Int64,
-			"note":   rejectionNote.String,
-		})
+	if err := rows.Err(); err != nil {
+		return nil, err
 	}
 	return history, nil
 }
 
-// GET all verified achievement references
+// GET all verified achievement references (revoked otomatis tidak termasuk)
 func (r *AchievementRepository) GetVerifiedAchievementRefs(
 	ctx context.Context,
 ) ([]map[string]interface{}, error) {
@@ -629,14 +1010,14 @@ func (r *AchievementRepository) GetVerifiedAchievementRefs(
 			return nil, err
 		}
 		results = append(results, map[string]interface{}{
-			"mongo_id":   mongoID,
+			"mongo_id":    mongoID,
 			"verified_at": verifiedAt,
 		})
 	}
 	return results, nil
 }
 
-// GET top 5 students dengan achievement VERIFIED terbanyak
+// GET top 5 students dengan achievement VERIFIED terbanyak (revoked tidak dihitung)
 func (r *AchievementRepository) GetTopStudents(
 	ctx context.Context,
 ) ([]map[string]interface{}, error) {
@@ -670,7 +1051,20 @@ func (r *AchievementRepository) GetTopStudents(
 	return results, nil
 }
 
-// GET achievements by student UUID
+// Hitung achievement yang dicabut (revoked), untuk laporan
+func (r *AchievementRepository) CountRevoked(ctx context.Context) (int64, error) {
+	query := `
+		SELECT COUNT(*)
+		FROM achievement_references
+		WHERE status = 'revoked'
+		  AND is_deleted = false
+	`
+	var total int64
+	err := r.DB.QueryRowContext(ctx, query).Scan(&total)
+	return total, err
+}
+
+// GET achievements by student UUID (achievement revoked tidak ikut dihitung)
 func (r *AchievementRepository) GetByStudentUUID(
 	ctx context.Context,
 	studentUUID string,
@@ -680,10 +1074,13 @@ func (r *AchievementRepository) GetByStudentUUID(
 		SELECT
 			mongo_achievement_id,
 			status,
-			verified_at
+			verified_at,
+			claimed_points,
+			approved_points
 		FROM achievement_references
 		WHERE student_uuid = $1
 		  AND is_deleted = false
+		  AND status <> 'revoked'
 	`
 	rows, err := r.DB.QueryContext(ctx, query, studentUUID)
 	if err != nil {
@@ -695,13 +1092,16 @@ func (r *AchievementRepository) GetByStudentUUID(
 		var mongoID string
 		var status string
 		var verifiedAt *string
-		if err := rows.Scan(&mongoID, &status, &verifiedAt); err != nil {
+		var claimed, approved *float64
+		if err := rows.Scan(&mongoID, &status, &verifiedAt, &claimed, &approved); err != nil {
 			return nil, err
 		}
 		results = append(results, map[string]interface{}{
-			"mongo_id":    mongoID,
-			"status":      status,
-			"verified_at": verifiedAt,
+			"mongo_id":        mongoID,
+			"status":          status,
+			"verified_at":     verifiedAt,
+			"claimed_points":  claimed,
+			"approved_points": approved,
 		})
 	}
 	return results, nil
@@ -0,0 +1,139 @@
+package repository
+
+import (
+	"context"
+	"database/sql"
+	"errors"
+	"time"
+
+	"uas/app/model"
+)
+
+// ErrSyncOpNotPending: operasi sudah selesai atau sudah dikompensasi worker
+var ErrSyncOpNotPending = errors.New("sync operation is no longer pending")
+
+type AchievementSyncRepository interface {
+	Begin(ctx context.Context, op *model.AchievementSyncOp) error
+	Complete(ctx context.Context, id int64) error
+	MarkCompensated(ctx context.Context, id int64, reason string) error
+	RecordFailure(ctx context.Context, id int64, reason string) error
+	ListPending(ctx context.Context, olderThan time.Time, limit int) ([]model.AchievementSyncOp, error)
+	HasPending(ctx context.Context, mongoID string) (bool, error)
+}
+
+type AchievementSyncRepositoryImpl struct {
+	DB *sql.DB
+}
+
+func NewAchievementSyncRepository(db *sql.DB) AchievementSyncRepository {
+	return &AchievementSyncRepositoryImpl{DB: db}
+}
+
+// Catat operasi sebagai pending sebelum menulis ke Mongo
+func (r *AchievementSyncRepositoryImpl) Begin(ctx context.Context, op *model.AchievementSyncOp) error {
+	payload := []byte(op.Payload)
+	if len(payload) == 0 {
+		payload = []byte("{}")
+	}
+	return r.DB.QueryRowContext(ctx, `
+		INSERT INTO achievement_sync_ops (
+			op, mongo_achievement_id, payload, status, attempts, created_at, updated_at
+		)
+		VALUES ($1, $2, $3, 'pending', 0, NOW(), NOW())
+		RETURNING id, status, created_at, updated_at
+	`, op.Op, op.MongoAchievementID, payload).Scan(&op.ID, &op.Status, &op.CreatedAt, &op.UpdatedAt)
+}
+
+func (r *AchievementSyncRepositoryImpl) Complete(ctx context.Context, id int64) error {
+	return completeSyncOp(ctx, r.DB, id)
+}
+
+func (r *AchievementSyncRepositoryImpl) MarkCompensated(ctx context.Context, id int64, reason string) error {
+	_, err := r.DB.ExecContext(ctx, `
+		UPDATE achievement_sync_ops
+		SET status = 'compensated',
+		    attempts = attempts + 1,
+		    last_error = $2,
+		    updated_at = NOW()
+		WHERE id = $1 AND status = 'pending'
+	`, id, reason)
+	return err
+}
+
+// Kompensasi gagal: tetap pending, worker akan mencoba lagi
+func (r *AchievementSyncRepositoryImpl) RecordFailure(ctx context.Context, id int64, reason string) error {
+	_, err := r.DB.ExecContext(ctx, `
+		UPDATE achievement_sync_ops
+		SET attempts = attempts + 1,
+		    last_error = $2,
+		    updated_at = NOW()
+		WHERE id = $1 AND status = 'pending'
+	`, id, reason)
+	return err
+}
+
+// HasPending: ada operasi dual-write yang belum selesai untuk dokumen ini
+func (r *AchievementSyncRepositoryImpl) HasPending(ctx context.Context, mongoID string) (bool, error) {
+	var pending bool
+	err := r.DB.QueryRowContext(ctx, `
+		SELECT EXISTS (
+			SELECT 1 FROM achievement_sync_ops
+			WHERE status = 'pending' AND mongo_achievement_id = $1
+		)
+	`, mongoID).Scan(&pending)
+	return pending, err
+}
+
+func (r *AchievementSyncRepositoryImpl) ListPending(
+	ctx context.Context,
+	olderThan time.Time,
+	limit int,
+) ([]model.AchievementSyncOp, error) {
+	rows, err := r.DB.QueryContext(ctx, `
+		SELECT id, op, mongo_achievement_id, payload, status, attempts, last_error, created_at, updated_at
+		FROM achievement_sync_ops
+		WHERE status = 'pending' AND created_at < $1
+		ORDER BY id
+		LIMIT $2
+	`, olderThan, limit)
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+
+	var results []model.AchievementSyncOp
+	for rows.Next() {
+		var op model.AchievementSyncOp
+		var payload []byte
+		if err := rows.Scan(
+			&op.ID, &op.Op, &op.MongoAchievementID, &payload, &op.Status,
+			&op.Attempts, &op.LastError, &op.CreatedAt, &op.UpdatedAt,
+		); err != nil {
+			return nil, err
+		}
+		op.Payload = payload
+		results = append(results, op)
+	}
+	return results, rows.Err()
+}
+
+type sqlExecer interface {
+	ExecContext(ctx context.Context, query string, args ...interface{}) (sql.Result, error)
+}
+
+// Tandai operasi selesai; dipanggil di tx yang sama dengan perubahan PostgreSQL
+// supaya "sudah ada di PostgreSQL" == "operasi done".
+func completeSyncOp(ctx context.Context, db sqlExecer, id int64) error {
+	result, err := db.ExecContext(ctx, `
+		UPDATE achievement_sync_ops
+		SET status = 'done', updated_at = NOW()
+		WHERE id = $1 AND status = 'pending'
+	`, id)
+	if err != nil {
+		return err
+	}
+	if rows, _ := result.RowsAffected(); rows == 0 {
+		return ErrSyncOpNotPending
+	}
+	return nil
+}
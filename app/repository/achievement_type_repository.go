@@ -0,0 +1,165 @@
+package repository
+
+import (
+	"context"
+	"database/sql"
+	"encoding/json"
+
+	"uas/app/model"
+)
+
+type AchievementTypeRepository interface {
+	Create(ctx context.Context, t model.AchievementType) (*model.AchievementType, error)
+	Update(ctx context.Context, t model.AchievementType) (*model.AchievementType, error)
+	Delete(ctx context.Context, key string) error
+	GetByKey(ctx context.Context, key string) (*model.AchievementType, error)
+	List(ctx context.Context) ([]model.AchievementType, error)
+}
+
+type AchievementTypeRepositoryImpl struct {
+	DB *sql.DB
+}
+
+func NewAchievementTypeRepository(db *sql.DB) AchievementTypeRepository {
+	return &AchievementTypeRepositoryImpl{DB: db}
+}
+
+const achievementTypeColumns = `
+	key, label_id, label_en, details_schema, required_attachments,
+	default_rubric, is_active, created_at, updated_at
+`
+
+// CREATE kategori baru di katalog
+func (r *AchievementTypeRepositoryImpl) Create(ctx context.Context, t model.AchievementType) (*model.AchievementType, error) {
+	attachments, rubric, err := marshalAchievementTypeLists(t)
+	if err != nil {
+		return nil, err
+	}
+	row := r.DB.QueryRowContext(ctx, `
+		INSERT INTO achievement_types (
+			key, label_id, label_en, details_schema, required_attachments,
+			default_rubric, is_active, created_at, updated_at
+		)
+		VALUES ($1, $2, $3, $4, $5, $6, $7, NOW(), NOW())
+		RETURNING `+achievementTypeColumns,
+		t.Key, t.LabelID, t.LabelEN, []byte(t.DetailsSchema), attachments, rubric, t.IsActive,
+	)
+	return scanAchievementType(row)
+}
+
+// UPDATE (upsert): kategori bawaan yang belum ada barisnya ikut tersimpan
+func (r *AchievementTypeRepositoryImpl) Update(ctx context.Context, t model.AchievementType) (*model.AchievementType, error) {
+	attachments, rubric, err := marshalAchievementTypeLists(t)
+	if err != nil {
+		return nil, err
+	}
+	row := r.DB.QueryRowContext(ctx, `
+		INSERT INTO achievement_types (
+			key, label_id, label_en, details_schema, required_attachments,
+			default_rubric, is_active, created_at, updated_at
+		)
+		VALUES ($1, $2, $3, $4, $5, $6, $7, NOW(), NOW())
+		ON CONFLICT (key) DO UPDATE
+		SET label_id = EXCLUDED.label_id,
+		    label_en = EXCLUDED.label_en,
+		    details_schema = EXCLUDED.details_schema,
+		    required_attachments = EXCLUDED.required_attachments,
+		    default_rubric = EXCLUDED.default_rubric,
+		    is_active = EXCLUDED.is_active,
+		    updated_at = NOW()
+		RETURNING `+achievementTypeColumns,
+		t.Key, t.LabelID, t.LabelEN, []byte(t.DetailsSchema), attachments, rubric, t.IsActive,
+	)
+	return scanAchievementType(row)
+}
+
+func (r *AchievementTypeRepositoryImpl) Delete(ctx context.Context, key string) error {
+	result, err := r.DB.ExecContext(ctx, `DELETE FROM achievement_types WHERE key = $1`, key)
+	if err != nil {
+		return err
+	}
+	if rows, _ := result.RowsAffected(); rows == 0 {
+		return sql.ErrNoRows
+	}
+	return nil
+}
+
+func (r *AchievementTypeRepositoryImpl) GetByKey(ctx context.Context, key string) (*model.AchievementType, error) {
+	row := r.DB.QueryRowContext(ctx, `
+		SELECT `+achievementTypeColumns+`
+		FROM achievement_types
+		WHERE key = $1
+	`, key)
+	return scanAchievementType(row)
+}
+
+func (r *AchievementTypeRepositoryImpl) List(ctx context.Context) ([]model.AchievementType, error) {
+	rows, err := r.DB.QueryContext(ctx, `
+		SELECT `+achievementTypeColumns+`
+		FROM achievement_types
+		ORDER BY key
+	`)
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+
+	var results []model.AchievementType
+	for rows.Next() {
+		t, err := scanAchievementType(rows)
+		if err != nil {
+			return nil, err
+		}
+		results = append(results, *t)
+	}
+	return results, rows.Err()
+}
+
+type rowScanner interface {
+	Scan(dest ...interface{}) error
+}
+
+func scanAchievementType(row rowScanner) (*model.AchievementType, error) {
+	var t model.AchievementType
+	var schema, attachments, rubric []byte
+	var createdAt, updatedAt sql.NullTime
+	if err := row.Scan(
+		&t.Key, &t.LabelID, &t.LabelEN, &schema, &attachments,
+		&rubric, &t.IsActive, &createdAt, &updatedAt,
+	); err != nil {
+		return nil, err
+	}
+	t.DetailsSchema = json.RawMessage(schema)
+	if err := json.Unmarshal(attachments, &t.RequiredAttachments); err != nil {
+		return nil, err
+	}
+	if err := json.Unmarshal(rubric, &t.DefaultRubric); err != nil {
+		return nil, err
+	}
+	if createdAt.Valid {
+		t.CreatedAt = &createdAt.Time
+	}
+	if updatedAt.Valid {
+		t.UpdatedAt = &updatedAt.Time
+	}
+	return &t, nil
+}
+
+// required_attachments dan default_rubric disimpan sebagai JSONB
+func marshalAchievementTypeLists(t model.AchievementType) ([]byte, []byte, error) {
+	if t.RequiredAttachments == nil {
+		t.RequiredAttachments = []string{}
+	}
+	if t.DefaultRubric == nil {
+		t.DefaultRubric = []model.PointRubricEntry{}
+	}
+	attachments, err := json.Marshal(t.RequiredAttachments)
+	if err != nil {
+		return nil, nil, err
+	}
+	rubric, err := json.Marshal(t.DefaultRubric)
+	if err != nil {
+		return nil, nil, err
+	}
+	return attachments, rubric, nil
+}
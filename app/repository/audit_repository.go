@@ -0,0 +1,43 @@
+package repository
+
+import (
+	"context"
+	"database/sql"
+	"encoding/json"
+	"uas/app/model"
+)
+
+type AuditRepository interface {
+	Record(ctx context.Context, entry *model.AuditLog) error
+}
+
+type AuditRepositoryImpl struct {
+	DB *sql.DB
+}
+
+func NewAuditRepository(db *sql.DB) AuditRepository {
+	return &AuditRepositoryImpl{DB: db}
+}
+
+func (r *AuditRepositoryImpl) Record(ctx context.Context, entry *model.AuditLog) error {
+	details := entry.Details
+	if details == nil {
+		details = map[string]interface{}{}
+	}
+	raw, err := json.Marshal(details)
+	if err != nil {
+		return err
+	}
+	query := `
+		INSERT INTO audit_logs (event, user_id, actor_id, ip_address, details)
+		VALUES ($1, $2, $3, $4, $5)
+		RETURNING id, created_at
+	`
+	return r.DB.QueryRowContext(ctx, query,
+		entry.Event,
+		entry.UserID,
+		entry.ActorID,
+		entry.IPAddress,
+		raw,
+	).Scan(&entry.ID, &entry.CreatedAt)
+}
@@ -0,0 +1,175 @@
+package repository
+
+import (
+	"context"
+	"database/sql"
+	"errors"
+	"time"
+
+	"uas/app/model"
+
+	"github.com/lib/pq"
+)
+
+// ErrProvisionConflict: username, email, NIM atau NIP sudah dipakai user lain
+var ErrProvisionConflict = errors.New("identity already used by another user")
+
+type IdentityRepository interface {
+	// LOGIN STATE (PKCE)
+	SaveLoginState(ctx context.Context, stateHash string, state model.OIDCLoginState, expiresAt time.Time) error
+	ConsumeLoginState(ctx context.Context, stateHash string) (*model.OIDCLoginState, error)
+
+	// AKUN IdP ↔ USER
+	FindUserID(ctx context.Context, issuer, subject string) (int64, error)
+	MatchUsers(ctx context.Context, email, nim, nip string) ([]int64, error)
+	Link(ctx context.Context, userID int64, identity model.OIDCIdentity) error
+	Provision(ctx context.Context, p model.OIDCProvision) (int64, error)
+}
+
+type IdentityRepositoryImpl struct {
+	DB *sql.DB
+}
+
+func NewIdentityRepository(db *sql.DB) IdentityRepository {
+	return &IdentityRepositoryImpl{DB: db}
+}
+
+// SaveLoginState menyimpan verifier + nonce; state kedaluwarsa dibersihkan sekalian
+func (r *IdentityRepositoryImpl) SaveLoginState(
+	ctx context.Context,
+	stateHash string,
+	state model.OIDCLoginState,
+	expiresAt time.Time,
+) error {
+	if _, err := r.DB.ExecContext(ctx, `DELETE FROM oidc_login_states WHERE expires_at <= NOW()`); err != nil {
+		return err
+	}
+	_, err := r.DB.ExecContext(ctx, `
+		INSERT INTO oidc_login_states (state_hash, code_verifier, nonce, expires_at)
+		VALUES ($1, $2, $3, $4)
+	`, stateHash, state.CodeVerifier, state.Nonce, expiresAt)
+	return err
+}
+
+// ConsumeLoginState menghapus state dan mengembalikan isinya. Atomic: satu
+// state hanya bisa dipakai sekali. sql.ErrNoRows jika tidak ada / kedaluwarsa.
+func (r *IdentityRepositoryImpl) ConsumeLoginState(ctx context.Context, stateHash string) (*model.OIDCLoginState, error) {
+	var s model.OIDCLoginState
+	err := r.DB.QueryRowContext(ctx, `
+		DELETE FROM oidc_login_states
+		WHERE state_hash = $1 AND expires_at > NOW()
+		RETURNING code_verifier, nonce
+	`, stateHash).Scan(&s.CodeVerifier, &s.Nonce)
+	if err != nil {
+		return nil, err
+	}
+	return &s, nil
+}
+
+// FindUserID: user yang sudah terhubung ke akun IdP; sql.ErrNoRows jika belum
+func (r *IdentityRepositoryImpl) FindUserID(ctx context.Context, issuer, subject string) (int64, error) {
+	var userID int64
+	err := r.DB.QueryRowContext(ctx, `
+		SELECT user_id FROM user_identities WHERE issuer = $1 AND subject = $2
+	`, issuer, subject).Scan(&userID)
+	return userID, err
+}
+
+// MatchUsers: user lokal yang cocok dengan email, NIM atau NIP (argumen
+// kosong diabaikan). Lebih dari satu hasil berarti data tidak konsisten.
+func (r *IdentityRepositoryImpl) MatchUsers(ctx context.Context, email, nim, nip string) ([]int64, error) {
+	rows, err := r.DB.QueryContext(ctx, `
+		SELECT DISTINCT u.id
+		FROM users u
+		LEFT JOIN students s ON s.user_id = u.id
+		LEFT JOIN lecturers l ON l.user_id = u.id
+		WHERE ($1 <> '' AND LOWER(u.email) = LOWER($1))
+		   OR ($2 <> '' AND s.nim = $2)
+		   OR ($3 <> '' AND l.nip = $3)
+		ORDER BY u.id
+	`, email, nim, nip)
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+
+	ids := []int64{}
+	for rows.Next() {
+		var id int64
+		if err := rows.Scan(&id); err != nil {
+			return nil, err
+		}
+		ids = append(ids, id)
+	}
+	return ids, rows.Err()
+}
+
+// Link menghubungkan akun IdP ke user (atau memperbarui last_login_at).
+// sql.ErrNoRows jika akun IdP sudah terhubung ke user lain.
+func (r *IdentityRepositoryImpl) Link(ctx context.Context, userID int64, identity model.OIDCIdentity) error {
+	return linkIdentity(ctx, r.DB, userID, identity)
+}
+
+// Provision membuat user, profil (mahasiswa / dosen wali) dan tautan akun
+// IdP dalam satu transaksi
+func (r *IdentityRepositoryImpl) Provision(ctx context.Context, p model.OIDCProvision) (int64, error) {
+	tx, err := r.DB.BeginTx(ctx, nil)
+	if err != nil {
+		return 0, err
+	}
+	defer tx.Rollback()
+
+	var userID int64
+	err = tx.QueryRowContext(ctx, `
+		INSERT INTO users (username, full_name, email, password_hash, role_id, is_active)
+		VALUES ($1, $2, $3, $4, $5, TRUE)
+		RETURNING id
+	`, p.Username, p.Identity.FullName, p.Identity.Email, p.PasswordHash, p.RoleID).Scan(&userID)
+	if err != nil {
+		return 0, uniqueViolation(err)
+	}
+
+	switch p.Role {
+	case model.RoleStudent:
+		_, err = tx.ExecContext(ctx, `
+			INSERT INTO students (user_id, nim, program_study)
+			VALUES ($1, $2, NULLIF($3, ''))
+		`, userID, p.Identity.NIM, p.Identity.ProgramStudy)
+	case model.RoleLecturer:
+		_, err = tx.ExecContext(ctx, `
+			INSERT INTO lecturers (user_id, nip, department)
+			VALUES ($1, $2, NULLIF($3, ''))
+		`, userID, p.Identity.NIP, p.Identity.Department)
+	}
+	if err != nil {
+		return 0, uniqueViolation(err)
+	}
+
+	if err := linkIdentity(ctx, tx, userID, p.Identity); err != nil {
+		return 0, err
+	}
+	return userID, tx.Commit()
+}
+
+func uniqueViolation(err error) error {
+	var pqErr *pq.Error
+	if errors.As(err, &pqErr) && pqErr.Code == "23505" {
+		return ErrProvisionConflict
+	}
+	return err
+}
+
+type execer interface {
+	ExecContext(ctx context.Context, query string, args ...interface{}) (sql.Result, error)
+}
+
+func linkIdentity(ctx context.Context, db execer, userID int64, identity model.OIDCIdentity) error {
+	result, err := db.ExecContext(ctx, `
+		INSERT INTO user_identities (issuer, subject, user_id, email)
+		VALUES ($1, $2, $3, NULLIF($4, ''))
+		ON CONFLICT (issuer, subject) DO UPDATE
+		SET last_login_at = NOW(), email = EXCLUDED.email
+		WHERE user_identities.user_id = EXCLUDED.user_id
+	`, identity.Issuer, identity.Subject, userID, identity.Email)
+	return expectOneRow(result, err)
+}
@@ -0,0 +1,79 @@
+package repository
+
+import (
+	"context"
+	"database/sql"
+	"time"
+
+	"github.com/lib/pq"
+)
+
+// LoginThrottleRepository: penghitung login gagal per key ("user:<id>", "ip:<alamat>")
+type LoginThrottleRepository interface {
+	LockedUntil(ctx context.Context, keys []string) (time.Time, error)
+	RecordFailure(ctx context.Context, key string, window time.Duration) (int, error)
+	Lock(ctx context.Context, key string, until time.Time) error
+	Reset(ctx context.Context, key string) (bool, error)
+}
+
+type LoginThrottleRepositoryImpl struct {
+	DB *sql.DB
+}
+
+func NewLoginThrottleRepository(db *sql.DB) LoginThrottleRepository {
+	return &LoginThrottleRepositoryImpl{DB: db}
+}
+
+// LockedUntil: kunci paling lama dari semua key; zero time jika tidak terkunci
+func (r *LoginThrottleRepositoryImpl) LockedUntil(ctx context.Context, keys []string) (time.Time, error) {
+	query := `
+		SELECT MAX(locked_until)
+		FROM login_throttles
+		WHERE key = ANY($1) AND locked_until > NOW()
+	`
+	var until sql.NullTime
+	if err := r.DB.QueryRowContext(ctx, query, pq.Array(keys)).Scan(&until); err != nil {
+		return time.Time{}, err
+	}
+	return until.Time, nil
+}
+
+// RecordFailure menambah hitungan gagal dan mengembalikan jumlahnya.
+// Tidak ada gagal selama window → hitungan mulai dari 1 lagi. Window dihitung
+// dari akhir kunci terakhir: selama dikunci tidak ada gagal yang tercatat,
+// jadi kunci yang lebih lama dari window tidak boleh mereset backoff.
+func (r *LoginThrottleRepositoryImpl) RecordFailure(ctx context.Context, key string, window time.Duration) (int, error) {
+	query := `
+		INSERT INTO login_throttles (key, failures, last_failure_at)
+		VALUES ($1, 1, NOW())
+		ON CONFLICT (key) DO UPDATE
+		SET failures = CASE
+		        WHEN GREATEST(login_throttles.last_failure_at, login_throttles.locked_until)
+		             < NOW() - make_interval(secs => $2)
+		        THEN 1 ELSE login_throttles.failures + 1
+		    END,
+		    last_failure_at = NOW()
+		RETURNING failures
+	`
+	var failures int
+	err := r.DB.QueryRowContext(ctx, query, key, window.Seconds()).Scan(&failures)
+	return failures, err
+}
+
+func (r *LoginThrottleRepositoryImpl) Lock(ctx context.Context, key string, until time.Time) error {
+	query := `UPDATE login_throttles SET locked_until = $2 WHERE key = $1`
+	_, err := r.DB.ExecContext(ctx, query, key, until)
+	return err
+}
+
+// Reset menghapus hitungan key; true jika key sempat dikunci
+// (locked_until terisi, sudah lewat maupun belum)
+func (r *LoginThrottleRepositoryImpl) Reset(ctx context.Context, key string) (bool, error) {
+	query := `DELETE FROM login_throttles WHERE key = $1 RETURNING locked_until IS NOT NULL`
+	var wasLocked bool
+	err := r.DB.QueryRowContext(ctx, query, key).Scan(&wasLocked)
+	if err == sql.ErrNoRows {
+		return false, nil
+	}
+	return wasLocked, err
+}
@@ -0,0 +1,345 @@
+package repository
+
+import (
+	"bytes"
+	"context"
+	"fmt"
+	"strings"
+	"sync"
+	"time"
+
+	"uas/app/model"
+
+	"go.mongodb.org/mongo-driver/bson"
+	"go.mongodb.org/mongo-driver/bson/primitive"
+)
+
+// MemoryAchievementStore: AchievementDocumentStore di memori untuk test dan
+// mode dev lokal (ACHIEVEMENT_STORE=memory). Dokumen disimpan sebagai BSON
+// sehingga tipe hasil baca sama dengan Mongo (mis. time.Time → primitive.DateTime).
+//
+// Filter yang didukung: kesamaan field top-level dan {"$exists": bool}.
+// Update yang didukung: $set, $unset, $push, $pull (kesamaan sub-field).
+type MemoryAchievementStore struct {
+	mu    sync.RWMutex
+	docs  map[primitive.ObjectID][]byte
+	order []primitive.ObjectID
+}
+
+func NewMemoryAchievementStore() *MemoryAchievementStore {
+	return &MemoryAchievementStore{docs: map[primitive.ObjectID][]byte{}}
+}
+
+func (m *MemoryAchievementStore) Insert(ctx context.Context, doc bson.M) error {
+	id, ok := doc["_id"].(primitive.ObjectID)
+	if !ok {
+		// seperti driver Mongo: _id dibuat tanpa mengubah map pemanggil
+		id = primitive.NewObjectID()
+		withID := bson.M{"_id": id}
+		for k, v := range doc {
+			withID[k] = v
+		}
+		doc = withID
+	}
+	raw, err := bson.Marshal(doc)
+	if err != nil {
+		return err
+	}
+
+	m.mu.Lock()
+	defer m.mu.Unlock()
+	if _, exists := m.docs[id]; exists {
+		return fmt.Errorf("duplicate key _id %s", id.Hex())
+	}
+	m.docs[id] = raw
+	m.order = append(m.order, id)
+	return nil
+}
+
+func (m *MemoryAchievementStore) Update(ctx context.Context, filter bson.M, update bson.M) (int64, error) {
+	m.mu.Lock()
+	defer m.mu.Unlock()
+
+	for _, id := range m.order {
+		doc, err := decodeDocument(m.docs[id])
+		if err != nil {
+			return 0, err
+		}
+		ok, err := matchDocument(doc, filter)
+		if err != nil {
+			return 0, err
+		}
+		if !ok {
+			continue
+		}
+		if err := applyUpdate(doc, update); err != nil {
+			return 0, err
+		}
+		raw, err := bson.Marshal(doc)
+		if err != nil {
+			return 0, err
+		}
+		m.docs[id] = raw
+		return 1, nil
+	}
+	return 0, nil
+}
+
+func (m *MemoryAchievementStore) SoftDelete(ctx context.Context, id primitive.ObjectID, deletedAt time.Time) error {
+	matched, err := m.Update(ctx,
+		bson.M{"_id": id, "deletedAt": bson.M{"$exists": false}},
+		bson.M{"$set": bson.M{"deletedAt": deletedAt}},
+	)
+	if err != nil {
+		return err
+	}
+	if matched == 0 {
+		return ErrDocumentNotFound
+	}
+	return nil
+}
+
+func (m *MemoryAchievementStore) PushAttachment(ctx context.Context, id primitive.ObjectID, attachment bson.M) error {
+	matched, err := m.Update(ctx, bson.M{"_id": id}, bson.M{"$push": bson.M{"attachments": attachment}})
+	if err != nil {
+		return err
+	}
+	if matched == 0 {
+		return ErrDocumentNotFound
+	}
+	return nil
+}
+
+func (m *MemoryAchievementStore) Delete(ctx context.Context, filter bson.M) error {
+	m.mu.Lock()
+	defer m.mu.Unlock()
+
+	for i, id := range m.order {
+		doc, err := decodeDocument(m.docs[id])
+		if err != nil {
+			return err
+		}
+		ok, err := matchDocument(doc, filter)
+		if err != nil {
+			return err
+		}
+		if ok {
+			delete(m.docs, id)
+			m.order = append(m.order[:i], m.order[i+1:]...)
+			return nil
+		}
+	}
+	return nil
+}
+
+func (m *MemoryAchievementStore) FindOne(ctx context.Context, filter bson.M) (bson.M, error) {
+	m.mu.RLock()
+	defer m.mu.RUnlock()
+
+	for _, id := range m.order {
+		doc, err := decodeDocument(m.docs[id])
+		if err != nil {
+			return nil, err
+		}
+		ok, err := matchDocument(doc, filter)
+		if err != nil {
+			return nil, err
+		}
+		if ok {
+			return doc, nil
+		}
+	}
+	return nil, ErrDocumentNotFound
+}
+
+func (m *MemoryAchievementStore) FindManyByIDs(ctx context.Context, ids []primitive.ObjectID) ([]bson.M, error) {
+	m.mu.RLock()
+	defer m.mu.RUnlock()
+
+	results := []bson.M{}
+	for _, id := range uniqueObjectIDs(ids) {
+		raw, ok := m.docs[id]
+		if !ok {
+			continue
+		}
+		doc, err := decodeDocument(raw)
+		if err != nil {
+			return nil, err
+		}
+		results = append(results, doc)
+	}
+	return results, nil
+}
+
+func (m *MemoryAchievementStore) FindByIDs(ctx context.Context, ids []primitive.ObjectID) ([]Achievement, error) {
+	m.mu.RLock()
+	defer m.mu.RUnlock()
+
+	var results []Achievement
+	for _, id := range uniqueObjectIDs(ids) {
+		raw, ok := m.docs[id]
+		if !ok {
+			continue
+		}
+		var a Achievement
+		if err := bson.Unmarshal(raw, &a); err != nil {
+			return nil, err
+		}
+		results = append(results, a)
+	}
+	return results, nil
+}
+
+func (m *MemoryAchievementStore) ListDocumentStates(ctx context.Context) ([]model.DocumentState, error) {
+	m.mu.RLock()
+	defer m.mu.RUnlock()
+
+	var results []model.DocumentState
+	for _, id := range m.order {
+		var doc struct {
+			StudentID string     `bson:"studentId"`
+			DeletedAt *time.Time `bson:"deletedAt"`
+		}
+		if err := bson.Unmarshal(m.docs[id], &doc); err != nil {
+			return nil, err
+		}
+		results = append(results, model.DocumentState{
+			MongoID:   id.Hex(),
+			StudentID: doc.StudentID,
+			DeletedAt: doc.DeletedAt,
+		})
+	}
+	return results, nil
+}
+
+func (m *MemoryAchievementStore) GetDocumentState(ctx context.Context, id primitive.ObjectID) (*model.DocumentState, error) {
+	m.mu.RLock()
+	defer m.mu.RUnlock()
+
+	raw, ok := m.docs[id]
+	if !ok {
+		return nil, ErrDocumentNotFound
+	}
+	var doc struct {
+		StudentID string     `bson:"studentId"`
+		DeletedAt *time.Time `bson:"deletedAt"`
+	}
+	if err := bson.Unmarshal(raw, &doc); err != nil {
+		return nil, err
+	}
+	return &model.DocumentState{MongoID: id.Hex(), StudentID: doc.StudentID, DeletedAt: doc.DeletedAt}, nil
+}
+
+func decodeDocument(raw []byte) (bson.M, error) {
+	var doc bson.M
+	if err := bson.Unmarshal(raw, &doc); err != nil {
+		return nil, err
+	}
+	return doc, nil
+}
+
+func uniqueObjectIDs(ids []primitive.ObjectID) []primitive.ObjectID {
+	seen := map[primitive.ObjectID]bool{}
+	out := make([]primitive.ObjectID, 0, len(ids))
+	for _, id := range ids {
+		if !seen[id] {
+			seen[id] = true
+			out = append(out, id)
+		}
+	}
+	return out
+}
+
+// Dua nilai dianggap sama jika encoding BSON-nya sama
+// (time.Time vs primitive.DateTime, int vs int32, dst.)
+func bsonValueEqual(a, b interface{}) bool {
+	ra, errA := bson.Marshal(bson.M{"v": a})
+	rb, errB := bson.Marshal(bson.M{"v": b})
+	return errA == nil && errB == nil && bytes.Equal(ra, rb)
+}
+
+func matchDocument(doc bson.M, filter bson.M) (bool, error) {
+	for field, want := range filter {
+		if strings.HasPrefix(field, "$") {
+			return false, fmt.Errorf("memory store: unsupported filter operator %s", field)
+		}
+		got, exists := doc[field]
+		if cond, ok := want.(bson.M); ok && len(cond) > 0 && isOperatorMap(cond) {
+			for op, arg := range cond {
+				switch op {
+				case "$exists":
+					if arg.(bool) != exists {
+						return false, nil
+					}
+				default:
+					return false, fmt.Errorf("memory store: unsupported filter operator %s", op)
+				}
+			}
+			continue
+		}
+		if !exists || !bsonValueEqual(got, want) {
+			return false, nil
+		}
+	}
+	return true, nil
+}
+
+func isOperatorMap(m bson.M) bool {
+	for k := range m {
+		if !strings.HasPrefix(k, "$") {
+			return false
+		}
+	}
+	return true
+}
+
+func applyUpdate(doc bson.M, update bson.M) error {
+	for op, arg := range update {
+		fields, ok := arg.(bson.M)
+		if !ok {
+			return fmt.Errorf("memory store: %s expects a document", op)
+		}
+		switch op {
+		case "$set":
+			for k, v := range fields {
+				doc[k] = v
+			}
+		case "$unset":
+			for k := range fields {
+				delete(doc, k)
+			}
+		case "$push":
+			for k, v := range fields {
+				list, _ := doc[k].(bson.A)
+				doc[k] = append(list, v)
+			}
+		case "$pull":
+			for k, cond := range fields {
+				list, _ := doc[k].(bson.A)
+				kept := bson.A{}
+				for _, item := range list {
+					if !pullMatches(item, cond) {
+						kept = append(kept, item)
+					}
+				}
+				doc[k] = kept
+			}
+		default:
+			return fmt.Errorf("memory store: unsupported update operator %s", op)
+		}
+	}
+	return nil
+}
+
+func pullMatches(item interface{}, cond interface{}) bool {
+	condDoc, ok := cond.(bson.M)
+	if !ok {
+		return bsonValueEqual(item, cond)
+	}
+	itemDoc, ok := item.(bson.M)
+	if !ok {
+		return false
+	}
+	matched, err := matchDocument(itemDoc, condDoc)
+	return err == nil && matched
+}
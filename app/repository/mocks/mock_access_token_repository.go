@@ -0,0 +1,44 @@
+package mocks
+
+import (
+	"context"
+	"time"
+
+	"uas/app/model"
+	"uas/app/repository"
+
+	"github.com/stretchr/testify/mock"
+)
+
+type MockAccessTokenRepository struct {
+	mock.Mock
+}
+
+var _ repository.AccessTokenRepository = (*MockAccessTokenRepository)(nil)
+
+func (m *MockAccessTokenRepository) State(
+	ctx context.Context,
+	userID int64,
+	jti, sessionID string,
+) (*model.AccessTokenState, error) {
+	args := m.Called(ctx, userID, jti, sessionID)
+	if args.Get(0) == nil {
+		return nil, args.Error(1)
+	}
+	return args.Get(0).(*model.AccessTokenState), args.Error(1)
+}
+
+func (m *MockAccessTokenRepository) Revoke(
+	ctx context.Context,
+	jti string,
+	userID int64,
+	expiresAt time.Time,
+) error {
+	args := m.Called(ctx, jti, userID, expiresAt)
+	return args.Error(0)
+}
+
+func (m *MockAccessTokenRepository) RevokeAll(ctx context.Context, userID int64) error {
+	args := m.Called(ctx, userID)
+	return args.Error(0)
+}
@@ -3,6 +3,7 @@ package mocks
 import (
 	"context"
 
+	"uas/app/model"
 	"uas/app/repository"
 
 	"github.com/stretchr/testify/mock"
@@ -14,6 +15,8 @@ type MockAchievementRepository struct {
 	mock.Mock
 }
 
+var _ repository.AchievementReferenceRepository = (*MockAchievementRepository)(nil)
+
 // =======================
 // METHODS YANG DIPAKAI SERVICE
 // =======================
@@ -30,8 +33,10 @@ func (m *MockAchievementRepository) InsertReference(
 	ctx context.Context,
 	studentID string,
 	mongoID string,
+	actor repository.AchievementActor,
+	syncOpID int64,
 ) error {
-	args := m.Called(ctx, studentID, mongoID)
+	args := m.Called(ctx, studentID, mongoID, actor, syncOpID)
 	return args.Error(0)
 }
 
@@ -39,8 +44,9 @@ func (m *MockAchievementRepository) Submit(
 	ctx context.Context,
 	achievementID string,
 	studentID string,
+	actor repository.AchievementActor,
 ) error {
-	args := m.Called(ctx, achievementID, studentID)
+	args := m.Called(ctx, achievementID, studentID, actor)
 	return args.Error(0)
 }
 
@@ -74,9 +80,12 @@ func (m *MockAchievementRepository) Verify(
 	achievementID string,
 	studentID string,
 	lecturerID int64,
-	points float64,
+	claimedPoints float64,
+	approvedPoints float64,
+	remarks string,
+	actor repository.AchievementActor,
 ) error {
-	args := m.Called(ctx, achievementID, studentID, lecturerID, points)
+	args := m.Called(ctx, achievementID, studentID, lecturerID, claimedPoints, approvedPoints, remarks, actor)
 	return args.Error(0)
 }
 
@@ -86,82 +95,175 @@ func (m *MockAchievementRepository) Reject(
 	studentID string,
 	lecturerID int64,
 	note string,
+	actor repository.AchievementActor,
 ) error {
-	args := m.Called(ctx, achievementID, studentID, lecturerID, note)
+	args := m.Called(ctx, achievementID, studentID, lecturerID, note, actor)
 	return args.Error(0)
 }
 
+func (m *MockAchievementRepository) Revoke(
+	ctx context.Context,
+	achievementID string,
+	reason string,
+	actor repository.AchievementActor,
+) (float64, error) {
+	args := m.Called(ctx, achievementID, reason, actor)
+	return args.Get(0).(float64), args.Error(1)
+}
+
+func (m *MockAchievementRepository) Revise(
+	ctx context.Context,
+	achievementID string,
+	studentID string,
+	note string,
+	actor repository.AchievementActor,
+) (int, error) {
+	args := m.Called(ctx, achievementID, studentID, note, actor)
+	return args.Int(0), args.Error(1)
+}
+
 func (m *MockAchievementRepository) SoftDelete(
 	ctx context.Context,
 	achievementID string,
 	userID int64,
+	actor repository.AchievementActor,
+	syncOpID int64,
 ) error {
-	args := m.Called(ctx, achievementID, userID)
+	args := m.Called(ctx, achievementID, userID, actor, syncOpID)
 	return args.Error(0)
 }
 
-// =======================
-// DUMMY METHODS (WAJIB ADA)
-// =======================
-
 func (m *MockAchievementRepository) GetByStudentID(
 	ctx context.Context,
 	studentID string,
 	includeDeleted bool,
 ) ([]map[string]interface{}, error) {
-	return []map[string]interface{}{}, nil
+	args := m.Called(ctx, studentID, includeDeleted)
+	if args.Get(0) == nil {
+		return nil, args.Error(1)
+	}
+	return args.Get(0).([]map[string]interface{}), args.Error(1)
 }
 
 func (m *MockAchievementRepository) AdminGetAll(
 	ctx context.Context,
 	f repository.AchievementAdminFilter,
 ) ([]map[string]interface{}, int64, error) {
-	return []map[string]interface{}{}, 0, nil
+	args := m.Called(ctx, f)
+	if args.Get(0) == nil {
+		return nil, args.Get(1).(int64), args.Error(2)
+	}
+	return args.Get(0).([]map[string]interface{}), args.Get(1).(int64), args.Error(2)
 }
 
 func (m *MockAchievementRepository) GetStudentsByAdvisor(
 	ctx context.Context,
 	advisorID int64,
 ) ([]string, error) {
-	return []string{}, nil
+	args := m.Called(ctx, advisorID)
+	if args.Get(0) == nil {
+		return nil, args.Error(1)
+	}
+	return args.Get(0).([]string), args.Error(1)
 }
 
 func (m *MockAchievementRepository) GetReferencesByStudentList(
 	ctx context.Context,
 	studentIDs []string,
 ) ([]map[string]interface{}, error) {
-	return []map[string]interface{}{}, nil
+	args := m.Called(ctx, studentIDs)
+	if args.Get(0) == nil {
+		return nil, args.Error(1)
+	}
+	return args.Get(0).([]map[string]interface{}), args.Error(1)
+}
+
+func (m *MockAchievementRepository) ListReferenceStates(
+	ctx context.Context,
+) ([]model.ReferenceState, error) {
+	args := m.Called(ctx)
+	if args.Get(0) == nil {
+		return nil, args.Error(1)
+	}
+	return args.Get(0).([]model.ReferenceState), args.Error(1)
+}
+
+func (m *MockAchievementRepository) GetReferenceState(
+	ctx context.Context,
+	mongoID string,
+) (*model.ReferenceState, error) {
+	args := m.Called(ctx, mongoID)
+	if args.Get(0) == nil {
+		return nil, args.Error(1)
+	}
+	return args.Get(0).(*model.ReferenceState), args.Error(1)
+}
+
+func (m *MockAchievementRepository) SoftDeleteDangling(
+	ctx context.Context,
+	refID string,
+	actor repository.AchievementActor,
+) error {
+	args := m.Called(ctx, refID, actor)
+	return args.Error(0)
 }
 
 func (m *MockAchievementRepository) GetReferenceByMongoID(
 	ctx context.Context,
 	mongoID string,
 ) (map[string]interface{}, error) {
-	return map[string]interface{}{}, nil
+	args := m.Called(ctx, mongoID)
+	if args.Get(0) == nil {
+		return nil, args.Error(1)
+	}
+	return args.Get(0).(map[string]interface{}), args.Error(1)
 }
 
 func (m *MockAchievementRepository) GetHistoryByMongoID(
 	ctx context.Context,
 	mongoID string,
-) ([]map[string]interface{}, error) {
-	return []map[string]interface{}{}, nil
+) ([]model.AchievementStatusEvent, error) {
+	args := m.Called(ctx, mongoID)
+	if args.Get(0) == nil {
+		return nil, args.Error(1)
+	}
+	return args.Get(0).([]model.AchievementStatusEvent), args.Error(1)
 }
 
 func (m *MockAchievementRepository) GetVerifiedAchievementRefs(
 	ctx context.Context,
 ) ([]map[string]interface{}, error) {
-	return []map[string]interface{}{}, nil
+	args := m.Called(ctx)
+	if args.Get(0) == nil {
+		return nil, args.Error(1)
+	}
+	return args.Get(0).([]map[string]interface{}), args.Error(1)
 }
 
 func (m *MockAchievementRepository) GetTopStudents(
 	ctx context.Context,
 ) ([]map[string]interface{}, error) {
-	return []map[string]interface{}{}, nil
+	args := m.Called(ctx)
+	if args.Get(0) == nil {
+		return nil, args.Error(1)
+	}
+	return args.Get(0).([]map[string]interface{}), args.Error(1)
+}
+
+func (m *MockAchievementRepository) CountRevoked(
+	ctx context.Context,
+) (int64, error) {
+	args := m.Called(ctx)
+	return args.Get(0).(int64), args.Error(1)
 }
 
 func (m *MockAchievementRepository) GetByStudentUUID(
 	ctx context.Context,
 	studentUUID string,
 ) ([]map[string]interface{}, error) {
-	return []map[string]interface{}{}, nil
+	args := m.Called(ctx, studentUUID)
+	if args.Get(0) == nil {
+		return nil, args.Error(1)
+	}
+	return args.Get(0).([]map[string]interface{}), args.Error(1)
 }
@@ -0,0 +1,65 @@
+package mocks
+
+import (
+	"context"
+	"time"
+
+	"uas/app/model"
+
+	"github.com/stretchr/testify/mock"
+)
+
+type MockAchievementSyncRepository struct {
+	mock.Mock
+}
+
+func (m *MockAchievementSyncRepository) Begin(
+	ctx context.Context,
+	op *model.AchievementSyncOp,
+) error {
+	args := m.Called(ctx, op)
+	return args.Error(0)
+}
+
+func (m *MockAchievementSyncRepository) Complete(
+	ctx context.Context,
+	id int64,
+) error {
+	args := m.Called(ctx, id)
+	return args.Error(0)
+}
+
+func (m *MockAchievementSyncRepository) MarkCompensated(
+	ctx context.Context,
+	id int64,
+	reason string,
+) error {
+	args := m.Called(ctx, id, reason)
+	return args.Error(0)
+}
+
+func (m *MockAchievementSyncRepository) RecordFailure(
+	ctx context.Context,
+	id int64,
+	reason string,
+) error {
+	args := m.Called(ctx, id, reason)
+	return args.Error(0)
+}
+
+func (m *MockAchievementSyncRepository) ListPending(
+	ctx context.Context,
+	olderThan time.Time,
+	limit int,
+) ([]model.AchievementSyncOp, error) {
+	args := m.Called(ctx, olderThan, limit)
+	if args.Get(0) == nil {
+		return nil, args.Error(1)
+	}
+	return args.Get(0).([]model.AchievementSyncOp), args.Error(1)
+}
+
+func (m *MockAchievementSyncRepository) HasPending(ctx context.Context, mongoID string) (bool, error) {
+	args := m.Called(ctx, mongoID)
+	return args.Bool(0), args.Error(1)
+}
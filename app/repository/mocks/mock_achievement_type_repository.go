@@ -0,0 +1,64 @@
+package mocks
+
+import (
+	"context"
+
+	"uas/app/model"
+
+	"github.com/stretchr/testify/mock"
+)
+
+type MockAchievementTypeRepository struct {
+	mock.Mock
+}
+
+func (m *MockAchievementTypeRepository) Create(
+	ctx context.Context,
+	t model.AchievementType,
+) (*model.AchievementType, error) {
+	args := m.Called(ctx, t)
+	if args.Get(0) == nil {
+		return nil, args.Error(1)
+	}
+	return args.Get(0).(*model.AchievementType), args.Error(1)
+}
+
+func (m *MockAchievementTypeRepository) Update(
+	ctx context.Context,
+	t model.AchievementType,
+) (*model.AchievementType, error) {
+	args := m.Called(ctx, t)
+	if args.Get(0) == nil {
+		return nil, args.Error(1)
+	}
+	return args.Get(0).(*model.AchievementType), args.Error(1)
+}
+
+func (m *MockAchievementTypeRepository) Delete(
+	ctx context.Context,
+	key string,
+) error {
+	args := m.Called(ctx, key)
+	return args.Error(0)
+}
+
+func (m *MockAchievementTypeRepository) GetByKey(
+	ctx context.Context,
+	key string,
+) (*model.AchievementType, error) {
+	args := m.Called(ctx, key)
+	if args.Get(0) == nil {
+		return nil, args.Error(1)
+	}
+	return args.Get(0).(*model.AchievementType), args.Error(1)
+}
+
+func (m *MockAchievementTypeRepository) List(
+	ctx context.Context,
+) ([]model.AchievementType, error) {
+	args := m.Called(ctx)
+	if args.Get(0) == nil {
+		return nil, args.Error(1)
+	}
+	return args.Get(0).([]model.AchievementType), args.Error(1)
+}
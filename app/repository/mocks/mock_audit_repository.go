@@ -0,0 +1,21 @@
+package mocks
+
+import (
+	"context"
+
+	"uas/app/model"
+	"uas/app/repository"
+
+	"github.com/stretchr/testify/mock"
+)
+
+type MockAuditRepository struct {
+	mock.Mock
+}
+
+var _ repository.AuditRepository = (*MockAuditRepository)(nil)
+
+func (m *MockAuditRepository) Record(ctx context.Context, entry *model.AuditLog) error {
+	args := m.Called(ctx, entry)
+	return args.Error(0)
+}
@@ -0,0 +1,58 @@
+package mocks
+
+import (
+	"context"
+	"time"
+
+	"uas/app/model"
+	"uas/app/repository"
+
+	"github.com/stretchr/testify/mock"
+)
+
+type MockIdentityRepository struct {
+	mock.Mock
+}
+
+var _ repository.IdentityRepository = (*MockIdentityRepository)(nil)
+
+func (m *MockIdentityRepository) SaveLoginState(
+	ctx context.Context,
+	stateHash string,
+	state model.OIDCLoginState,
+	expiresAt time.Time,
+) error {
+	args := m.Called(ctx, stateHash, state, expiresAt)
+	return args.Error(0)
+}
+
+func (m *MockIdentityRepository) ConsumeLoginState(ctx context.Context, stateHash string) (*model.OIDCLoginState, error) {
+	args := m.Called(ctx, stateHash)
+	if v := args.Get(0); v != nil {
+		return v.(*model.OIDCLoginState), args.Error(1)
+	}
+	return nil, args.Error(1)
+}
+
+func (m *MockIdentityRepository) FindUserID(ctx context.Context, issuer, subject string) (int64, error) {
+	args := m.Called(ctx, issuer, subject)
+	return args.Get(0).(int64), args.Error(1)
+}
+
+func (m *MockIdentityRepository) MatchUsers(ctx context.Context, email, nim, nip string) ([]int64, error) {
+	args := m.Called(ctx, email, nim, nip)
+	if v := args.Get(0); v != nil {
+		return v.([]int64), args.Error(1)
+	}
+	return nil, args.Error(1)
+}
+
+func (m *MockIdentityRepository) Link(ctx context.Context, userID int64, identity model.OIDCIdentity) error {
+	args := m.Called(ctx, userID, identity)
+	return args.Error(0)
+}
+
+func (m *MockIdentityRepository) Provision(ctx context.Context, p model.OIDCProvision) (int64, error) {
+	args := m.Called(ctx, p)
+	return args.Get(0).(int64), args.Error(1)
+}
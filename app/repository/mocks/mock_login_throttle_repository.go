@@ -0,0 +1,36 @@
+package mocks
+
+import (
+	"context"
+	"time"
+
+	"uas/app/repository"
+
+	"github.com/stretchr/testify/mock"
+)
+
+type MockLoginThrottleRepository struct {
+	mock.Mock
+}
+
+var _ repository.LoginThrottleRepository = (*MockLoginThrottleRepository)(nil)
+
+func (m *MockLoginThrottleRepository) LockedUntil(ctx context.Context, keys []string) (time.Time, error) {
+	args := m.Called(ctx, keys)
+	return args.Get(0).(time.Time), args.Error(1)
+}
+
+func (m *MockLoginThrottleRepository) RecordFailure(ctx context.Context, key string, window time.Duration) (int, error) {
+	args := m.Called(ctx, key, window)
+	return args.Int(0), args.Error(1)
+}
+
+func (m *MockLoginThrottleRepository) Lock(ctx context.Context, key string, until time.Time) error {
+	args := m.Called(ctx, key, until)
+	return args.Error(0)
+}
+
+func (m *MockLoginThrottleRepository) Reset(ctx context.Context, key string) (bool, error) {
+	args := m.Called(ctx, key)
+	return args.Bool(0), args.Error(1)
+}
@@ -0,0 +1,37 @@
+package mocks
+
+import (
+	"context"
+	"time"
+
+	"uas/app/repository"
+
+	"github.com/stretchr/testify/mock"
+)
+
+type MockPasswordResetRepository struct {
+	mock.Mock
+}
+
+var _ repository.PasswordResetRepository = (*MockPasswordResetRepository)(nil)
+
+func (m *MockPasswordResetRepository) Create(
+	ctx context.Context,
+	userID int64,
+	tokenHash string,
+	expiresAt time.Time,
+	requestedBy *int64,
+) error {
+	args := m.Called(ctx, userID, tokenHash, expiresAt, requestedBy)
+	return args.Error(0)
+}
+
+func (m *MockPasswordResetRepository) Consume(ctx context.Context, tokenHash string) (int64, error) {
+	args := m.Called(ctx, tokenHash)
+	return args.Get(0).(int64), args.Error(1)
+}
+
+func (m *MockPasswordResetRepository) Lookup(ctx context.Context, tokenHash string) (int64, error) {
+	args := m.Called(ctx, tokenHash)
+	return args.Get(0).(int64), args.Error(1)
+}
@@ -0,0 +1,43 @@
+package mocks
+
+import (
+	"context"
+
+	"uas/app/model"
+
+	"github.com/stretchr/testify/mock"
+)
+
+type MockPointRepository struct {
+	mock.Mock
+}
+
+func (m *MockPointRepository) GetBalance(
+	ctx context.Context,
+	studentID string,
+) (float64, float64, error) {
+	args := m.Called(ctx, studentID)
+	return args.Get(0).(float64), args.Get(1).(float64), args.Error(2)
+}
+
+func (m *MockPointRepository) GetTransactions(
+	ctx context.Context,
+	studentID string,
+) ([]model.PointTransaction, error) {
+	args := m.Called(ctx, studentID)
+	if args.Get(0) == nil {
+		return nil, args.Error(1)
+	}
+	return args.Get(0).([]model.PointTransaction), args.Error(1)
+}
+
+func (m *MockPointRepository) RecomputeAll(
+	ctx context.Context,
+	apply bool,
+) ([]model.PointDrift, error) {
+	args := m.Called(ctx, apply)
+	if args.Get(0) == nil {
+		return nil, args.Error(1)
+	}
+	return args.Get(0).([]model.PointDrift), args.Error(1)
+}
@@ -5,6 +5,7 @@ import (
 	"time"
 
 	"uas/app/model"
+	"uas/app/repository"
 
 	"github.com/stretchr/testify/mock"
 )
@@ -13,31 +14,72 @@ type MockRefreshTokenRepository struct {
 	mock.Mock
 }
 
+var _ repository.RefreshTokenRepository = (*MockRefreshTokenRepository)(nil)
+
 func (m *MockRefreshTokenRepository) Save(
 	ctx context.Context,
-	userID int64,
-	token string,
+	session *model.AuthSession,
+	tokenHash string,
 	expiresAt time.Time,
 ) error {
-	args := m.Called(ctx, userID, token, expiresAt)
+	args := m.Called(ctx, session, tokenHash, expiresAt)
 	return args.Error(0)
 }
 
 func (m *MockRefreshTokenRepository) Get(
 	ctx context.Context,
-	token string,
+	tokenHash string,
 ) (*model.RefreshToken, error) {
-	args := m.Called(ctx, token)
+	args := m.Called(ctx, tokenHash)
 	if args.Get(0) == nil {
 		return nil, args.Error(1)
 	}
 	return args.Get(0).(*model.RefreshToken), args.Error(1)
 }
 
-func (m *MockRefreshTokenRepository) DeleteByUserID(
+func (m *MockRefreshTokenRepository) Rotate(
 	ctx context.Context,
-	userID int64,
+	current *model.RefreshToken,
+	nextHash string,
+	expiresAt time.Time,
+	ipAddress string,
+) error {
+	args := m.Called(ctx, current, nextHash, expiresAt, ipAddress)
+	return args.Error(0)
+}
+
+func (m *MockRefreshTokenRepository) RevokeFamily(
+	ctx context.Context,
+	familyID string,
 ) error {
+	args := m.Called(ctx, familyID)
+	return args.Error(0)
+}
+
+func (m *MockRefreshTokenRepository) ListSessions(
+	ctx context.Context,
+	userID int64,
+) ([]model.AuthSession, error) {
 	args := m.Called(ctx, userID)
+	if args.Get(0) == nil {
+		return nil, args.Error(1)
+	}
+	return args.Get(0).([]model.AuthSession), args.Error(1)
+}
+
+func (m *MockRefreshTokenRepository) RevokeSession(
+	ctx context.Context,
+	userID int64,
+	sessionID string,
+) error {
+	args := m.Called(ctx, userID, sessionID)
 	return args.Error(0)
 }
+
+func (m *MockRefreshTokenRepository) RevokeAllSessions(
+	ctx context.Context,
+	userID int64,
+) (int64, error) {
+	args := m.Called(ctx, userID)
+	return args.Get(0).(int64), args.Error(1)
+}
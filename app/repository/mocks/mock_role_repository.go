@@ -0,0 +1,112 @@
+package mocks
+
+import (
+	"context"
+
+	"uas/app/model"
+	"uas/app/repository"
+
+	"github.com/stretchr/testify/mock"
+)
+
+type MockRoleRepository struct {
+	mock.Mock
+}
+
+var _ repository.RoleRepository = (*MockRoleRepository)(nil)
+
+func (m *MockRoleRepository) GetRoleIDByName(ctx context.Context, name string) (string, error) {
+	args := m.Called(ctx, name)
+	return args.String(0), args.Error(1)
+}
+
+func (m *MockRoleRepository) GetPermissionsByRole(ctx context.Context, name string) ([]string, error) {
+	args := m.Called(ctx, name)
+	if v := args.Get(0); v != nil {
+		return v.([]string), args.Error(1)
+	}
+	return nil, args.Error(1)
+}
+
+func (m *MockRoleRepository) ListRoles(ctx context.Context) ([]model.RoleDetail, error) {
+	args := m.Called(ctx)
+	if v := args.Get(0); v != nil {
+		return v.([]model.RoleDetail), args.Error(1)
+	}
+	return nil, args.Error(1)
+}
+
+func (m *MockRoleRepository) GetRole(ctx context.Context, id string) (*model.RoleDetail, error) {
+	args := m.Called(ctx, id)
+	if v := args.Get(0); v != nil {
+		return v.(*model.RoleDetail), args.Error(1)
+	}
+	return nil, args.Error(1)
+}
+
+func (m *MockRoleRepository) CreateRole(ctx context.Context, name, description string) (*model.Role, error) {
+	args := m.Called(ctx, name, description)
+	if v := args.Get(0); v != nil {
+		return v.(*model.Role), args.Error(1)
+	}
+	return nil, args.Error(1)
+}
+
+func (m *MockRoleRepository) UpdateRole(ctx context.Context, id, name, description string) error {
+	args := m.Called(ctx, id, name, description)
+	return args.Error(0)
+}
+
+func (m *MockRoleRepository) SetRequire2FA(ctx context.Context, id string, required bool) error {
+	args := m.Called(ctx, id, required)
+	return args.Error(0)
+}
+
+func (m *MockRoleRepository) DeleteRole(ctx context.Context, id string) error {
+	args := m.Called(ctx, id)
+	return args.Error(0)
+}
+
+func (m *MockRoleRepository) ListPermissions(ctx context.Context) ([]model.Permission, error) {
+	args := m.Called(ctx)
+	if v := args.Get(0); v != nil {
+		return v.([]model.Permission), args.Error(1)
+	}
+	return nil, args.Error(1)
+}
+
+func (m *MockRoleRepository) GetPermission(ctx context.Context, id string) (*model.Permission, error) {
+	args := m.Called(ctx, id)
+	if v := args.Get(0); v != nil {
+		return v.(*model.Permission), args.Error(1)
+	}
+	return nil, args.Error(1)
+}
+
+func (m *MockRoleRepository) CreatePermission(ctx context.Context, p model.Permission) (*model.Permission, error) {
+	args := m.Called(ctx, p)
+	if v := args.Get(0); v != nil {
+		return v.(*model.Permission), args.Error(1)
+	}
+	return nil, args.Error(1)
+}
+
+func (m *MockRoleRepository) UpdatePermission(ctx context.Context, p model.Permission) error {
+	args := m.Called(ctx, p)
+	return args.Error(0)
+}
+
+func (m *MockRoleRepository) DeletePermission(ctx context.Context, id string) error {
+	args := m.Called(ctx, id)
+	return args.Error(0)
+}
+
+func (m *MockRoleRepository) SetRolePermissions(ctx context.Context, roleID string, permissionIDs []string) error {
+	args := m.Called(ctx, roleID, permissionIDs)
+	return args.Error(0)
+}
+
+func (m *MockRoleRepository) CountActiveRolesWithPermission(ctx context.Context, permission string, excludeRoleID string) (int, error) {
+	args := m.Called(ctx, permission, excludeRoleID)
+	return args.Int(0), args.Error(1)
+}
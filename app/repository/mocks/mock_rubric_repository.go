@@ -0,0 +1,63 @@
+package mocks
+
+import (
+	"context"
+
+	"uas/app/model"
+
+	"github.com/stretchr/testify/mock"
+)
+
+type MockRubricRepository struct {
+	mock.Mock
+}
+
+func (m *MockRubricRepository) Create(
+	ctx context.Context,
+	req model.PointRubricRequest,
+) (*model.PointRubric, error) {
+	args := m.Called(ctx, req)
+	if args.Get(0) == nil {
+		return nil, args.Error(1)
+	}
+	return args.Get(0).(*model.PointRubric), args.Error(1)
+}
+
+func (m *MockRubricRepository) Delete(
+	ctx context.Context,
+	id string,
+) error {
+	args := m.Called(ctx, id)
+	return args.Error(0)
+}
+
+func (m *MockRubricRepository) GetByID(
+	ctx context.Context,
+	id string,
+) (*model.PointRubric, error) {
+	args := m.Called(ctx, id)
+	if args.Get(0) == nil {
+		return nil, args.Error(1)
+	}
+	return args.Get(0).(*model.PointRubric), args.Error(1)
+}
+
+func (m *MockRubricRepository) List(
+	ctx context.Context,
+) ([]model.PointRubric, error) {
+	args := m.Called(ctx)
+	if args.Get(0) == nil {
+		return nil, args.Error(1)
+	}
+	return args.Get(0).([]model.PointRubric), args.Error(1)
+}
+
+func (m *MockRubricRepository) GetActive(
+	ctx context.Context,
+) (*model.PointRubric, error) {
+	args := m.Called(ctx)
+	if args.Get(0) == nil {
+		return nil, args.Error(1)
+	}
+	return args.Get(0).(*model.PointRubric), args.Error(1)
+}
@@ -0,0 +1,67 @@
+package mocks
+
+import (
+	"context"
+
+	"uas/app/repository"
+
+	"github.com/stretchr/testify/mock"
+)
+
+type MockSeedRepository struct {
+	mock.Mock
+}
+
+var _ repository.SeedRepository = (*MockSeedRepository)(nil)
+
+func (m *MockSeedRepository) EnsureRole(ctx context.Context, name, description string, require2FA bool) (string, error) {
+	args := m.Called(ctx, name, description, require2FA)
+	return args.String(0), args.Error(1)
+}
+
+func (m *MockSeedRepository) EnsurePermission(ctx context.Context, name, resource, action, description string) (string, error) {
+	args := m.Called(ctx, name, resource, action, description)
+	return args.String(0), args.Error(1)
+}
+
+func (m *MockSeedRepository) GrantPermission(ctx context.Context, roleID, permissionID string) (bool, error) {
+	args := m.Called(ctx, roleID, permissionID)
+	return args.Bool(0), args.Error(1)
+}
+
+func (m *MockSeedRepository) EnsureUser(
+	ctx context.Context,
+	username, email, fullName, passHash, roleID string,
+) (int64, bool, error) {
+	args := m.Called(ctx, username, email, fullName, passHash, roleID)
+	return args.Get(0).(int64), args.Bool(1), args.Error(2)
+}
+
+func (m *MockSeedRepository) EnsureLecturer(ctx context.Context, userID int64, nip, department string) (int64, error) {
+	args := m.Called(ctx, userID, nip, department)
+	return args.Get(0).(int64), args.Error(1)
+}
+
+func (m *MockSeedRepository) EnsureStudent(
+	ctx context.Context,
+	userID int64,
+	nim, programStudy, academicYear string,
+	advisorID int64,
+) (string, error) {
+	args := m.Called(ctx, userID, nim, programStudy, academicYear, advisorID)
+	return args.String(0), args.Error(1)
+}
+
+func (m *MockSeedRepository) CountReferences(ctx context.Context, studentID string) (int, error) {
+	args := m.Called(ctx, studentID)
+	return args.Int(0), args.Error(1)
+}
+
+func (m *MockSeedRepository) InsertSeedReference(
+	ctx context.Context,
+	studentID, mongoID, status string,
+	actor repository.AchievementActor,
+) error {
+	args := m.Called(ctx, studentID, mongoID, status, actor)
+	return args.Error(0)
+}
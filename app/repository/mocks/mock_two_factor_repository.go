@@ -0,0 +1,86 @@
+package mocks
+
+import (
+	"context"
+	"time"
+
+	"uas/app/model"
+	"uas/app/repository"
+
+	"github.com/stretchr/testify/mock"
+)
+
+type MockTwoFactorRepository struct {
+	mock.Mock
+}
+
+var _ repository.TwoFactorRepository = (*MockTwoFactorRepository)(nil)
+
+func (m *MockTwoFactorRepository) Status(ctx context.Context, userID int64) (*model.TwoFactorStatus, error) {
+	args := m.Called(ctx, userID)
+	if v := args.Get(0); v != nil {
+		return v.(*model.TwoFactorStatus), args.Error(1)
+	}
+	return nil, args.Error(1)
+}
+
+func (m *MockTwoFactorRepository) GetSecret(ctx context.Context, userID int64) (*model.TOTPSecret, error) {
+	args := m.Called(ctx, userID)
+	if v := args.Get(0); v != nil {
+		return v.(*model.TOTPSecret), args.Error(1)
+	}
+	return nil, args.Error(1)
+}
+
+func (m *MockTwoFactorRepository) SaveSecret(ctx context.Context, userID int64, secret string) error {
+	args := m.Called(ctx, userID, secret)
+	return args.Error(0)
+}
+
+func (m *MockTwoFactorRepository) Confirm(ctx context.Context, userID int64, step int64, codeHashes []string) error {
+	args := m.Called(ctx, userID, step, codeHashes)
+	return args.Error(0)
+}
+
+func (m *MockTwoFactorRepository) UseStep(ctx context.Context, userID int64, step int64) error {
+	args := m.Called(ctx, userID, step)
+	return args.Error(0)
+}
+
+func (m *MockTwoFactorRepository) Disable(ctx context.Context, userID int64) error {
+	args := m.Called(ctx, userID)
+	return args.Error(0)
+}
+
+func (m *MockTwoFactorRepository) ReplaceRecoveryCodes(ctx context.Context, userID int64, codeHashes []string) error {
+	args := m.Called(ctx, userID, codeHashes)
+	return args.Error(0)
+}
+
+func (m *MockTwoFactorRepository) UseRecoveryCode(ctx context.Context, userID int64, codeHash string) error {
+	args := m.Called(ctx, userID, codeHash)
+	return args.Error(0)
+}
+
+func (m *MockTwoFactorRepository) CreateChallenge(ctx context.Context, userID int64, tokenHash string, expiresAt time.Time) error {
+	args := m.Called(ctx, userID, tokenHash, expiresAt)
+	return args.Error(0)
+}
+
+func (m *MockTwoFactorRepository) GetChallenge(ctx context.Context, tokenHash string) (*model.LoginChallenge, error) {
+	args := m.Called(ctx, tokenHash)
+	if v := args.Get(0); v != nil {
+		return v.(*model.LoginChallenge), args.Error(1)
+	}
+	return nil, args.Error(1)
+}
+
+func (m *MockTwoFactorRepository) FailChallenge(ctx context.Context, tokenHash string) (int, error) {
+	args := m.Called(ctx, tokenHash)
+	return args.Int(0), args.Error(1)
+}
+
+func (m *MockTwoFactorRepository) ConsumeChallenge(ctx context.Context, tokenHash string) (int64, error) {
+	args := m.Called(ctx, tokenHash)
+	return args.Get(0).(int64), args.Error(1)
+}
@@ -50,6 +50,32 @@ func (m *MockUserRepository) UpdateRole(
 	return args.Error(0)
 }
 
+func (m *MockUserRepository) SetActive(ctx context.Context, userID int64, active bool) error {
+	args := m.Called(ctx, userID, active)
+	return args.Error(0)
+}
+
+func (m *MockUserRepository) FindCredentialsByID(ctx context.Context, userID int64) (*model.User, error) {
+	args := m.Called(ctx, userID)
+	if args.Get(0) == nil {
+		return nil, args.Error(1)
+	}
+	return args.Get(0).(*model.User), args.Error(1)
+}
+
+func (m *MockUserRepository) FindCredentialsByEmail(ctx context.Context, email string) (*model.User, error) {
+	args := m.Called(ctx, email)
+	if args.Get(0) == nil {
+		return nil, args.Error(1)
+	}
+	return args.Get(0).(*model.User), args.Error(1)
+}
+
+func (m *MockUserRepository) UpdatePassword(ctx context.Context, userID int64, passwordHash string) error {
+	args := m.Called(ctx, userID, passwordHash)
+	return args.Error(0)
+}
+
 // ===== DUMMY METHODS (TIDAK DIPAKAI, TAPI WAJIB ADA) =====
 func (m *MockUserRepository) Create(
 	ctx context.Context,
@@ -2,10 +2,15 @@ package repository
 
 import (
 	"context"
+	"errors"
+	"time"
+
+	"uas/app/model"
 
 	"go.mongodb.org/mongo-driver/bson"
 	"go.mongodb.org/mongo-driver/bson/primitive"
 	"go.mongodb.org/mongo-driver/mongo"
+	"go.mongodb.org/mongo-driver/mongo/options"
 )
 
 type MongoAchievement struct {
@@ -20,7 +25,8 @@ type MongoAchievementRepository struct {
 }
 
 type Achievement struct {
-	AchievementType string `bson:"achievementType"`
+	ID              primitive.ObjectID `bson:"_id"`
+	AchievementType string             `bson:"achievementType"`
 
 	Details struct {
 		Year             int    `bson:"year"`
@@ -28,12 +34,40 @@ type Achievement struct {
 	} `bson:"details"`
 }
 
+// AchievementDocumentStore: akses dokumen achievement (detail, lampiran).
+// Implementasi: MongoAchievementRepository dan MemoryAchievementStore
+// (untuk test dan mode dev lokal tanpa Mongo).
+type AchievementDocumentStore interface {
+	Insert(ctx context.Context, doc bson.M) error
+	// Update mengembalikan jumlah dokumen yang cocok dengan filter
+	Update(ctx context.Context, filter bson.M, update bson.M) (int64, error)
+	SoftDelete(ctx context.Context, id primitive.ObjectID, deletedAt time.Time) error
+	// Delete menghapus dokumen secara permanen (dipakai kompensasi create)
+	Delete(ctx context.Context, filter bson.M) error
+	FindOne(ctx context.Context, filter bson.M) (bson.M, error)
+	FindManyByIDs(ctx context.Context, ids []primitive.ObjectID) ([]bson.M, error)
+	FindByIDs(ctx context.Context, ids []primitive.ObjectID) ([]Achievement, error)
+	PushAttachment(ctx context.Context, id primitive.ObjectID, attachment bson.M) error
+	// ListDocumentStates: ringkasan semua dokumen (untuk reconcile)
+	ListDocumentStates(ctx context.Context) ([]model.DocumentState, error)
+	// GetDocumentState: ringkasan satu dokumen; ErrDocumentNotFound jika tidak ada
+	GetDocumentState(ctx context.Context, id primitive.ObjectID) (*model.DocumentState, error)
+}
+
+// ErrDocumentNotFound: tidak ada dokumen yang cocok dengan filter
+var ErrDocumentNotFound = errors.New("achievement document not found")
+
 func NewMongoAchievementRepository(col *mongo.Collection) *MongoAchievementRepository {
 	return &MongoAchievementRepository{
 		Collection: col,
 	}
 }
 
+// NewMongoAchievementStore memakai nama database / collection dari config
+func NewMongoAchievementStore(client *mongo.Client, database, collection string) *MongoAchievementRepository {
+	return NewMongoAchievementRepository(client.Database(database).Collection(collection))
+}
+
 // FindByIDs mengambil banyak achievement dari MongoDB berdasarkan daftar ID
 func (r *MongoAchievementRepository) FindByIDs(
 	ctx context.Context,
@@ -55,3 +89,123 @@ func (r *MongoAchievementRepository) FindByIDs(
 	return results, nil
 }
 
+func (r *MongoAchievementRepository) Insert(ctx context.Context, doc bson.M) error {
+	_, err := r.Collection.InsertOne(ctx, doc)
+	return err
+}
+
+func (r *MongoAchievementRepository) Update(ctx context.Context, filter bson.M, update bson.M) (int64, error) {
+	result, err := r.Collection.UpdateOne(ctx, filter, update)
+	if err != nil {
+		return 0, err
+	}
+	return result.MatchedCount, nil
+}
+
+func (r *MongoAchievementRepository) Delete(ctx context.Context, filter bson.M) error {
+	_, err := r.Collection.DeleteOne(ctx, filter)
+	return err
+}
+
+// SoftDelete hanya untuk dokumen yang belum dihapus: deletedAt lama tidak
+// ditimpa (kompensasi $unset mencocokkan deletedAt milik operasinya sendiri)
+func (r *MongoAchievementRepository) SoftDelete(ctx context.Context, id primitive.ObjectID, deletedAt time.Time) error {
+	result, err := r.Collection.UpdateOne(ctx,
+		bson.M{"_id": id, "deletedAt": bson.M{"$exists": false}},
+		bson.M{"$set": bson.M{"deletedAt": deletedAt}},
+	)
+	if err != nil {
+		return err
+	}
+	if result.MatchedCount == 0 {
+		return ErrDocumentNotFound
+	}
+	return nil
+}
+
+func (r *MongoAchievementRepository) PushAttachment(ctx context.Context, id primitive.ObjectID, attachment bson.M) error {
+	result, err := r.Collection.UpdateOne(ctx,
+		bson.M{"_id": id},
+		bson.M{"$push": bson.M{"attachments": attachment}},
+	)
+	if err != nil {
+		return err
+	}
+	if result.MatchedCount == 0 {
+		return ErrDocumentNotFound
+	}
+	return nil
+}
+
+func (r *MongoAchievementRepository) FindOne(ctx context.Context, filter bson.M) (bson.M, error) {
+	var doc bson.M
+	if err := r.Collection.FindOne(ctx, filter).Decode(&doc); err != nil {
+		if errors.Is(err, mongo.ErrNoDocuments) {
+			return nil, ErrDocumentNotFound
+		}
+		return nil, err
+	}
+	return doc, nil
+}
+
+// FindManyByIDs: satu query $in, dokumen dikembalikan mentah (urutan tidak dijamin)
+func (r *MongoAchievementRepository) FindManyByIDs(ctx context.Context, ids []primitive.ObjectID) ([]bson.M, error) {
+	if len(ids) == 0 {
+		return []bson.M{}, nil
+	}
+	cursor, err := r.Collection.Find(ctx, bson.M{"_id": bson.M{"$in": ids}})
+	if err != nil {
+		return nil, err
+	}
+	defer cursor.Close(ctx)
+
+	var results []bson.M
+	if err := cursor.All(ctx, &results); err != nil {
+		return nil, err
+	}
+	return results, nil
+}
+
+// GetDocumentState membaca ulang satu dokumen (cek ulang sebelum repair reconcile)
+func (r *MongoAchievementRepository) GetDocumentState(ctx context.Context, id primitive.ObjectID) (*model.DocumentState, error) {
+	opts := options.FindOne().SetProjection(bson.M{"_id": 1, "studentId": 1, "deletedAt": 1})
+	var doc struct {
+		StudentID string     `bson:"studentId"`
+		DeletedAt *time.Time `bson:"deletedAt"`
+	}
+	if err := r.Collection.FindOne(ctx, bson.M{"_id": id}, opts).Decode(&doc); err != nil {
+		if errors.Is(err, mongo.ErrNoDocuments) {
+			return nil, ErrDocumentNotFound
+		}
+		return nil, err
+	}
+	return &model.DocumentState{MongoID: id.Hex(), StudentID: doc.StudentID, DeletedAt: doc.DeletedAt}, nil
+}
+
+// ListDocumentStates mengambil _id, studentId dan deletedAt semua dokumen
+func (r *MongoAchievementRepository) ListDocumentStates(ctx context.Context) ([]model.DocumentState, error) {
+	opts := options.Find().SetProjection(bson.M{"_id": 1, "studentId": 1, "deletedAt": 1})
+	cursor, err := r.Collection.Find(ctx, bson.M{}, opts)
+	if err != nil {
+		return nil, err
+	}
+	defer cursor.Close(ctx)
+
+	var results []model.DocumentState
+	for cursor.Next(ctx) {
+		var doc struct {
+			ID        primitive.ObjectID `bson:"_id"`
+			StudentID string             `bson:"studentId"`
+			DeletedAt *time.Time         `bson:"deletedAt"`
+		}
+		if err := cursor.Decode(&doc); err != nil {
+			return nil, err
+		}
+		results = append(results, model.DocumentState{
+			MongoID:   doc.ID.Hex(),
+			StudentID: doc.StudentID,
+			DeletedAt: doc.DeletedAt,
+		})
+	}
+	return results, cursor.Err()
+}
@@ -0,0 +1,78 @@
+package repository
+
+import (
+	"context"
+	"database/sql"
+	"time"
+)
+
+type PasswordResetRepository interface {
+	Create(ctx context.Context, userID int64, tokenHash string, expiresAt time.Time, requestedBy *int64) error
+	Lookup(ctx context.Context, tokenHash string) (int64, error)
+	Consume(ctx context.Context, tokenHash string) (int64, error)
+}
+
+type PasswordResetRepositoryImpl struct {
+	DB *sql.DB
+}
+
+func NewPasswordResetRepository(db *sql.DB) PasswordResetRepository {
+	return &PasswordResetRepositoryImpl{DB: db}
+}
+
+// Create menyimpan token baru; token lama user yang belum dipakai dihapus,
+// jadi hanya link di email terakhir yang berlaku
+func (r *PasswordResetRepositoryImpl) Create(
+	ctx context.Context,
+	userID int64,
+	tokenHash string,
+	expiresAt time.Time,
+	requestedBy *int64,
+) error {
+	tx, err := r.DB.BeginTx(ctx, nil)
+	if err != nil {
+		return err
+	}
+	defer tx.Rollback()
+
+	_, err = tx.ExecContext(ctx,
+		`DELETE FROM password_reset_tokens WHERE user_id = $1 AND used_at IS NULL`, userID)
+	if err != nil {
+		return err
+	}
+	query := `
+		INSERT INTO password_reset_tokens (user_id, token_hash, expires_at, requested_by)
+		VALUES ($1, $2, $3, $4)
+	`
+	if _, err := tx.ExecContext(ctx, query, userID, tokenHash, expiresAt, requestedBy); err != nil {
+		return err
+	}
+	return tx.Commit()
+}
+
+// Lookup: user_id pemilik token yang masih berlaku, tanpa memakainya.
+// sql.ErrNoRows jika token tidak ada, sudah dipakai atau kedaluwarsa.
+func (r *PasswordResetRepositoryImpl) Lookup(ctx context.Context, tokenHash string) (int64, error) {
+	query := `
+		SELECT user_id FROM password_reset_tokens
+		WHERE token_hash = $1 AND used_at IS NULL AND expires_at > NOW()
+	`
+	var userID int64
+	err := r.DB.QueryRowContext(ctx, query, tokenHash).Scan(&userID)
+	return userID, err
+}
+
+// Consume menandai token terpakai dan mengembalikan user_id-nya.
+// Atomic: dua request dengan token sama, hanya satu yang berhasil.
+// sql.ErrNoRows jika token tidak ada, sudah dipakai atau kedaluwarsa.
+func (r *PasswordResetRepositoryImpl) Consume(ctx context.Context, tokenHash string) (int64, error) {
+	query := `
+		UPDATE password_reset_tokens
+		SET used_at = NOW()
+		WHERE token_hash = $1 AND used_at IS NULL AND expires_at > NOW()
+		RETURNING user_id
+	`
+	var userID int64
+	err := r.DB.QueryRowContext(ctx, query, tokenHash).Scan(&userID)
+	return userID, err
+}
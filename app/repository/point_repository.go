@@ -0,0 +1,179 @@
+package repository
+
+import (
+	"context"
+	"database/sql"
+	"time"
+
+	"uas/app/model"
+	"uas/utils"
+)
+
+type PointRepository interface {
+	GetBalance(ctx context.Context, studentID string) (ledger float64, cached float64, err error)
+	GetTransactions(ctx context.Context, studentID string) ([]model.PointTransaction, error)
+	RecomputeAll(ctx context.Context, apply bool) ([]model.PointDrift, error)
+}
+
+type PointRepositoryImpl struct {
+	DB *sql.DB
+}
+
+func NewPointRepository(db *sql.DB) PointRepository {
+	return &PointRepositoryImpl{DB: db}
+}
+
+// Saldo ledger (SUM delta) dan nilai cache di students.points
+func (r *PointRepositoryImpl) GetBalance(ctx context.Context, studentID string) (float64, float64, error) {
+	query := `
+		SELECT
+			COALESCE((SELECT SUM(delta) FROM point_transactions WHERE student_uuid = s.id), 0),
+			s.points
+		FROM students s
+		WHERE s.id = $1
+	`
+	var ledger, cached float64
+	err := r.DB.QueryRowContext(ctx, query, studentID).Scan(&ledger, &cached)
+	return ledger, cached, err
+}
+
+// Semua transaksi poin milik mahasiswa (terbaru dulu)
+func (r *PointRepositoryImpl) GetTransactions(ctx context.Context, studentID string) ([]model.PointTransaction, error) {
+	query := `
+		SELECT
+			id,
+			achievement_ref_id,
+			mongo_achievement_id,
+			student_uuid,
+			delta,
+			reason,
+			actor_user_id,
+			semester,
+			created_at
+		FROM point_transactions
+		WHERE student_uuid = $1
+		ORDER BY created_at DESC, id DESC
+	`
+	rows, err := r.DB.QueryContext(ctx, query, studentID)
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+	txs := []model.PointTransaction{}
+	for rows.Next() {
+		var pt model.PointTransaction
+		if err := rows.Scan(
+			&pt.ID,
+			&pt.AchievementRefID,
+			&pt.MongoAchievementID,
+			&pt.StudentID,
+			&pt.Delta,
+			&pt.Reason,
+			&pt.ActorUserID,
+			&pt.Semester,
+			&pt.CreatedAt,
+		); err != nil {
+			return nil, err
+		}
+		txs = append(txs, pt)
+	}
+	if err := rows.Err(); err != nil {
+		return nil, err
+	}
+	return txs, nil
+}
+
+// Bandingkan students.points dengan saldo ledger untuk semua mahasiswa.
+// Jika apply = true, students.points ditimpa dengan saldo ledger.
+func (r *PointRepositoryImpl) RecomputeAll(ctx context.Context, apply bool) ([]model.PointDrift, error) {
+	tx, err := r.DB.BeginTx(ctx, nil)
+	if err != nil {
+		return nil, err
+	}
+	rows, err := tx.QueryContext(ctx, `
+		SELECT s.id, s.points, COALESCE(l.balance, 0)
+		FROM students s
+		LEFT JOIN (
+			SELECT student_uuid, SUM(delta) AS balance
+			FROM point_transactions
+			GROUP BY student_uuid
+		) l ON l.student_uuid = s.id
+		WHERE s.points <> COALESCE(l.balance, 0)
+		ORDER BY s.id
+		FOR UPDATE OF s
+	`)
+	if err != nil {
+		tx.Rollback()
+		return nil, err
+	}
+	drifts := []model.PointDrift{}
+	for rows.Next() {
+		var d model.PointDrift
+		if err := rows.Scan(&d.StudentID, &d.CachedPoints, &d.LedgerPoints); err != nil {
+			rows.Close()
+			tx.Rollback()
+			return nil, err
+		}
+		d.Drift = d.CachedPoints - d.LedgerPoints
+		drifts = append(drifts, d)
+	}
+	rows.Close()
+	if err := rows.Err(); err != nil {
+		tx.Rollback()
+		return nil, err
+	}
+	if !apply {
+		return drifts, tx.Rollback()
+	}
+	for _, d := range drifts {
+		if err := refreshStudentPoints(ctx, tx, d.StudentID); err != nil {
+			tx.Rollback()
+			return nil, err
+		}
+	}
+	return drifts, tx.Commit()
+}
+
+// Tulis satu transaksi ledger di dalam tx. Mengembalikan false jika baris
+// untuk (achievement, reason) sudah ada (unique constraint), sehingga
+// verifikasi atau revoke yang diulang tidak menggandakan poin.
+func insertPointTransaction(
+	ctx context.Context,
+	tx *sql.Tx,
+	refID string,
+	mongoID string,
+	studentID string,
+	delta float64,
+	reason model.PointReason,
+	actor AchievementActor,
+) (bool, error) {
+	result, err := tx.ExecContext(ctx, `
+		INSERT INTO point_transactions (
+			achievement_ref_id, mongo_achievement_id, student_uuid,
+			delta, reason, actor_user_id, semester, created_at
+		) VALUES (
+			$1, $2, $3, $4, $5, NULLIF($6, 0), $7, NOW()
+		)
+		ON CONFLICT DO NOTHING
+	`, refID, mongoID, studentID, delta, string(reason), actor.UserID, utils.SemesterOf(time.Now()))
+	if err != nil {
+		return false, err
+	}
+	rows, err := result.RowsAffected()
+	if err != nil {
+		return false, err
+	}
+	return rows > 0, nil
+}
+
+// students.points hanyalah cache dari SUM(point_transactions.delta)
+func refreshStudentPoints(ctx context.Context, tx *sql.Tx, studentID string) error {
+	_, err := tx.ExecContext(ctx, `
+		UPDATE students
+		SET points = COALESCE((
+			SELECT SUM(delta) FROM point_transactions WHERE student_uuid = $1
+		), 0)
+		WHERE id = $1
+	`, studentID)
+	return err
+}
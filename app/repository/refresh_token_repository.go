@@ -3,14 +3,24 @@ package repository
 import (
 	"context"
 	"database/sql"
+	"errors"
 	"time"
 	"uas/app/model"
 )
 
+// ErrRefreshTokenReused: token sudah pernah di-rotate / dicabut
+var ErrRefreshTokenReused = errors.New("refresh token already used")
+
 type RefreshTokenRepository interface {
-	Save(ctx context.Context, userID int64, token string, expiresAt time.Time) error
-	Get(ctx context.Context, token string) (*model.RefreshToken, error)
-	DeleteByUserID(ctx context.Context, userID int64) error
+	Save(ctx context.Context, session *model.AuthSession, tokenHash string, expiresAt time.Time) error
+	Get(ctx context.Context, tokenHash string) (*model.RefreshToken, error)
+	Rotate(ctx context.Context, current *model.RefreshToken, nextHash string, expiresAt time.Time, ipAddress string) error
+	RevokeFamily(ctx context.Context, familyID string) error
+
+	// SESSIONS (satu family = satu sesi)
+	ListSessions(ctx context.Context, userID int64) ([]model.AuthSession, error)
+	RevokeSession(ctx context.Context, userID int64, sessionID string) error
+	RevokeAllSessions(ctx context.Context, userID int64) (int64, error)
 }
 
 type RefreshTokenRepositoryImpl struct {
@@ -21,29 +31,212 @@ func NewRefreshTokenRepository(db *sql.DB) RefreshTokenRepository {
 	return &RefreshTokenRepositoryImpl{DB: db}
 }
 
-func (r *RefreshTokenRepositoryImpl) Save(ctx context.Context, userID int64, token string, expiresAt time.Time) error {
+// Save membuat sesi baru beserta refresh token pertamanya
+func (r *RefreshTokenRepositoryImpl) Save(
+	ctx context.Context,
+	session *model.AuthSession,
+	tokenHash string,
+	expiresAt time.Time,
+) error {
+	tx, err := r.DB.BeginTx(ctx, nil)
+	if err != nil {
+		return err
+	}
+	defer tx.Rollback()
+
+	err = tx.QueryRowContext(ctx, `
+		INSERT INTO auth_sessions (id, user_id, user_agent, ip_address)
+		VALUES ($1, $2, $3, $4)
+		RETURNING created_at, last_used_at
+	`, session.ID, session.UserID, session.UserAgent, session.IPAddress).
+		Scan(&session.CreatedAt, &session.LastUsedAt)
+	if err != nil {
+		return err
+	}
+
 	query := `
-        INSERT INTO refresh_tokens (user_id, token, expires_at)
-        VALUES ($1, $2, $3)
+        INSERT INTO refresh_tokens (user_id, token_hash, family_id, expires_at)
+        VALUES ($1, $2, $3, $4)
     `
-	_, err := r.DB.ExecContext(ctx, query, userID, token, expiresAt)
-	return err
+	if _, err := tx.ExecContext(ctx, query, session.UserID, tokenHash, session.ID, expiresAt); err != nil {
+		return err
+	}
+	return tx.Commit()
 }
 
-func (r *RefreshTokenRepositoryImpl) Get(ctx context.Context, token string) (*model.RefreshToken, error) {
-	query := `SELECT id, user_id, token, expires_at, created_at FROM refresh_tokens WHERE token=$1`
+func (r *RefreshTokenRepositoryImpl) Get(ctx context.Context, tokenHash string) (*model.RefreshToken, error) {
+	query := `
+		SELECT id, user_id, token_hash, family_id, expires_at, created_at, rotated_at, revoked_at
+		FROM refresh_tokens
+		WHERE token_hash=$1
+	`
 
-	row := r.DB.QueryRowContext(ctx, query, token)
+	row := r.DB.QueryRowContext(ctx, query, tokenHash)
 
 	var rt model.RefreshToken
-	err := row.Scan(&rt.ID, &rt.UserID, &rt.Token, &rt.ExpiresAt, &rt.CreatedAt)
+	err := row.Scan(
+		&rt.ID, &rt.UserID, &rt.TokenHash, &rt.FamilyID,
+		&rt.ExpiresAt, &rt.CreatedAt, &rt.RotatedAt, &rt.RevokedAt,
+	)
 	if err != nil {
 		return nil, err
 	}
 	return &rt, nil
 }
 
-func (r *RefreshTokenRepositoryImpl) DeleteByUserID(ctx context.Context, userID int64) error {
-	_, err := r.DB.ExecContext(ctx, `DELETE FROM refresh_tokens WHERE user_id=$1`, userID)
+// Rotate menandai token lama terpakai dan menyimpan penggantinya di family
+// yang sama, dalam satu transaksi. Jika token lama ternyata sudah terpakai
+// (request paralel / pemakaian ulang) hasilnya ErrRefreshTokenReused.
+func (r *RefreshTokenRepositoryImpl) Rotate(
+	ctx context.Context,
+	current *model.RefreshToken,
+	nextHash string,
+	expiresAt time.Time,
+	ipAddress string,
+) error {
+	tx, err := r.DB.BeginTx(ctx, nil)
+	if err != nil {
+		return err
+	}
+	defer tx.Rollback()
+
+	result, err := tx.ExecContext(ctx, `
+		UPDATE refresh_tokens
+		SET rotated_at = NOW()
+		WHERE id = $1 AND rotated_at IS NULL AND revoked_at IS NULL
+	`, current.ID)
+	if err != nil {
+		return err
+	}
+	if n, err := result.RowsAffected(); err != nil {
+		return err
+	} else if n == 0 {
+		return ErrRefreshTokenReused
+	}
+
+	_, err = tx.ExecContext(ctx, `
+		INSERT INTO refresh_tokens (user_id, token_hash, family_id, expires_at)
+		VALUES ($1, $2, $3, $4)
+	`, current.UserID, nextHash, current.FamilyID, expiresAt)
+	if err != nil {
+		return err
+	}
+
+	_, err = tx.ExecContext(ctx, `
+		UPDATE auth_sessions
+		SET last_used_at = NOW(), ip_address = $2
+		WHERE id = $1
+	`, current.FamilyID, ipAddress)
+	if err != nil {
+		return err
+	}
+	return tx.Commit()
+}
+
+// RevokeFamily mencabut semua token dalam satu family beserta sesinya
+// (dipakai saat reuse terdeteksi / logout)
+func (r *RefreshTokenRepositoryImpl) RevokeFamily(ctx context.Context, familyID string) error {
+	_, err := r.DB.ExecContext(ctx, `
+		WITH s AS (
+			UPDATE auth_sessions SET revoked_at = NOW()
+			WHERE id = $1 AND revoked_at IS NULL
+		)
+		UPDATE refresh_tokens
+		SET revoked_at = NOW()
+		WHERE family_id = $1 AND revoked_at IS NULL
+	`, familyID)
 	return err
 }
+
+// ListSessions: sesi yang belum dicabut dan masih punya refresh token berlaku
+func (r *RefreshTokenRepositoryImpl) ListSessions(ctx context.Context, userID int64) ([]model.AuthSession, error) {
+	rows, err := r.DB.QueryContext(ctx, `
+		SELECT s.id, s.user_id, s.user_agent, s.ip_address, s.created_at, s.last_used_at
+		FROM auth_sessions s
+		WHERE s.user_id = $1
+		  AND s.revoked_at IS NULL
+		  AND EXISTS (
+			SELECT 1 FROM refresh_tokens t
+			WHERE t.family_id = s.id
+			  AND t.rotated_at IS NULL
+			  AND t.revoked_at IS NULL
+			  AND t.expires_at > NOW()
+		  )
+		ORDER BY s.last_used_at DESC
+	`, userID)
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+
+	sessions := []model.AuthSession{}
+	for rows.Next() {
+		var s model.AuthSession
+		if err := rows.Scan(&s.ID, &s.UserID, &s.UserAgent, &s.IPAddress, &s.CreatedAt, &s.LastUsedAt); err != nil {
+			return nil, err
+		}
+		sessions = append(sessions, s)
+	}
+	return sessions, rows.Err()
+}
+
+// RevokeSession mencabut satu sesi milik user; sql.ErrNoRows jika tidak ada
+func (r *RefreshTokenRepositoryImpl) RevokeSession(ctx context.Context, userID int64, sessionID string) error {
+	tx, err := r.DB.BeginTx(ctx, nil)
+	if err != nil {
+		return err
+	}
+	defer tx.Rollback()
+
+	result, err := tx.ExecContext(ctx, `
+		UPDATE auth_sessions SET revoked_at = NOW()
+		WHERE id = $1 AND user_id = $2 AND revoked_at IS NULL
+	`, sessionID, userID)
+	if err != nil {
+		return err
+	}
+	if n, err := result.RowsAffected(); err != nil {
+		return err
+	} else if n == 0 {
+		return sql.ErrNoRows
+	}
+
+	_, err = tx.ExecContext(ctx, `
+		UPDATE refresh_tokens SET revoked_at = NOW()
+		WHERE family_id = $1 AND revoked_at IS NULL
+	`, sessionID)
+	if err != nil {
+		return err
+	}
+	return tx.Commit()
+}
+
+// RevokeAllSessions: "logout dari semua perangkat", mengembalikan jumlah sesi
+func (r *RefreshTokenRepositoryImpl) RevokeAllSessions(ctx context.Context, userID int64) (int64, error) {
+	tx, err := r.DB.BeginTx(ctx, nil)
+	if err != nil {
+		return 0, err
+	}
+	defer tx.Rollback()
+
+	result, err := tx.ExecContext(ctx, `
+		UPDATE auth_sessions SET revoked_at = NOW()
+		WHERE user_id = $1 AND revoked_at IS NULL
+	`, userID)
+	if err != nil {
+		return 0, err
+	}
+	n, err := result.RowsAffected()
+	if err != nil {
+		return 0, err
+	}
+
+	_, err = tx.ExecContext(ctx, `
+		UPDATE refresh_tokens SET revoked_at = NOW()
+		WHERE user_id = $1 AND revoked_at IS NULL
+	`, userID)
+	if err != nil {
+		return 0, err
+	}
+	return n, tx.Commit()
+}
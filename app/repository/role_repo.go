@@ -4,17 +4,45 @@ import (
 	"context"
 	"database/sql"
 	"errors"
+
+	"uas/app/model"
+
+	"github.com/lib/pq"
 )
 
-type RoleRepository struct {
+type RoleRepository interface {
+	GetRoleIDByName(ctx context.Context, name string) (string, error)
+	GetPermissionsByRole(ctx context.Context, name string) ([]string, error)
+
+	// ROLE
+	ListRoles(ctx context.Context) ([]model.RoleDetail, error)
+	GetRole(ctx context.Context, id string) (*model.RoleDetail, error)
+	CreateRole(ctx context.Context, name, description string) (*model.Role, error)
+	UpdateRole(ctx context.Context, id, name, description string) error
+	DeleteRole(ctx context.Context, id string) error
+	SetRequire2FA(ctx context.Context, id string, required bool) error
+
+	// PERMISSION
+	ListPermissions(ctx context.Context) ([]model.Permission, error)
+	GetPermission(ctx context.Context, id string) (*model.Permission, error)
+	CreatePermission(ctx context.Context, p model.Permission) (*model.Permission, error)
+	UpdatePermission(ctx context.Context, p model.Permission) error
+	DeletePermission(ctx context.Context, id string) error
+
+	// ROLE ↔ PERMISSION
+	SetRolePermissions(ctx context.Context, roleID string, permissionIDs []string) error
+	CountActiveRolesWithPermission(ctx context.Context, permission string, excludeRoleID string) (int, error)
+}
+
+type RoleRepositoryImpl struct {
 	DB *sql.DB
 }
 
-func NewRoleRepository(db *sql.DB) *RoleRepository {
-	return &RoleRepository{DB: db}
+func NewRoleRepository(db *sql.DB) RoleRepository {
+	return &RoleRepositoryImpl{DB: db}
 }
 
-func (r *RoleRepository) GetRoleIDByName(ctx context.Context, name string) (string, error) {
+func (r *RoleRepositoryImpl) GetRoleIDByName(ctx context.Context, name string) (string, error) {
 	query := `SELECT id FROM roles WHERE name=$1`
 
 	var id string
@@ -24,3 +52,254 @@ func (r *RoleRepository) GetRoleIDByName(ctx context.Context, name string) (stri
 	}
 	return id, nil
 }
+
+// Nama permission milik role (dipakai middleware.PermissionCache)
+func (r *RoleRepositoryImpl) GetPermissionsByRole(ctx context.Context, name string) ([]string, error) {
+	query := `
+		SELECT p.name
+		FROM permissions p
+		JOIN role_permissions rp ON rp.permission_id = p.id
+		JOIN roles r ON r.id = rp.role_id
+		WHERE r.name = $1
+	`
+	rows, err := r.DB.QueryContext(ctx, query, name)
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+
+	perms := []string{}
+	for rows.Next() {
+		var p string
+		if err := rows.Scan(&p); err != nil {
+			return nil, err
+		}
+		perms = append(perms, p)
+	}
+	return perms, rows.Err()
+}
+
+const roleDetailQuery = `
+	SELECT
+		r.id, r.name, COALESCE(r.description, ''), r.created_at,
+		COALESCE(ARRAY(
+			SELECT p.name
+			FROM role_permissions rp
+			JOIN permissions p ON p.id = rp.permission_id
+			WHERE rp.role_id = r.id
+			ORDER BY p.name
+		), '{}'),
+		(SELECT COUNT(*) FROM users u WHERE u.role_id = r.id),
+		r.require_2fa
+	FROM roles r
+`
+
+func scanRoleDetail(scan func(dest ...interface{}) error) (*model.RoleDetail, error) {
+	var d model.RoleDetail
+	var perms pq.StringArray
+	if err := scan(&d.ID, &d.Name, &d.Description, &d.CreatedAt, &perms, &d.UserCount, &d.Require2FA); err != nil {
+		return nil, err
+	}
+	d.Permissions = []string(perms)
+	d.IsBuiltin = model.IsBuiltinRole(d.Name)
+	return &d, nil
+}
+
+func (r *RoleRepositoryImpl) ListRoles(ctx context.Context) ([]model.RoleDetail, error) {
+	rows, err := r.DB.QueryContext(ctx, roleDetailQuery+` ORDER BY r.name`)
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+
+	roles := []model.RoleDetail{}
+	for rows.Next() {
+		d, err := scanRoleDetail(rows.Scan)
+		if err != nil {
+			return nil, err
+		}
+		roles = append(roles, *d)
+	}
+	return roles, rows.Err()
+}
+
+func (r *RoleRepositoryImpl) GetRole(ctx context.Context, id string) (*model.RoleDetail, error) {
+	row := r.DB.QueryRowContext(ctx, roleDetailQuery+` WHERE r.id = $1`, id)
+	return scanRoleDetail(row.Scan)
+}
+
+func (r *RoleRepositoryImpl) CreateRole(ctx context.Context, name, description string) (*model.Role, error) {
+	role := model.Role{Name: name, Description: description}
+	err := r.DB.QueryRowContext(ctx, `
+		INSERT INTO roles (name, description)
+		VALUES ($1, $2)
+		RETURNING id, created_at
+	`, name, description).Scan(&role.ID, &role.CreatedAt)
+	if err != nil {
+		return nil, err
+	}
+	return &role, nil
+}
+
+// UpdateRole; jika nama berubah, token_version user role tsb ikut naik di tx
+// yang sama. Access token membawa nama role dan permission dicari dengan
+// nama itu, jadi token lama harus ditolak (refresh menerbitkan nama baru).
+func (r *RoleRepositoryImpl) UpdateRole(ctx context.Context, id, name, description string) error {
+	tx, err := r.DB.BeginTx(ctx, nil)
+	if err != nil {
+		return err
+	}
+	var oldName string
+	err = tx.QueryRowContext(ctx, `SELECT name FROM roles WHERE id = $1 FOR UPDATE`, id).Scan(&oldName)
+	if err != nil {
+		tx.Rollback()
+		return err
+	}
+	_, err = tx.ExecContext(ctx,
+		`UPDATE roles SET name = $1, description = $2 WHERE id = $3`,
+		name, description, id,
+	)
+	if err != nil {
+		tx.Rollback()
+		return err
+	}
+	if oldName != name {
+		_, err = tx.ExecContext(ctx,
+			`UPDATE users SET token_version = token_version + 1 WHERE role_id = $1`,
+			id,
+		)
+		if err != nil {
+			tx.Rollback()
+			return err
+		}
+	}
+	return tx.Commit()
+}
+
+// DeleteRole gagal (FK) jika role masih dipakai user; cek UserCount dulu
+func (r *RoleRepositoryImpl) DeleteRole(ctx context.Context, id string) error {
+	result, err := r.DB.ExecContext(ctx, `DELETE FROM roles WHERE id = $1`, id)
+	return expectOneRow(result, err)
+}
+
+func (r *RoleRepositoryImpl) ListPermissions(ctx context.Context) ([]model.Permission, error) {
+	rows, err := r.DB.QueryContext(ctx, `
+		SELECT id, name, resource, action, COALESCE(description, '')
+		FROM permissions
+		ORDER BY name
+	`)
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+
+	perms := []model.Permission{}
+	for rows.Next() {
+		var p model.Permission
+		if err := rows.Scan(&p.ID, &p.Name, &p.Resource, &p.Action, &p.Description); err != nil {
+			return nil, err
+		}
+		perms = append(perms, p)
+	}
+	return perms, rows.Err()
+}
+
+func (r *RoleRepositoryImpl) GetPermission(ctx context.Context, id string) (*model.Permission, error) {
+	var p model.Permission
+	err := r.DB.QueryRowContext(ctx, `
+		SELECT id, name, resource, action, COALESCE(description, '')
+		FROM permissions
+		WHERE id = $1
+	`, id).Scan(&p.ID, &p.Name, &p.Resource, &p.Action, &p.Description)
+	if err != nil {
+		return nil, err
+	}
+	return &p, nil
+}
+
+func (r *RoleRepositoryImpl) CreatePermission(ctx context.Context, p model.Permission) (*model.Permission, error) {
+	err := r.DB.QueryRowContext(ctx, `
+		INSERT INTO permissions (name, resource, action, description)
+		VALUES ($1, $2, $3, $4)
+		RETURNING id
+	`, p.Name, p.Resource, p.Action, p.Description).Scan(&p.ID)
+	if err != nil {
+		return nil, err
+	}
+	return &p, nil
+}
+
+func (r *RoleRepositoryImpl) UpdatePermission(ctx context.Context, p model.Permission) error {
+	result, err := r.DB.ExecContext(ctx, `
+		UPDATE permissions
+		SET name = $1, resource = $2, action = $3, description = $4
+		WHERE id = $5
+	`, p.Name, p.Resource, p.Action, p.Description, p.ID)
+	return expectOneRow(result, err)
+}
+
+// Wajib 2FA berlaku di login berikutnya; sesi yang sudah berjalan tidak diputus
+func (r *RoleRepositoryImpl) SetRequire2FA(ctx context.Context, id string, required bool) error {
+	result, err := r.DB.ExecContext(ctx, `UPDATE roles SET require_2fa = $1 WHERE id = $2`, required, id)
+	return expectOneRow(result, err)
+}
+
+// role_permissions ikut terhapus (ON DELETE CASCADE)
+func (r *RoleRepositoryImpl) DeletePermission(ctx context.Context, id string) error {
+	result, err := r.DB.ExecContext(ctx, `DELETE FROM permissions WHERE id = $1`, id)
+	return expectOneRow(result, err)
+}
+
+// SetRolePermissions mengganti seluruh permission role dalam satu transaksi
+func (r *RoleRepositoryImpl) SetRolePermissions(ctx context.Context, roleID string, permissionIDs []string) error {
+	tx, err := r.DB.BeginTx(ctx, nil)
+	if err != nil {
+		return err
+	}
+	defer tx.Rollback()
+
+	if _, err := tx.ExecContext(ctx, `DELETE FROM role_permissions WHERE role_id = $1`, roleID); err != nil {
+		return err
+	}
+	if len(permissionIDs) > 0 {
+		_, err = tx.ExecContext(ctx, `
+			INSERT INTO role_permissions (role_id, permission_id)
+			SELECT $1, UNNEST($2::uuid[])
+			ON CONFLICT DO NOTHING
+		`, roleID, pq.Array(permissionIDs))
+		if err != nil {
+			return err
+		}
+	}
+	return tx.Commit()
+}
+
+// Jumlah role (selain excludeRoleID) yang memegang permission tertentu dan
+// punya minimal satu user aktif; role kosong tidak dihitung karena tidak
+// ada yang bisa memakainya
+func (r *RoleRepositoryImpl) CountActiveRolesWithPermission(ctx context.Context, permission string, excludeRoleID string) (int, error) {
+	var n int
+	err := r.DB.QueryRowContext(ctx, `
+		SELECT COUNT(DISTINCT rp.role_id)
+		FROM role_permissions rp
+		JOIN permissions p ON p.id = rp.permission_id
+		JOIN users u ON u.role_id = rp.role_id AND u.is_active = TRUE
+		WHERE p.name = $1
+		  AND ($2 = '' OR rp.role_id::text <> $2)
+	`, permission, excludeRoleID).Scan(&n)
+	return n, err
+}
+
+func expectOneRow(result sql.Result, err error) error {
+	if err != nil {
+		return err
+	}
+	n, err := result.RowsAffected()
+	if err != nil {
+		return err
+	}
+	if n == 0 {
+		return sql.ErrNoRows
+	}
+	return nil
+}
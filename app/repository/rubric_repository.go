@@ -0,0 +1,178 @@
+package repository
+
+import (
+	"context"
+	"database/sql"
+
+	"uas/app/model"
+)
+
+type RubricRepository interface {
+	Create(ctx context.Context, req model.PointRubricRequest) (*model.PointRubric, error)
+	Delete(ctx context.Context, id string) error
+	GetByID(ctx context.Context, id string) (*model.PointRubric, error)
+	List(ctx context.Context) ([]model.PointRubric, error)
+	GetActive(ctx context.Context) (*model.PointRubric, error)
+}
+
+type RubricRepositoryImpl struct {
+	DB *sql.DB
+}
+
+func NewRubricRepository(db *sql.DB) RubricRepository {
+	return &RubricRepositoryImpl{DB: db}
+}
+
+// CREATE rubrik baru; versi = versi terakhir tahun akademik tsb + 1.
+// Tidak ada UPDATE: perubahan rubrik selalu menjadi versi baru.
+func (r *RubricRepositoryImpl) Create(ctx context.Context, req model.PointRubricRequest) (*model.PointRubric, error) {
+	tx, err := r.DB.BeginTx(ctx, nil)
+	if err != nil {
+		return nil, err
+	}
+	if req.IsActive {
+		if err := deactivateRubrics(ctx, tx); err != nil {
+			tx.Rollback()
+			return nil, err
+		}
+	}
+	var id string
+	err = tx.QueryRowContext(ctx, `
+		INSERT INTO point_rubrics (id, academic_year, version, description, is_active, created_at, updated_at)
+		VALUES (
+			gen_random_uuid(), $1,
+			COALESCE((SELECT MAX(version) FROM point_rubrics WHERE academic_year = $1), 0) + 1,
+			$2, $3, NOW(), NOW()
+		)
+		RETURNING id
+	`, req.AcademicYear, req.Description, req.IsActive).Scan(&id)
+	if err != nil {
+		tx.Rollback()
+		return nil, err
+	}
+	if err := insertRubricEntries(ctx, tx, id, req.Entries); err != nil {
+		tx.Rollback()
+		return nil, err
+	}
+	if err := tx.Commit(); err != nil {
+		return nil, err
+	}
+	return r.GetByID(ctx, id)
+}
+
+// DELETE rubrik (entries ikut terhapus via ON DELETE CASCADE)
+func (r *RubricRepositoryImpl) Delete(ctx context.Context, id string) error {
+	result, err := r.DB.ExecContext(ctx, `DELETE FROM point_rubrics WHERE id = $1`, id)
+	if err != nil {
+		return err
+	}
+	if rows, _ := result.RowsAffected(); rows == 0 {
+		return sql.ErrNoRows
+	}
+	return nil
+}
+
+// GET rubrik by id beserta entries
+func (r *RubricRepositoryImpl) GetByID(ctx context.Context, id string) (*model.PointRubric, error) {
+	query := `
+		SELECT id, academic_year, version, description, is_active, created_at, updated_at
+		FROM point_rubrics
+		WHERE id = $1
+	`
+	return r.getOne(ctx, query, id)
+}
+
+// GET rubrik aktif (jika lebih dari satu, ambil tahun & versi terbaru)
+func (r *RubricRepositoryImpl) GetActive(ctx context.Context) (*model.PointRubric, error) {
+	query := `
+		SELECT id, academic_year, version, description, is_active, created_at, updated_at
+		FROM point_rubrics
+		WHERE is_active = TRUE
+		ORDER BY academic_year DESC, version DESC
+		LIMIT 1
+	`
+	return r.getOne(ctx, query)
+}
+
+// LIST semua rubrik (tanpa entries)
+func (r *RubricRepositoryImpl) List(ctx context.Context) ([]model.PointRubric, error) {
+	rows, err := r.DB.QueryContext(ctx, `
+		SELECT id, academic_year, version, description, is_active, created_at, updated_at
+		FROM point_rubrics
+		ORDER BY academic_year DESC, version DESC
+	`)
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+	rubrics := []model.PointRubric{}
+	for rows.Next() {
+		var rb model.PointRubric
+		if err := rows.Scan(
+			&rb.ID,
+			&rb.AcademicYear,
+			&rb.Version,
+			&rb.Description,
+			&rb.IsActive,
+			&rb.CreatedAt,
+			&rb.UpdatedAt,
+		); err != nil {
+			return nil, err
+		}
+		rubrics = append(rubrics, rb)
+	}
+	return rubrics, rows.Err()
+}
+
+func (r *RubricRepositoryImpl) getOne(ctx context.Context, query string, args ...interface{}) (*model.PointRubric, error) {
+	var rb model.PointRubric
+	err := r.DB.QueryRowContext(ctx, query, args...).Scan(
+		&rb.ID,
+		&rb.AcademicYear,
+		&rb.Version,
+		&rb.Description,
+		&rb.IsActive,
+		&rb.CreatedAt,
+		&rb.UpdatedAt,
+	)
+	if err != nil {
+		return nil, err
+	}
+	rows, err := r.DB.QueryContext(ctx, `
+		SELECT id, achievement_type, level, rank, points
+		FROM point_rubric_entries
+		WHERE rubric_id = $1
+		ORDER BY achievement_type, level, rank
+	`, rb.ID)
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+	rb.Entries = []model.PointRubricEntry{}
+	for rows.Next() {
+		var e model.PointRubricEntry
+		if err := rows.Scan(&e.ID, &e.AchievementType, &e.Level, &e.Rank, &e.Points); err != nil {
+			return nil, err
+		}
+		rb.Entries = append(rb.Entries, e)
+	}
+	return &rb, rows.Err()
+}
+
+func deactivateRubrics(ctx context.Context, tx *sql.Tx) error {
+	_, err := tx.ExecContext(ctx, `UPDATE point_rubrics SET is_active = FALSE, updated_at = NOW() WHERE is_active = TRUE`)
+	return err
+}
+
+func insertRubricEntries(ctx context.Context, tx *sql.Tx, rubricID string, entries []model.PointRubricEntry) error {
+	for _, e := range entries {
+		_, err := tx.ExecContext(ctx, `
+			INSERT INTO point_rubric_entries (rubric_id, achievement_type, level, rank, points)
+			VALUES ($1, $2, $3, $4, $5)
+		`, rubricID, e.AchievementType, e.Level, e.Rank, e.Points)
+		if err != nil {
+			return err
+		}
+	}
+	return nil
+}
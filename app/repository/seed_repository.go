@@ -0,0 +1,196 @@
+package repository
+
+import (
+	"context"
+	"database/sql"
+	"errors"
+)
+
+// SeedRepository: insert idempoten untuk perintah `seed`.
+// Semua method aman dijalankan berulang kali; baris yang sudah ada tidak diubah.
+type SeedRepository interface {
+	EnsureRole(ctx context.Context, name, description string, require2FA bool) (string, error)
+	EnsurePermission(ctx context.Context, name, resource, action, description string) (string, error)
+	GrantPermission(ctx context.Context, roleID, permissionID string) (bool, error)
+	EnsureUser(ctx context.Context, username, email, fullName, passHash, roleID string) (int64, bool, error)
+	EnsureLecturer(ctx context.Context, userID int64, nip, department string) (int64, error)
+	EnsureStudent(ctx context.Context, userID int64, nim, programStudy, academicYear string, advisorID int64) (string, error)
+	CountReferences(ctx context.Context, studentID string) (int, error)
+	InsertSeedReference(ctx context.Context, studentID, mongoID, status string, actor AchievementActor) error
+}
+
+type SeedRepositoryImpl struct {
+	DB *sql.DB
+}
+
+func NewSeedRepository(db *sql.DB) SeedRepository {
+	return &SeedRepositoryImpl{DB: db}
+}
+
+func (r *SeedRepositoryImpl) EnsureRole(ctx context.Context, name, description string, require2FA bool) (string, error) {
+	query := `
+		WITH ins AS (
+			INSERT INTO roles (name, description, require_2fa)
+			VALUES ($1, $2, $3)
+			ON CONFLICT (name) DO NOTHING
+			RETURNING id
+		)
+		SELECT id FROM ins
+		UNION ALL
+		SELECT id FROM roles WHERE name = $1
+		LIMIT 1
+	`
+	var id string
+	err := r.DB.QueryRowContext(ctx, query, name, description, require2FA).Scan(&id)
+	return id, err
+}
+
+func (r *SeedRepositoryImpl) EnsurePermission(ctx context.Context, name, resource, action, description string) (string, error) {
+	query := `
+		WITH ins AS (
+			INSERT INTO permissions (name, resource, action, description)
+			VALUES ($1, $2, $3, $4)
+			ON CONFLICT (name) DO NOTHING
+			RETURNING id
+		)
+		SELECT id FROM ins
+		UNION ALL
+		SELECT id FROM permissions WHERE name = $1
+		LIMIT 1
+	`
+	var id string
+	err := r.DB.QueryRowContext(ctx, query, name, resource, action, description).Scan(&id)
+	return id, err
+}
+
+// GrantPermission mengembalikan true jika baris role_permissions baru dibuat
+func (r *SeedRepositoryImpl) GrantPermission(ctx context.Context, roleID, permissionID string) (bool, error) {
+	result, err := r.DB.ExecContext(ctx, `
+		INSERT INTO role_permissions (role_id, permission_id)
+		VALUES ($1, $2)
+		ON CONFLICT DO NOTHING
+	`, roleID, permissionID)
+	if err != nil {
+		return false, err
+	}
+	n, err := result.RowsAffected()
+	return n > 0, err
+}
+
+// EnsureUser: created=false jika username sudah ada (password tidak diubah)
+func (r *SeedRepositoryImpl) EnsureUser(
+	ctx context.Context,
+	username, email, fullName, passHash, roleID string,
+) (int64, bool, error) {
+	var id int64
+	err := r.DB.QueryRowContext(ctx, `SELECT id FROM users WHERE username = $1`, username).Scan(&id)
+	if err == nil {
+		return id, false, nil
+	}
+	if !errors.Is(err, sql.ErrNoRows) {
+		return 0, false, err
+	}
+
+	err = r.DB.QueryRowContext(ctx, `
+		INSERT INTO users (username, email, full_name, password_hash, role_id, is_active)
+		VALUES ($1, $2, $3, $4, $5, TRUE)
+		RETURNING id
+	`, username, email, fullName, passHash, roleID).Scan(&id)
+	if err != nil {
+		return 0, false, err
+	}
+	return id, true, nil
+}
+
+func (r *SeedRepositoryImpl) EnsureLecturer(ctx context.Context, userID int64, nip, department string) (int64, error) {
+	query := `
+		WITH ins AS (
+			INSERT INTO lecturers (user_id, nip, department)
+			VALUES ($1, $2, $3)
+			ON CONFLICT (user_id) DO NOTHING
+			RETURNING id
+		)
+		SELECT id FROM ins
+		UNION ALL
+		SELECT id FROM lecturers WHERE user_id = $1
+		LIMIT 1
+	`
+	var id int64
+	err := r.DB.QueryRowContext(ctx, query, userID, nip, department).Scan(&id)
+	return id, err
+}
+
+func (r *SeedRepositoryImpl) EnsureStudent(
+	ctx context.Context,
+	userID int64,
+	nim, programStudy, academicYear string,
+	advisorID int64,
+) (string, error) {
+	query := `
+		WITH ins AS (
+			INSERT INTO students (user_id, nim, program_study, academic_year, advisor_id)
+			VALUES ($1, $2, $3, $4, $5)
+			ON CONFLICT (user_id) DO NOTHING
+			RETURNING id
+		)
+		SELECT id FROM ins
+		UNION ALL
+		SELECT id FROM students WHERE user_id = $1
+		LIMIT 1
+	`
+	var id string
+	err := r.DB.QueryRowContext(ctx, query, userID, nim, programStudy, academicYear, advisorID).Scan(&id)
+	return id, err
+}
+
+func (r *SeedRepositoryImpl) CountReferences(ctx context.Context, studentID string) (int, error) {
+	var n int
+	err := r.DB.QueryRowContext(ctx,
+		`SELECT COUNT(*) FROM achievement_references WHERE student_uuid = $1`, studentID,
+	).Scan(&n)
+	return n, err
+}
+
+// InsertSeedReference membuat reference berstatus draft atau submitted
+// beserta event log-nya, seperti jika dibuat lewat API.
+func (r *SeedRepositoryImpl) InsertSeedReference(
+	ctx context.Context,
+	studentID, mongoID, status string,
+	actor AchievementActor,
+) error {
+	tx, err := r.DB.BeginTx(ctx, nil)
+	if err != nil {
+		return err
+	}
+	defer tx.Rollback()
+
+	var refID string
+	err = tx.QueryRowContext(ctx, `
+		INSERT INTO achievement_references (
+			student_uuid, mongo_achievement_id, status, submitted_at, created_at, updated_at
+		) VALUES (
+			$1, $2, $3, CASE WHEN $4 THEN NOW() END, NOW(), NOW()
+		)
+		RETURNING id
+	`, studentID, mongoID, status, status == "submitted").Scan(&refID)
+	if err != nil {
+		return err
+	}
+
+	events := [][2]string{{"", "draft"}}
+	if status != "draft" {
+		events = append(events, [2]string{"draft", status})
+	}
+	for _, e := range events {
+		_, err = tx.ExecContext(ctx, `
+			INSERT INTO achievement_status_events (
+				achievement_ref_id, mongo_achievement_id, round, from_status, to_status,
+				actor_user_id, actor_role, note, created_at
+			) VALUES ($1, $2, 1, NULLIF($3, ''), $4, $5, $6, 'seed', NOW())
+		`, refID, mongoID, e[0], e[1], actor.UserID, actor.Role)
+		if err != nil {
+			return err
+		}
+	}
+	return tx.Commit()
+}
@@ -0,0 +1,234 @@
+package repository
+
+import (
+	"context"
+	"database/sql"
+	"time"
+
+	"uas/app/model"
+
+	"github.com/lib/pq"
+)
+
+type TwoFactorRepository interface {
+	Status(ctx context.Context, userID int64) (*model.TwoFactorStatus, error)
+
+	// TOTP
+	GetSecret(ctx context.Context, userID int64) (*model.TOTPSecret, error)
+	SaveSecret(ctx context.Context, userID int64, secret string) error
+	Confirm(ctx context.Context, userID int64, step int64, codeHashes []string) error
+	UseStep(ctx context.Context, userID int64, step int64) error
+	Disable(ctx context.Context, userID int64) error
+
+	// RECOVERY CODE
+	ReplaceRecoveryCodes(ctx context.Context, userID int64, codeHashes []string) error
+	UseRecoveryCode(ctx context.Context, userID int64, codeHash string) error
+
+	// LOGIN CHALLENGE
+	CreateChallenge(ctx context.Context, userID int64, tokenHash string, expiresAt time.Time) error
+	GetChallenge(ctx context.Context, tokenHash string) (*model.LoginChallenge, error)
+	FailChallenge(ctx context.Context, tokenHash string) (int, error)
+	ConsumeChallenge(ctx context.Context, tokenHash string) (int64, error)
+}
+
+type TwoFactorRepositoryImpl struct {
+	DB *sql.DB
+}
+
+func NewTwoFactorRepository(db *sql.DB) TwoFactorRepository {
+	return &TwoFactorRepositoryImpl{DB: db}
+}
+
+// Status: sql.ErrNoRows jika user tidak ada
+func (r *TwoFactorRepositoryImpl) Status(ctx context.Context, userID int64) (*model.TwoFactorStatus, error) {
+	query := `
+		SELECT
+			t.confirmed_at IS NOT NULL,
+			t.user_id IS NOT NULL AND t.confirmed_at IS NULL,
+			COALESCE(r.require_2fa, FALSE),
+			(SELECT COUNT(*) FROM user_recovery_codes c
+			 WHERE c.user_id = u.id AND c.used_at IS NULL)
+		FROM users u
+		LEFT JOIN roles r ON r.id = u.role_id
+		LEFT JOIN user_totp t ON t.user_id = u.id
+		WHERE u.id = $1
+	`
+	var s model.TwoFactorStatus
+	err := r.DB.QueryRowContext(ctx, query, userID).
+		Scan(&s.Enabled, &s.Pending, &s.Required, &s.RecoveryCodesLeft)
+	if err != nil {
+		return nil, err
+	}
+	return &s, nil
+}
+
+// GetSecret: sql.ErrNoRows jika user belum pernah enroll
+func (r *TwoFactorRepositoryImpl) GetSecret(ctx context.Context, userID int64) (*model.TOTPSecret, error) {
+	query := `
+		SELECT user_id, secret, confirmed_at IS NOT NULL, last_used_step
+		FROM user_totp
+		WHERE user_id = $1
+	`
+	var s model.TOTPSecret
+	err := r.DB.QueryRowContext(ctx, query, userID).
+		Scan(&s.UserID, &s.Secret, &s.Confirmed, &s.LastUsedStep)
+	if err != nil {
+		return nil, err
+	}
+	return &s, nil
+}
+
+// SaveSecret menyimpan secret yang belum dikonfirmasi (enroll ulang menimpa
+// secret lama). sql.ErrNoRows jika 2FA user sudah aktif.
+func (r *TwoFactorRepositoryImpl) SaveSecret(ctx context.Context, userID int64, secret string) error {
+	result, err := r.DB.ExecContext(ctx, `
+		INSERT INTO user_totp (user_id, secret)
+		VALUES ($1, $2)
+		ON CONFLICT (user_id) DO UPDATE
+		SET secret = EXCLUDED.secret, last_used_step = 0, created_at = NOW()
+		WHERE user_totp.confirmed_at IS NULL
+	`, userID, secret)
+	return expectOneRow(result, err)
+}
+
+// Confirm mengaktifkan 2FA dengan kode pertama (step) sekaligus menyimpan
+// recovery code, dalam satu transaksi. sql.ErrNoRows jika sudah aktif atau
+// step sudah pernah dipakai.
+func (r *TwoFactorRepositoryImpl) Confirm(ctx context.Context, userID int64, step int64, codeHashes []string) error {
+	tx, err := r.DB.BeginTx(ctx, nil)
+	if err != nil {
+		return err
+	}
+	defer tx.Rollback()
+
+	result, err := tx.ExecContext(ctx, `
+		UPDATE user_totp
+		SET confirmed_at = NOW(), last_used_step = $2
+		WHERE user_id = $1 AND confirmed_at IS NULL AND last_used_step < $2
+	`, userID, step)
+	if err := expectOneRow(result, err); err != nil {
+		return err
+	}
+	if err := replaceRecoveryCodes(ctx, tx, userID, codeHashes); err != nil {
+		return err
+	}
+	return tx.Commit()
+}
+
+// UseStep mencatat langkah TOTP yang baru dipakai. Atomic: kode yang sama
+// (atau yang lebih lama) tidak bisa dipakai dua kali → sql.ErrNoRows.
+func (r *TwoFactorRepositoryImpl) UseStep(ctx context.Context, userID int64, step int64) error {
+	result, err := r.DB.ExecContext(ctx, `
+		UPDATE user_totp
+		SET last_used_step = $2
+		WHERE user_id = $1 AND confirmed_at IS NOT NULL AND last_used_step < $2
+	`, userID, step)
+	return expectOneRow(result, err)
+}
+
+// Disable menghapus secret dan seluruh recovery code user
+func (r *TwoFactorRepositoryImpl) Disable(ctx context.Context, userID int64) error {
+	tx, err := r.DB.BeginTx(ctx, nil)
+	if err != nil {
+		return err
+	}
+	defer tx.Rollback()
+
+	if _, err := tx.ExecContext(ctx, `DELETE FROM user_recovery_codes WHERE user_id = $1`, userID); err != nil {
+		return err
+	}
+	result, err := tx.ExecContext(ctx, `DELETE FROM user_totp WHERE user_id = $1`, userID)
+	if err := expectOneRow(result, err); err != nil {
+		return err
+	}
+	return tx.Commit()
+}
+
+// ReplaceRecoveryCodes mengganti seluruh recovery code; yang lama tidak berlaku lagi
+func (r *TwoFactorRepositoryImpl) ReplaceRecoveryCodes(ctx context.Context, userID int64, codeHashes []string) error {
+	tx, err := r.DB.BeginTx(ctx, nil)
+	if err != nil {
+		return err
+	}
+	defer tx.Rollback()
+
+	if err := replaceRecoveryCodes(ctx, tx, userID, codeHashes); err != nil {
+		return err
+	}
+	return tx.Commit()
+}
+
+func replaceRecoveryCodes(ctx context.Context, tx *sql.Tx, userID int64, codeHashes []string) error {
+	if _, err := tx.ExecContext(ctx, `DELETE FROM user_recovery_codes WHERE user_id = $1`, userID); err != nil {
+		return err
+	}
+	_, err := tx.ExecContext(ctx, `
+		INSERT INTO user_recovery_codes (user_id, code_hash)
+		SELECT $1, UNNEST($2::text[])
+		ON CONFLICT DO NOTHING
+	`, userID, pq.Array(codeHashes))
+	return err
+}
+
+// UseRecoveryCode menandai kode terpakai. sql.ErrNoRows jika kode tidak
+// dikenal atau sudah dipakai.
+func (r *TwoFactorRepositoryImpl) UseRecoveryCode(ctx context.Context, userID int64, codeHash string) error {
+	result, err := r.DB.ExecContext(ctx, `
+		UPDATE user_recovery_codes
+		SET used_at = NOW()
+		WHERE user_id = $1 AND code_hash = $2 AND used_at IS NULL
+	`, userID, codeHash)
+	return expectOneRow(result, err)
+}
+
+// CreateChallenge menyimpan hash token tahap kedua; challenge kedaluwarsa
+// dibersihkan sekalian
+func (r *TwoFactorRepositoryImpl) CreateChallenge(ctx context.Context, userID int64, tokenHash string, expiresAt time.Time) error {
+	if _, err := r.DB.ExecContext(ctx, `DELETE FROM login_challenges WHERE expires_at <= NOW()`); err != nil {
+		return err
+	}
+	_, err := r.DB.ExecContext(ctx, `
+		INSERT INTO login_challenges (token_hash, user_id, expires_at)
+		VALUES ($1, $2, $3)
+	`, tokenHash, userID, expiresAt)
+	return err
+}
+
+// GetChallenge: sql.ErrNoRows jika tidak ada atau kedaluwarsa
+func (r *TwoFactorRepositoryImpl) GetChallenge(ctx context.Context, tokenHash string) (*model.LoginChallenge, error) {
+	query := `
+		SELECT user_id, attempts, expires_at
+		FROM login_challenges
+		WHERE token_hash = $1 AND expires_at > NOW()
+	`
+	var ch model.LoginChallenge
+	err := r.DB.QueryRowContext(ctx, query, tokenHash).Scan(&ch.UserID, &ch.Attempts, &ch.ExpiresAt)
+	if err != nil {
+		return nil, err
+	}
+	return &ch, nil
+}
+
+// FailChallenge menambah hitungan kode salah dan mengembalikan totalnya
+func (r *TwoFactorRepositoryImpl) FailChallenge(ctx context.Context, tokenHash string) (int, error) {
+	var attempts int
+	err := r.DB.QueryRowContext(ctx, `
+		UPDATE login_challenges
+		SET attempts = attempts + 1
+		WHERE token_hash = $1
+		RETURNING attempts
+	`, tokenHash).Scan(&attempts)
+	return attempts, err
+}
+
+// ConsumeChallenge menghapus challenge dan mengembalikan user_id-nya.
+// Atomic: satu challenge hanya bisa ditukar dengan token sekali.
+func (r *TwoFactorRepositoryImpl) ConsumeChallenge(ctx context.Context, tokenHash string) (int64, error) {
+	var userID int64
+	err := r.DB.QueryRowContext(ctx, `
+		DELETE FROM login_challenges
+		WHERE token_hash = $1 AND expires_at > NOW()
+		RETURNING user_id
+	`, tokenHash).Scan(&userID)
+	return userID, err
+}
@@ -19,6 +19,10 @@ type UserRepository interface {
 	GetPermissionsByUserID(userID int64) ([]string, error)
 	Logout()
 	UpdateRole(ctx context.Context, userID int64, roleID string) error
+	SetActive(ctx context.Context, userID int64, active bool) error
+	FindCredentialsByID(ctx context.Context, userID int64) (*model.User, error)
+	FindCredentialsByEmail(ctx context.Context, email string) (*model.User, error)
+	UpdatePassword(ctx context.Context, userID int64, passwordHash string) error
 }
 
 type UserRepositoryImpl struct {
@@ -74,7 +78,12 @@ func (r *UserRepositoryImpl) Delete(ctx context.Context, userID int64) error {
 
 // FIND ALL USERS
 func (r *UserRepositoryImpl) FindAll(ctx context.Context) (*[]model.UserResponse, error) {
-	sqlQuery := `SELECT id, username, full_name, role_id FROM users`
+	sqlQuery := `
+		SELECT u.id, u.username, u.full_name, r.name, u.is_active
+		FROM users u
+		JOIN roles r ON r.id = u.role_id
+		ORDER BY u.id
+	`
 
 	rows, err := r.DB.QueryContext(ctx, sqlQuery)
 	if err != nil {
@@ -85,7 +94,7 @@ func (r *UserRepositoryImpl) FindAll(ctx context.Context) (*[]model.UserResponse
 	var users []model.UserResponse
 	for rows.Next() {
 		var u model.UserResponse
-		if err := rows.Scan(&u.ID, &u.Username, &u.FullName, &u.Role); err != nil {
+		if err := rows.Scan(&u.ID, &u.Username, &u.FullName, &u.Role, &u.IsActive); err != nil {
 			return nil, err
 		}
 		users = append(users, u)
@@ -95,12 +104,18 @@ func (r *UserRepositoryImpl) FindAll(ctx context.Context) (*[]model.UserResponse
 
 // FIND USER BY ID
 func (r *UserRepositoryImpl) FindById(ctx context.Context, id int64) (*model.UserResponse, error) {
-	sqlQuery := `SELECT id, username, full_name, role_id FROM users WHERE id=$1`
+	// role berisi nama role (sama dengan klaim JWT), bukan role_id
+	sqlQuery := `
+		SELECT u.id, u.username, u.full_name, r.name, u.is_active, u.token_version
+		FROM users u
+		JOIN roles r ON r.id = u.role_id
+		WHERE u.id=$1
+	`
 
 	row := r.DB.QueryRowContext(ctx, sqlQuery, id)
 
 	var u model.UserResponse
-	if err := row.Scan(&u.ID, &u.Username, &u.FullName, &u.Role); err != nil {
+	if err := row.Scan(&u.ID, &u.Username, &u.FullName, &u.Role, &u.IsActive, &u.TokenVersion); err != nil {
 		return nil, err
 	}
 	return &u, nil
@@ -112,7 +127,7 @@ func (r *UserRepositoryImpl) FindByUsernameOrEmail(usernameOrEmail string) (*mod
         SELECT 
             u.id, u.username, u.full_name, u.email,
             u.password_hash, u.role_id, u.is_active,
-            r.name AS role_name
+            u.token_version, r.name AS role_name
         FROM users u
         JOIN roles r ON r.id = u.role_id
         WHERE u.username=$1 OR u.email=$1
@@ -127,6 +142,7 @@ func (r *UserRepositoryImpl) FindByUsernameOrEmail(usernameOrEmail string) (*mod
 		&u.PasswordHash,
 		&u.RoleID,
 		&u.IsActive,
+		&u.TokenVersion,
 		&u.Role, // ← inilah field Role yang benar!
 	); err != nil {
 		return nil, err
@@ -189,6 +205,8 @@ func (r *UserRepositoryImpl) UpdateRaw(
             full_name=$2,
             email=$3,
             role_id=$4,
+            -- role berubah → access token lama (klaim role lama) tidak berlaku
+            token_version = token_version + CASE WHEN role_id <> $4 THEN 1 ELSE 0 END,
             updated_at = NOW()
         WHERE id=$5
     `
@@ -210,6 +228,7 @@ func (r *UserRepositoryImpl) UpdateRole(
 	query := `
 		UPDATE users
 		SET role_id = $1,
+		    token_version = token_version + CASE WHEN role_id <> $1 THEN 1 ELSE 0 END,
 		    updated_at = NOW()
 		WHERE id = $2
 	`
@@ -227,3 +246,96 @@ func (r *UserRepositoryImpl) UpdateRole(
 	return nil
 }
 
+// SetActive mengaktifkan / menonaktifkan akun. Menonaktifkan juga menaikkan
+// token_version sehingga semua access token yang beredar langsung ditolak.
+func (r *UserRepositoryImpl) SetActive(ctx context.Context, userID int64, active bool) error {
+	query := `
+		UPDATE users
+		SET is_active = $1,
+		    token_version = token_version + CASE WHEN $1 THEN 0 ELSE 1 END,
+		    updated_at = NOW()
+		WHERE id = $2
+	`
+	result, err := r.DB.ExecContext(ctx, query, active, userID)
+	if err != nil {
+		return err
+	}
+	rows, err := result.RowsAffected()
+	if err != nil {
+		return err
+	}
+	if rows == 0 {
+		return sql.ErrNoRows
+	}
+	return nil
+}
+
+// FindCredentialsByID: data login (email, password_hash, status) untuk
+// ganti / reset password
+func (r *UserRepositoryImpl) FindCredentialsByID(ctx context.Context, userID int64) (*model.User, error) {
+	query := `
+		SELECT u.id, u.username, u.full_name, u.email,
+		       u.password_hash, u.role_id, u.is_active,
+		       u.token_version, r.name
+		FROM users u
+		JOIN roles r ON r.id = u.role_id
+		WHERE u.id = $1
+	`
+	var u model.User
+	err := r.DB.QueryRowContext(ctx, query, userID).Scan(
+		&u.ID, &u.Username, &u.FullName, &u.Email,
+		&u.PasswordHash, &u.RoleID, &u.IsActive,
+		&u.TokenVersion, &u.Role,
+	)
+	if err != nil {
+		return nil, err
+	}
+	return &u, nil
+}
+
+// FindCredentialsByEmail: sama seperti FindCredentialsByID, dicari lewat
+// email saja (lupa password tidak menerima username)
+func (r *UserRepositoryImpl) FindCredentialsByEmail(ctx context.Context, email string) (*model.User, error) {
+	query := `
+		SELECT u.id, u.username, u.full_name, u.email,
+		       u.password_hash, u.role_id, u.is_active,
+		       u.token_version, r.name
+		FROM users u
+		JOIN roles r ON r.id = u.role_id
+		WHERE LOWER(u.email) = LOWER($1)
+	`
+	var u model.User
+	err := r.DB.QueryRowContext(ctx, query, email).Scan(
+		&u.ID, &u.Username, &u.FullName, &u.Email,
+		&u.PasswordHash, &u.RoleID, &u.IsActive,
+		&u.TokenVersion, &u.Role,
+	)
+	if err != nil {
+		return nil, err
+	}
+	return &u, nil
+}
+
+// UpdatePassword mengganti password_hash dan menaikkan token_version
+// (semua access token lama langsung ditolak)
+func (r *UserRepositoryImpl) UpdatePassword(ctx context.Context, userID int64, passwordHash string) error {
+	query := `
+		UPDATE users
+		SET password_hash = $1,
+		    token_version = token_version + 1,
+		    updated_at = NOW()
+		WHERE id = $2
+	`
+	result, err := r.DB.ExecContext(ctx, query, passwordHash, userID)
+	if err != nil {
+		return err
+	}
+	rows, err := result.RowsAffected()
+	if err != nil {
+		return err
+	}
+	if rows == 0 {
+		return sql.ErrNoRows
+	}
+	return nil
+}
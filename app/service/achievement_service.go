@@ -2,43 +2,56 @@ package service
 
 import (
 	"context"
+	"database/sql"
 	"errors"
 	"fmt"
+	"log"
+	"os"
 	"strconv"
 	"strings"
-	"time"
-	"os"
 
 	"path/filepath"
+	"uas/app/model"
 	"uas/app/repository"
 	"uas/utils"
 
 	"github.com/gofiber/fiber/v2"
 	"go.mongodb.org/mongo-driver/bson"
 	"go.mongodb.org/mongo-driver/bson/primitive"
-	"go.mongodb.org/mongo-driver/mongo"
 )
 
 type AchievementService struct {
-	Repo  *repository.AchievementRepository
-	Mongo *mongo.Client
+	Repo    repository.AchievementReferenceRepository
+	Docs    repository.AchievementDocumentStore
+	SyncOps repository.AchievementSyncRepository
+	Rubrics repository.RubricRepository
+	Types   repository.AchievementTypeRepository
 }
 
-func NewAchievementService(repo *repository.AchievementRepository, mongo *mongo.Client) *AchievementService {
+func NewAchievementService(
+	repo repository.AchievementReferenceRepository,
+	docs repository.AchievementDocumentStore,
+	syncOps repository.AchievementSyncRepository,
+	rubrics repository.RubricRepository,
+	types repository.AchievementTypeRepository,
+) *AchievementService {
 	return &AchievementService{
-		Repo:  repo,
-		Mongo: mongo,
+		Repo:    repo,
+		Docs:    docs,
+		SyncOps: syncOps,
+		Rubrics: rubrics,
+		Types:   types,
 	}
 }
 
 // DTO INPUT
+// Poin tidak diterima dari client; dihitung dari rubrik aktif.
 type AchievementInput struct {
 	AchievementType string                 `json:"achievement_type"`
 	Title           string                 `json:"title"`
 	Description     string                 `json:"description"`
 	Details         map[string]interface{} `json:"details"`
 	Tags            []string               `json:"tags"`
-	Points          float64                `json:"points"`
 }
 
 // DTO OUTPUT
@@ -49,6 +62,103 @@ type AchievementOutput struct {
 	Data      map[string]interface{} `json:"data"`
 }
 
+// Validasi input create/update: title wajib, tipe harus ada di katalog aktif dan
+// details harus lolos JSON Schema kategori. Kategori bawaan tanpa baris di
+// katalog juga di-decode ke struct bertipe (year jadi integer, dst) lalu
+// dinormalisasi; jika admin menimpanya di katalog, hanya schema katalog yang
+// berlaku (struct bertipe bisa menolak field yang sudah diizinkan admin).
+func (s *AchievementService) validateAchievementInput(
+	ctx context.Context,
+	input *AchievementInput,
+) (*model.AchievementType, error) {
+	errs := model.ValidationErrors{}
+	if strings.TrimSpace(input.Title) == "" {
+		errs["title"] = "is required"
+	}
+	if input.AchievementType == "" {
+		errs["achievement_type"] = "is required"
+		return nil, errs
+	}
+
+	achievementType, inCatalog, err := lookupAchievementType(ctx, s.Types, input.AchievementType)
+	if err != nil {
+		var verrs model.ValidationErrors
+		if errors.As(err, &verrs) {
+			for field, msg := range verrs {
+				errs[field] = msg
+			}
+			return nil, errs
+		}
+		return nil, err
+	}
+
+	if achievementType.IsBuiltin && !inCatalog {
+		details, detailErrs := model.ParseAchievementDetails(input.AchievementType, input.Details)
+		for field, msg := range detailErrs {
+			errs[field] = msg
+		}
+		if details != nil {
+			input.Details = model.DetailsToMap(details)
+		}
+	}
+	for field, msg := range validateDetailsSchema(achievementType, input.Details) {
+		setFieldError(errs, field, msg)
+	}
+	if len(errs) > 0 {
+		return nil, errs
+	}
+	return achievementType, nil
+}
+
+// Response 422 berisi error per field untuk ValidationErrors, 400 untuk error lain
+func achievementInputError(c *fiber.Ctx, err error) error {
+	var verrs model.ValidationErrors
+	if errors.As(err, &verrs) {
+		return c.Status(422).JSON(fiber.Map{
+			"error":  "validation_failed",
+			"fields": verrs,
+		})
+	}
+	return c.Status(400).JSON(fiber.Map{"error": err.Error()})
+}
+
+// Actor untuk event log diambil dari JWT claims
+func actorFromClaims(claims *utils.Claims) repository.AchievementActor {
+	return repository.AchievementActor{
+		UserID: claims.UserID,
+		Role:   claims.Role,
+	}
+}
+
+// Hitung poin saran dari rubrik aktif. Jika rubrik aktif tidak punya entry untuk
+// tipe ini, pakai default_rubric dari katalog. Jika keduanya kosong,
+// poin = 0 dan rubricVersion = nil (dosen wali yang menentukan saat verifikasi).
+func (s *AchievementService) rubricPoints(
+	ctx context.Context,
+	achievementType *model.AchievementType,
+	details map[string]interface{},
+) (float64, interface{}, error) {
+	rubric, err := s.Rubrics.GetActive(ctx)
+	if err != nil && !errors.Is(err, sql.ErrNoRows) {
+		return 0, nil, errors.New("failed to load points rubric")
+	}
+	if rubric != nil {
+		if points, ok := suggestPoints(rubric, achievementType.Key, details); ok {
+			return points, rubric.Label(), nil
+		}
+	}
+	if len(achievementType.DefaultRubric) > 0 {
+		fallback := &model.PointRubric{Entries: achievementType.DefaultRubric}
+		if points, ok := suggestPoints(fallback, achievementType.Key, details); ok {
+			return points, "default:" + achievementType.Key, nil
+		}
+	}
+	if rubric != nil {
+		return 0, rubric.Label(), nil
+	}
+	return 0, nil, nil
+}
+
 //	HANDLER (FIBER)
 //
 // CREATE
@@ -61,7 +171,7 @@ func (s *AchievementService) Create(c *fiber.Ctx) error {
 	}
 	result, err := s.CreateAchievement(c.Context(), claims.UserID, claims.Role, input)
 	if err != nil {
-		return c.Status(400).JSON(fiber.Map{"error": err.Error()})
+		return achievementInputError(c, err)
 	}
 	return c.JSON(result)
 }
@@ -78,9 +188,7 @@ func (s *AchievementService) CreateHandler(c *fiber.Ctx) error {
 	}
 	result, err := s.CreateAchievement(c.Context(), userID, role, input)
 	if err != nil {
-		return c.Status(400).JSON(fiber.Map{
-			"error": err.Error(),
-		})
+		return achievementInputError(c, err)
 	}
 	return c.JSON(result)
 }
@@ -88,9 +196,36 @@ func (s *AchievementService) CreateHandler(c *fiber.Ctx) error {
 // SUBMIT (draft → submitted)
 func (s *AchievementService) Submit(c *fiber.Ctx) error {
 	claims := c.Locals("claims").(*utils.Claims)
+	ctx := c.Context()
 	achievementID := c.Params("id")
 
-	err := s.SubmitAchievement(c.Context(), claims.UserID, claims.Role, achievementID)
+	// Cek ownership dulu: dokumen milik orang lain tidak boleh dibaca
+	// (daftar lampiran yang belum ada) sebelum ditolak
+	studentID, err := s.Repo.GetStudentID(ctx, claims.UserID)
+	if err != nil {
+		return c.Status(400).JSON(fiber.Map{"error": "student profile not found"})
+	}
+	ref, err := s.Repo.GetReferenceByMongoID(ctx, achievementID)
+	if err != nil {
+		return c.Status(404).JSON(fiber.Map{"error": "achievement_not_found"})
+	}
+	if ref["student_uuid"] != studentID {
+		return c.Status(403).JSON(fiber.Map{"error": "not_achievement_owner"})
+	}
+
+	// Lampiran wajib sesuai katalog harus sudah diunggah sebelum submit
+	missing, err := s.missingAttachmentKinds(ctx, achievementID)
+	if err != nil {
+		return c.Status(400).JSON(fiber.Map{"error": err.Error()})
+	}
+	if len(missing) > 0 {
+		return c.Status(422).JSON(fiber.Map{
+			"error":   "required_attachments_missing",
+			"missing": missing,
+		})
+	}
+
+	err = s.SubmitAchievement(ctx, claims.UserID, claims.Role, achievementID)
 	if err != nil {
 		return c.Status(400).JSON(fiber.Map{"error": err.Error()})
 	}
@@ -102,6 +237,39 @@ func (s *AchievementService) Submit(c *fiber.Ctx) error {
 	})
 }
 
+// Kind lampiran yang diwajibkan katalog tapi belum ada di dokumen Mongo
+func (s *AchievementService) missingAttachmentKinds(ctx context.Context, achievementID string) ([]string, error) {
+	objID, err := primitive.ObjectIDFromHex(achievementID)
+	if err != nil {
+		return nil, errors.New("invalid achievement id")
+	}
+	doc, err := s.Docs.FindOne(ctx, bson.M{"_id": objID})
+	if err != nil {
+		return nil, errors.New("achievement not found")
+	}
+	typeKey, _ := doc["achievementType"].(string)
+	achievementType, err := resolveAchievementType(ctx, s.Types, typeKey)
+	if err != nil {
+		// tipe lama yang sudah tidak ada di katalog tidak memblokir submit
+		return nil, nil
+	}
+	uploaded := map[string]bool{}
+	attachments, _ := doc["attachments"].(bson.A)
+	for _, a := range attachments {
+		if att, ok := a.(bson.M); ok {
+			kind, _ := att["kind"].(string)
+			uploaded[kind] = true
+		}
+	}
+	missing := []string{}
+	for _, kind := range achievementType.RequiredAttachments {
+		if !uploaded[kind] {
+			missing = append(missing, kind)
+		}
+	}
+	return missing, nil
+}
+
 //	INTERNAL BUSINESS LOGIC
 //
 // Create Logic
@@ -112,8 +280,9 @@ func (s *AchievementService) CreateAchievement(
 	input AchievementInput,
 ) (*AchievementOutput, error) {
 
-	if role != "mahasiswa" {
-		return nil, errors.New("only students can create achievements")
+	achievementType, err := s.validateAchievementInput(ctx, &input)
+	if err != nil {
+		return nil, err
 	}
 
 	studentID, err := s.Repo.GetStudentID(ctx, userID)
@@ -121,26 +290,42 @@ func (s *AchievementService) CreateAchievement(
 		return nil, errors.New("student profile not found")
 	}
 
-	// Build Mongo document
+	// Poin dari rubrik (bukan dari client)
+	points, rubricVersion, err := s.rubricPoints(ctx, achievementType, input.Details)
+	if err != nil {
+		return nil, err
+	}
+
+	// Build Mongo document (ObjectID dibuat di sini supaya bisa dicatat di outbox)
+	objID := primitive.NewObjectID()
+	objectID := objID.Hex()
+	now := syncTimestamp()
 	doc := map[string]interface{}{
+		"_id":             objID,
 		"studentId":       studentID,
 		"achievementType": input.AchievementType,
 		"title":           input.Title,
 		"description":     input.Description,
 		"details":         input.Details,
 		"tags":            input.Tags,
-		"points":          input.Points,
-		"createdAt":       time.Now(),
-		"updatedAt":       time.Now(),
+		"points":          points,
+		"rubricVersion":   rubricVersion,
+		"createdAt":       now,
+		"updatedAt":       now,
 	}
-	collection := s.Mongo.Database("uas").Collection("achievements")
-	result, err := collection.InsertOne(ctx, doc)
+
+	// Dual-write: outbox pending → Mongo → reference + done (satu tx)
+	syncOp, err := s.beginSync(ctx, model.SyncOpCreate, objectID, struct{}{})
 	if err != nil {
+		return nil, errors.New("failed to save achievement")
+	}
+	if err := s.Docs.Insert(ctx, bson.M(doc)); err != nil {
+		s.compensate(ctx, syncOp, err)
 		return nil, errors.New("failed to save achievement to mongo")
 	}
-	objectID := result.InsertedID.(primitive.ObjectID).Hex()
-	err = s.Repo.InsertReference(ctx, studentID, objectID)
+	err = s.Repo.InsertReference(ctx, studentID, objectID, repository.AchievementActor{UserID: userID, Role: role}, syncOp.ID)
 	if err != nil {
+		s.compensate(ctx, syncOp, err)
 		return nil, errors.New("failed to save reference to postgres")
 	}
 	return &AchievementOutput{
@@ -158,14 +343,11 @@ func (s *AchievementService) SubmitAchievement(
 	role string,
 	achievementID string,
 ) error {
-	if role != "mahasiswa" {
-		return errors.New("only students can submit achievements")
-	}
 	studentID, err := s.Repo.GetStudentID(ctx, userID)
 	if err != nil {
 		return errors.New("student profile not found")
 	}
-	err = s.Repo.Submit(ctx, achievementID, studentID)
+	err = s.Repo.Submit(ctx, achievementID, studentID, repository.AchievementActor{UserID: userID, Role: role})
 	if err != nil {
 		return err
 	}
@@ -176,14 +358,7 @@ func (s *AchievementService) GetMyAchievements(c *fiber.Ctx) error {
 	claims := c.Locals("claims").(*utils.Claims)
 	ctx := c.Context()
 
-	// 1. Hanya mahasiswa yang boleh melihat prestasinya
-	if claims.Role != "mahasiswa" {
-		return c.Status(403).JSON(fiber.Map{
-			"error": "only students can view their achievements",
-		})
-	}
-
-	// 2. Ambil student_id dari PostgreSQL
+	// 1. Ambil student_id dari PostgreSQL (hanya user dengan profil mahasiswa)
 	studentID, err := s.Repo.GetStudentID(ctx, claims.UserID)
 	if err != nil {
 		return c.Status(400).JSON(fiber.Map{
@@ -191,7 +366,7 @@ func (s *AchievementService) GetMyAchievements(c *fiber.Ctx) error {
 		})
 	}
 
-	// 3. Ambil reference dari PostgreSQL
+	// 2. Ambil reference dari PostgreSQL
 	refs, err := s.Repo.GetByStudentID(ctx, studentID, false)
 	if err != nil {
 		return c.Status(400).JSON(fiber.Map{
@@ -199,27 +374,61 @@ func (s *AchievementService) GetMyAchievements(c *fiber.Ctx) error {
 		})
 	}
 
-	// 4. Gabungkan dengan MongoDB
-	collection := s.Mongo.Database("uas").Collection("achievements")
-
-	var results []map[string]interface{}
+	// 3. Gabungkan dengan MongoDB (satu query $in)
+	results, err := s.joinAchievementDocuments(ctx, refs)
+	if err != nil {
+		return c.Status(500).JSON(fiber.Map{"error": "failed_get_mongo_achievements"})
+	}
+	return c.JSON(results)
+}
 
+// Gabungkan reference PostgreSQL dengan dokumen Mongo lewat satu query $in.
+// Urutan mengikuti refs; reference tanpa dokumen tetap dikembalikan dengan
+// "mongo": null dan "document_missing": true.
+func (s *AchievementService) joinAchievementDocuments(
+	ctx context.Context,
+	refs []map[string]interface{},
+) ([]map[string]interface{}, error) {
+	ids := make([]primitive.ObjectID, 0, len(refs))
 	for _, ref := range refs {
-		mongoID := ref["mongo_id"].(string)
+		mongoID, _ := ref["mongo_id"].(string)
+		if objID, err := primitive.ObjectIDFromHex(mongoID); err == nil {
+			ids = append(ids, objID)
+		}
+	}
+	docs, err := s.Docs.FindManyByIDs(ctx, ids)
+	if err != nil {
+		return nil, err
+	}
+	return mergeAchievementDocuments(refs, docs), nil
+}
 
-		var mongoDoc map[string]interface{}
-		objID, _ := primitive.ObjectIDFromHex(mongoID)
+func mergeAchievementDocuments(refs []map[string]interface{}, docs []bson.M) []map[string]interface{} {
+	docByID := make(map[string]bson.M, len(docs))
+	for _, doc := range docs {
+		if objID, ok := doc["_id"].(primitive.ObjectID); ok {
+			docByID[objID.Hex()] = doc
+		}
+	}
 
-		err := collection.FindOne(ctx, primitive.M{"_id": objID}).Decode(&mongoDoc)
-		if err != nil {
-			continue
+	results := make([]map[string]interface{}, 0, len(refs))
+	var missing []string
+	for _, ref := range refs {
+		mongoID, _ := ref["mongo_id"].(string)
+		item := map[string]interface{}{"reference": ref}
+		if doc, ok := docByID[mongoID]; ok {
+			item["mongo"] = doc
+		} else {
+			item["mongo"] = nil
+			item["document_missing"] = true
+			missing = append(missing, mongoID)
 		}
-		results = append(results, map[string]interface{}{
-			"reference": ref,
-			"mongo":     mongoDoc,
-		})
+		results = append(results, item)
 	}
-	return c.JSON(results)
+	if len(missing) > 0 {
+		log.Printf("achievement listing: %d mongo document(s) missing: %s", len(missing), strings.Join(missing, ", "))
+	}
+	return results
 }
 
 func (s *AchievementService) GetSupervisedAchievements(c *fiber.Ctx) error {
@@ -227,20 +436,13 @@ func (s *AchievementService) GetSupervisedAchievements(c *fiber.Ctx) error {
 	claims := c.Locals("claims").(*utils.Claims)
 	ctx := c.Context()
 
-	// 1. Hanya dosen wali yang boleh melihat ini
-	if claims.Role != "dosen wali" {
-		return c.Status(403).JSON(fiber.Map{
-			"error": "only academic advisors can view supervised achievements",
-		})
-	}
-
-	// 2. Ambil lecturer_id berdasarkan user_id
+	// 1. Ambil lecturer_id berdasarkan user_id
 	advisorID, err := s.Repo.GetLecturerID(ctx, claims.UserID)
 	if err != nil {
 		return c.Status(400).JSON(fiber.Map{"error": "lecturer profile not found"})
 	}
 
-	// 3. Ambil semua mahasiswa bimbingan
+	// 2. Ambil semua mahasiswa bimbingan
 	studentIDs, err := s.Repo.GetStudentsByAdvisor(ctx, advisorID)
 	if err != nil {
 		return c.Status(400).JSON(fiber.Map{"error": err.Error()})
@@ -251,45 +453,43 @@ func (s *AchievementService) GetSupervisedAchievements(c *fiber.Ctx) error {
 		return c.JSON([]interface{}{})
 	}
 
-	// 4. Ambil semua references
+	// 3. Ambil semua references
 	refs, err := s.Repo.GetReferencesByStudentList(ctx, studentIDs)
 	if err != nil {
 		return c.Status(400).JSON(fiber.Map{"error": err.Error()})
 	}
 
-	// 5. Ambil dokumen mongo untuk masing-masing prestasi
-	collection := s.Mongo.Database("uas").Collection("achievements")
-
-	var results []map[string]interface{}
-	for _, ref := range refs {
-		mongoID := ref["mongo_id"].(string)
-		var mongoDoc map[string]interface{}
-		objID, _ := primitive.ObjectIDFromHex(mongoID)
-		err := collection.FindOne(ctx, primitive.M{"_id": objID}).Decode(&mongoDoc)
-		if err != nil {
-			continue
-		}
-		results = append(results, map[string]interface{}{
-			"reference": ref,
-			"mongo":     mongoDoc,
-		})
+	// 4. Ambil dokumen mongo untuk semua prestasi sekaligus
+	results, err := s.joinAchievementDocuments(ctx, refs)
+	if err != nil {
+		return c.Status(500).JSON(fiber.Map{"error": "failed_get_mongo_achievements"})
 	}
 	return c.JSON(results)
 }
 
+// Body verify opsional: approved_points kosong berarti setujui poin rubrik
+type VerifyInput struct {
+	ApprovedPoints *float64 `json:"approved_points"`
+	Remarks        string   `json:"remarks"`
+}
+
 func (s *AchievementService) Verify(c *fiber.Ctx) error {
 	claims := c.Locals("claims").(*utils.Claims)
 
-	// 1. Hanya dosen wali
-	if claims.Role != "dosen wali" {
-		return c.Status(403).JSON(fiber.Map{
-			"error": "only advisors can verify achievements",
-		})
-	}
-
 	achievementID := c.Params("id")
 	ctx := c.Context()
 
+	// 1. Body parser (opsional)
+	var input VerifyInput
+	if len(c.Body()) > 0 {
+		if err := c.BodyParser(&input); err != nil {
+			return c.Status(400).JSON(fiber.Map{"error": "invalid_request"})
+		}
+	}
+	if input.ApprovedPoints != nil && *input.ApprovedPoints < 0 {
+		return c.Status(422).JSON(fiber.Map{"error": "approved_points must not be negative"})
+	}
+
 	// 2. Ambil lecturer_id
 	lecturerID, err := s.Repo.GetLecturerID(ctx, claims.UserID)
 	if err != nil {
@@ -310,30 +510,42 @@ func (s *AchievementService) Verify(c *fiber.Ctx) error {
 		})
 	}
 
-	// 5. Ambil dokumen Mongo untuk melihat poin
-	collection := s.Mongo.Database("uas").Collection("achievements")
-
-	var mongoDoc map[string]interface{}
+	// 5. Ambil dokumen Mongo untuk melihat poin klaim (hasil rubrik)
 	objID, _ := primitive.ObjectIDFromHex(achievementID)
-
-	err = collection.FindOne(ctx, primitive.M{"_id": objID}).Decode(&mongoDoc)
+	mongoDoc, err := s.Docs.FindOne(ctx, bson.M{"_id": objID})
 	if err != nil {
 		return c.Status(400).JSON(fiber.Map{"error": "mongo document not found"})
 	}
 
-	points, _ := mongoDoc["points"].(float64)
+	claimedPoints, _ := mongoDoc["points"].(float64)
+	approvedPoints := claimedPoints
+	if input.ApprovedPoints != nil {
+		approvedPoints = *input.ApprovedPoints
+	}
 
-	// 6. Update postgres: verified + tambah poin
-	err = s.Repo.Verify(ctx, achievementID, studentID, lecturerID, points)
+	// 6. Update postgres: verified + tambah poin yang disetujui
+	err = s.Repo.Verify(
+		ctx,
+		achievementID,
+		studentID,
+		lecturerID,
+		claimedPoints,
+		approvedPoints,
+		input.Remarks,
+		actorFromClaims(claims),
+	)
 	if err != nil {
 		return c.Status(400).JSON(fiber.Map{"error": err.Error()})
 	}
 
 	return c.JSON(fiber.Map{
-		"achievement_id": achievementID,
-		"status":         "verified",
-		"added_points":   points,
-		"message":        "achievement verified successfully",
+		"achievement_id":  achievementID,
+		"status":          "verified",
+		"claimed_points":  claimedPoints,
+		"approved_points": approvedPoints,
+		"added_points":    approvedPoints,
+		"remarks":         input.Remarks,
+		"message":         "achievement verified successfully",
 	})
 }
 
@@ -344,17 +556,10 @@ type RejectInput struct {
 func (s *AchievementService) Reject(c *fiber.Ctx) error {
 	claims := c.Locals("claims").(*utils.Claims)
 
-	// 1. Harus dosen wali
-	if claims.Role != "dosen wali" {
-		return c.Status(403).JSON(fiber.Map{
-			"error": "only advisors can reject achievements",
-		})
-	}
-
 	achievementID := c.Params("id")
 	ctx := c.Context()
 
-	// 2. Body Parser
+	// 1. Body Parser
 	var input RejectInput
 	if err := c.BodyParser(&input); err != nil {
 		return c.Status(400).JSON(fiber.Map{"error": "invalid_request"})
@@ -364,20 +569,20 @@ func (s *AchievementService) Reject(c *fiber.Ctx) error {
 		return c.Status(400).JSON(fiber.Map{"error": "rejection note is required"})
 	}
 
-	// 3. Ambil lecturer_id
+	// 2. Ambil lecturer_id
 	lecturerID, err := s.Repo.GetLecturerID(ctx, claims.UserID)
 	fmt.Println("DEBUG: claims.UserID =", claims.UserID, " -> lecturerID =", lecturerID, " err =", err)
 	if err != nil {
 		return c.Status(400).JSON(fiber.Map{"error": "lecturer profile not found"})
 	}
 
-	// 4. Ambil student_id pemilik achievement
+	// 3. Ambil student_id pemilik achievement
 	studentID, err := s.Repo.GetStudentIDByAchievement(ctx, achievementID)
 	if err != nil {
 		return c.Status(400).JSON(fiber.Map{"error": "achievement not found"})
 	}
 
-	// 5. Cek apakah mahasiswa bimbingannya
+	// 4. Cek apakah mahasiswa bimbingannya
 	ok, err := s.Repo.IsStudentSupervised(ctx, lecturerID, studentID)
 	if err != nil || !ok {
 		return c.Status(403).JSON(fiber.Map{
@@ -385,8 +590,8 @@ func (s *AchievementService) Reject(c *fiber.Ctx) error {
 		})
 	}
 
-	// 6. Jalankan reject (Postgres)
-	err = s.Repo.Reject(ctx, achievementID, studentID, lecturerID, input.Note)
+	// 5. Jalankan reject (Postgres)
+	err = s.Repo.Reject(ctx, achievementID, studentID, lecturerID, input.Note, actorFromClaims(claims))
 	if err != nil {
 		return c.Status(400).JSON(fiber.Map{"error": err.Error()})
 	}
@@ -399,31 +604,152 @@ func (s *AchievementService) Reject(c *fiber.Ctx) error {
 	})
 }
 
-func (s *AchievementService) Delete(ctx context.Context, userID int64, role string, achievementID string) error {
+type RevokeInput struct {
+	Reason string `json:"reason"`
+}
+
+// REVOKE (verified → revoked, poin dikembalikan)
+func (s *AchievementService) Revoke(c *fiber.Ctx) error {
+	claims := c.Locals("claims").(*utils.Claims)
+	ctx := c.Context()
+	achievementID := c.Params("id")
+
+	// 1. Body parser, alasan wajib
+	var input RevokeInput
+	if err := c.BodyParser(&input); err != nil {
+		return c.Status(400).JSON(fiber.Map{"error": "invalid_request"})
+	}
+	if strings.TrimSpace(input.Reason) == "" {
+		return c.Status(400).JSON(fiber.Map{"error": "revocation reason is required"})
+	}
+
+	// 2. Tanpa achievement:read_all hanya untuk mahasiswa bimbingan sendiri
+	if !utils.HasPermission(c, model.PermAchievementReadAll) {
+		lecturerID, err := s.Repo.GetLecturerID(ctx, claims.UserID)
+		if err != nil {
+			return c.Status(403).JSON(fiber.Map{"error": "lecturer profile not found"})
+		}
+		studentID, err := s.Repo.GetStudentIDByAchievement(ctx, achievementID)
+		if err != nil {
+			return c.Status(400).JSON(fiber.Map{"error": "achievement not found"})
+		}
+		ok, err := s.Repo.IsStudentSupervised(ctx, lecturerID, studentID)
+		if err != nil || !ok {
+			return c.Status(403).JSON(fiber.Map{
+				"error": "student not supervised by this lecturer",
+			})
+		}
+	}
+
+	// 3. Jalankan revoke (Postgres, termasuk pengurangan poin)
+	points, err := s.Repo.Revoke(ctx, achievementID, input.Reason, actorFromClaims(claims))
+	if err != nil {
+		return c.Status(422).JSON(fiber.Map{"error": err.Error()})
+	}
 
-	if role != "mahasiswa" {
-		return errors.New("only mahasiswa can delete")
+	return c.JSON(fiber.Map{
+		"achievement_id":  achievementID,
+		"status":          "revoked",
+		"reason":          input.Reason,
+		"reverted_points": points,
+		"message":         "achievement revoked",
+	})
+}
+
+type ReviseInput struct {
+	Note string `json:"note"`
+}
+
+// REVISE (rejected → draft, round berikutnya)
+func (s *AchievementService) Revise(c *fiber.Ctx) error {
+	claims := c.Locals("claims").(*utils.Claims)
+	ctx := c.Context()
+	achievementID := c.Params("id")
+
+	// 1. Body opsional (catatan perbaikan)
+	var input ReviseInput
+	if len(c.Body()) > 0 {
+		if err := c.BodyParser(&input); err != nil {
+			return c.Status(400).JSON(fiber.Map{"error": "invalid_request"})
+		}
 	}
 
-	// 1. Soft delete MongoDB (tambahkan deletedAt)
-	collection := s.Mongo.Database("uas").Collection("achievements")
+	// 2. Ambil student_id
+	studentID, err := s.Repo.GetStudentID(ctx, claims.UserID)
+	if err != nil {
+		return c.Status(400).JSON(fiber.Map{"error": "student profile not found"})
+	}
+
+	// 3. Ambil reference (untuk rejection note terakhir)
+	ref, err := s.Repo.GetReferenceByMongoID(ctx, achievementID)
+	if err != nil {
+		return c.Status(404).JSON(fiber.Map{"error": "achievement_not_found"})
+	}
+	if ref["student_uuid"] != studentID {
+		return c.Status(403).JSON(fiber.Map{"error": "not_achievement_owner"})
+	}
+
+	// 4. Jalankan revise (Postgres)
+	round, err := s.Repo.Revise(ctx, achievementID, studentID, input.Note, actorFromClaims(claims))
+	if err != nil {
+		return c.Status(422).JSON(fiber.Map{"error": err.Error()})
+	}
+
+	return c.JSON(fiber.Map{
+		"achievement_id": achievementID,
+		"status":         "draft",
+		"revision_round": round,
+		"rejection_note": ref["rejection_note"],
+		"message":        "achievement returned to draft for revision",
+	})
+}
+
+var (
+	errNotAchievementOwner     = errors.New("not_achievement_owner")
+	errAchievementNotDeletable = errors.New("cannot delete: not found or not in draft status")
+)
+
+func (s *AchievementService) Delete(ctx context.Context, userID int64, role string, achievementID string) error {
 
 	objID, err := primitive.ObjectIDFromHex(achievementID)
 	if err != nil {
 		return errors.New("invalid_mongo_id")
 	}
 
-	filter := bson.M{"_id": objID}
-	update := bson.M{"$set": bson.M{"deletedAt": time.Now()}}
+	// Cek pemilik dan status draft sebelum Mongo disentuh: dokumen orang lain
+	// atau yang sudah dihapus tidak boleh ikut ditandai deletedAt
+	studentID, err := s.Repo.GetStudentID(ctx, userID)
+	if err != nil {
+		return errors.New("student profile not found")
+	}
+	ref, err := s.Repo.GetReferenceByMongoID(ctx, achievementID)
+	if err != nil {
+		return errAchievementNotDeletable
+	}
+	if ref["student_uuid"] != studentID {
+		return errNotAchievementOwner
+	}
+	if ref["status"] != "draft" {
+		return errAchievementNotDeletable
+	}
 
-	_, err = collection.UpdateOne(ctx, filter, update)
+	// 0. Catat operasi delete di outbox
+	deletedAt := syncTimestamp()
+	syncOp, err := s.beginSync(ctx, model.SyncOpDelete, achievementID, syncDeletePayload{DeletedAt: deletedAt})
 	if err != nil {
+		return errors.New("failed_soft_delete")
+	}
+
+	// 1. Soft delete MongoDB (tambahkan deletedAt, hanya jika belum dihapus)
+	if err := s.Docs.SoftDelete(ctx, objID, deletedAt); err != nil {
+		s.compensate(ctx, syncOp, err)
 		return errors.New("failed_soft_delete_mongo")
 	}
 
-	// 2. Soft delete reference PostgreSQL
-	err = s.Repo.SoftDelete(ctx, achievementID, userID)
+	// 2. Soft delete reference PostgreSQL (+ operasi done di tx yang sama)
+	err = s.Repo.SoftDelete(ctx, achievementID, userID, repository.AchievementActor{UserID: userID, Role: role}, syncOp.ID)
 	if err != nil {
+		s.compensate(ctx, syncOp, err)
 		return err
 	}
 
@@ -437,6 +763,9 @@ func (s *AchievementService) DeleteHandler(c *fiber.Ctx) error {
 	achievementID := c.Params("id")
 
 	err := s.Delete(c.Context(), userID, role, achievementID)
+	if errors.Is(err, errNotAchievementOwner) {
+		return c.Status(403).JSON(fiber.Map{"error": err.Error()})
+	}
 	if err != nil {
 		return c.Status(400).JSON(fiber.Map{"error": err.Error()})
 	}
@@ -493,25 +822,9 @@ func (s *AchievementService) AdminListAchievements(c *fiber.Ctx) error {
 	}
 
 	// --- Join dengan MongoDB ---
-	collection := s.Mongo.Database("uas").Collection("achievements")
-	var results []map[string]interface{}
-	for _, ref := range refs {
-		mongoID, ok := ref["mongo_id"].(string)
-		if !ok || mongoID == "" {
-			continue
-		}
-		objID, err := primitive.ObjectIDFromHex(mongoID)
-		if err != nil {
-			continue
-		}
-		var mongoDoc map[string]interface{}
-		if err := collection.FindOne(ctx, bson.M{"_id": objID}).Decode(&mongoDoc); err != nil {
-			continue
-		}
-		results = append(results, map[string]interface{}{
-			"reference": ref,
-			"mongo":     mongoDoc,
-		})
+	results, err := s.joinAchievementDocuments(ctx, refs)
+	if err != nil {
+		return c.Status(500).JSON(fiber.Map{"error": "failed_get_mongo_achievements"})
 	}
 
 	// --- Pagination meta ---
@@ -549,38 +862,15 @@ func (s *AchievementService) GetDetail(c *fiber.Ctx) error {
 		})
 	}
 	studentUUID, _ := ref["student_uuid"].(string)
-	// 3. RBAC CHECK
-	switch claims.Role {
-	case "admin":
-	case "mahasiswa":
-		myStudentID, err := s.Repo.GetStudentID(ctx, claims.UserID)
-		if err != nil || myStudentID != studentUUID {
-			return c.Status(403).JSON(fiber.Map{
-				"error": "forbidden",
-			})
-		}
-	case "dosen wali":
-		lecturerID, err := s.Repo.GetLecturerID(ctx, claims.UserID)
-		if err != nil {
-			return c.Status(403).JSON(fiber.Map{
-				"error": "lecturer_profile_not_found",
-			})
-		}
-		ok, err := s.Repo.IsStudentSupervised(ctx, lecturerID, studentUUID)
-		if err != nil || !ok {
-			return c.Status(403).JSON(fiber.Map{
-				"error": "student_not_supervised",
-			})
-		}
-	default:
+	// 3. Cakupan akses
+	if code := studentAccessError(c, s.Repo, claims.UserID, studentUUID); code != "" {
 		return c.Status(403).JSON(fiber.Map{
-			"error": "forbidden",
+			"error": code,
 		})
 	}
 	// 4. Ambil dokumen Mongo
-	collection := s.Mongo.Database("uas").Collection("achievements")
-	var mongoDoc map[string]interface{}
-	if err := collection.FindOne(ctx, bson.M{"_id": objID}).Decode(&mongoDoc); err != nil {
+	mongoDoc, err := s.Docs.FindOne(ctx, bson.M{"_id": objID})
+	if err != nil {
 		return c.Status(404).JSON(fiber.Map{
 			"error": "mongo_document_not_found",
 		})
@@ -592,20 +882,47 @@ func (s *AchievementService) GetDetail(c *fiber.Ctx) error {
 	})
 }
 
+// Cakupan data achievement milik studentUUID:
+//   - achievement:read_all → semua mahasiswa
+//   - user dengan profil mahasiswa → hanya miliknya sendiri
+//   - user dengan profil dosen → hanya mahasiswa bimbingannya
+//
+// Mengembalikan kode error (403) atau "" jika boleh. Dipakai juga untuk
+// laporan per mahasiswa (ReportService).
+func studentAccessError(
+	c *fiber.Ctx,
+	repo repository.AchievementReferenceRepository,
+	userID int64,
+	studentUUID string,
+) string {
+	if utils.HasPermission(c, model.PermAchievementReadAll) {
+		return ""
+	}
+	ctx := c.Context()
+	if myStudentID, err := repo.GetStudentID(ctx, userID); err == nil {
+		if myStudentID != studentUUID {
+			return "forbidden"
+		}
+		return ""
+	}
+	lecturerID, err := repo.GetLecturerID(ctx, userID)
+	if err != nil {
+		return "forbidden"
+	}
+	ok, err := repo.IsStudentSupervised(ctx, lecturerID, studentUUID)
+	if err != nil || !ok {
+		return "student_not_supervised"
+	}
+	return ""
+}
+
 // UPDATE DRAFT ACHIEVEMENT
 func (s *AchievementService) UpdateDraft(c *fiber.Ctx) error {
 	claims := c.Locals("claims").(*utils.Claims)
 	ctx := c.Context()
 	achievementID := c.Params("id")
 
-	// 1. Hanya mahasiswa
-	if claims.Role != "mahasiswa" {
-		return c.Status(403).JSON(fiber.Map{
-			"error": "only students can update achievements",
-		})
-	}
-
-	// 2. Validasi ObjectId
+	// 1. Validasi ObjectId
 	objID, err := primitive.ObjectIDFromHex(achievementID)
 	if err != nil {
 		return c.Status(400).JSON(fiber.Map{
@@ -613,7 +930,7 @@ func (s *AchievementService) UpdateDraft(c *fiber.Ctx) error {
 		})
 	}
 
-	// 3. Ambil student_id
+	// 2. Ambil student_id
 	studentID, err := s.Repo.GetStudentID(ctx, claims.UserID)
 	if err != nil {
 		return c.Status(400).JSON(fiber.Map{
@@ -621,7 +938,7 @@ func (s *AchievementService) UpdateDraft(c *fiber.Ctx) error {
 		})
 	}
 
-	// 4. Cek reference & status
+	// 3. Cek reference & status
 	ref, err := s.Repo.GetReferenceByMongoID(ctx, achievementID)
 	if err != nil {
 		return c.Status(404).JSON(fiber.Map{
@@ -639,46 +956,84 @@ func (s *AchievementService) UpdateDraft(c *fiber.Ctx) error {
 		})
 	}
 
-	// 5. Body parser (reuse DTO Create)
+	// 4. Body parser (reuse DTO Create)
 	var input AchievementInput
 	if err := c.BodyParser(&input); err != nil {
 		return c.Status(400).JSON(fiber.Map{
 			"error": "invalid_request",
 		})
 	}
+	achievementType, err := s.validateAchievementInput(ctx, &input)
+	if err != nil {
+		return achievementInputError(c, err)
+	}
 
-	// 6. Update Mongo document
-	collection := s.Mongo.Database("uas").Collection("achievements")
-	update := bson.M{
-		"$set": bson.M{
-			"achievementType": input.AchievementType,
-			"title":           input.Title,
-			"description":     input.Description,
-			"details":         input.Details,
-			"tags":            input.Tags,
-			"points":          input.Points,
-			"updatedAt":       time.Now(),
-		},
+	// 5. Hitung ulang poin dari rubrik
+	points, rubricVersion, err := s.rubricPoints(ctx, achievementType, input.Details)
+	if err != nil {
+		return c.Status(500).JSON(fiber.Map{
+			"error": err.Error(),
+		})
 	}
-	result, err := collection.UpdateOne(
-		ctx,
-		bson.M{"_id": objID},
-		update,
-	)
+
+	// 6. Snapshot field lama untuk kompensasi
+	current, err := s.Docs.FindOne(ctx, bson.M{"_id": objID})
+	if err != nil {
+		return c.Status(404).JSON(fiber.Map{
+			"error": "mongo_document_not_found",
+		})
+	}
+	fields := bson.M{
+		"achievementType": input.AchievementType,
+		"title":           input.Title,
+		"description":     input.Description,
+		"details":         input.Details,
+		"tags":            input.Tags,
+		"points":          points,
+		"rubricVersion":   rubricVersion,
+	}
+	before := map[string]interface{}{}
+	for k := range fields {
+		before[k] = current[k]
+	}
+	updatedAt := syncTimestamp()
+	fields["updatedAt"] = updatedAt
+
+	syncOp, err := s.beginSync(ctx, model.SyncOpUpdate, achievementID, syncUpdatePayload{
+		Before:    before,
+		UpdatedAt: updatedAt,
+	})
 	if err != nil {
+		return c.Status(500).JSON(fiber.Map{
+			"error": "failed_update",
+		})
+	}
+
+	// 7. Update Mongo document
+	matched, err := s.Docs.Update(ctx, bson.M{"_id": objID}, bson.M{"$set": fields})
+	if err != nil {
+		s.compensate(ctx, syncOp, err)
 		return c.Status(500).JSON(fiber.Map{
 			"error": "failed_update_mongo",
 		})
 	}
-	if result.MatchedCount == 0 {
+	if matched == 0 {
+		s.compensate(ctx, syncOp, errors.New("mongo document not found"))
 		return c.Status(404).JSON(fiber.Map{
 			"error": "mongo_document_not_found",
 		})
 	}
 
-	// 7. Ambil data terbaru untuk response
-	var mongoDoc map[string]interface{}
-	_ = collection.FindOne(ctx, bson.M{"_id": objID}).Decode(&mongoDoc)
+	// 8. Tandai operasi done di PostgreSQL
+	if err := s.SyncOps.Complete(ctx, syncOp.ID); err != nil {
+		s.compensate(ctx, syncOp, err)
+		return c.Status(500).JSON(fiber.Map{
+			"error": "failed_update",
+		})
+	}
+
+	// 9. Ambil data terbaru untuk response
+	mongoDoc, _ := s.Docs.FindOne(ctx, bson.M{"_id": objID})
 	return c.JSON(fiber.Map{
 		"message": "achievement updated",
 		"id":      achievementID,
@@ -709,25 +1064,9 @@ func (s *AchievementService) GetHistory(c *fiber.Ctx) error {
 	}
 	studentUUID, _ := ref["student_uuid"].(string)
 
-	// 3. RBAC
-	switch claims.Role {
-	case "admin":
-	case "mahasiswa":
-		myStudentID, err := s.Repo.GetStudentID(ctx, claims.UserID)
-		if err != nil || myStudentID != studentUUID {
-			return c.Status(403).JSON(fiber.Map{"error": "forbidden"})
-		}
-	case "dosen wali":
-		lecturerID, err := s.Repo.GetLecturerID(ctx, claims.UserID)
-		if err != nil {
-			return c.Status(403).JSON(fiber.Map{"error": "lecturer_profile_not_found"})
-		}
-		ok, err := s.Repo.IsStudentSupervised(ctx, lecturerID, studentUUID)
-		if err != nil || !ok {
-			return c.Status(403).JSON(fiber.Map{"error": "student_not_supervised"})
-		}
-	default:
-		return c.Status(403).JSON(fiber.Map{"error": "forbidden"})
+	// 3. Cakupan akses
+	if code := studentAccessError(c, s.Repo, claims.UserID, studentUUID); code != "" {
+		return c.Status(403).JSON(fiber.Map{"error": code})
 	}
 
 	// 4. Ambil history
@@ -740,23 +1079,36 @@ func (s *AchievementService) GetHistory(c *fiber.Ctx) error {
 	return c.JSON(fiber.Map{
 		"achievement_id": achievementID,
 		"history":        history,
+		"rounds":         groupHistoryByRound(history),
 	})
 }
 
+// Kelompokkan event log per revision round (urutan round naik)
+func groupHistoryByRound(history []model.AchievementStatusEvent) []fiber.Map {
+	rounds := []fiber.Map{}
+	index := map[int]int{}
+	for _, ev := range history {
+		i, ok := index[ev.Round]
+		if !ok {
+			i = len(rounds)
+			index[ev.Round] = i
+			rounds = append(rounds, fiber.Map{
+				"round":  ev.Round,
+				"events": []model.AchievementStatusEvent{},
+			})
+		}
+		rounds[i]["events"] = append(rounds[i]["events"].([]model.AchievementStatusEvent), ev)
+	}
+	return rounds
+}
+
 // UPLOAD ATTACHMENT
 func (s *AchievementService) UploadAttachment(c *fiber.Ctx) error {
 	claims := c.Locals("claims").(*utils.Claims)
 	ctx := c.Context()
 	achievementID := c.Params("id")
 
-	// 1. Hanya mahasiswa
-	if claims.Role != "mahasiswa" {
-		return c.Status(403).JSON(fiber.Map{
-			"error": "only students can upload attachments",
-		})
-	}
-
-	// 2. Validasi ObjectId
+	// 1. Validasi ObjectId
 	objID, err := primitive.ObjectIDFromHex(achievementID)
 	if err != nil {
 		return c.Status(400).JSON(fiber.Map{
@@ -764,7 +1116,7 @@ func (s *AchievementService) UploadAttachment(c *fiber.Ctx) error {
 		})
 	}
 
-	// 3. Ambil student_id
+	// 2. Ambil student_id
 	studentID, err := s.Repo.GetStudentID(ctx, claims.UserID)
 	if err != nil {
 		return c.Status(400).JSON(fiber.Map{
@@ -772,7 +1124,7 @@ func (s *AchievementService) UploadAttachment(c *fiber.Ctx) error {
 		})
 	}
 
-	// 4. Ambil reference & cek ownership
+	// 3. Ambil reference & cek ownership
 	ref, err := s.Repo.GetReferenceByMongoID(ctx, achievementID)
 	if err != nil {
 		return c.Status(404).JSON(fiber.Map{
@@ -785,14 +1137,14 @@ func (s *AchievementService) UploadAttachment(c *fiber.Ctx) error {
 		})
 	}
 
-	// 5. Status check
+	// 4. Status check
 	if ref["status"] != "draft" && ref["status"] != "submitted" {
 		return c.Status(422).JSON(fiber.Map{
 			"error": "attachments_not_allowed_for_this_status",
 		})
 	}
 
-	// 6. Ambil file
+	// 5. Ambil file
 	file, err := c.FormFile("file")
 	if err != nil {
 		return c.Status(400).JSON(fiber.Map{
@@ -800,7 +1152,7 @@ func (s *AchievementService) UploadAttachment(c *fiber.Ctx) error {
 		})
 	}
 
-	// 7. Validasi tipe file (basic)
+	// 6. Validasi tipe file (basic)
 	allowed := map[string]bool{
 		".pdf":  true,
 		".jpg":  true,
@@ -814,38 +1166,72 @@ func (s *AchievementService) UploadAttachment(c *fiber.Ctx) error {
 		})
 	}
 
-	// 8. Simpan file
+	// Jenis lampiran (contoh: certificate), dicocokkan dengan required_attachments katalog
+	kind := strings.TrimSpace(c.FormValue("kind"))
+	if kind == "" {
+		kind = "other"
+	}
+
+	// 7. Catat operasi attach di outbox (file + Mongo dibatalkan jika gagal)
+	attachmentID := primitive.NewObjectID().Hex()
 	basePath := fmt.Sprintf("./uploads/achievements/%s", achievementID)
+	savePath := attachmentPath(basePath, attachmentID, ext)
+	filename := filepath.Base(file.Filename) // hanya metadata, tidak dipakai di path
+	syncOp, err := s.beginSync(ctx, model.SyncOpAttach, achievementID, syncAttachPayload{
+		AttachmentID: attachmentID,
+		Path:         savePath,
+	})
+	if err != nil {
+		return c.Status(500).JSON(fiber.Map{
+			"error": "failed_save_file",
+		})
+	}
+
+	// 8. Simpan file
 	_ = os.MkdirAll(basePath, os.ModePerm)
-	savePath := fmt.Sprintf("%s/%s", basePath, file.Filename)
 	if err := c.SaveFile(file, savePath); err != nil {
+		s.compensate(ctx, syncOp, err)
 		return c.Status(500).JSON(fiber.Map{
 			"error": "failed_save_file",
 		})
 	}
 
 	// 9. Update MongoDB
-	collection := s.Mongo.Database("uas").Collection("achievements")
 	attachment := bson.M{
-		"filename":   file.Filename,
+		"id":         attachmentID,
+		"filename":   filename,
+		"kind":       kind,
 		"path":       savePath,
-		"uploadedAt": time.Now(),
+		"uploadedAt": syncTimestamp(),
 	}
-	_, err = collection.UpdateOne(
-		ctx,
-		bson.M{"_id": objID},
-		bson.M{"$push": bson.M{"attachments": attachment}},
-	)
-	if err != nil {
+	if err := s.Docs.PushAttachment(ctx, objID, attachment); err != nil {
+		s.compensate(ctx, syncOp, err)
 		return c.Status(500).JSON(fiber.Map{
 			"error": "failed_update_mongo",
 		})
 	}
+
+	// 10. Tandai operasi done di PostgreSQL
+	if err := s.SyncOps.Complete(ctx, syncOp.ID); err != nil {
+		s.compensate(ctx, syncOp, err)
+		return c.Status(500).JSON(fiber.Map{
+			"error": "failed_save_attachment",
+		})
+	}
 	return c.JSON(fiber.Map{
 		"message": "attachment uploaded",
 		"file": fiber.Map{
-			"name": file.Filename,
+			"id":   attachmentID,
+			"name": filename,
+			"kind": kind,
 			"path": savePath,
 		},
 	})
 }
+
+// attachmentPath: file disimpan dengan nama attachmentID, bukan nama dari
+// client, supaya dua lampiran bernama sama tidak saling menimpa (dan
+// kompensasi satu upload tidak menghapus file lampiran lain)
+func attachmentPath(basePath, attachmentID, ext string) string {
+	return fmt.Sprintf("%s/%s%s", basePath, attachmentID, ext)
+}
@@ -1,14 +1,28 @@
 package service
 
 import (
+	"bytes"
 	"context"
+	"database/sql"
+	"encoding/json"
 	"errors"
+	"net/http"
+	"net/http/httptest"
 	"testing"
+	"time"
 
+	"uas/app/model"
+	"uas/app/repository"
 	"uas/app/repository/mocks"
+	"uas/middleware"
+	"uas/utils"
 
+	"github.com/DATA-DOG/go-sqlmock"
+	"github.com/gofiber/fiber/v2"
 	"github.com/stretchr/testify/assert"
-	// "github.com/stretchr/testify/mock"
+	"github.com/stretchr/testify/mock"
+	"go.mongodb.org/mongo-driver/bson"
+	"go.mongodb.org/mongo-driver/bson/primitive"
 )
 
 // =======================
@@ -29,66 +43,66 @@ func TestSubmitAchievement_Success(t *testing.T) {
 	repo.On("GetStudentID", ctx, userID).
 		Return(studentID, nil)
 
-	repo.On("Submit", ctx, achievementID, studentID).
+	actor := repository.AchievementActor{UserID: userID, Role: role}
+	repo.On("Submit", ctx, achievementID, studentID, actor).
 		Return(nil)
 
-	// simulate internal logic (SubmitAchievement)
-	err := func() error {
-		if role != "mahasiswa" {
-			return errors.New("only students can submit achievements")
-		}
-
-		sid, err := repo.GetStudentID(ctx, userID)
-		if err != nil {
-			return errors.New("student profile not found")
-		}
-
-		return repo.Submit(ctx, achievementID, sid)
-	}()
+	service := NewAchievementService(repo, nil, nil, nil, nil)
+	err := service.SubmitAchievement(ctx, userID, role, achievementID)
 
 	assert.NoError(t, err)
 	repo.AssertExpectations(t)
 }
 
-func TestSubmitAchievement_Fail_NotMahasiswa(t *testing.T) {
+// Submit achievement milik mahasiswa lain: 403 sebelum dokumen Mongo dibaca
+func TestSubmitHandler_NotOwner(t *testing.T) {
+	repo := new(mocks.MockAchievementRepository)
+	repo.On("GetStudentID", mock.Anything, int64(10)).Return("student-uuid-1", nil)
+	repo.On("GetReferenceByMongoID", mock.Anything, "693a838b456e26eb7bec0101").
+		Return(map[string]interface{}{"student_uuid": "student-uuid-2", "status": "draft"}, nil)
+	// store kosong: sebelum cek ownership, submit berhenti di "achievement not found"
+	service := NewAchievementService(repo, repository.NewMemoryAchievementStore(), nil, nil, nil)
+
+	app := fiber.New()
+	app.Use(func(c *fiber.Ctx) error {
+		c.Locals("claims", &utils.Claims{UserID: 10, Role: "mahasiswa"})
+		return c.Next()
+	})
+	app.Post("/achievements/:id/submit", service.Submit)
+
+	resp, err := app.Test(httptest.NewRequest(http.MethodPost, "/achievements/693a838b456e26eb7bec0101/submit", nil))
+	assert.NoError(t, err)
+	assert.Equal(t, 403, resp.StatusCode)
+	repo.AssertNotCalled(t, "Submit", mock.Anything, mock.Anything, mock.Anything, mock.Anything)
+}
+
+// Tanpa profil mahasiswa tidak bisa submit, apa pun role-nya
+func TestSubmitAchievement_Fail_NoStudentProfile(t *testing.T) {
 	ctx := context.Background()
-	_ = ctx
 
-	role := "admin"
+	repo := new(mocks.MockAchievementRepository)
+	repo.On("GetStudentID", ctx, int64(1)).Return("", sql.ErrNoRows)
 
-	err := func() error {
-		if role != "mahasiswa" {
-			return errors.New("only students can submit achievements")
-		}
-		return nil
-	}()
+	service := NewAchievementService(repo, nil, nil, nil, nil)
+	err := service.SubmitAchievement(ctx, 1, "admin", "mongo123")
 
 	assert.Error(t, err)
-	assert.Equal(t, "only students can submit achievements", err.Error())
+	assert.Equal(t, "student profile not found", err.Error())
+	repo.AssertNotCalled(t, "Submit", mock.Anything, mock.Anything, mock.Anything, mock.Anything)
 }
 
 func TestSubmitAchievement_Fail_StudentNotFound(t *testing.T) {
 	ctx := context.Background()
 
 	userID := int64(99)
-	role := "mahasiswa"
 
 	repo := new(mocks.MockAchievementRepository)
 
 	repo.On("GetStudentID", ctx, userID).
-		Return("", errors.New("student profile not found"))
+		Return("", sql.ErrNoRows)
 
-	err := func() error {
-		if role != "mahasiswa" {
-			return errors.New("only students can submit achievements")
-		}
-
-		_, err := repo.GetStudentID(ctx, userID)
-		if err != nil {
-			return errors.New("student profile not found")
-		}
-		return nil
-	}()
+	service := NewAchievementService(repo, nil, nil, nil, nil)
+	err := service.SubmitAchievement(ctx, userID, "mahasiswa", "mongo123")
 
 	assert.Error(t, err)
 	assert.Equal(t, "student profile not found", err.Error())
@@ -96,70 +110,91 @@ func TestSubmitAchievement_Fail_StudentNotFound(t *testing.T) {
 }
 
 // =======================
-// CREATE ACHIEVEMENT (LOGIC ONLY)
+// CREATE ACHIEVEMENT
 // =======================
 
-func TestCreateAchievement_Fail_NotMahasiswa(t *testing.T) {
+func TestCreateAchievement_Fail_NoStudentProfile(t *testing.T) {
 	ctx := context.Background()
-	_ = ctx
 
-	role := "admin"
+	repo := new(mocks.MockAchievementRepository)
+	repo.On("GetStudentID", ctx, int64(1)).Return("", sql.ErrNoRows)
 
-	err := func() error {
-		if role != "mahasiswa" {
-			return errors.New("only students can create achievements")
-		}
-		return nil
-	}()
+	docs := &fakeDocs{}
+	service := NewAchievementService(repo, docs, nil, nil, emptyTypeCatalog())
+	_, err := service.CreateAchievement(ctx, 1, "admin", validCompetitionInput())
 
 	assert.Error(t, err)
-	assert.Equal(t, "only students can create achievements", err.Error())
+	assert.Equal(t, "student profile not found", err.Error())
+	assert.Empty(t, docs.inserted)
 }
 
 func TestCreateAchievement_Fail_StudentNotFound(t *testing.T) {
 	ctx := context.Background()
 
 	userID := int64(10)
-	role := "mahasiswa"
 
 	repo := new(mocks.MockAchievementRepository)
 
 	repo.On("GetStudentID", ctx, userID).
-		Return("", errors.New("student profile not found"))
+		Return("", sql.ErrNoRows)
 
-	err := func() error {
-		if role != "mahasiswa" {
-			return errors.New("only students can create achievements")
-		}
-
-		_, err := repo.GetStudentID(ctx, userID)
-		if err != nil {
-			return errors.New("student profile not found")
-		}
-		return nil
-	}()
+	docs := &fakeDocs{}
+	service := NewAchievementService(repo, docs, nil, nil, emptyTypeCatalog())
+	_, err := service.CreateAchievement(ctx, userID, "mahasiswa", validCompetitionInput())
 
 	assert.Error(t, err)
 	assert.Equal(t, "student profile not found", err.Error())
+	assert.Empty(t, docs.inserted)
 	repo.AssertExpectations(t)
 }
 
 // =======================
-// VERIFY / REJECT (RBAC LOGIC ONLY)
+// VERIFY / REJECT (RBAC LEWAT PERMISSION)
 // =======================
 
-func TestVerifyAchievement_Fail_NotAdvisor(t *testing.T) {
-	role := "mahasiswa"
+// Matriks permission statis untuk menguji guard route
+type staticPermissions map[string][]string
 
-	err := func() error {
-		if role != "dosen wali" {
-			return errors.New("only advisors can verify achievements")
-		}
-		return nil
-	}()
+func (p staticPermissions) GetPermissionsByRole(ctx context.Context, role string) ([]string, error) {
+	return p[role], nil
+}
 
-	assert.Error(t, err)
-	assert.Equal(t, "only advisors can verify achievements", err.Error())
+var testPermissions = staticPermissions{
+	"mahasiswa":  {model.PermAchievementRead, model.PermAchievementSubmit},
+	"dosen wali": {model.PermAchievementRead, model.PermAchievementVerify, model.PermAchievementRevoke},
+}
+
+// Permission yang biasanya diisi RequirePermission, untuk memanggil handler langsung
+func withPermissions(perms ...string) fiber.Handler {
+	set := utils.PermissionSet{}
+	for _, p := range perms {
+		set[p] = true
+	}
+	return func(c *fiber.Ctx) error {
+		c.Locals(utils.PermissionsLocal, set)
+		return c.Next()
+	}
+}
+
+func TestVerifyAchievement_Fail_WithoutVerifyPermission(t *testing.T) {
+	service := NewAchievementService(nil, nil, nil, nil, nil)
+	perms := middleware.NewPermissionCache(testPermissions, time.Minute)
+
+	app := fiber.New()
+	app.Use(func(c *fiber.Ctx) error {
+		c.Locals("claims", &utils.Claims{UserID: 10, Role: "mahasiswa"})
+		return c.Next()
+	})
+	app.Post("/achievements/:id/verify", perms.RequirePermission(model.PermAchievementVerify), service.Verify)
+
+	resp, err := app.Test(httptest.NewRequest(http.MethodPost, "/achievements/abc/verify", nil))
+	assert.NoError(t, err)
+	assert.Equal(t, 403, resp.StatusCode)
+
+	var out map[string]interface{}
+	assert.NoError(t, json.NewDecoder(resp.Body).Decode(&out))
+	assert.Equal(t, "permission_denied", out["error"])
+	assert.Equal(t, model.PermAchievementVerify, out["permission"])
 }
 
 func TestRejectAchievement_Fail_EmptyNote(t *testing.T) {
@@ -193,3 +228,503 @@ func TestDeleteAchievement_Fail_NotMahasiswa(t *testing.T) {
 	assert.Error(t, err)
 	assert.Equal(t, "only mahasiswa can delete", err.Error())
 }
+
+// =======================
+// STATUS EVENT LOG (SQLMOCK)
+// =======================
+
+// referenceRow: satu baris hasil AchievementRepository.GetReferenceByMongoID
+func referenceRow(mongoID, studentID, status, rejectionNote string, round int) *sqlmock.Rows {
+	now := time.Now()
+	return sqlmock.NewRows([]string{
+		"id", "student_uuid", "mongo_achievement_id", "status", "submitted_at",
+		"verified_at", "verified_by", "rejection_note", "revision_round",
+		"claimed_points", "approved_points", "verification_remarks",
+		"revoked_at", "revocation_reason", "created_at", "updated_at",
+	}).AddRow(
+		"ref-1", studentID, mongoID, status, now,
+		nil, nil, rejectionNote, round,
+		nil, nil, nil,
+		nil, nil, now, now,
+	)
+}
+
+func TestSubmitAchievement_WritesStatusEventInSameTx(t *testing.T) {
+	db, sqlMock, err := sqlmock.New()
+	assert.NoError(t, err)
+	defer db.Close()
+
+	service := NewAchievementService(repository.NewAchievementRepository(db), nil, nil, nil, nil)
+
+	sqlMock.ExpectQuery("SELECT id\\s+FROM students").
+		WithArgs(int64(10)).
+		WillReturnRows(sqlmock.NewRows([]string{"id"}).AddRow("student-uuid-1"))
+	sqlMock.ExpectBegin()
+	sqlMock.ExpectQuery("UPDATE achievement_references").
+		WithArgs("mongo123", "student-uuid-1").
+		WillReturnRows(sqlmock.NewRows([]string{"id"}).AddRow("ref-1"))
+	sqlMock.ExpectExec("INSERT INTO achievement_status_events").
+		WithArgs("ref-1", "mongo123", "draft", "submitted", int64(10), "mahasiswa", "").
+		WillReturnResult(sqlmock.NewResult(1, 1))
+	sqlMock.ExpectCommit()
+
+	err = service.SubmitAchievement(context.Background(), 10, "mahasiswa", "mongo123")
+
+	assert.NoError(t, err)
+	assert.NoError(t, sqlMock.ExpectationsWereMet())
+}
+
+func TestSubmitAchievement_NotDraft_RollsBack(t *testing.T) {
+	db, sqlMock, err := sqlmock.New()
+	assert.NoError(t, err)
+	defer db.Close()
+
+	service := NewAchievementService(repository.NewAchievementRepository(db), nil, nil, nil, nil)
+
+	sqlMock.ExpectQuery("SELECT id\\s+FROM students").
+		WillReturnRows(sqlmock.NewRows([]string{"id"}).AddRow("student-uuid-1"))
+	sqlMock.ExpectBegin()
+	sqlMock.ExpectQuery("UPDATE achievement_references").
+		WillReturnRows(sqlmock.NewRows([]string{"id"}))
+	sqlMock.ExpectRollback()
+
+	err = service.SubmitAchievement(context.Background(), 10, "mahasiswa", "mongo123")
+
+	assert.Error(t, err)
+	assert.Equal(t, "cannot submit: not found or not in draft status", err.Error())
+	assert.NoError(t, sqlMock.ExpectationsWereMet())
+}
+
+func TestGetHistory_ReturnsEventLog(t *testing.T) {
+	db, sqlMock, err := sqlmock.New()
+	assert.NoError(t, err)
+	defer db.Close()
+
+	service := NewAchievementService(repository.NewAchievementRepository(db), nil, nil, nil, nil)
+
+	app := fiber.New()
+	app.Use(func(c *fiber.Ctx) error {
+		c.Locals("claims", &utils.Claims{UserID: 1, Role: "admin"})
+		return c.Next()
+	})
+	app.Get("/achievements/:id/history", withPermissions(model.PermAchievementReadAll), service.GetHistory)
+
+	mongoID := "693a838b456e26eb7bec08a4"
+	now := time.Now()
+
+	sqlMock.ExpectQuery("FROM achievement_references").
+		WithArgs(mongoID).
+		WillReturnRows(referenceRow(mongoID, "student-uuid-1", "rejected", "blurry", 1))
+	sqlMock.ExpectQuery("FROM achievement_status_events").
+		WithArgs(mongoID).
+		WillReturnRows(sqlmock.NewRows([]string{
+			"id", "achievement_ref_id", "mongo_achievement_id", "round", "from_status", "to_status",
+			"actor_user_id", "actor_role", "note", "created_at",
+		}).
+			AddRow(1, "ref-1", mongoID, 1, nil, "draft", 10, "mahasiswa", nil, now).
+			AddRow(2, "ref-1", mongoID, 1, "draft", "submitted", 10, "mahasiswa", nil, now).
+			AddRow(3, "ref-1", mongoID, 1, "submitted", "rejected", 20, "dosen wali", "blurry", now))
+
+	req := httptest.NewRequest(http.MethodGet, "/achievements/"+mongoID+"/history", nil)
+	resp, err := app.Test(req)
+	assert.NoError(t, err)
+	assert.Equal(t, 200, resp.StatusCode)
+
+	var body struct {
+		History []model.AchievementStatusEvent `json:"history"`
+	}
+	assert.NoError(t, json.NewDecoder(resp.Body).Decode(&body))
+	assert.Len(t, body.History, 3)
+	assert.Nil(t, body.History[0].FromStatus)
+	assert.Equal(t, "rejected", body.History[2].ToStatus)
+	assert.Equal(t, "blurry", *body.History[2].Note)
+	assert.Equal(t, int64(20), *body.History[2].ActorUserID)
+	assert.NoError(t, sqlMock.ExpectationsWereMet())
+}
+
+// =======================
+// REVISE (REJECTED → DRAFT)
+// =======================
+
+func TestRevise_Success_KeepsRejectionNoteAndBumpsRound(t *testing.T) {
+	db, sqlMock, err := sqlmock.New()
+	assert.NoError(t, err)
+	defer db.Close()
+
+	service := NewAchievementService(repository.NewAchievementRepository(db), nil, nil, nil, nil)
+
+	app := fiber.New()
+	app.Use(func(c *fiber.Ctx) error {
+		c.Locals("claims", &utils.Claims{UserID: 10, Role: "mahasiswa"})
+		return c.Next()
+	})
+	app.Post("/achievements/:id/revise", service.Revise)
+
+	mongoID := "693a838b456e26eb7bec08a4"
+
+	sqlMock.ExpectQuery("SELECT id\\s+FROM students").
+		WithArgs(int64(10)).
+		WillReturnRows(sqlmock.NewRows([]string{"id"}).AddRow("student-uuid-1"))
+	sqlMock.ExpectQuery("FROM achievement_references").
+		WithArgs(mongoID).
+		WillReturnRows(referenceRow(mongoID, "student-uuid-1", "rejected", "blurry scan", 1))
+	sqlMock.ExpectBegin()
+	sqlMock.ExpectQuery("UPDATE achievement_references").
+		WithArgs(mongoID, "student-uuid-1").
+		WillReturnRows(sqlmock.NewRows([]string{"id", "revision_round"}).AddRow("ref-1", 2))
+	sqlMock.ExpectExec("INSERT INTO achievement_status_events").
+		WithArgs("ref-1", mongoID, "rejected", "draft", int64(10), "mahasiswa", "").
+		WillReturnResult(sqlmock.NewResult(1, 1))
+	sqlMock.ExpectCommit()
+
+	req := httptest.NewRequest(http.MethodPost, "/achievements/"+mongoID+"/revise", nil)
+	resp, err := app.Test(req)
+	assert.NoError(t, err)
+	assert.Equal(t, 200, resp.StatusCode)
+
+	var body map[string]interface{}
+	assert.NoError(t, json.NewDecoder(resp.Body).Decode(&body))
+	assert.Equal(t, "draft", body["status"])
+	assert.Equal(t, float64(2), body["revision_round"])
+	assert.Equal(t, "blurry scan", body["rejection_note"])
+	assert.NoError(t, sqlMock.ExpectationsWereMet())
+}
+
+func TestGroupHistoryByRound(t *testing.T) {
+	draft := "draft"
+	submitted := "submitted"
+	rejected := "rejected"
+	history := []model.AchievementStatusEvent{
+		{ID: 1, Round: 1, ToStatus: "draft"},
+		{ID: 2, Round: 1, FromStatus: &draft, ToStatus: "submitted"},
+		{ID: 3, Round: 1, FromStatus: &submitted, ToStatus: "rejected"},
+		{ID: 4, Round: 2, FromStatus: &rejected, ToStatus: "draft"},
+		{ID: 5, Round: 2, FromStatus: &draft, ToStatus: "submitted"},
+	}
+
+	rounds := groupHistoryByRound(history)
+
+	assert.Len(t, rounds, 2)
+	assert.Equal(t, 1, rounds[0]["round"])
+	assert.Len(t, rounds[0]["events"], 3)
+	assert.Equal(t, 2, rounds[1]["round"])
+	assert.Len(t, rounds[1]["events"], 2)
+}
+
+// =======================
+// REVOKE (VERIFIED → REVOKED)
+// =======================
+
+func setupRevokeApp(service *AchievementService, role string, perms ...string) *fiber.App {
+	app := fiber.New()
+	app.Use(func(c *fiber.Ctx) error {
+		c.Locals("claims", &utils.Claims{UserID: 1, Role: role})
+		return c.Next()
+	})
+	app.Post("/achievements/:id/revoke", withPermissions(perms...), service.Revoke)
+	return app
+}
+
+func TestRevoke_Admin_RevertsGrantedPoints(t *testing.T) {
+	db, sqlMock, err := sqlmock.New()
+	assert.NoError(t, err)
+	defer db.Close()
+
+	service := NewAchievementService(repository.NewAchievementRepository(db), nil, nil, nil, nil)
+	app := setupRevokeApp(service, "admin", model.PermAchievementRevoke, model.PermAchievementReadAll)
+
+	mongoID := "693a838b456e26eb7bec08a4"
+
+	sqlMock.ExpectBegin()
+	sqlMock.ExpectQuery("SET status = 'revoked'").
+		WithArgs(int64(1), "forged certificate", mongoID).
+		WillReturnRows(sqlmock.NewRows([]string{"id", "student_uuid", "granted_points"}).
+			AddRow("ref-1", "student-uuid-1", 25.0))
+	sqlMock.ExpectExec("INSERT INTO point_transactions").
+		WithArgs("ref-1", mongoID, "student-uuid-1", -25.0, "revoke", int64(1), sqlmock.AnyArg()).
+		WillReturnResult(sqlmock.NewResult(1, 1))
+	sqlMock.ExpectExec("UPDATE students").
+		WithArgs("student-uuid-1").
+		WillReturnResult(sqlmock.NewResult(0, 1))
+	sqlMock.ExpectExec("INSERT INTO achievement_status_events").
+		WithArgs("ref-1", mongoID, "verified", "revoked", int64(1), "admin", "forged certificate").
+		WillReturnResult(sqlmock.NewResult(1, 1))
+	sqlMock.ExpectCommit()
+
+	body, _ := json.Marshal(RevokeInput{Reason: "forged certificate"})
+	req := httptest.NewRequest(http.MethodPost, "/achievements/"+mongoID+"/revoke", bytes.NewBuffer(body))
+	req.Header.Set("Content-Type", "application/json")
+
+	resp, err := app.Test(req)
+	assert.NoError(t, err)
+	assert.Equal(t, 200, resp.StatusCode)
+
+	var out map[string]interface{}
+	assert.NoError(t, json.NewDecoder(resp.Body).Decode(&out))
+	assert.Equal(t, "revoked", out["status"])
+	assert.Equal(t, 25.0, out["reverted_points"])
+	assert.NoError(t, sqlMock.ExpectationsWereMet())
+}
+
+func TestRevoke_Fail_MissingReason(t *testing.T) {
+	service := NewAchievementService(nil, nil, nil, nil, nil)
+	app := setupRevokeApp(service, "admin")
+
+	body, _ := json.Marshal(RevokeInput{Reason: "  "})
+	req := httptest.NewRequest(http.MethodPost, "/achievements/abc/revoke", bytes.NewBuffer(body))
+	req.Header.Set("Content-Type", "application/json")
+
+	resp, err := app.Test(req)
+	assert.NoError(t, err)
+	assert.Equal(t, 400, resp.StatusCode)
+}
+
+func TestRevoke_Fail_Student(t *testing.T) {
+	service := NewAchievementService(nil, nil, nil, nil, nil)
+	perms := middleware.NewPermissionCache(testPermissions, time.Minute)
+
+	app := fiber.New()
+	app.Use(func(c *fiber.Ctx) error {
+		c.Locals("claims", &utils.Claims{UserID: 1, Role: "mahasiswa"})
+		return c.Next()
+	})
+	app.Post("/achievements/:id/revoke", perms.RequirePermission(model.PermAchievementRevoke), service.Revoke)
+
+	body, _ := json.Marshal(RevokeInput{Reason: "mistake"})
+	req := httptest.NewRequest(http.MethodPost, "/achievements/abc/revoke", bytes.NewBuffer(body))
+	req.Header.Set("Content-Type", "application/json")
+
+	resp, err := app.Test(req)
+	assert.NoError(t, err)
+	assert.Equal(t, 403, resp.StatusCode)
+}
+
+// =======================
+// VERIFY: POINT LEDGER
+// =======================
+
+func TestVerify_DuplicateGrant_RollsBack(t *testing.T) {
+	db, sqlMock, err := sqlmock.New()
+	assert.NoError(t, err)
+	defer db.Close()
+
+	repo := repository.NewAchievementRepository(db)
+
+	sqlMock.ExpectBegin()
+	sqlMock.ExpectQuery("SET status = 'verified'").
+		WillReturnRows(sqlmock.NewRows([]string{"id"}).AddRow("ref-1"))
+	sqlMock.ExpectExec("INSERT INTO point_transactions").
+		WithArgs("ref-1", "mongo123", "student-uuid-1", 25.0, "grant", int64(20), sqlmock.AnyArg()).
+		WillReturnResult(sqlmock.NewResult(0, 0))
+	sqlMock.ExpectRollback()
+
+	err = repo.Verify(context.Background(), "mongo123", "student-uuid-1", 7, 30, 25, "scan ok",
+		repository.AchievementActor{UserID: 20, Role: "dosen wali"})
+
+	assert.Error(t, err)
+	assert.Equal(t, "cannot verify: points already granted for this achievement", err.Error())
+	assert.NoError(t, sqlMock.ExpectationsWereMet())
+}
+
+func TestVerify_StoresClaimedAndApprovedPoints(t *testing.T) {
+	db, sqlMock, err := sqlmock.New()
+	assert.NoError(t, err)
+	defer db.Close()
+
+	repo := repository.NewAchievementRepository(db)
+
+	sqlMock.ExpectBegin()
+	sqlMock.ExpectQuery("SET status = 'verified'").
+		WithArgs(int64(7), "mongo123", "student-uuid-1", 30.0, 20.0, "juara harapan, bukan juara 2").
+		WillReturnRows(sqlmock.NewRows([]string{"id"}).AddRow("ref-1"))
+	sqlMock.ExpectExec("INSERT INTO point_transactions").
+		WithArgs("ref-1", "mongo123", "student-uuid-1", 20.0, "grant", int64(20), sqlmock.AnyArg()).
+		WillReturnResult(sqlmock.NewResult(1, 1))
+	sqlMock.ExpectExec("UPDATE students").
+		WithArgs("student-uuid-1").
+		WillReturnResult(sqlmock.NewResult(0, 1))
+	sqlMock.ExpectExec("INSERT INTO achievement_status_events").
+		WithArgs("ref-1", "mongo123", "submitted", "verified", int64(20), "dosen wali", "juara harapan, bukan juara 2").
+		WillReturnResult(sqlmock.NewResult(1, 1))
+	sqlMock.ExpectCommit()
+
+	err = repo.Verify(context.Background(), "mongo123", "student-uuid-1", 7, 30, 20, "juara harapan, bukan juara 2",
+		repository.AchievementActor{UserID: 20, Role: "dosen wali"})
+
+	assert.NoError(t, err)
+	assert.NoError(t, sqlMock.ExpectationsWereMet())
+}
+
+func TestVerify_Fail_NegativeApprovedPoints(t *testing.T) {
+	service := NewAchievementService(nil, nil, nil, nil, nil)
+	app := fiber.New()
+	app.Use(func(c *fiber.Ctx) error {
+		c.Locals("claims", &utils.Claims{UserID: 20, Role: "dosen wali"})
+		return c.Next()
+	})
+	app.Post("/achievements/:id/verify", service.Verify)
+
+	body := []byte(`{"approved_points": -5}`)
+	req := httptest.NewRequest(http.MethodPost, "/achievements/abc/verify", bytes.NewBuffer(body))
+	req.Header.Set("Content-Type", "application/json")
+
+	resp, err := app.Test(req)
+	assert.NoError(t, err)
+	assert.Equal(t, 422, resp.StatusCode)
+}
+
+// =======================
+// DETAILS SCHEMA VALIDATION
+// =======================
+
+func setupCreateApp(service *AchievementService) *fiber.App {
+	app := fiber.New()
+	app.Use(func(c *fiber.Ctx) error {
+		c.Locals("claims", &utils.Claims{UserID: 10, Role: "mahasiswa"})
+		return c.Next()
+	})
+	app.Post("/achievements", service.CreateHandler)
+	return app
+}
+
+// Katalog kosong: hanya kategori bawaan yang dikenal
+func emptyTypeCatalog() *mocks.MockAchievementTypeRepository {
+	types := new(mocks.MockAchievementTypeRepository)
+	types.On("GetByKey", mock.Anything, mock.Anything).Return(nil, sql.ErrNoRows)
+	return types
+}
+
+func TestCreateHandler_InvalidDetails_PerFieldErrors(t *testing.T) {
+	service := NewAchievementService(nil, nil, nil, nil, emptyTypeCatalog())
+	app := setupCreateApp(service)
+
+	body := []byte(`{
+		"achievement_type": "competition",
+		"title": "Gemastik",
+		"details": {"year": "2024", "competitionLevel": "galactic", "medalType": "gold"}
+	}`)
+	req := httptest.NewRequest(http.MethodPost, "/achievements", bytes.NewBuffer(body))
+	req.Header.Set("Content-Type", "application/json")
+
+	resp, err := app.Test(req)
+	assert.NoError(t, err)
+	assert.Equal(t, 422, resp.StatusCode)
+
+	var out struct {
+		Error  string            `json:"error"`
+		Fields map[string]string `json:"fields"`
+	}
+	assert.NoError(t, json.NewDecoder(resp.Body).Decode(&out))
+	assert.Equal(t, "validation_failed", out.Error)
+	assert.Equal(t, "must be an integer", out.Fields["details.year"])
+	assert.Contains(t, out.Fields["details.competitionLevel"], "must be one of")
+	assert.Equal(t, "is required", out.Fields["details.competitionName"])
+	assert.Equal(t, "is required", out.Fields["details.rank"])
+	assert.NotContains(t, out.Fields, "details.medalType")
+}
+
+func TestCreateHandler_UnknownType(t *testing.T) {
+	service := NewAchievementService(nil, nil, nil, nil, emptyTypeCatalog())
+	app := setupCreateApp(service)
+
+	body := []byte(`{"achievement_type": "hackathon", "title": "x", "details": {"year": 2024}}`)
+	req := httptest.NewRequest(http.MethodPost, "/achievements", bytes.NewBuffer(body))
+	req.Header.Set("Content-Type", "application/json")
+
+	resp, err := app.Test(req)
+	assert.NoError(t, err)
+	assert.Equal(t, 422, resp.StatusCode)
+}
+
+func TestValidateAchievementInput_NormalizesDetails(t *testing.T) {
+	service := NewAchievementService(nil, nil, nil, nil, emptyTypeCatalog())
+	ctx := context.Background()
+	input := AchievementInput{
+		AchievementType: model.AchievementTypeInternship,
+		Title:           "Magang Backend",
+		Details: map[string]interface{}{
+			"year":        2025.0,
+			"companyName": "PT Contoh",
+			"position":    "Backend Intern",
+			"startDate":   "2025-02-01",
+			"endDate":     "2025-01-01",
+		},
+	}
+	_, err := service.validateAchievementInput(ctx, &input)
+	var verrs model.ValidationErrors
+	assert.True(t, errors.As(err, &verrs))
+	assert.Equal(t, "must not be before the start date", verrs["details.endDate"])
+
+	input.Details["endDate"] = "2025-06-30"
+	achievementType, err := service.validateAchievementInput(ctx, &input)
+	assert.NoError(t, err)
+	assert.True(t, achievementType.IsBuiltin)
+	assert.Equal(t, 2025.0, input.Details["year"])
+	assert.Equal(t, "PT Contoh", input.Details["companyName"])
+
+	input.Details["stipend"] = 100
+	verrs = nil
+	_, err = service.validateAchievementInput(ctx, &input)
+	assert.True(t, errors.As(err, &verrs))
+	assert.Equal(t, "unknown field", verrs["details.stipend"])
+}
+
+// =======================
+// LISTING (BATCH MONGO LOOKUP)
+// =======================
+
+func TestGetMyAchievements_BatchLookupKeepsOrderAndReportsMissing(t *testing.T) {
+	db, sqlMock, err := sqlmock.New()
+	assert.NoError(t, err)
+	defer db.Close()
+
+	first, _ := primitive.ObjectIDFromHex("693a838b456e26eb7bec0101")
+	missing := "693a838b456e26eb7bec0102"
+	third, _ := primitive.ObjectIDFromHex("693a838b456e26eb7bec0103")
+
+	sqlMock.ExpectQuery("SELECT id").
+		WithArgs(int64(1)).
+		WillReturnRows(sqlmock.NewRows([]string{"id"}).AddRow("student-1"))
+	sqlMock.ExpectQuery("SELECT mongo_achievement_id, status").
+		WithArgs("student-1", false).
+		WillReturnRows(sqlmock.NewRows([]string{
+			"mongo_achievement_id", "status", "submitted_at", "verified_at", "rejection_note", "revision_round",
+		}).
+			AddRow(first.Hex(), "draft", nil, nil, nil, 0).
+			AddRow(missing, "submitted", nil, nil, nil, 0).
+			AddRow(third.Hex(), "verified", nil, nil, nil, 0))
+
+	// urutan Mongo sengaja berbeda dari PostgreSQL
+	docs := &fakeDocs{docs: []bson.M{
+		{"_id": third, "title": "Third"},
+		{"_id": first, "title": "First"},
+	}}
+	service := NewAchievementService(repository.NewAchievementRepository(db), docs, nil, nil, nil)
+
+	app := fiber.New()
+	app.Use(func(c *fiber.Ctx) error {
+		c.Locals("claims", &utils.Claims{UserID: 1, Role: "mahasiswa"})
+		return c.Next()
+	})
+	app.Get("/achievements/me", service.GetMyAchievements)
+
+	resp, err := app.Test(httptest.NewRequest(http.MethodGet, "/achievements/me", nil))
+	assert.NoError(t, err)
+	assert.Equal(t, 200, resp.StatusCode)
+
+	var out []struct {
+		Reference       map[string]interface{} `json:"reference"`
+		Mongo           map[string]interface{} `json:"mongo"`
+		DocumentMissing bool                   `json:"document_missing"`
+	}
+	assert.NoError(t, json.NewDecoder(resp.Body).Decode(&out))
+	assert.Len(t, out, 3)
+	assert.Equal(t, "First", out[0].Mongo["title"])
+	assert.Nil(t, out[1].Mongo)
+	assert.True(t, out[1].DocumentMissing)
+	assert.Equal(t, missing, out[1].Reference["mongo_id"])
+	assert.Equal(t, "Third", out[2].Mongo["title"])
+
+	assert.Equal(t, 1, docs.findManyCalls)
+	assert.NoError(t, sqlMock.ExpectationsWereMet())
+}
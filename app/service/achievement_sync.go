@@ -0,0 +1,212 @@
+package service
+
+import (
+	"context"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"log"
+	"os"
+	"time"
+
+	"uas/app/model"
+	"uas/app/repository"
+
+	"go.mongodb.org/mongo-driver/bson"
+	"go.mongodb.org/mongo-driver/bson/primitive"
+)
+
+// Alur dual-write achievement (create, update, delete, attachment):
+//  1. catat operasi pending di achievement_sync_ops (PostgreSQL)
+//  2. tulis ke Mongo
+//  3. perubahan PostgreSQL + tandai operasi done dalam satu tx
+//
+// Jika langkah 2 atau 3 gagal, perubahan Mongo langsung dibatalkan (kompensasi).
+// Operasi yang masih pending setelah grace period (proses mati di tengah,
+// kompensasi gagal) dibatalkan oleh AchievementSyncWorker.
+
+// Payload kompensasi per jenis operasi
+type syncUpdatePayload struct {
+	Before    map[string]interface{} `json:"before"`
+	UpdatedAt time.Time              `json:"updated_at"`
+}
+
+type syncDeletePayload struct {
+	DeletedAt time.Time `json:"deleted_at"`
+}
+
+type syncAttachPayload struct {
+	AttachmentID string `json:"attachment_id"`
+	Path         string `json:"path"`
+}
+
+// Waktu yang disimpan di Mongo dibulatkan ke milidetik supaya bisa dipakai
+// sebagai filter saat kompensasi
+func syncTimestamp() time.Time {
+	return time.Now().UTC().Truncate(time.Millisecond)
+}
+
+func (s *AchievementService) beginSync(
+	ctx context.Context,
+	op string,
+	mongoID string,
+	payload interface{},
+) (*model.AchievementSyncOp, error) {
+	raw, err := json.Marshal(payload)
+	if err != nil {
+		return nil, err
+	}
+	syncOp := &model.AchievementSyncOp{
+		Op:                 op,
+		MongoAchievementID: mongoID,
+		Payload:            raw,
+	}
+	if err := s.SyncOps.Begin(ctx, syncOp); err != nil {
+		return nil, err
+	}
+	return syncOp, nil
+}
+
+// Batalkan perubahan Mongo untuk operasi yang gagal di tengah jalan.
+// Jika kompensasi juga gagal, operasi tetap pending untuk worker.
+func (s *AchievementService) compensate(ctx context.Context, op *model.AchievementSyncOp, cause error) {
+	if err := undoSyncOp(ctx, s.Docs, op); err != nil {
+		log.Printf("achievement sync: compensate op %d (%s %s) failed: %v", op.ID, op.Op, op.MongoAchievementID, err)
+		if err := s.SyncOps.RecordFailure(ctx, op.ID, err.Error()); err != nil {
+			log.Printf("achievement sync: record failure op %d: %v", op.ID, err)
+		}
+		return
+	}
+	if err := s.SyncOps.MarkCompensated(ctx, op.ID, cause.Error()); err != nil {
+		log.Printf("achievement sync: mark compensated op %d: %v", op.ID, err)
+	}
+}
+
+// Undo perubahan Mongo untuk satu operasi. Semua langkah idempotent.
+func undoSyncOp(ctx context.Context, docs repository.AchievementDocumentStore, op *model.AchievementSyncOp) error {
+	objID, err := primitive.ObjectIDFromHex(op.MongoAchievementID)
+	if err != nil {
+		return fmt.Errorf("invalid mongo id %q", op.MongoAchievementID)
+	}
+
+	switch op.Op {
+	case model.SyncOpCreate:
+		return docs.Delete(ctx, bson.M{"_id": objID})
+
+	case model.SyncOpUpdate:
+		var p syncUpdatePayload
+		if err := json.Unmarshal(op.Payload, &p); err != nil {
+			return err
+		}
+		if p.Before == nil {
+			return nil
+		}
+		restore := bson.M{}
+		for k, v := range p.Before {
+			restore[k] = v
+		}
+		restore["updatedAt"] = syncTimestamp()
+		// hanya jika dokumen masih hasil update ini (belum ditimpa update lain)
+		_, err := docs.Update(ctx,
+			bson.M{"_id": objID, "updatedAt": p.UpdatedAt},
+			bson.M{"$set": restore},
+		)
+		return err
+
+	case model.SyncOpDelete:
+		var p syncDeletePayload
+		if err := json.Unmarshal(op.Payload, &p); err != nil {
+			return err
+		}
+		_, err := docs.Update(ctx,
+			bson.M{"_id": objID, "deletedAt": p.DeletedAt},
+			bson.M{"$unset": bson.M{"deletedAt": ""}},
+		)
+		return err
+
+	case model.SyncOpAttach:
+		var p syncAttachPayload
+		if err := json.Unmarshal(op.Payload, &p); err != nil {
+			return err
+		}
+		if _, err := docs.Update(ctx,
+			bson.M{"_id": objID},
+			bson.M{"$pull": bson.M{"attachments": bson.M{"id": p.AttachmentID}}},
+		); err != nil {
+			return err
+		}
+		if p.Path != "" {
+			if err := os.Remove(p.Path); err != nil && !errors.Is(err, os.ErrNotExist) {
+				return err
+			}
+		}
+		return nil
+	}
+	return fmt.Errorf("unknown sync op %q", op.Op)
+}
+
+// Operasi dual-write yang lebih muda dari ini dianggap masih berjalan
+const syncGracePeriod = 2 * time.Minute
+
+// AchievementSyncWorker membatalkan operasi dual-write yang tertinggal pending
+type AchievementSyncWorker struct {
+	Ops      repository.AchievementSyncRepository
+	Docs     repository.AchievementDocumentStore
+	Grace    time.Duration
+	Interval time.Duration
+}
+
+func NewAchievementSyncWorker(
+	ops repository.AchievementSyncRepository,
+	docs repository.AchievementDocumentStore,
+) *AchievementSyncWorker {
+	return &AchievementSyncWorker{
+		Ops:      ops,
+		Docs:     docs,
+		Grace:    syncGracePeriod,
+		Interval: time.Minute,
+	}
+}
+
+// RunOnce memproses satu batch operasi pending yang lebih tua dari Grace.
+// Mengembalikan jumlah operasi yang berhasil dikompensasi.
+func (w *AchievementSyncWorker) RunOnce(ctx context.Context) (int, error) {
+	ops, err := w.Ops.ListPending(ctx, time.Now().Add(-w.Grace), 100)
+	if err != nil {
+		return 0, err
+	}
+	compensated := 0
+	for i := range ops {
+		op := &ops[i]
+		if err := undoSyncOp(ctx, w.Docs, op); err != nil {
+			if err := w.Ops.RecordFailure(ctx, op.ID, err.Error()); err != nil {
+				return compensated, err
+			}
+			continue
+		}
+		if err := w.Ops.MarkCompensated(ctx, op.ID, "stale pending operation"); err != nil {
+			return compensated, err
+		}
+		compensated++
+	}
+	return compensated, nil
+}
+
+// Run menjalankan RunOnce setiap Interval sampai ctx dibatalkan
+func (w *AchievementSyncWorker) Run(ctx context.Context) {
+	ticker := time.NewTicker(w.Interval)
+	defer ticker.Stop()
+	for {
+		n, err := w.RunOnce(ctx)
+		if err != nil {
+			log.Printf("achievement sync worker: %v", err)
+		} else if n > 0 {
+			log.Printf("achievement sync worker: compensated %d pending operation(s)", n)
+		}
+		select {
+		case <-ctx.Done():
+			return
+		case <-ticker.C:
+		}
+	}
+}
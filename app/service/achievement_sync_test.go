@@ -0,0 +1,591 @@
+package service
+
+import (
+	"bytes"
+	"context"
+	"database/sql"
+	"encoding/json"
+	"errors"
+	"net/http"
+	"net/http/httptest"
+	"os"
+	"path/filepath"
+	"testing"
+	"time"
+
+	"uas/app/model"
+	"uas/app/repository"
+	"uas/app/repository/mocks"
+	"uas/utils"
+
+	"github.com/DATA-DOG/go-sqlmock"
+	"github.com/gofiber/fiber/v2"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/mock"
+	"go.mongodb.org/mongo-driver/bson"
+	"go.mongodb.org/mongo-driver/bson/primitive"
+)
+
+// fakeDocs mencatat operasi ke "Mongo" dan bisa diberi error per langkah
+type fakeDocs struct {
+	insertErr error
+	updateErr []error // dipakai berurutan per panggilan Update
+	deleteErr error
+	doc       bson.M
+	docs      []bson.M              // hasil FindManyByIDs (difilter berdasarkan id)
+	states    []model.DocumentState // hasil ListDocumentStates
+
+	inserted []bson.M
+	updates  []bson.M
+	filters  []bson.M
+	deleted  []bson.M
+
+	findManyCalls int
+}
+
+func (f *fakeDocs) Insert(ctx context.Context, doc bson.M) error {
+	if f.insertErr != nil {
+		return f.insertErr
+	}
+	f.inserted = append(f.inserted, doc)
+	return nil
+}
+
+func (f *fakeDocs) Update(ctx context.Context, filter bson.M, update bson.M) (int64, error) {
+	if len(f.updateErr) > 0 {
+		err := f.updateErr[0]
+		f.updateErr = f.updateErr[1:]
+		if err != nil {
+			return 0, err
+		}
+	}
+	f.filters = append(f.filters, filter)
+	f.updates = append(f.updates, update)
+	return 1, nil
+}
+
+func (f *fakeDocs) Delete(ctx context.Context, filter bson.M) error {
+	if f.deleteErr != nil {
+		return f.deleteErr
+	}
+	f.deleted = append(f.deleted, filter)
+	return nil
+}
+
+func (f *fakeDocs) FindOne(ctx context.Context, filter bson.M) (bson.M, error) {
+	if f.doc == nil {
+		return nil, errors.New("not found")
+	}
+	return f.doc, nil
+}
+
+func (f *fakeDocs) FindManyByIDs(ctx context.Context, ids []primitive.ObjectID) ([]bson.M, error) {
+	f.findManyCalls++
+	wanted := map[primitive.ObjectID]bool{}
+	for _, id := range ids {
+		wanted[id] = true
+	}
+	var out []bson.M
+	for _, d := range f.docs {
+		if wanted[d["_id"].(primitive.ObjectID)] {
+			out = append(out, d)
+		}
+	}
+	return out, nil
+}
+
+// SoftDelete dan PushAttachment dicatat sebagai Update supaya bisa diinjeksi error
+func (f *fakeDocs) SoftDelete(ctx context.Context, id primitive.ObjectID, deletedAt time.Time) error {
+	_, err := f.Update(ctx,
+		bson.M{"_id": id, "deletedAt": bson.M{"$exists": false}},
+		bson.M{"$set": bson.M{"deletedAt": deletedAt}},
+	)
+	return err
+}
+
+func (f *fakeDocs) PushAttachment(ctx context.Context, id primitive.ObjectID, attachment bson.M) error {
+	_, err := f.Update(ctx, bson.M{"_id": id}, bson.M{"$push": bson.M{"attachments": attachment}})
+	return err
+}
+
+func (f *fakeDocs) FindByIDs(ctx context.Context, ids []primitive.ObjectID) ([]repository.Achievement, error) {
+	return nil, nil
+}
+
+func (f *fakeDocs) ListDocumentStates(ctx context.Context) ([]model.DocumentState, error) {
+	return f.states, nil
+}
+
+func (f *fakeDocs) GetDocumentState(ctx context.Context, id primitive.ObjectID) (*model.DocumentState, error) {
+	for _, st := range f.states {
+		if st.MongoID == id.Hex() {
+			return &st, nil
+		}
+	}
+	return nil, repository.ErrDocumentNotFound
+}
+
+// Begin mengisi ID operasi seperti RETURNING id
+func expectBegin(syncOps *mocks.MockAchievementSyncRepository, op string, id int64) {
+	syncOps.On("Begin", mock.Anything, mock.MatchedBy(func(o *model.AchievementSyncOp) bool {
+		return o.Op == op
+	})).Run(func(args mock.Arguments) {
+		args.Get(1).(*model.AchievementSyncOp).ID = id
+	}).Return(nil).Once()
+}
+
+func newSyncTestService(t *testing.T, docs *fakeDocs) (*AchievementService, sqlmock.Sqlmock, *mocks.MockAchievementSyncRepository) {
+	db, sqlMock, err := sqlmock.New()
+	assert.NoError(t, err)
+	t.Cleanup(func() { db.Close() })
+
+	rubrics := new(mocks.MockRubricRepository)
+	rubrics.On("GetActive", mock.Anything).Return(nil, sql.ErrNoRows)
+	syncOps := new(mocks.MockAchievementSyncRepository)
+
+	service := NewAchievementService(
+		repository.NewAchievementRepository(db),
+		docs,
+		syncOps,
+		rubrics,
+		emptyTypeCatalog(),
+	)
+	return service, sqlMock, syncOps
+}
+
+func validCompetitionInput() AchievementInput {
+	return AchievementInput{
+		AchievementType: model.AchievementTypeCompetition,
+		Title:           "Gemastik",
+		Details: map[string]interface{}{
+			"year":             2025.0,
+			"competitionName":  "Gemastik XVIII",
+			"competitionLevel": "national",
+			"rank":             "second",
+		},
+	}
+}
+
+// =======================
+// CREATE
+// =======================
+
+func TestCreate_BeginFails_NothingWritten(t *testing.T) {
+	docs := &fakeDocs{}
+	service, sqlMock, syncOps := newSyncTestService(t, docs)
+
+	sqlMock.ExpectQuery("SELECT id\\s+FROM students").
+		WillReturnRows(sqlmock.NewRows([]string{"id"}).AddRow("student-uuid-1"))
+	syncOps.On("Begin", mock.Anything, mock.Anything).Return(errors.New("pg down"))
+
+	_, err := service.CreateAchievement(context.Background(), 10, "mahasiswa", validCompetitionInput())
+
+	assert.Error(t, err)
+	assert.Empty(t, docs.inserted)
+	assert.NoError(t, sqlMock.ExpectationsWereMet())
+}
+
+func TestCreate_MongoFails_CompensatedAndNoReference(t *testing.T) {
+	docs := &fakeDocs{insertErr: errors.New("mongo timeout")}
+	service, sqlMock, syncOps := newSyncTestService(t, docs)
+
+	sqlMock.ExpectQuery("SELECT id\\s+FROM students").
+		WillReturnRows(sqlmock.NewRows([]string{"id"}).AddRow("student-uuid-1"))
+	expectBegin(syncOps, model.SyncOpCreate, 7)
+	syncOps.On("MarkCompensated", mock.Anything, int64(7), "mongo timeout").Return(nil)
+
+	_, err := service.CreateAchievement(context.Background(), 10, "mahasiswa", validCompetitionInput())
+
+	assert.EqualError(t, err, "failed to save achievement to mongo")
+	// timeout bisa saja sudah tertulis: dokumen tetap dihapus
+	assert.Len(t, docs.deleted, 1)
+	// tidak ada INSERT achievement_references
+	assert.NoError(t, sqlMock.ExpectationsWereMet())
+	syncOps.AssertExpectations(t)
+}
+
+func TestCreate_PostgresFails_MongoDocumentRemoved(t *testing.T) {
+	docs := &fakeDocs{}
+	service, sqlMock, syncOps := newSyncTestService(t, docs)
+
+	sqlMock.ExpectQuery("SELECT id\\s+FROM students").
+		WillReturnRows(sqlmock.NewRows([]string{"id"}).AddRow("student-uuid-1"))
+	expectBegin(syncOps, model.SyncOpCreate, 7)
+	sqlMock.ExpectBegin()
+	sqlMock.ExpectQuery("INSERT INTO achievement_references").
+		WillReturnError(errors.New("connection reset"))
+	sqlMock.ExpectRollback()
+	syncOps.On("MarkCompensated", mock.Anything, int64(7), "connection reset").Return(nil)
+
+	_, err := service.CreateAchievement(context.Background(), 10, "mahasiswa", validCompetitionInput())
+
+	assert.EqualError(t, err, "failed to save reference to postgres")
+	assert.Len(t, docs.inserted, 1)
+	assert.Len(t, docs.deleted, 1)
+	assert.Equal(t, docs.inserted[0]["_id"], docs.deleted[0]["_id"])
+	assert.NoError(t, sqlMock.ExpectationsWereMet())
+	syncOps.AssertExpectations(t)
+}
+
+func TestCreate_CompleteFails_ReferenceRolledBackAndMongoRemoved(t *testing.T) {
+	docs := &fakeDocs{}
+	service, sqlMock, syncOps := newSyncTestService(t, docs)
+
+	sqlMock.ExpectQuery("SELECT id\\s+FROM students").
+		WillReturnRows(sqlmock.NewRows([]string{"id"}).AddRow("student-uuid-1"))
+	expectBegin(syncOps, model.SyncOpCreate, 7)
+	sqlMock.ExpectBegin()
+	sqlMock.ExpectQuery("INSERT INTO achievement_references").
+		WillReturnRows(sqlmock.NewRows([]string{"id"}).AddRow("ref-1"))
+	sqlMock.ExpectExec("INSERT INTO achievement_status_events").
+		WillReturnResult(sqlmock.NewResult(1, 1))
+	// worker sudah mengkompensasi operasi ini → 0 baris
+	sqlMock.ExpectExec("UPDATE achievement_sync_ops").
+		WithArgs(int64(7)).
+		WillReturnResult(sqlmock.NewResult(0, 0))
+	sqlMock.ExpectRollback()
+	syncOps.On("MarkCompensated", mock.Anything, int64(7), repository.ErrSyncOpNotPending.Error()).Return(nil)
+
+	_, err := service.CreateAchievement(context.Background(), 10, "mahasiswa", validCompetitionInput())
+
+	assert.Error(t, err)
+	assert.Len(t, docs.deleted, 1)
+	assert.NoError(t, sqlMock.ExpectationsWereMet())
+}
+
+func TestCreate_Success_CompletesSyncOpInReferenceTx(t *testing.T) {
+	docs := &fakeDocs{}
+	service, sqlMock, syncOps := newSyncTestService(t, docs)
+
+	sqlMock.ExpectQuery("SELECT id\\s+FROM students").
+		WillReturnRows(sqlmock.NewRows([]string{"id"}).AddRow("student-uuid-1"))
+	expectBegin(syncOps, model.SyncOpCreate, 7)
+	sqlMock.ExpectBegin()
+	sqlMock.ExpectQuery("INSERT INTO achievement_references").
+		WillReturnRows(sqlmock.NewRows([]string{"id"}).AddRow("ref-1"))
+	sqlMock.ExpectExec("INSERT INTO achievement_status_events").
+		WillReturnResult(sqlmock.NewResult(1, 1))
+	sqlMock.ExpectExec("UPDATE achievement_sync_ops").
+		WithArgs(int64(7)).
+		WillReturnResult(sqlmock.NewResult(0, 1))
+	sqlMock.ExpectCommit()
+
+	out, err := service.CreateAchievement(context.Background(), 10, "mahasiswa", validCompetitionInput())
+
+	assert.NoError(t, err)
+	assert.Equal(t, "draft", out.Status)
+	assert.Len(t, docs.inserted, 1)
+	assert.Empty(t, docs.deleted)
+	assert.NoError(t, sqlMock.ExpectationsWereMet())
+	syncOps.AssertNotCalled(t, "MarkCompensated", mock.Anything, mock.Anything, mock.Anything)
+}
+
+func TestCreate_CompensationFails_LeftPendingForWorker(t *testing.T) {
+	docs := &fakeDocs{insertErr: errors.New("mongo timeout"), deleteErr: errors.New("mongo still down")}
+	service, sqlMock, syncOps := newSyncTestService(t, docs)
+
+	sqlMock.ExpectQuery("SELECT id\\s+FROM students").
+		WillReturnRows(sqlmock.NewRows([]string{"id"}).AddRow("student-uuid-1"))
+	expectBegin(syncOps, model.SyncOpCreate, 7)
+	syncOps.On("RecordFailure", mock.Anything, int64(7), "mongo still down").Return(nil)
+
+	_, err := service.CreateAchievement(context.Background(), 10, "mahasiswa", validCompetitionInput())
+
+	assert.Error(t, err)
+	syncOps.AssertExpectations(t)
+	syncOps.AssertNotCalled(t, "MarkCompensated", mock.Anything, mock.Anything, mock.Anything)
+}
+
+// =======================
+// DELETE
+// =======================
+
+const syncMongoID = "693a838b456e26eb7bec08a4"
+
+// Pemilik + status draft dicek di PostgreSQL sebelum Mongo disentuh
+func expectDeletableReference(sqlMock sqlmock.Sqlmock, studentID, status string) {
+	sqlMock.ExpectQuery("SELECT id\\s+FROM students").
+		WithArgs(int64(10)).
+		WillReturnRows(sqlmock.NewRows([]string{"id"}).AddRow("student-uuid-1"))
+	sqlMock.ExpectQuery("FROM achievement_references").
+		WithArgs(syncMongoID).
+		WillReturnRows(referenceRow(syncMongoID, studentID, status, "", 0))
+}
+
+func TestDelete_MongoFails_PostgresUntouched(t *testing.T) {
+	docs := &fakeDocs{updateErr: []error{errors.New("mongo timeout")}}
+	service, sqlMock, syncOps := newSyncTestService(t, docs)
+
+	expectDeletableReference(sqlMock, "student-uuid-1", "draft")
+	expectBegin(syncOps, model.SyncOpDelete, 8)
+	syncOps.On("MarkCompensated", mock.Anything, int64(8), "mongo timeout").Return(nil)
+
+	err := service.Delete(context.Background(), 10, "mahasiswa", syncMongoID)
+
+	assert.EqualError(t, err, "failed_soft_delete_mongo")
+	// kompensasi: $unset deletedAt hanya untuk deletedAt milik operasi ini
+	assert.Len(t, docs.updates, 1)
+	assert.Contains(t, docs.updates[0], "$unset")
+	assert.Contains(t, docs.filters[0], "deletedAt")
+	assert.NoError(t, sqlMock.ExpectationsWereMet())
+}
+
+func TestDelete_PostgresFails_MongoRestored(t *testing.T) {
+	docs := &fakeDocs{}
+	service, sqlMock, syncOps := newSyncTestService(t, docs)
+
+	expectDeletableReference(sqlMock, "student-uuid-1", "draft")
+	expectBegin(syncOps, model.SyncOpDelete, 8)
+	sqlMock.ExpectBegin()
+	sqlMock.ExpectQuery("SET is_deleted = true").
+		WillReturnRows(sqlmock.NewRows([]string{"id"}))
+	sqlMock.ExpectRollback()
+	syncOps.On("MarkCompensated", mock.Anything, int64(8), mock.Anything).Return(nil)
+
+	err := service.Delete(context.Background(), 10, "mahasiswa", syncMongoID)
+
+	assert.EqualError(t, err, "cannot delete: not found or not in draft status")
+	assert.Len(t, docs.updates, 2)
+	assert.Contains(t, docs.updates[0], "$set")
+	assert.Equal(t, bson.M{"$exists": false}, docs.filters[0]["deletedAt"])
+	assert.Contains(t, docs.updates[1], "$unset")
+	assert.NoError(t, sqlMock.ExpectationsWereMet())
+	syncOps.AssertExpectations(t)
+}
+
+// Achievement mahasiswa lain / bukan draft: ditolak tanpa outbox dan tanpa Mongo
+func TestDelete_RejectedBeforeMongo(t *testing.T) {
+	cases := map[string]struct {
+		studentID, status string
+		want              error
+	}{
+		"bukan pemilik": {"student-uuid-2", "draft", errNotAchievementOwner},
+		"bukan draft":   {"student-uuid-1", "submitted", errAchievementNotDeletable},
+	}
+	for name, tc := range cases {
+		docs := &fakeDocs{}
+		service, sqlMock, syncOps := newSyncTestService(t, docs)
+		expectDeletableReference(sqlMock, tc.studentID, tc.status)
+
+		err := service.Delete(context.Background(), 10, "mahasiswa", syncMongoID)
+
+		assert.ErrorIs(t, err, tc.want, name)
+		assert.Empty(t, docs.updates, name)
+		syncOps.AssertNotCalled(t, "Begin", mock.Anything, mock.Anything)
+		assert.NoError(t, sqlMock.ExpectationsWereMet(), name)
+	}
+}
+
+// DELETE kedua pada dokumen yang sudah dihapus tidak menimpa deletedAt,
+// jadi kompensasinya tidak menghidupkan dokumen lagi
+func TestSoftDelete_AlreadyDeletedKeepsDeletedAt(t *testing.T) {
+	ctx := context.Background()
+	store := repository.NewMemoryAchievementStore()
+	objID, _ := primitive.ObjectIDFromHex(syncMongoID)
+	assert.NoError(t, store.Insert(ctx, bson.M{"_id": objID, "title": "x"}))
+
+	first := time.Date(2026, 1, 1, 0, 0, 0, 0, time.UTC)
+	assert.NoError(t, store.SoftDelete(ctx, objID, first))
+	second := first.Add(time.Hour)
+	assert.ErrorIs(t, store.SoftDelete(ctx, objID, second), repository.ErrDocumentNotFound)
+
+	payload, _ := json.Marshal(syncDeletePayload{DeletedAt: second})
+	assert.NoError(t, undoSyncOp(ctx, store, &model.AchievementSyncOp{
+		Op: model.SyncOpDelete, MongoAchievementID: syncMongoID, Payload: payload,
+	}))
+	states, err := store.ListDocumentStates(ctx)
+	assert.NoError(t, err)
+	if assert.Len(t, states, 1) && assert.NotNil(t, states[0].DeletedAt) {
+		assert.True(t, first.Equal(*states[0].DeletedAt))
+	}
+}
+
+// =======================
+// UPDATE DRAFT
+// =======================
+
+func setupUpdateDraftApp(service *AchievementService) *fiber.App {
+	app := fiber.New()
+	app.Use(func(c *fiber.Ctx) error {
+		c.Locals("claims", &utils.Claims{UserID: 10, Role: "mahasiswa"})
+		return c.Next()
+	})
+	app.Put("/achievements/:id", service.UpdateDraft)
+	return app
+}
+
+func TestUpdateDraft_CompleteFails_MongoRestoredToBefore(t *testing.T) {
+	docs := &fakeDocs{doc: bson.M{"_id": syncMongoID, "title": "Judul lama", "achievementType": "competition"}}
+	service, sqlMock, syncOps := newSyncTestService(t, docs)
+	app := setupUpdateDraftApp(service)
+
+	sqlMock.ExpectQuery("SELECT id\\s+FROM students").
+		WillReturnRows(sqlmock.NewRows([]string{"id"}).AddRow("student-uuid-1"))
+	sqlMock.ExpectQuery("FROM achievement_references").
+		WillReturnRows(referenceRow(syncMongoID, "student-uuid-1", "draft", "", 1))
+
+	var began *model.AchievementSyncOp
+	syncOps.On("Begin", mock.Anything, mock.Anything).Run(func(args mock.Arguments) {
+		began = args.Get(1).(*model.AchievementSyncOp)
+		began.ID = 9
+	}).Return(nil)
+	syncOps.On("Complete", mock.Anything, int64(9)).Return(errors.New("pg down"))
+	syncOps.On("MarkCompensated", mock.Anything, int64(9), "pg down").Return(nil)
+
+	body, _ := json.Marshal(validCompetitionInput())
+	req := httptest.NewRequest(http.MethodPut, "/achievements/"+syncMongoID, bytes.NewBuffer(body))
+	req.Header.Set("Content-Type", "application/json")
+
+	resp, err := app.Test(req)
+	assert.NoError(t, err)
+	assert.Equal(t, 500, resp.StatusCode)
+
+	var payload syncUpdatePayload
+	assert.NoError(t, json.Unmarshal(began.Payload, &payload))
+	assert.Equal(t, "Judul lama", payload.Before["title"])
+
+	// update, lalu restore field lama dengan filter updatedAt milik operasi ini
+	assert.Len(t, docs.updates, 2)
+	restore := docs.updates[1]["$set"].(bson.M)
+	assert.Equal(t, "Judul lama", restore["title"])
+	assert.Equal(t, payload.UpdatedAt, docs.filters[1]["updatedAt"])
+	syncOps.AssertExpectations(t)
+}
+
+// =======================
+// ATTACHMENT + WORKER
+// =======================
+
+func TestUndoSyncOp_Attach_PullsAndRemovesFile(t *testing.T) {
+	path := filepath.Join(t.TempDir(), "sertifikat.pdf")
+	assert.NoError(t, os.WriteFile(path, []byte("pdf"), 0o644))
+
+	payload, _ := json.Marshal(syncAttachPayload{AttachmentID: "att-1", Path: path})
+	docs := &fakeDocs{}
+	err := undoSyncOp(context.Background(), docs, &model.AchievementSyncOp{
+		ID: 1, Op: model.SyncOpAttach, MongoAchievementID: syncMongoID, Payload: payload,
+	})
+
+	assert.NoError(t, err)
+	assert.Contains(t, docs.updates[0], "$pull")
+	_, statErr := os.Stat(path)
+	assert.True(t, os.IsNotExist(statErr))
+
+	// idempotent: file sudah tidak ada
+	assert.NoError(t, undoSyncOp(context.Background(), docs, &model.AchievementSyncOp{
+		ID: 1, Op: model.SyncOpAttach, MongoAchievementID: syncMongoID, Payload: payload,
+	}))
+}
+
+// Dua upload "sertifikat.pdf": kompensasi yang kedua tidak boleh menghapus
+// file milik lampiran pertama
+func TestUndoSyncOp_Attach_SameFilenameKeepsOtherAttachment(t *testing.T) {
+	dir := t.TempDir()
+	first := attachmentPath(dir, primitive.NewObjectID().Hex(), ".pdf")
+	second := attachmentPath(dir, primitive.NewObjectID().Hex(), ".pdf")
+	assert.NotEqual(t, first, second)
+	assert.NoError(t, os.WriteFile(first, []byte("pertama"), 0o644))
+	assert.NoError(t, os.WriteFile(second, []byte("kedua"), 0o644))
+
+	payload, _ := json.Marshal(syncAttachPayload{AttachmentID: "att-2", Path: second})
+	assert.NoError(t, undoSyncOp(context.Background(), &fakeDocs{}, &model.AchievementSyncOp{
+		ID: 2, Op: model.SyncOpAttach, MongoAchievementID: syncMongoID, Payload: payload,
+	}))
+
+	content, err := os.ReadFile(first)
+	assert.NoError(t, err)
+	assert.Equal(t, "pertama", string(content))
+}
+
+func TestSyncWorker_CompensatesStalePendingOps(t *testing.T) {
+	docs := &fakeDocs{}
+	syncOps := new(mocks.MockAchievementSyncRepository)
+	deletePayload, _ := json.Marshal(syncDeletePayload{DeletedAt: time.Now().UTC().Truncate(time.Millisecond)})
+
+	syncOps.On("ListPending", mock.Anything, mock.Anything, 100).Return([]model.AchievementSyncOp{
+		{ID: 1, Op: model.SyncOpCreate, MongoAchievementID: syncMongoID, Payload: []byte("{}")},
+		{ID: 2, Op: model.SyncOpDelete, MongoAchievementID: syncMongoID, Payload: deletePayload},
+		{ID: 3, Op: model.SyncOpCreate, MongoAchievementID: "not-an-object-id", Payload: []byte("{}")},
+	}, nil)
+	syncOps.On("MarkCompensated", mock.Anything, int64(1), "stale pending operation").Return(nil)
+	syncOps.On("MarkCompensated", mock.Anything, int64(2), "stale pending operation").Return(nil)
+	syncOps.On("RecordFailure", mock.Anything, int64(3), mock.Anything).Return(nil)
+
+	worker := NewAchievementSyncWorker(syncOps, docs)
+	n, err := worker.RunOnce(context.Background())
+
+	assert.NoError(t, err)
+	assert.Equal(t, 2, n)
+	assert.Len(t, docs.deleted, 1)
+	assert.Contains(t, docs.updates[0], "$unset")
+	syncOps.AssertExpectations(t)
+}
+
+// Kompensasi dijalankan terhadap store memori: filter updatedAt / deletedAt
+// harus cocok dengan nilai yang sudah melewati encoding BSON
+func TestUndoSyncOp_MemoryStore_RestoresDocument(t *testing.T) {
+	ctx := context.Background()
+	store := repository.NewMemoryAchievementStore()
+	objID, _ := primitive.ObjectIDFromHex(syncMongoID)
+	assert.NoError(t, store.Insert(ctx, bson.M{"_id": objID, "title": "Judul lama"}))
+
+	// update → undo kembali ke snapshot "before"
+	updatedAt := syncTimestamp()
+	_, err := store.Update(ctx, bson.M{"_id": objID}, bson.M{"$set": bson.M{"title": "Judul baru", "updatedAt": updatedAt}})
+	assert.NoError(t, err)
+	payload, _ := json.Marshal(syncUpdatePayload{Before: map[string]interface{}{"title": "Judul lama"}, UpdatedAt: updatedAt})
+	assert.NoError(t, undoSyncOp(ctx, store, &model.AchievementSyncOp{
+		Op: model.SyncOpUpdate, MongoAchievementID: syncMongoID, Payload: payload,
+	}))
+	doc, err := store.FindOne(ctx, bson.M{"_id": objID})
+	assert.NoError(t, err)
+	assert.Equal(t, "Judul lama", doc["title"])
+
+	// soft delete → undo menghapus deletedAt
+	deletedAt := syncTimestamp()
+	assert.NoError(t, store.SoftDelete(ctx, objID, deletedAt))
+	payload, _ = json.Marshal(syncDeletePayload{DeletedAt: deletedAt})
+	assert.NoError(t, undoSyncOp(ctx, store, &model.AchievementSyncOp{
+		Op: model.SyncOpDelete, MongoAchievementID: syncMongoID, Payload: payload,
+	}))
+	states, err := store.ListDocumentStates(ctx)
+	assert.NoError(t, err)
+	assert.Len(t, states, 1)
+	assert.Nil(t, states[0].DeletedAt)
+
+	// attachment → undo menarik kembali lampiran
+	assert.NoError(t, store.PushAttachment(ctx, objID, bson.M{"id": "att-1", "kind": "certificate"}))
+	payload, _ = json.Marshal(syncAttachPayload{AttachmentID: "att-1"})
+	assert.NoError(t, undoSyncOp(ctx, store, &model.AchievementSyncOp{
+		Op: model.SyncOpAttach, MongoAchievementID: syncMongoID, Payload: payload,
+	}))
+	doc, _ = store.FindOne(ctx, bson.M{"_id": objID})
+	assert.Empty(t, doc["attachments"])
+
+	// create → undo menghapus dokumen
+	assert.NoError(t, undoSyncOp(ctx, store, &model.AchievementSyncOp{
+		Op: model.SyncOpCreate, MongoAchievementID: syncMongoID, Payload: []byte("{}"),
+	}))
+	_, err = store.FindOne(ctx, bson.M{"_id": objID})
+	assert.ErrorIs(t, err, repository.ErrDocumentNotFound)
+}
+
+func TestMissingAttachmentKinds_MemoryStore(t *testing.T) {
+	ctx := context.Background()
+	store := repository.NewMemoryAchievementStore()
+	objID, _ := primitive.ObjectIDFromHex(syncMongoID)
+	assert.NoError(t, store.Insert(ctx, bson.M{"_id": objID, "achievementType": "hackathon"}))
+
+	types := new(mocks.MockAchievementTypeRepository)
+	types.On("GetByKey", mock.Anything, "hackathon").Return(hackathonType(), nil)
+	service := NewAchievementService(nil, store, nil, nil, types)
+
+	missing, err := service.missingAttachmentKinds(ctx, syncMongoID)
+	assert.NoError(t, err)
+	assert.Equal(t, []string{"certificate"}, missing)
+
+	assert.NoError(t, store.PushAttachment(ctx, objID, bson.M{"id": "att-1", "kind": "certificate"}))
+	missing, err = service.missingAttachmentKinds(ctx, syncMongoID)
+	assert.NoError(t, err)
+	assert.Empty(t, missing)
+}
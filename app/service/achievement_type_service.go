@@ -0,0 +1,286 @@
+package service
+
+import (
+	"bytes"
+	"context"
+	"database/sql"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"regexp"
+	"sort"
+	"strings"
+
+	"uas/app/model"
+	"uas/app/repository"
+	"uas/utils"
+
+	"github.com/gofiber/fiber/v2"
+	"github.com/santhosh-tekuri/jsonschema/v5"
+)
+
+type AchievementTypeService struct {
+	Repo repository.AchievementTypeRepository
+}
+
+func NewAchievementTypeService(repo repository.AchievementTypeRepository) *AchievementTypeService {
+	return &AchievementTypeService{Repo: repo}
+}
+
+var achievementTypeKeyPattern = regexp.MustCompile(`^[a-z][a-z0-9_]{1,49}$`)
+
+// GET /achievement-types
+// Default hanya kategori aktif; pemilik achievement_type:manage bisa ?include_inactive=true
+func (s *AchievementTypeService) List(c *fiber.Ctx) error {
+	includeInactive := utils.HasPermission(c, model.PermAchievementTypeManage) && c.QueryBool("include_inactive")
+
+	types, err := achievementTypeCatalog(c.Context(), s.Repo)
+	if err != nil {
+		return c.Status(500).JSON(fiber.Map{"error": "failed_get_achievement_types"})
+	}
+	data := []model.AchievementType{}
+	for _, t := range types {
+		if t.IsActive || includeInactive {
+			data = append(data, t)
+		}
+	}
+	return c.JSON(fiber.Map{"data": data})
+}
+
+// ADMIN: POST /admin/achievement-types
+func (s *AchievementTypeService) Create(c *fiber.Ctx) error {
+	var input model.AchievementTypeRequest
+	if err := c.BodyParser(&input); err != nil {
+		return c.Status(400).JSON(fiber.Map{"error": "invalid_request"})
+	}
+	t, verrs := buildAchievementType(input.Key, input)
+	if verrs != nil {
+		return c.Status(422).JSON(fiber.Map{"error": "validation_failed", "fields": verrs})
+	}
+	if _, err := s.Repo.GetByKey(c.Context(), t.Key); err == nil {
+		return c.Status(409).JSON(fiber.Map{"error": "achievement_type_exists"})
+	} else if !errors.Is(err, sql.ErrNoRows) {
+		return c.Status(500).JSON(fiber.Map{"error": "failed_get_achievement_type"})
+	}
+	created, err := s.Repo.Create(c.Context(), t)
+	if err != nil {
+		return c.Status(500).JSON(fiber.Map{"error": "failed_create_achievement_type"})
+	}
+	created.IsBuiltin = isBuiltinType(created.Key)
+	return c.Status(201).JSON(created)
+}
+
+// ADMIN: PUT /admin/achievement-types/:key
+// Juga dipakai untuk menimpa / menonaktifkan kategori bawaan.
+func (s *AchievementTypeService) Update(c *fiber.Ctx) error {
+	var input model.AchievementTypeRequest
+	if err := c.BodyParser(&input); err != nil {
+		return c.Status(400).JSON(fiber.Map{"error": "invalid_request"})
+	}
+	key := c.Params("key")
+	if _, err := s.Repo.GetByKey(c.Context(), key); err != nil {
+		if !errors.Is(err, sql.ErrNoRows) {
+			return c.Status(500).JSON(fiber.Map{"error": "failed_get_achievement_type"})
+		}
+		if !isBuiltinType(key) {
+			return c.Status(404).JSON(fiber.Map{"error": "achievement_type_not_found"})
+		}
+	}
+	t, verrs := buildAchievementType(key, input)
+	if verrs != nil {
+		return c.Status(422).JSON(fiber.Map{"error": "validation_failed", "fields": verrs})
+	}
+	updated, err := s.Repo.Update(c.Context(), t)
+	if err != nil {
+		return c.Status(500).JSON(fiber.Map{"error": "failed_update_achievement_type"})
+	}
+	updated.IsBuiltin = isBuiltinType(updated.Key)
+	return c.JSON(updated)
+}
+
+// ADMIN: DELETE /admin/achievement-types/:key
+// Untuk kategori bawaan, hapus berarti kembali ke definisi default.
+func (s *AchievementTypeService) Delete(c *fiber.Ctx) error {
+	key := c.Params("key")
+	if err := s.Repo.Delete(c.Context(), key); err != nil {
+		if errors.Is(err, sql.ErrNoRows) {
+			return c.Status(404).JSON(fiber.Map{"error": "achievement_type_not_found"})
+		}
+		return c.Status(500).JSON(fiber.Map{"error": "failed_delete_achievement_type"})
+	}
+	return c.JSON(fiber.Map{
+		"message": "achievement type deleted",
+		"key":     key,
+	})
+}
+
+func buildAchievementType(key string, input model.AchievementTypeRequest) (model.AchievementType, model.ValidationErrors) {
+	errs := model.ValidationErrors{}
+	if !achievementTypeKeyPattern.MatchString(key) {
+		errs["key"] = "must be lowercase letters, digits or underscore (2-50 chars)"
+	}
+	if strings.TrimSpace(input.LabelID) == "" {
+		errs["label_id"] = "is required"
+	}
+	if strings.TrimSpace(input.LabelEN) == "" {
+		errs["label_en"] = "is required"
+	}
+	if len(input.DetailsSchema) == 0 {
+		errs["details_schema"] = "is required"
+	} else if _, err := compileDetailsSchema(key, input.DetailsSchema); err != nil {
+		errs["details_schema"] = "invalid JSON Schema: " + err.Error()
+	}
+	for i, e := range input.DefaultRubric {
+		if e.Points < 0 {
+			errs[fmt.Sprintf("default_rubric[%d].points", i)] = "must not be negative"
+		}
+	}
+	if len(errs) > 0 {
+		return model.AchievementType{}, errs
+	}
+	isActive := true
+	if input.IsActive != nil {
+		isActive = *input.IsActive
+	}
+	for i := range input.DefaultRubric {
+		input.DefaultRubric[i].AchievementType = key
+	}
+	return model.AchievementType{
+		Key:                 key,
+		LabelID:             input.LabelID,
+		LabelEN:             input.LabelEN,
+		DetailsSchema:       input.DetailsSchema,
+		RequiredAttachments: input.RequiredAttachments,
+		DefaultRubric:       input.DefaultRubric,
+		IsActive:            isActive,
+	}, nil
+}
+
+func isBuiltinType(key string) bool {
+	return model.NewAchievementDetails(key) != nil
+}
+
+// Katalog lengkap: kategori bawaan ditimpa baris achievement_types dengan key sama
+func achievementTypeCatalog(ctx context.Context, repo repository.AchievementTypeRepository) ([]model.AchievementType, error) {
+	rows, err := repo.List(ctx)
+	if err != nil {
+		return nil, err
+	}
+	byKey := map[string]model.AchievementType{}
+	for _, t := range model.BuiltinAchievementTypes() {
+		byKey[t.Key] = t
+	}
+	for _, t := range rows {
+		t.IsBuiltin = isBuiltinType(t.Key)
+		byKey[t.Key] = t
+	}
+	types := make([]model.AchievementType, 0, len(byKey))
+	for _, t := range byKey {
+		types = append(types, t)
+	}
+	sort.Slice(types, func(i, j int) bool { return types[i].Key < types[j].Key })
+	return types, nil
+}
+
+// Cari kategori aktif untuk key; kategori tidak dikenal / nonaktif → ValidationErrors
+func resolveAchievementType(
+	ctx context.Context,
+	repo repository.AchievementTypeRepository,
+	key string,
+) (*model.AchievementType, error) {
+	t, _, err := lookupAchievementType(ctx, repo, key)
+	return t, err
+}
+
+// lookupAchievementType seperti resolveAchievementType; inCatalog = ada baris
+// di katalog (bisa menimpa schema bawaan), false = schema bawaan dipakai
+func lookupAchievementType(
+	ctx context.Context,
+	repo repository.AchievementTypeRepository,
+	key string,
+) (*model.AchievementType, bool, error) {
+	inCatalog := true
+	t, err := repo.GetByKey(ctx, key)
+	if err != nil {
+		if !errors.Is(err, sql.ErrNoRows) {
+			return nil, false, errors.New("failed to load achievement type")
+		}
+		inCatalog = false
+		t = nil
+		for _, b := range model.BuiltinAchievementTypes() {
+			if b.Key == key {
+				t = &b
+				break
+			}
+		}
+	}
+	if t == nil || !t.IsActive {
+		return nil, false, model.ValidationErrors{"achievement_type": "unknown achievement type"}
+	}
+	t.IsBuiltin = isBuiltinType(t.Key)
+	return t, inCatalog, nil
+}
+
+func compileDetailsSchema(key string, schema json.RawMessage) (*jsonschema.Schema, error) {
+	compiler := jsonschema.NewCompiler()
+	compiler.AssertFormat = true
+	url := "achievement-types/" + key + ".json"
+	if err := compiler.AddResource(url, bytes.NewReader(schema)); err != nil {
+		return nil, err
+	}
+	return compiler.Compile(url)
+}
+
+var missingPropertiesPattern = regexp.MustCompile(`'([^']+)'`)
+
+// Validasi details terhadap JSON Schema kategori, error dipetakan per field
+func validateDetailsSchema(t *model.AchievementType, details map[string]interface{}) model.ValidationErrors {
+	errs := model.ValidationErrors{}
+	schema, err := compileDetailsSchema(t.Key, t.DetailsSchema)
+	if err != nil {
+		errs["achievement_type"] = "details schema is invalid"
+		return errs
+	}
+
+	// Normalisasi ke tipe JSON murni (float64, map, slice) sebelum validasi
+	var doc interface{} = map[string]interface{}{}
+	if details != nil {
+		b, _ := json.Marshal(details)
+		_ = json.Unmarshal(b, &doc)
+	}
+	err = schema.Validate(doc)
+	var ve *jsonschema.ValidationError
+	if !errors.As(err, &ve) {
+		return nil
+	}
+	collectSchemaErrors(ve, errs)
+	return errs
+}
+
+func collectSchemaErrors(ve *jsonschema.ValidationError, errs model.ValidationErrors) {
+	if len(ve.Causes) > 0 {
+		for _, cause := range ve.Causes {
+			collectSchemaErrors(cause, errs)
+		}
+		return
+	}
+	field := "details" + strings.ReplaceAll(ve.InstanceLocation, "/", ".")
+	switch {
+	case strings.HasPrefix(ve.Message, "missing properties"):
+		for _, m := range missingPropertiesPattern.FindAllStringSubmatch(ve.Message, -1) {
+			setFieldError(errs, field+"."+m[1], "is required")
+		}
+	case strings.HasPrefix(ve.Message, "additionalProperties"):
+		for _, m := range missingPropertiesPattern.FindAllStringSubmatch(ve.Message, -1) {
+			setFieldError(errs, field+"."+m[1], "unknown field")
+		}
+	default:
+		setFieldError(errs, field, ve.Message)
+	}
+}
+
+func setFieldError(errs model.ValidationErrors, field, msg string) {
+	if _, exists := errs[field]; !exists {
+		errs[field] = msg
+	}
+}
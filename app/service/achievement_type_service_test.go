@@ -0,0 +1,206 @@
+package service
+
+import (
+	"bytes"
+	"context"
+	"database/sql"
+	"encoding/json"
+	"errors"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+
+	"uas/app/model"
+	"uas/app/repository/mocks"
+	"uas/utils"
+
+	"github.com/gofiber/fiber/v2"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/mock"
+)
+
+var hackathonSchema = json.RawMessage(`{
+	"type": "object",
+	"required": ["year", "teamName"],
+	"properties": {
+		"year": {"type": "integer"},
+		"teamName": {"type": "string", "minLength": 1},
+		"rank": {"type": "string", "enum": ["winner", "runner_up", "participant"]}
+	},
+	"additionalProperties": false
+}`)
+
+func hackathonType() *model.AchievementType {
+	return &model.AchievementType{
+		Key:                 "hackathon",
+		LabelID:             "Hackathon",
+		LabelEN:             "Hackathon",
+		DetailsSchema:       hackathonSchema,
+		RequiredAttachments: []string{"certificate"},
+		DefaultRubric: []model.PointRubricEntry{
+			{AchievementType: "hackathon", Rank: "winner", Points: 40},
+			{AchievementType: "hackathon", Points: 10},
+		},
+		IsActive: true,
+	}
+}
+
+func setupAchievementTypeApp(service *AchievementTypeService, role string) *fiber.App {
+	app := fiber.New()
+	app.Use(func(c *fiber.Ctx) error {
+		c.Locals("claims", &utils.Claims{UserID: 1, Role: role})
+		return c.Next()
+	})
+	app.Get("/achievement-types", service.List)
+	app.Post("/admin/achievement-types", service.Create)
+	return app
+}
+
+func TestAchievementTypes_List_MergesBuiltinsAndHidesInactive(t *testing.T) {
+	repo := new(mocks.MockAchievementTypeRepository)
+	inactiveInternship := model.AchievementType{Key: model.AchievementTypeInternship, IsActive: false}
+	repo.On("List", mock.Anything).Return([]model.AchievementType{*hackathonType(), inactiveInternship}, nil)
+
+	app := setupAchievementTypeApp(NewAchievementTypeService(repo), "mahasiswa")
+	resp, err := app.Test(httptest.NewRequest(http.MethodGet, "/achievement-types", nil))
+	assert.NoError(t, err)
+	assert.Equal(t, 200, resp.StatusCode)
+
+	var out struct {
+		Data []model.AchievementType `json:"data"`
+	}
+	assert.NoError(t, json.NewDecoder(resp.Body).Decode(&out))
+	keys := []string{}
+	for _, at := range out.Data {
+		keys = append(keys, at.Key)
+	}
+	assert.Contains(t, keys, "hackathon")
+	assert.Contains(t, keys, model.AchievementTypeCompetition)
+	assert.NotContains(t, keys, model.AchievementTypeInternship)
+}
+
+func TestAchievementTypes_Create_InvalidSchema(t *testing.T) {
+	repo := new(mocks.MockAchievementTypeRepository)
+	app := setupAchievementTypeApp(NewAchievementTypeService(repo), "admin")
+
+	body := []byte(`{
+		"key": "hackathon",
+		"label_id": "Hackathon",
+		"label_en": "Hackathon",
+		"details_schema": {"type": "objekt"}
+	}`)
+	req := httptest.NewRequest(http.MethodPost, "/admin/achievement-types", bytes.NewBuffer(body))
+	req.Header.Set("Content-Type", "application/json")
+
+	resp, err := app.Test(req)
+	assert.NoError(t, err)
+	assert.Equal(t, 422, resp.StatusCode)
+	repo.AssertNotCalled(t, "Create", mock.Anything, mock.Anything)
+}
+
+func TestAchievementTypes_Create_Success(t *testing.T) {
+	repo := new(mocks.MockAchievementTypeRepository)
+	repo.On("GetByKey", mock.Anything, "hackathon").Return(nil, sql.ErrNoRows)
+	repo.On("Create", mock.Anything, mock.MatchedBy(func(t model.AchievementType) bool {
+		return t.Key == "hackathon" && t.IsActive && t.DefaultRubric[0].AchievementType == "hackathon"
+	})).Return(hackathonType(), nil)
+
+	app := setupAchievementTypeApp(NewAchievementTypeService(repo), "admin")
+
+	body, _ := json.Marshal(model.AchievementTypeRequest{
+		Key:                 "hackathon",
+		LabelID:             "Hackathon",
+		LabelEN:             "Hackathon",
+		DetailsSchema:       hackathonSchema,
+		RequiredAttachments: []string{"certificate"},
+		DefaultRubric:       []model.PointRubricEntry{{Rank: "winner", Points: 40}},
+	})
+	req := httptest.NewRequest(http.MethodPost, "/admin/achievement-types", bytes.NewBuffer(body))
+	req.Header.Set("Content-Type", "application/json")
+
+	resp, err := app.Test(req)
+	assert.NoError(t, err)
+	assert.Equal(t, 201, resp.StatusCode)
+	repo.AssertExpectations(t)
+}
+
+func TestValidateAchievementInput_CatalogSchema(t *testing.T) {
+	types := new(mocks.MockAchievementTypeRepository)
+	types.On("GetByKey", mock.Anything, "hackathon").Return(hackathonType(), nil)
+	service := NewAchievementService(nil, nil, nil, nil, types)
+	ctx := context.Background()
+
+	input := AchievementInput{
+		AchievementType: "hackathon",
+		Title:           "Hack Jakarta",
+		Details:         map[string]interface{}{"year": "2024", "rank": "gold", "extra": true},
+	}
+	_, err := service.validateAchievementInput(ctx, &input)
+	var verrs model.ValidationErrors
+	assert.True(t, errors.As(err, &verrs))
+	assert.Contains(t, verrs, "details.year")
+	assert.Equal(t, "is required", verrs["details.teamName"])
+	assert.Contains(t, verrs, "details.rank")
+	assert.Equal(t, "unknown field", verrs["details.extra"])
+
+	input.Details = map[string]interface{}{"year": 2024.0, "teamName": "Kopi", "rank": "winner"}
+	achievementType, err := service.validateAchievementInput(ctx, &input)
+	assert.NoError(t, err)
+	assert.False(t, achievementType.IsBuiltin)
+}
+
+// Kategori bawaan yang ditimpa di katalog: hanya schema katalog yang berlaku,
+// field tambahan dari admin tidak ditolak struct bertipe
+func TestValidateAchievementInput_CatalogOverridesBuiltin(t *testing.T) {
+	types := new(mocks.MockAchievementTypeRepository)
+	types.On("GetByKey", mock.Anything, model.AchievementTypeInternship).Return(&model.AchievementType{
+		Key:      model.AchievementTypeInternship,
+		IsActive: true,
+		DetailsSchema: json.RawMessage(`{
+			"type": "object",
+			"required": ["companyName", "supervisor"],
+			"properties": {
+				"companyName": {"type": "string"},
+				"supervisor": {"type": "string"}
+			},
+			"additionalProperties": false
+		}`),
+	}, nil)
+	service := NewAchievementService(nil, nil, nil, nil, types)
+
+	input := AchievementInput{
+		AchievementType: model.AchievementTypeInternship,
+		Title:           "Magang",
+		Details:         map[string]interface{}{"companyName": "PT Contoh", "supervisor": "Bu Sari"},
+	}
+	achievementType, err := service.validateAchievementInput(context.Background(), &input)
+	assert.NoError(t, err)
+	assert.True(t, achievementType.IsBuiltin)
+	assert.Equal(t, map[string]interface{}{"companyName": "PT Contoh", "supervisor": "Bu Sari"}, input.Details)
+}
+
+func TestValidateAchievementInput_InactiveBuiltinRejected(t *testing.T) {
+	types := new(mocks.MockAchievementTypeRepository)
+	types.On("GetByKey", mock.Anything, model.AchievementTypeCompetition).
+		Return(&model.AchievementType{Key: model.AchievementTypeCompetition, IsActive: false}, nil)
+	service := NewAchievementService(nil, nil, nil, nil, types)
+
+	input := AchievementInput{AchievementType: model.AchievementTypeCompetition, Title: "x"}
+	_, err := service.validateAchievementInput(context.Background(), &input)
+
+	var verrs model.ValidationErrors
+	assert.True(t, errors.As(err, &verrs))
+	assert.Equal(t, "unknown achievement type", verrs["achievement_type"])
+}
+
+func TestRubricPoints_FallsBackToDefaultRubric(t *testing.T) {
+	rubrics := new(mocks.MockRubricRepository)
+	rubrics.On("GetActive", mock.Anything).Return(nil, sql.ErrNoRows)
+	service := NewAchievementService(nil, nil, nil, rubrics, nil)
+
+	points, version, err := service.rubricPoints(context.Background(), hackathonType(),
+		map[string]interface{}{"rank": "winner"})
+	assert.NoError(t, err)
+	assert.Equal(t, 40.0, points)
+	assert.Equal(t, "default:hackathon", version)
+}
@@ -5,6 +5,7 @@ import (
 	"uas/app/repository"
 	"uas/utils"
 	"database/sql"
+	"strings"
 	
 	"github.com/gofiber/fiber/v2"
 )
@@ -13,16 +14,19 @@ type AdminService struct {
 	UserRepo     repository.UserRepository
 	StudentRepo  repository.StudentRepository
 	LecturerRepo repository.LecturerRepository
+	Policy       *utils.PasswordPolicy
 }
 
 // CONSTRUCTOR
 func NewAdminService(userRepo repository.UserRepository,
 	studentRepo repository.StudentRepository,
-	lecturerRepo repository.LecturerRepository) *AdminService {
+	lecturerRepo repository.LecturerRepository,
+	policy *utils.PasswordPolicy) *AdminService {
 	return &AdminService{
 		UserRepo:     userRepo,
 		StudentRepo:  studentRepo,
 		LecturerRepo: lecturerRepo,
+		Policy:       policy,
 	}
 }
 
@@ -33,11 +37,19 @@ func (s *AdminService) CreateUser(c *fiber.Ctx) error {
 		return c.Status(400).JSON(fiber.Map{"error": "invalid_request"})
 	}
 
-	// --- VALIDASI ROLE ---
-	if input.Role != "mahasiswa" && input.Role != "dosen wali" && input.Role != "admin" {
+	// --- VALIDASI ROLE (boleh role apa pun yang ada di tabel roles) ---
+	if strings.TrimSpace(input.Role) == "" {
 		return c.Status(400).JSON(fiber.Map{"error": "invalid_role"})
 	}
 
+	// --- VALIDASI PASSWORD (password policy) ---
+	if err := s.Policy.Check(input.Password, input.Username, input.Email); err != nil {
+		return c.Status(422).JSON(fiber.Map{
+			"error":  "validation_failed",
+			"fields": model.ValidationErrors{"password": err.Error()},
+		})
+	}
+
 	// --- HASH PASSWORD ---
 	passHash, err := utils.HashPassword(input.Password)
 	if err != nil {
@@ -64,7 +76,7 @@ func (s *AdminService) CreateUser(c *fiber.Ctx) error {
 	}
 
 	// ROLE = MAHASISWA → buat profile
-	if input.Role == "mahasiswa" {
+	if input.Role == model.RoleStudent {
 		// generate NIM: 43420 + user_id
 		nim := "4342025" + utils.IntToString(userID)
 		student := model.StudentCreate{
@@ -86,7 +98,7 @@ func (s *AdminService) CreateUser(c *fiber.Ctx) error {
 	}
 
 	// ROLE = DOSEN WALI → buat profile
-	if input.Role == "dosen wali" {
+	if input.Role == model.RoleLecturer {
 		if input.NIP == "" || input.Department == "" {
 			return c.Status(400).JSON(fiber.Map{"error": "nip_and_department_required"})
 		}
@@ -106,10 +118,15 @@ func (s *AdminService) CreateUser(c *fiber.Ctx) error {
 		})
 	}
 
-	// ROLE = ADMIN → hanya insert user
+	// ROLE LAIN (admin / role buatan) → hanya insert user
+	message := "user created"
+	if input.Role == model.RoleAdmin {
+		message = "admin user created"
+	}
 	return c.JSON(fiber.Map{
-		"message": "admin user created",
+		"message": message,
 		"user_id": userID,
+		"role":    input.Role,
 	})
 }
 
@@ -126,7 +143,7 @@ func (s *AdminService) UpdateUser(c *fiber.Ctx) error {
 	}
 
 	// VALIDASI ROLE
-	if input.Role != "admin" && input.Role != "mahasiswa" && input.Role != "dosen wali" {
+	if strings.TrimSpace(input.Role) == "" {
 		return c.Status(400).JSON(fiber.Map{"error": "invalid_role"})
 	}
 
@@ -148,7 +165,7 @@ func (s *AdminService) UpdateUser(c *fiber.Ctx) error {
 	}
 
 	// UPDATE MAHASISWA PROFILE
-	if input.Role == "mahasiswa" {
+	if input.Role == model.RoleStudent {
 		err = s.StudentRepo.UpdateProfile(
 			c.Context(),
 			model.StudentCreate{
@@ -164,7 +181,7 @@ func (s *AdminService) UpdateUser(c *fiber.Ctx) error {
 	}
 
 	// UPDATE DOSEN PROFILE
-	if input.Role == "dosen wali" {
+	if input.Role == model.RoleLecturer {
 		err = s.LecturerRepo.Update(c.Context(),
 			model.LecturerCreate{
 				UserID:     int64(userID),
@@ -252,7 +269,7 @@ func (s *AdminService) UpdateUserRole(c *fiber.Ctx) error {
 			"error": "invalid_request",
 		})
 	}
-	if input.Role != "admin" && input.Role != "mahasiswa" && input.Role != "dosen wali" {
+	if strings.TrimSpace(input.Role) == "" {
 		return c.Status(422).JSON(fiber.Map{
 			"error": "invalid_role",
 		})
@@ -280,3 +297,44 @@ func (s *AdminService) UpdateUserRole(c *fiber.Ctx) error {
 		"role":    input.Role,
 	})
 }
+
+// UPDATE USER STATUS (aktif / nonaktif)
+// Menonaktifkan akun menaikkan token_version, jadi access token yang masih
+// beredar langsung ditolak dan refresh berikutnya mencabut sesinya.
+func (s *AdminService) UpdateUserStatus(c *fiber.Ctx) error {
+	userID, err := c.ParamsInt("id")
+	if err != nil {
+		return c.Status(400).JSON(fiber.Map{
+			"error": "invalid_user_id",
+		})
+	}
+	var input model.UserStatusRequest
+	if err := c.BodyParser(&input); err != nil || input.IsActive == nil {
+		return c.Status(400).JSON(fiber.Map{
+			"error": "invalid_request",
+		})
+	}
+	// admin tidak boleh mengunci dirinya sendiri
+	if claims, ok := c.Locals("claims").(*utils.Claims); ok &&
+		claims.UserID == int64(userID) && !*input.IsActive {
+		return c.Status(422).JSON(fiber.Map{
+			"error": "cannot_deactivate_self",
+		})
+	}
+	err = s.UserRepo.SetActive(c.Context(), int64(userID), *input.IsActive)
+	if err != nil {
+		if err == sql.ErrNoRows {
+			return c.Status(404).JSON(fiber.Map{
+				"error": "user_not_found",
+			})
+		}
+		return c.Status(500).JSON(fiber.Map{
+			"error": "failed_update_status",
+		})
+	}
+	return c.JSON(fiber.Map{
+		"message":   "user status updated",
+		"user_id":   userID,
+		"is_active": *input.IsActive,
+	})
+}
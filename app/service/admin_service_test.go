@@ -2,6 +2,7 @@ package service
 
 import (
 	"bytes"
+	"errors"
 	"encoding/json"
 	"net/http"
 	"net/http/httptest"
@@ -9,6 +10,7 @@ import (
 
 	"uas/app/model"
 	"uas/app/repository/mocks"
+	"uas/utils"
 
 	"github.com/gofiber/fiber/v2"
 	"github.com/stretchr/testify/assert"
@@ -19,6 +21,16 @@ import (
 // HELPER
 // =======================
 
+func testPasswordPolicy() *utils.PasswordPolicy {
+	policy, _ := utils.LoadPasswordPolicy(utils.PasswordPolicyOptions{
+		MinLength:    8,
+		RequireUpper: true,
+		RequireLower: true,
+		RequireDigit: true,
+	})
+	return policy
+}
+
 func setupApp(service *AdminService) *fiber.App {
 	app := fiber.New()
 	app.Post("/users", service.CreateUser)
@@ -35,11 +47,15 @@ func TestCreateUser_InvalidRole(t *testing.T) {
 	studentRepo := new(mocks.MockStudentRepository)
 	lecturerRepo := new(mocks.MockLecturerRepository)
 
-	service := NewAdminService(userRepo, studentRepo, lecturerRepo)
+	// role tidak terdaftar di tabel roles
+	userRepo.On("GetRoleIDByName", "superadmin").Return("", errors.New("role_not_found"))
+
+	service := NewAdminService(userRepo, studentRepo, lecturerRepo, testPasswordPolicy())
 	app := setupApp(service)
 
 	input := model.AdminCreateUserRequest{
 		Username: "test",
+		Password: "Rahasia-2026",
 		Role:     "superadmin",
 	}
 
@@ -56,19 +72,41 @@ func TestCreateUser_InvalidRole(t *testing.T) {
 	assert.Equal(t, 400, resp.StatusCode)
 }
 
+// Password kosong / lemah ditolak sebelum menyentuh database
+func TestCreateUser_WeakPassword(t *testing.T) {
+	userRepo := new(mocks.MockUserRepository)
+	service := NewAdminService(userRepo, new(mocks.MockStudentRepository), new(mocks.MockLecturerRepository), testPasswordPolicy())
+	app := setupApp(service)
+
+	for _, password := range []string{"", "secret", "alllowercase1"} {
+		body, _ := json.Marshal(model.AdminCreateUserRequest{
+			Username: "test",
+			Password: password,
+			Role:     "admin",
+		})
+		req := httptest.NewRequest(http.MethodPost, "/users", bytes.NewBuffer(body))
+		req.Header.Set("Content-Type", "application/json")
+
+		resp, err := app.Test(req)
+		assert.NoError(t, err)
+		assert.Equal(t, 422, resp.StatusCode, password)
+	}
+	userRepo.AssertNotCalled(t, "CreateRaw", mock.Anything, mock.Anything, mock.Anything, mock.Anything, mock.Anything, mock.Anything)
+}
+
 func TestCreateUser_AdminSuccess(t *testing.T) {
 	userRepo := new(mocks.MockUserRepository)
 	studentRepo := new(mocks.MockStudentRepository)
 	lecturerRepo := new(mocks.MockLecturerRepository)
 
-	service := NewAdminService(userRepo, studentRepo, lecturerRepo)
+	service := NewAdminService(userRepo, studentRepo, lecturerRepo, testPasswordPolicy())
 	app := setupApp(service)
 
 	input := model.AdminCreateUserRequest{
 		Username: "admin1",
 		FullName: "Admin One",
 		Email:    "admin@test.com",
-		Password: "secret",
+		Password: "Rahasia-2026",
 		Role:     "admin",
 	}
 
@@ -100,6 +138,48 @@ func TestCreateUser_AdminSuccess(t *testing.T) {
 	userRepo.AssertExpectations(t)
 }
 
+// Role buatan admin (bukan bawaan) boleh dipakai, tanpa profil akademik
+func TestCreateUser_CustomRole(t *testing.T) {
+	userRepo := new(mocks.MockUserRepository)
+	studentRepo := new(mocks.MockStudentRepository)
+	lecturerRepo := new(mocks.MockLecturerRepository)
+
+	service := NewAdminService(userRepo, studentRepo, lecturerRepo, testPasswordPolicy())
+	app := setupApp(service)
+
+	input := model.AdminCreateUserRequest{
+		Username: "staf1",
+		FullName: "Staf Kemahasiswaan",
+		Email:    "staf@test.com",
+		Password: "Rahasia-2026",
+		Role:     "staf kemahasiswaan",
+	}
+
+	userRepo.On("GetRoleIDByName", "staf kemahasiswaan").
+		Return("role-staf", nil)
+	userRepo.On(
+		"CreateRaw",
+		mock.Anything,
+		"staf1",
+		"Staf Kemahasiswaan",
+		"staf@test.com",
+		mock.Anything,
+		"role-staf",
+	).Return(int64(11), nil)
+
+	body, _ := json.Marshal(input)
+	req := httptest.NewRequest(http.MethodPost, "/users", bytes.NewBuffer(body))
+	req.Header.Set("Content-Type", "application/json")
+
+	resp, err := app.Test(req)
+	assert.NoError(t, err)
+	assert.Equal(t, 200, resp.StatusCode)
+
+	userRepo.AssertExpectations(t)
+	studentRepo.AssertNotCalled(t, "Create", mock.Anything, mock.Anything)
+	lecturerRepo.AssertNotCalled(t, "Create", mock.Anything, mock.Anything)
+}
+
 // =======================
 // DELETE USER
 // =======================
@@ -109,7 +189,7 @@ func TestDeleteUser_Success(t *testing.T) {
 	studentRepo := new(mocks.MockStudentRepository)
 	lecturerRepo := new(mocks.MockLecturerRepository)
 
-	service := NewAdminService(userRepo, studentRepo, lecturerRepo)
+	service := NewAdminService(userRepo, studentRepo, lecturerRepo, testPasswordPolicy())
 	app := setupApp(service)
 
 	userRepo.On("Delete", mock.Anything, int64(1)).
@@ -133,3 +213,38 @@ func TestDeleteUser_Success(t *testing.T) {
 
 	userRepo.AssertExpectations(t)
 }
+
+// =======================
+// UPDATE USER STATUS
+// =======================
+
+func TestUpdateUserStatus_Deactivate(t *testing.T) {
+	userRepo := new(mocks.MockUserRepository)
+	service := NewAdminService(userRepo, new(mocks.MockStudentRepository), new(mocks.MockLecturerRepository), testPasswordPolicy())
+
+	app := fiber.New()
+	app.Use(func(c *fiber.Ctx) error {
+		c.Locals("claims", &utils.Claims{UserID: 1, Role: "admin"})
+		return c.Next()
+	})
+	app.Put("/users/:id/status", service.UpdateUserStatus)
+
+	userRepo.On("SetActive", mock.Anything, int64(5), false).Return(nil)
+
+	body, _ := json.Marshal(map[string]bool{"is_active": false})
+	req := httptest.NewRequest(http.MethodPut, "/users/5/status", bytes.NewReader(body))
+	req.Header.Set("Content-Type", "application/json")
+	resp, err := app.Test(req)
+	assert.NoError(t, err)
+	assert.Equal(t, 200, resp.StatusCode)
+
+	// admin tidak bisa menonaktifkan dirinya sendiri
+	req = httptest.NewRequest(http.MethodPut, "/users/1/status", bytes.NewReader(body))
+	req.Header.Set("Content-Type", "application/json")
+	resp, err = app.Test(req)
+	assert.NoError(t, err)
+	assert.Equal(t, 422, resp.StatusCode)
+
+	userRepo.AssertExpectations(t)
+	userRepo.AssertNotCalled(t, "SetActive", mock.Anything, int64(1), false)
+}
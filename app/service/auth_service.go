@@ -1,6 +1,7 @@
 package service
 
 import (
+	"context"
 	"errors"
 	"time"
 
@@ -13,36 +14,78 @@ import (
 )
 
 type AuthService struct {
-	userRepo  repository.UserRepository
-	tokenRepo repository.RefreshTokenRepository
+	userRepo   repository.UserRepository
+	tokenRepo  repository.RefreshTokenRepository
+	accessRepo repository.AccessTokenRepository
+	twoFactor  repository.TwoFactorRepository
+	keys       *utils.KeySet
+	guard      *LoginGuard
+	totpIssuer string // nama akun di authenticator app
 }
 
 func NewAuthService(
 	userRepo repository.UserRepository,
 	tokenRepo repository.RefreshTokenRepository,
+	accessRepo repository.AccessTokenRepository,
+	twoFactor repository.TwoFactorRepository,
+	keys *utils.KeySet,
+	guard *LoginGuard,
+	totpIssuer string,
 ) *AuthService {
 	return &AuthService{
-		userRepo:  userRepo,
-		tokenRepo: tokenRepo,
+		userRepo:   userRepo,
+		tokenRepo:  tokenRepo,
+		accessRepo: accessRepo,
+		twoFactor:  twoFactor,
+		keys:       keys,
+		guard:      guard,
+		totpIssuer: totpIssuer,
 	}
 }
 
+// Umur satu refresh token; diperpanjang setiap rotasi
+const refreshTokenTTL = 7 * 24 * time.Hour // 7 hari
+
 type LoginInput struct {
 	Username string `json:"username"`
 	Password string `json:"password"`
 }
 
+// LoginOutput berisi JWT, atau Challenge jika user masih harus memasukkan
+// kode 2FA (Token kosong)
 type LoginOutput struct {
-	Token       string      `json:"token"`
-	User        *model.User `json:"user"`
-	Permissions []string    `json:"permissions"`
+	Token       string          `json:"token"`
+	User        *model.User     `json:"user"`
+	Permissions []string        `json:"permissions"`
+	SessionID   string          `json:"-"`
+	Challenge   *LoginChallenge `json:"-"`
+}
+
+// LoginChallenge: token tahap kedua yang ditukar di POST /auth/2fa/verify
+type LoginChallenge struct {
+	Token              string
+	ExpiresIn          time.Duration
+	EnrollmentRequired bool // role mewajibkan 2FA tapi user belum enroll
 }
 
-func (s *AuthService) Login(input LoginInput) (*LoginOutput, error) {
+// Login dari ip; *LockedError jika IP / akun sedang dikunci karena terlalu
+// banyak gagal. User dengan 2FA aktif (atau diwajibkan role) mendapat
+// Challenge, bukan JWT.
+func (s *AuthService) Login(ctx context.Context, input LoginInput, ip string) (*LoginOutput, error) {
 
 	// 1. cek user
-	user, err := s.userRepo.FindByUsernameOrEmail(input.Username)
-	if err != nil {
+	user, findErr := s.userRepo.FindByUsernameOrEmail(input.Username)
+	var userID int64
+	if findErr == nil {
+		userID = user.ID
+	}
+
+	// 2. IP / akun sedang dikunci?
+	if err := s.guard.Check(ctx, userID, ip); err != nil {
+		return nil, err
+	}
+	if findErr != nil {
+		s.guard.Failure(ctx, 0, ip)
 		return nil, errors.New("invalid_credentials")
 	}
 
@@ -50,25 +93,52 @@ func (s *AuthService) Login(input LoginInput) (*LoginOutput, error) {
 		return nil, errors.New("user_inactive")
 	}
 
-	// 2. cek password
+	// 3. cek password
 	if !utils.CheckPassword(user.PasswordHash, input.Password) {
+		s.guard.Failure(ctx, user.ID, ip)
 		return nil, errors.New("invalid_credentials")
 	}
 
-	// 3. ambil permissions
+	// 4. 2FA / JWT
+	return s.afterFirstFactor(ctx, user, ip)
+}
+
+// afterFirstFactor: user sudah lolos faktor pertama (password atau SSO).
+// 2FA: hitungan gagal akun baru direset setelah kode benar, supaya tebakan
+// kode tetap terkunci walau faktor pertama sudah diketahui.
+func (s *AuthService) afterFirstFactor(ctx context.Context, user *model.User, ip string) (*LoginOutput, error) {
+	status, err := s.twoFactor.Status(ctx, user.ID)
+	if err != nil {
+		return nil, err
+	}
+	if status.Enabled || status.Required {
+		challenge, err := s.newLoginChallenge(ctx, user.ID, !status.Enabled)
+		if err != nil {
+			return nil, err
+		}
+		return &LoginOutput{User: user, Challenge: challenge}, nil
+	}
+	s.guard.Success(ctx, user.ID, ip)
+
+	return s.completeLogin(user)
+}
+
+// completeLogin: permissions + JWT untuk user yang sudah lolos semua tahap
+func (s *AuthService) completeLogin(user *model.User) (*LoginOutput, error) {
 	perms, err := s.userRepo.GetPermissionsByUserID(user.ID)
 	if err != nil {
 		return nil, err
 	}
 
-	// 4. generate JWT
-	secret := []byte("RAHASIA_TOKEN_APLIKASI")
-	token, err := utils.GenerateToken(
-		user.ID,
-		user.Username,
-		user.Role,
-		secret,
-	)
+	// generate JWT (kunci aktif dari KeySet), terikat ke sesi baru
+	sessionID := uuid.New().String()
+	token, err := s.keys.GenerateToken(utils.Claims{
+		UserID:       user.ID,
+		Username:     user.Username,
+		Role:         user.Role,
+		SessionID:    sessionID,
+		TokenVersion: user.TokenVersion,
+	})
 	if err != nil {
 		return nil, err
 	}
@@ -76,6 +146,7 @@ func (s *AuthService) Login(input LoginInput) (*LoginOutput, error) {
 		Token:       token,
 		User:        user,
 		Permissions: perms,
+		SessionID:   sessionID,
 	}, nil
 }
 
@@ -86,61 +157,152 @@ func (s *AuthService) LoginHandler(c *fiber.Ctx) error {
 		return c.Status(400).JSON(fiber.Map{"error": "invalid_request"})
 	}
 
-	output, err := s.Login(input)
+	output, err := s.Login(c.Context(), input, c.IP())
+	var locked *LockedError
+	if errors.As(err, &locked) {
+		return lockedResponse(c, err)
+	}
 	if err != nil {
 		return c.Status(401).JSON(fiber.Map{"error": err.Error()})
 	}
 
-	// generate refresh token
-	expiresAt := time.Now().Add(7 * 24 * time.Hour) // 7 hari
-	refreshToken := uuid.New().String()
+	return s.loginResult(c, output)
+}
+
+// loginResult: challenge 2FA (belum ada JWT sampai kode diverifikasi) atau
+// pasangan token
+func (s *AuthService) loginResult(c *fiber.Ctx, output *LoginOutput) error {
+	if ch := output.Challenge; ch != nil {
+		return c.JSON(fiber.Map{
+			"two_factor_required": true,
+			"enrollment_required": ch.EnrollmentRequired,
+			"challenge_token":     ch.Token,
+			"expires_in":          int(ch.ExpiresIn.Seconds()),
+		})
+	}
+	return s.loginResponse(c, output, nil)
+}
 
-	// simpan ke PostgreSQL
-	err = s.tokenRepo.Save(c.Context(), output.User.ID, refreshToken, expiresAt)
+// loginResponse membuat refresh token (sesi / family baru per login) dan
+// mengirim pasangan token; extra ditambahkan ke body (mis. recovery_codes)
+func (s *AuthService) loginResponse(c *fiber.Ctx, output *LoginOutput, extra fiber.Map) error {
+	refreshToken, err := s.issueRefreshToken(c, output.User.ID, output.SessionID)
 	if err != nil {
 		return c.Status(500).JSON(fiber.Map{"error": "failed_generate_refresh"})
 	}
-	return c.JSON(fiber.Map{
+	body := fiber.Map{
 		"access_token":  output.Token,
 		"refresh_token": refreshToken,
 		"user":          output.User,
 		"permissions":   output.Permissions,
-	})
+	}
+	for k, v := range extra {
+		body[k] = v
+	}
+	return c.JSON(body)
 }
 
 // REFRESH TOKEN
+// Setiap refresh token hanya berlaku sekali: ditukar dengan token baru di
+// family yang sama. Token lama yang dipakai lagi berarti bocor, seluruh
+// family dicabut sehingga pencuri maupun pemilik asli harus login ulang.
 func (s *AuthService) RefreshHandler(c *fiber.Ctx) error {
 	var req model.RefreshTokenRequest
-	if err := c.BodyParser(&req); err != nil {
+	if err := c.BodyParser(&req); err != nil || req.RefreshToken == "" {
 		return c.Status(400).JSON(fiber.Map{"error": "invalid_request"})
 	}
+	ctx := c.Context()
 
-	rt, err := s.tokenRepo.Get(c.Context(), req.RefreshToken)
-	if err != nil {
+	// 1. Cari berdasarkan hash
+	rt, err := s.tokenRepo.Get(ctx, utils.HashToken(req.RefreshToken))
+	if err != nil || rt.RevokedAt != nil {
 		return c.Status(401).JSON(fiber.Map{"error": "invalid_refresh_token"})
 	}
+
+	// 2. Sudah pernah di-rotate → reuse, cabut family
+	if rt.RotatedAt != nil {
+		return s.rejectReuse(c, rt)
+	}
 	if time.Now().After(rt.ExpiresAt) {
 		return c.Status(401).JSON(fiber.Map{"error": "refresh_expired"})
 	}
 
-	// get user
-	user, err := s.userRepo.FindById(c.Context(), rt.UserID)
+	// 3. User harus masih ada dan aktif
+	user, err := s.userRepo.FindById(ctx, rt.UserID)
 	if err != nil {
 		return c.Status(404).JSON(fiber.Map{"error": "user_not_found"})
 	}
+	if !user.IsActive {
+		if err := s.tokenRepo.RevokeFamily(ctx, rt.FamilyID); err != nil {
+			return c.Status(500).JSON(fiber.Map{"error": "failed_revoke_refresh"})
+		}
+		return c.Status(401).JSON(fiber.Map{"error": "user_inactive"})
+	}
+
+	// 4. Rotasi
+	nextToken, err := utils.NewOpaqueToken()
+	if err != nil {
+		return c.Status(500).JSON(fiber.Map{"error": "failed_generate_refresh"})
+	}
+	err = s.tokenRepo.Rotate(ctx, rt, utils.HashToken(nextToken), time.Now().Add(refreshTokenTTL), c.IP())
+	if errors.Is(err, repository.ErrRefreshTokenReused) {
+		return s.rejectReuse(c, rt)
+	}
+	if err != nil {
+		return c.Status(500).JSON(fiber.Map{"error": "failed_generate_refresh"})
+	}
 
-	// buat token baru
-	secret := []byte("RAHASIA_TOKEN_APLIKASI")
-	newToken, err := utils.GenerateToken(user.ID, user.Username, user.Role, secret)
+	// 5. Access token baru
+	newToken, err := s.keys.GenerateToken(utils.Claims{
+		UserID:       user.ID,
+		Username:     user.Username,
+		Role:         user.Role,
+		SessionID:    rt.FamilyID,
+		TokenVersion: user.TokenVersion,
+	})
 	if err != nil {
 		return c.Status(500).JSON(fiber.Map{"error": "token_failed"})
 	}
 	return c.JSON(fiber.Map{
 		"access_token":  newToken,
-		"refresh_token": req.RefreshToken,
+		"refresh_token": nextToken,
 	})
 }
 
+func (s *AuthService) rejectReuse(c *fiber.Ctx, rt *model.RefreshToken) error {
+	if err := s.tokenRepo.RevokeFamily(c.Context(), rt.FamilyID); err != nil {
+		return c.Status(500).JSON(fiber.Map{"error": "failed_revoke_refresh"})
+	}
+	return c.Status(401).JSON(fiber.Map{"error": "refresh_token_reused"})
+}
+
+// issueRefreshToken membuat sesi (family) baru dengan metadata perangkat;
+// yang disimpan hanya hash token
+func (s *AuthService) issueRefreshToken(c *fiber.Ctx, userID int64, sessionID string) (string, error) {
+	token, err := utils.NewOpaqueToken()
+	if err != nil {
+		return "", err
+	}
+	session := &model.AuthSession{
+		ID:        sessionID,
+		UserID:    userID,
+		UserAgent: truncate(c.Get(fiber.HeaderUserAgent), maxUserAgentLength),
+		IPAddress: c.IP(),
+	}
+	expiresAt := time.Now().Add(refreshTokenTTL)
+	if err := s.tokenRepo.Save(c.Context(), session, utils.HashToken(token), expiresAt); err != nil {
+		return "", err
+	}
+	return token, nil
+}
+
+// JWKS: GET /.well-known/jwks.json
+// Kunci publik (aktif + lama selama rotasi) untuk layanan lain yang memverifikasi token kita
+func (s *AuthService) JWKSHandler(c *fiber.Ctx) error {
+	c.Set("Cache-Control", "public, max-age=300")
+	return c.JSON(s.keys.JWKS())
+}
+
 // PROFILE
 func (s *AuthService) ProfileHandler(c *fiber.Ctx) error {
 	claims := c.Locals("claims").(*utils.Claims)
@@ -151,10 +313,22 @@ func (s *AuthService) ProfileHandler(c *fiber.Ctx) error {
 	return c.JSON(user)
 }
 
-// LOGOUT
+// LOGOUT: hanya sesi (perangkat) saat ini; access token yang dipakai
+// langsung masuk denylist
 func (s *AuthService) LogoutHandler(c *fiber.Ctx) error {
 	claims := c.Locals("claims").(*utils.Claims)
-	err := s.tokenRepo.DeleteByUserID(c.Context(), claims.UserID)
+
+	var err error
+	if claims.SessionID != "" {
+		err = s.tokenRepo.RevokeFamily(c.Context(), claims.SessionID)
+	} else {
+		// token lama tanpa sid: tidak tahu sesinya, cabut semua
+		_, err = s.tokenRepo.RevokeAllSessions(c.Context(), claims.UserID)
+	}
+	if err != nil {
+		return c.Status(500).JSON(fiber.Map{"error": "logout_failed"})
+	}
+	err = s.accessRepo.Revoke(c.Context(), claims.ID, claims.UserID, claims.ExpiresAt.Time)
 	if err != nil {
 		return c.Status(500).JSON(fiber.Map{"error": "logout_failed"})
 	}
@@ -2,6 +2,8 @@ package service
 
 import (
 	"bytes"
+	"context"
+	"database/sql"
 	"encoding/json"
 	"errors"
 	"net/http"
@@ -10,10 +12,12 @@ import (
 	"time"
 
 	"uas/app/model"
+	"uas/app/repository"
 	"uas/app/repository/mocks"
 	"uas/utils"
 
 	"github.com/gofiber/fiber/v2"
+	"github.com/golang-jwt/jwt/v5"
 	"github.com/stretchr/testify/assert"
 	"github.com/stretchr/testify/mock"
 )
@@ -22,14 +26,57 @@ import (
 // SETUP
 // =======================
 
+func testKeySet() *utils.KeySet {
+	keys := utils.NewKeySet("uas", []string{"uas-api"}, time.Hour)
+	key, _ := utils.NewSigningKey("test", utils.AlgHS256, []byte("test-secret"), nil, nil)
+	keys.Add(key, true)
+	return keys
+}
+
+// LoginGuard yang tidak pernah mengunci
+func openLoginGuard() *LoginGuard {
+	throttle := new(mocks.MockLoginThrottleRepository)
+	throttle.On("LockedUntil", mock.Anything, mock.Anything).Return(time.Time{}, nil)
+	throttle.On("RecordFailure", mock.Anything, mock.Anything, mock.Anything).Return(1, nil)
+	throttle.On("Reset", mock.Anything, mock.Anything).Return(false, nil)
+	return NewLoginGuard(throttle, new(mocks.MockAuditRepository), testGuardOptions)
+}
+
+// User tanpa 2FA: login langsung mendapat JWT
+func noTwoFactor() *mocks.MockTwoFactorRepository {
+	twoFactor := new(mocks.MockTwoFactorRepository)
+	twoFactor.On("Status", mock.Anything, mock.Anything).Return(&model.TwoFactorStatus{}, nil)
+	return twoFactor
+}
+
+var testGuardOptions = LoginGuardOptions{
+	MaxFailures:   3,
+	IPMaxFailures: 10,
+	LockoutBase:   30 * time.Second,
+	LockoutMax:    time.Hour,
+	FailureWindow: 15 * time.Minute,
+}
+
+const (
+	testSessionID = "0b8a3c44-0a5e-4a57-9a51-3f0f3c3f0001"
+	testTokenID   = "5d7e1f20-6c1b-4f59-8d0e-7a9b2c3d0001"
+)
+
+var testTokenExpiry = time.Now().Add(15 * time.Minute).Truncate(time.Second)
+
 func setupAuthApp(service *AuthService) *fiber.App {
 	app := fiber.New()
 
 	// mock auth middleware (claims)
 	app.Use(func(c *fiber.Ctx) error {
 		c.Locals("claims", &utils.Claims{
-			UserID: 1,
-			Role:   "admin",
+			UserID:    1,
+			Role:      "admin",
+			SessionID: testSessionID,
+			RegisteredClaims: jwt.RegisteredClaims{
+				ID:        testTokenID,
+				ExpiresAt: jwt.NewNumericDate(testTokenExpiry),
+			},
 		})
 		return c.Next()
 	})
@@ -38,6 +85,9 @@ func setupAuthApp(service *AuthService) *fiber.App {
 	app.Post("/refresh", service.RefreshHandler)
 	app.Get("/profile", service.ProfileHandler)
 	app.Post("/logout", service.LogoutHandler)
+	app.Get("/sessions", service.ListSessionsHandler)
+	app.Delete("/sessions", service.RevokeAllSessionsHandler)
+	app.Delete("/sessions/:id", service.RevokeSessionHandler)
 
 	return app
 }
@@ -50,7 +100,7 @@ func TestAuth_Login_Success(t *testing.T) {
 	userRepo := new(mocks.MockUserRepository)
 	tokenRepo := new(mocks.MockRefreshTokenRepository)
 
-	service := NewAuthService(userRepo, tokenRepo)
+	service := NewAuthService(userRepo, tokenRepo, new(mocks.MockAccessTokenRepository), noTwoFactor(), testKeySet(), openLoginGuard(), "UAS")
 
 	hash, _ := utils.HashPassword("secret")
 
@@ -68,13 +118,17 @@ func TestAuth_Login_Success(t *testing.T) {
 	userRepo.On("GetPermissionsByUserID", int64(1)).
 		Return([]string{"user.read"}, nil)
 
-	output, err := service.Login(LoginInput{
+	output, err := service.Login(context.Background(), LoginInput{
 		Username: "test",
 		Password: "secret",
-	})
+	}, "10.0.0.1")
 
 	assert.NoError(t, err)
 	assert.NotEmpty(t, output.Token)
+
+	claims, err := service.keys.ParseToken(output.Token)
+	assert.NoError(t, err)
+	assert.Equal(t, "admin", claims.Role)
 	assert.Equal(t, user.Username, output.User.Username)
 
 	userRepo.AssertExpectations(t)
@@ -88,7 +142,7 @@ func TestAuth_LoginHandler_Success(t *testing.T) {
 	userRepo := new(mocks.MockUserRepository)
 	tokenRepo := new(mocks.MockRefreshTokenRepository)
 
-	service := NewAuthService(userRepo, tokenRepo)
+	service := NewAuthService(userRepo, tokenRepo, new(mocks.MockAccessTokenRepository), noTwoFactor(), testKeySet(), openLoginGuard(), "UAS")
 	app := setupAuthApp(service)
 
 	hash, _ := utils.HashPassword("secret")
@@ -107,13 +161,18 @@ func TestAuth_LoginHandler_Success(t *testing.T) {
 	userRepo.On("GetPermissionsByUserID", int64(1)).
 		Return([]string{"user.read"}, nil)
 
+	var savedHash string
+	var session *model.AuthSession
 	tokenRepo.On(
 		"Save",
 		mock.Anything,
-		int64(1),
 		mock.Anything,
 		mock.Anything,
-	).Return(nil)
+		mock.Anything,
+	).Run(func(args mock.Arguments) {
+		session = args.Get(1).(*model.AuthSession)
+		savedHash = args.String(2)
+	}).Return(nil)
 
 	body, _ := json.Marshal(LoginInput{
 		Username: "test",
@@ -126,12 +185,29 @@ func TestAuth_LoginHandler_Success(t *testing.T) {
 		bytes.NewBuffer(body),
 	)
 	req.Header.Set("Content-Type", "application/json")
+	req.Header.Set("User-Agent", "Mozilla/5.0 (Android)")
 
 	resp, err := app.Test(req)
 
 	assert.NoError(t, err)
 	assert.Equal(t, 200, resp.StatusCode)
 
+	// sesi baru dengan metadata perangkat, sid access token = id sesi
+	assert.Equal(t, int64(1), session.UserID)
+	assert.Equal(t, "Mozilla/5.0 (Android)", session.UserAgent)
+
+	// yang disimpan hanya hash dari refresh token yang dikirim ke client
+	var out map[string]interface{}
+	assert.NoError(t, json.NewDecoder(resp.Body).Decode(&out))
+	refresh, _ := out["refresh_token"].(string)
+	assert.NotEmpty(t, refresh)
+	access, _ := out["access_token"].(string)
+	claims, err := service.keys.ParseToken(access)
+	assert.NoError(t, err)
+	assert.Equal(t, session.ID, claims.SessionID)
+	assert.Equal(t, utils.HashToken(refresh), savedHash)
+	assert.NotContains(t, savedHash, refresh)
+
 	userRepo.AssertExpectations(t)
 	tokenRepo.AssertExpectations(t)
 }
@@ -140,55 +216,160 @@ func TestAuth_LoginHandler_Success(t *testing.T) {
 // REFRESH TOKEN
 // =======================
 
+func refreshRequest(token string) *http.Request {
+	body, _ := json.Marshal(model.RefreshTokenRequest{RefreshToken: token})
+	req := httptest.NewRequest(http.MethodPost, "/refresh", bytes.NewBuffer(body))
+	req.Header.Set("Content-Type", "application/json")
+	return req
+}
+
+func activeRefreshToken() *model.RefreshToken {
+	return &model.RefreshToken{
+		ID:        5,
+		UserID:    1,
+		FamilyID:  "family-1",
+		TokenHash: utils.HashToken("refresh-token"),
+		ExpiresAt: time.Now().Add(1 * time.Hour),
+	}
+}
+
 func TestAuth_RefreshHandler_Success(t *testing.T) {
 	userRepo := new(mocks.MockUserRepository)
 	tokenRepo := new(mocks.MockRefreshTokenRepository)
 
-	service := NewAuthService(userRepo, tokenRepo)
+	service := NewAuthService(userRepo, tokenRepo, new(mocks.MockAccessTokenRepository), nil, testKeySet(), nil, "UAS")
 	app := setupAuthApp(service)
 
-	rt := &model.RefreshToken{
-		UserID:    1,
-		Token:     "refresh-token",
-		ExpiresAt: time.Now().Add(1 * time.Hour),
-	}
+	rt := activeRefreshToken()
 
 	tokenRepo.On(
 		"Get",
 		mock.Anything,
-		"refresh-token",
+		utils.HashToken("refresh-token"),
 	).Return(rt, nil)
 
 	userRepo.On(
 		"FindById",
 		mock.Anything,
 		int64(1),
-	)	.Return(&model.UserResponse{
-		ID:       1,
-		Username: "test",
-		Role:     "admin",
+	).Return(&model.UserResponse{
+		ID:           1,
+		Username:     "test",
+		Role:         "admin",
+		IsActive:     true,
+		TokenVersion: 4,
 	}, nil)
 
-	body, _ := json.Marshal(model.RefreshTokenRequest{
-		RefreshToken: "refresh-token",
-	})
+	var nextHash string
+	tokenRepo.On("Rotate", mock.Anything, rt, mock.Anything, mock.Anything, mock.Anything).
+		Run(func(args mock.Arguments) { nextHash = args.String(2) }).
+		Return(nil)
 
-	req := httptest.NewRequest(
-		http.MethodPost,
-		"/refresh",
-		bytes.NewBuffer(body),
-	)
-	req.Header.Set("Content-Type", "application/json")
-
-	resp, err := app.Test(req)
+	resp, err := app.Test(refreshRequest("refresh-token"))
 
 	assert.NoError(t, err)
 	assert.Equal(t, 200, resp.StatusCode)
 
+	// token baru berbeda, yang disimpan hanya hash-nya
+	var out map[string]interface{}
+	assert.NoError(t, json.NewDecoder(resp.Body).Decode(&out))
+	next, _ := out["refresh_token"].(string)
+	assert.NotEqual(t, "refresh-token", next)
+	assert.Equal(t, utils.HashToken(next), nextHash)
+
+	// access token baru membawa jti dan token_version terkini
+	claims, err := testKeySet().ParseToken(out["access_token"].(string))
+	assert.NoError(t, err)
+	assert.NotEmpty(t, claims.ID)
+	assert.Equal(t, int64(4), claims.TokenVersion)
+	assert.Equal(t, "family-1", claims.SessionID)
+
 	userRepo.AssertExpectations(t)
 	tokenRepo.AssertExpectations(t)
 }
 
+// Token yang sudah di-rotate dipakai lagi → seluruh family dicabut
+func TestAuth_RefreshHandler_ReuseRevokesFamily(t *testing.T) {
+	userRepo := new(mocks.MockUserRepository)
+	tokenRepo := new(mocks.MockRefreshTokenRepository)
+	app := setupAuthApp(NewAuthService(userRepo, tokenRepo, new(mocks.MockAccessTokenRepository), nil, testKeySet(), nil, "UAS"))
+
+	rt := activeRefreshToken()
+	rotatedAt := time.Now().Add(-time.Minute)
+	rt.RotatedAt = &rotatedAt
+	tokenRepo.On("Get", mock.Anything, utils.HashToken("refresh-token")).Return(rt, nil)
+	tokenRepo.On("RevokeFamily", mock.Anything, "family-1").Return(nil)
+
+	resp, err := app.Test(refreshRequest("refresh-token"))
+	assert.NoError(t, err)
+	assert.Equal(t, 401, resp.StatusCode)
+
+	var out map[string]interface{}
+	assert.NoError(t, json.NewDecoder(resp.Body).Decode(&out))
+	assert.Equal(t, "refresh_token_reused", out["error"])
+
+	tokenRepo.AssertExpectations(t)
+	tokenRepo.AssertNotCalled(t, "Rotate", mock.Anything, mock.Anything, mock.Anything, mock.Anything, mock.Anything)
+	userRepo.AssertNotCalled(t, "FindById", mock.Anything, mock.Anything)
+}
+
+// Dua request paralel dengan token yang sama: yang kalah dianggap reuse
+func TestAuth_RefreshHandler_ConcurrentRotationIsReuse(t *testing.T) {
+	userRepo := new(mocks.MockUserRepository)
+	tokenRepo := new(mocks.MockRefreshTokenRepository)
+	app := setupAuthApp(NewAuthService(userRepo, tokenRepo, new(mocks.MockAccessTokenRepository), nil, testKeySet(), nil, "UAS"))
+
+	rt := activeRefreshToken()
+	tokenRepo.On("Get", mock.Anything, utils.HashToken("refresh-token")).Return(rt, nil)
+	userRepo.On("FindById", mock.Anything, int64(1)).
+		Return(&model.UserResponse{ID: 1, Username: "test", Role: "admin", IsActive: true}, nil)
+	tokenRepo.On("Rotate", mock.Anything, rt, mock.Anything, mock.Anything, mock.Anything).
+		Return(repository.ErrRefreshTokenReused)
+	tokenRepo.On("RevokeFamily", mock.Anything, "family-1").Return(nil)
+
+	resp, err := app.Test(refreshRequest("refresh-token"))
+	assert.NoError(t, err)
+	assert.Equal(t, 401, resp.StatusCode)
+	tokenRepo.AssertExpectations(t)
+}
+
+func TestAuth_RefreshHandler_RevokedFamily(t *testing.T) {
+	userRepo := new(mocks.MockUserRepository)
+	tokenRepo := new(mocks.MockRefreshTokenRepository)
+	app := setupAuthApp(NewAuthService(userRepo, tokenRepo, new(mocks.MockAccessTokenRepository), nil, testKeySet(), nil, "UAS"))
+
+	rt := activeRefreshToken()
+	revokedAt := time.Now()
+	rt.RevokedAt = &revokedAt
+	tokenRepo.On("Get", mock.Anything, utils.HashToken("refresh-token")).Return(rt, nil)
+
+	resp, err := app.Test(refreshRequest("refresh-token"))
+	assert.NoError(t, err)
+	assert.Equal(t, 401, resp.StatusCode)
+	tokenRepo.AssertNotCalled(t, "Rotate", mock.Anything, mock.Anything, mock.Anything, mock.Anything, mock.Anything)
+}
+
+func TestAuth_RefreshHandler_InactiveUser(t *testing.T) {
+	userRepo := new(mocks.MockUserRepository)
+	tokenRepo := new(mocks.MockRefreshTokenRepository)
+	app := setupAuthApp(NewAuthService(userRepo, tokenRepo, new(mocks.MockAccessTokenRepository), nil, testKeySet(), nil, "UAS"))
+
+	rt := activeRefreshToken()
+	tokenRepo.On("Get", mock.Anything, utils.HashToken("refresh-token")).Return(rt, nil)
+	userRepo.On("FindById", mock.Anything, int64(1)).
+		Return(&model.UserResponse{ID: 1, Username: "test", Role: "admin", IsActive: false}, nil)
+	tokenRepo.On("RevokeFamily", mock.Anything, "family-1").Return(nil)
+
+	resp, err := app.Test(refreshRequest("refresh-token"))
+	assert.NoError(t, err)
+	assert.Equal(t, 401, resp.StatusCode)
+
+	var out map[string]interface{}
+	assert.NoError(t, json.NewDecoder(resp.Body).Decode(&out))
+	assert.Equal(t, "user_inactive", out["error"])
+	tokenRepo.AssertNotCalled(t, "Rotate", mock.Anything, mock.Anything, mock.Anything, mock.Anything, mock.Anything)
+}
+
 // =======================
 // LOGOUT
 // =======================
@@ -196,14 +377,24 @@ func TestAuth_RefreshHandler_Success(t *testing.T) {
 func TestAuth_LogoutHandler_Success(t *testing.T) {
 	userRepo := new(mocks.MockUserRepository)
 	tokenRepo := new(mocks.MockRefreshTokenRepository)
+	accessRepo := new(mocks.MockAccessTokenRepository)
 
-	service := NewAuthService(userRepo, tokenRepo)
+	service := NewAuthService(userRepo, tokenRepo, accessRepo, nil, testKeySet(), nil, "UAS")
 	app := setupAuthApp(service)
 
+	// hanya sesi perangkat ini yang dicabut
 	tokenRepo.On(
-		"DeleteByUserID",
+		"RevokeFamily",
+		mock.Anything,
+		testSessionID,
+	).Return(nil)
+	// access token yang dipakai masuk denylist sampai exp-nya
+	accessRepo.On(
+		"Revoke",
 		mock.Anything,
+		testTokenID,
 		int64(1),
+		testTokenExpiry,
 	).Return(nil)
 
 	req := httptest.NewRequest(
@@ -218,6 +409,77 @@ func TestAuth_LogoutHandler_Success(t *testing.T) {
 	assert.Equal(t, 200, resp.StatusCode)
 
 	tokenRepo.AssertExpectations(t)
+	tokenRepo.AssertNotCalled(t, "RevokeAllSessions", mock.Anything, mock.Anything)
+	accessRepo.AssertExpectations(t)
+	accessRepo.AssertNotCalled(t, "RevokeAll", mock.Anything, mock.Anything)
+}
+
+// =======================
+// SESSIONS
+// =======================
+
+func TestAuth_ListSessions_MarksCurrent(t *testing.T) {
+	userRepo := new(mocks.MockUserRepository)
+	tokenRepo := new(mocks.MockRefreshTokenRepository)
+	app := setupAuthApp(NewAuthService(userRepo, tokenRepo, new(mocks.MockAccessTokenRepository), nil, testKeySet(), nil, "UAS"))
+
+	tokenRepo.On("ListSessions", mock.Anything, int64(1)).Return([]model.AuthSession{
+		{ID: testSessionID, UserID: 1, UserAgent: "laptop"},
+		{ID: "other-session", UserID: 1, UserAgent: "phone"},
+	}, nil)
+
+	resp, err := app.Test(httptest.NewRequest(http.MethodGet, "/sessions", nil))
+	assert.NoError(t, err)
+	assert.Equal(t, 200, resp.StatusCode)
+
+	var out struct {
+		Data []model.AuthSession `json:"data"`
+	}
+	assert.NoError(t, json.NewDecoder(resp.Body).Decode(&out))
+	assert.Len(t, out.Data, 2)
+	assert.True(t, out.Data[0].Current)
+	assert.False(t, out.Data[1].Current)
+}
+
+func TestAuth_RevokeSession(t *testing.T) {
+	userRepo := new(mocks.MockUserRepository)
+	tokenRepo := new(mocks.MockRefreshTokenRepository)
+	app := setupAuthApp(NewAuthService(userRepo, tokenRepo, new(mocks.MockAccessTokenRepository), nil, testKeySet(), nil, "UAS"))
+
+	other := "0b8a3c44-0a5e-4a57-9a51-3f0f3c3f0002"
+	missing := "0b8a3c44-0a5e-4a57-9a51-3f0f3c3f0003"
+	tokenRepo.On("RevokeSession", mock.Anything, int64(1), other).Return(nil)
+	// sesi milik user lain / sudah dicabut
+	tokenRepo.On("RevokeSession", mock.Anything, int64(1), missing).Return(sql.ErrNoRows)
+
+	resp, err := app.Test(httptest.NewRequest(http.MethodDelete, "/sessions/"+other, nil))
+	assert.NoError(t, err)
+	assert.Equal(t, 200, resp.StatusCode)
+
+	resp, err = app.Test(httptest.NewRequest(http.MethodDelete, "/sessions/"+missing, nil))
+	assert.NoError(t, err)
+	assert.Equal(t, 404, resp.StatusCode)
+
+	resp, err = app.Test(httptest.NewRequest(http.MethodDelete, "/sessions/not-a-uuid", nil))
+	assert.NoError(t, err)
+	assert.Equal(t, 400, resp.StatusCode)
+}
+
+func TestAuth_RevokeAllSessions(t *testing.T) {
+	userRepo := new(mocks.MockUserRepository)
+	tokenRepo := new(mocks.MockRefreshTokenRepository)
+	accessRepo := new(mocks.MockAccessTokenRepository)
+	app := setupAuthApp(NewAuthService(userRepo, tokenRepo, accessRepo, nil, testKeySet(), nil, "UAS"))
+
+	tokenRepo.On("RevokeAllSessions", mock.Anything, int64(1)).Return(int64(3), nil)
+	// access token di perangkat lain ikut mati lewat token_version
+	accessRepo.On("RevokeAll", mock.Anything, int64(1)).Return(nil)
+
+	resp, err := app.Test(httptest.NewRequest(http.MethodDelete, "/sessions", nil))
+	assert.NoError(t, err)
+	assert.Equal(t, 200, resp.StatusCode)
+	tokenRepo.AssertExpectations(t)
+	accessRepo.AssertExpectations(t)
 }
 
 // =======================
@@ -228,17 +490,17 @@ func TestAuth_Login_InvalidCredential(t *testing.T) {
 	userRepo := new(mocks.MockUserRepository)
 	tokenRepo := new(mocks.MockRefreshTokenRepository)
 
-	service := NewAuthService(userRepo, tokenRepo)
+	service := NewAuthService(userRepo, tokenRepo, new(mocks.MockAccessTokenRepository), noTwoFactor(), testKeySet(), openLoginGuard(), "UAS")
 
 	userRepo.On(
 		"FindByUsernameOrEmail",
 		"test",
 	).Return(nil, errors.New("not found"))
 
-	_, err := service.Login(LoginInput{
+	_, err := service.Login(context.Background(), LoginInput{
 		Username: "test",
 		Password: "wrong",
-	})
+	}, "10.0.0.1")
 
 	assert.Error(t, err)
 	assert.Equal(t, "invalid_credentials", err.Error())
@@ -0,0 +1,122 @@
+package service
+
+import (
+	"database/sql"
+	"errors"
+
+	"uas/utils"
+
+	"github.com/gofiber/fiber/v2"
+	"github.com/google/uuid"
+)
+
+// Batas panjang User-Agent yang disimpan di auth_sessions
+const maxUserAgentLength = 512
+
+// GET /auth/sessions
+// Daftar perangkat yang sedang login; sesi token ini ditandai current
+func (s *AuthService) ListSessionsHandler(c *fiber.Ctx) error {
+	claims := c.Locals("claims").(*utils.Claims)
+
+	sessions, err := s.tokenRepo.ListSessions(c.Context(), claims.UserID)
+	if err != nil {
+		return c.Status(500).JSON(fiber.Map{"error": "failed_get_sessions"})
+	}
+	for i := range sessions {
+		sessions[i].Current = sessions[i].ID == claims.SessionID
+	}
+	return c.JSON(fiber.Map{"data": sessions})
+}
+
+// DELETE /auth/sessions/:id
+// Logout satu perangkat (boleh sesi sendiri maupun perangkat lain)
+func (s *AuthService) RevokeSessionHandler(c *fiber.Ctx) error {
+	claims := c.Locals("claims").(*utils.Claims)
+	sessionID := c.Params("id")
+	if _, err := uuid.Parse(sessionID); err != nil {
+		return c.Status(400).JSON(fiber.Map{"error": "invalid_session_id"})
+	}
+
+	err := s.tokenRepo.RevokeSession(c.Context(), claims.UserID, sessionID)
+	if err != nil {
+		if errors.Is(err, sql.ErrNoRows) {
+			return c.Status(404).JSON(fiber.Map{"error": "session_not_found"})
+		}
+		return c.Status(500).JSON(fiber.Map{"error": "failed_revoke_session"})
+	}
+	return c.JSON(fiber.Map{
+		"message": "session revoked",
+		"id":      sessionID,
+	})
+}
+
+// DELETE /auth/sessions
+// Logout dari semua perangkat, termasuk perangkat ini
+func (s *AuthService) RevokeAllSessionsHandler(c *fiber.Ctx) error {
+	claims := c.Locals("claims").(*utils.Claims)
+
+	n, err := s.revokeAll(c, claims.UserID)
+	if err != nil {
+		return c.Status(500).JSON(fiber.Map{"error": "logout_failed"})
+	}
+	return c.JSON(fiber.Map{
+		"message":  "logged_out_everywhere",
+		"sessions": n,
+	})
+}
+
+// ADMIN: DELETE /admin/users/:id/sessions
+// Paksa logout semua sesi seorang user (mis. akun dicurigai bocor)
+func (s *AuthService) AdminRevokeUserSessionsHandler(c *fiber.Ctx) error {
+	userID, err := c.ParamsInt("id")
+	if err != nil || userID <= 0 {
+		return c.Status(400).JSON(fiber.Map{"error": "invalid_user_id"})
+	}
+
+	n, err := s.revokeAll(c, int64(userID))
+	if err != nil {
+		return c.Status(500).JSON(fiber.Map{"error": "failed_revoke_sessions"})
+	}
+	return c.JSON(fiber.Map{
+		"message":  "sessions revoked",
+		"user_id":  userID,
+		"sessions": n,
+	})
+}
+
+// ADMIN: DELETE /admin/users/:id/lockout
+// Buka kunci login akun sebelum waktunya (tercatat di audit_logs)
+func (s *AuthService) AdminUnlockUserHandler(c *fiber.Ctx) error {
+	userID, err := c.ParamsInt("id")
+	if err != nil || userID <= 0 {
+		return c.Status(400).JSON(fiber.Map{"error": "invalid_user_id"})
+	}
+	claims := c.Locals("claims").(*utils.Claims)
+
+	wasLocked, err := s.guard.Unlock(c.Context(), int64(userID), claims.UserID, c.IP())
+	if err != nil {
+		return c.Status(500).JSON(fiber.Map{"error": "failed_unlock_user"})
+	}
+	return c.JSON(fiber.Map{
+		"message":    "user unlocked",
+		"user_id":    userID,
+		"was_locked": wasLocked,
+	})
+}
+
+// revokeAll mencabut semua refresh token (sesi) sekaligus semua access
+// token user lewat token_version
+func (s *AuthService) revokeAll(c *fiber.Ctx, userID int64) (int64, error) {
+	n, err := s.tokenRepo.RevokeAllSessions(c.Context(), userID)
+	if err != nil {
+		return 0, err
+	}
+	return n, s.accessRepo.RevokeAll(c.Context(), userID)
+}
+
+func truncate(s string, n int) string {
+	if len(s) <= n {
+		return s
+	}
+	return s[:n]
+}
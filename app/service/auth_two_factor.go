@@ -0,0 +1,383 @@
+package service
+
+import (
+	"context"
+	"database/sql"
+	"errors"
+	"log"
+	"strconv"
+	"time"
+
+	"uas/app/model"
+	"uas/utils"
+
+	"github.com/gofiber/fiber/v2"
+)
+
+const (
+	// Umur token tahap kedua login dan batas kode salah per token
+	loginChallengeTTL    = 5 * time.Minute
+	maxChallengeAttempts = 5
+
+	recoveryCodeCount = 10
+)
+
+// newLoginChallenge: token acak untuk tahap kedua; yang disimpan hanya hash-nya
+func (s *AuthService) newLoginChallenge(ctx context.Context, userID int64, enrollment bool) (*LoginChallenge, error) {
+	token, err := utils.NewOpaqueToken()
+	if err != nil {
+		return nil, err
+	}
+	expiresAt := time.Now().Add(loginChallengeTTL)
+	if err := s.twoFactor.CreateChallenge(ctx, userID, utils.HashToken(token), expiresAt); err != nil {
+		return nil, err
+	}
+	return &LoginChallenge{Token: token, ExpiresIn: loginChallengeTTL, EnrollmentRequired: enrollment}, nil
+}
+
+// POST /auth/2fa/verify (public)
+// Tahap kedua login: challenge_token + kode TOTP atau recovery code ditukar
+// dengan access / refresh token. Jika enrollment belum dikonfirmasi (2FA
+// diwajibkan role), kode pertama sekaligus mengaktifkan 2FA dan respons
+// berisi recovery_codes yang hanya ditampilkan sekali.
+func (s *AuthService) VerifyTwoFactorHandler(c *fiber.Ctx) error {
+	var input model.TwoFactorVerifyRequest
+	if err := c.BodyParser(&input); err != nil || input.ChallengeToken == "" {
+		return c.Status(400).JSON(fiber.Map{"error": "invalid_request"})
+	}
+	if (input.Code == "") == (input.RecoveryCode == "") {
+		return c.Status(422).JSON(fiber.Map{
+			"error":  "validation_failed",
+			"fields": model.ValidationErrors{"code": "either code or recovery_code is required"},
+		})
+	}
+	ctx := c.Context()
+	tokenHash := utils.HashToken(input.ChallengeToken)
+
+	// 1. Challenge masih berlaku
+	ch, status, errCode := s.loadChallenge(ctx, tokenHash)
+	if ch == nil {
+		return c.Status(status).JSON(fiber.Map{"error": errCode})
+	}
+
+	// 2. Akun / IP sedang dikunci?
+	if err := s.guard.Check(ctx, ch.UserID, c.IP()); err != nil {
+		return lockedResponse(c, err)
+	}
+
+	// 3. User harus masih aktif
+	user, err := s.userRepo.FindCredentialsByID(ctx, ch.UserID)
+	if err != nil {
+		return c.Status(401).JSON(fiber.Map{"error": "invalid_challenge"})
+	}
+	if !user.IsActive {
+		return c.Status(401).JSON(fiber.Map{"error": "user_inactive"})
+	}
+
+	// 4. Cek kode (atau konfirmasi enrollment saat login)
+	secret, err := s.twoFactor.GetSecret(ctx, user.ID)
+	if errors.Is(err, sql.ErrNoRows) {
+		return c.Status(409).JSON(fiber.Map{"error": "enrollment_not_started"})
+	}
+	if err != nil {
+		return c.Status(500).JSON(fiber.Map{"error": "failed_verify_2fa"})
+	}
+	var recoveryCodes []string
+	var ok bool
+	if secret.Confirmed {
+		ok, err = s.checkSecondFactor(ctx, secret, input.Code, input.RecoveryCode)
+	} else if input.Code != "" {
+		recoveryCodes, ok, err = s.confirmEnrollment(ctx, secret, input.Code)
+	}
+	if err != nil {
+		return c.Status(500).JSON(fiber.Map{"error": "failed_verify_2fa"})
+	}
+	if !ok {
+		return s.failChallenge(c, tokenHash, user.ID)
+	}
+
+	// 5. Challenge hanya bisa ditukar sekali
+	if _, err := s.twoFactor.ConsumeChallenge(ctx, tokenHash); err != nil {
+		return c.Status(401).JSON(fiber.Map{"error": "invalid_challenge"})
+	}
+	s.guard.Success(ctx, user.ID, c.IP())
+
+	output, err := s.completeLogin(user)
+	if err != nil {
+		return c.Status(500).JSON(fiber.Map{"error": "token_failed"})
+	}
+	var extra fiber.Map
+	if recoveryCodes != nil {
+		extra = fiber.Map{"recovery_codes": recoveryCodes}
+	}
+	return s.loginResponse(c, output, extra)
+}
+
+// POST /auth/2fa/setup (public)
+// Enrollment wajib saat login: challenge_token dari LoginHandler ditukar
+// dengan secret baru, lalu dikonfirmasi lewat /auth/2fa/verify
+func (s *AuthService) SetupTwoFactorHandler(c *fiber.Ctx) error {
+	var input model.TwoFactorSetupRequest
+	if err := c.BodyParser(&input); err != nil || input.ChallengeToken == "" {
+		return c.Status(400).JSON(fiber.Map{"error": "invalid_request"})
+	}
+	ch, status, errCode := s.loadChallenge(c.Context(), utils.HashToken(input.ChallengeToken))
+	if ch == nil {
+		return c.Status(status).JSON(fiber.Map{"error": errCode})
+	}
+	user, err := s.userRepo.FindCredentialsByID(c.Context(), ch.UserID)
+	if err != nil || !user.IsActive {
+		return c.Status(401).JSON(fiber.Map{"error": "invalid_challenge"})
+	}
+	return s.startEnrollment(c, user)
+}
+
+// GET /auth/2fa
+func (s *AuthService) TwoFactorStatusHandler(c *fiber.Ctx) error {
+	claims := c.Locals("claims").(*utils.Claims)
+
+	status, err := s.twoFactor.Status(c.Context(), claims.UserID)
+	if err != nil {
+		return c.Status(500).JSON(fiber.Map{"error": "failed_get_2fa_status"})
+	}
+	return c.JSON(status)
+}
+
+// POST /auth/2fa/enroll
+// Secret baru (belum aktif) + URI otpauth:// untuk QR code
+func (s *AuthService) EnrollTwoFactorHandler(c *fiber.Ctx) error {
+	claims := c.Locals("claims").(*utils.Claims)
+
+	user, err := s.userRepo.FindCredentialsByID(c.Context(), claims.UserID)
+	if err != nil {
+		return c.Status(404).JSON(fiber.Map{"error": "user_not_found"})
+	}
+	return s.startEnrollment(c, user)
+}
+
+// POST /auth/2fa/confirm
+// Kode pertama dari authenticator app mengaktifkan 2FA; recovery code
+// dikembalikan sekali ini saja
+func (s *AuthService) ConfirmTwoFactorHandler(c *fiber.Ctx) error {
+	claims := c.Locals("claims").(*utils.Claims)
+	var input model.TwoFactorCodeRequest
+	if err := c.BodyParser(&input); err != nil || input.Code == "" {
+		return c.Status(400).JSON(fiber.Map{"error": "invalid_request"})
+	}
+	ctx := c.Context()
+
+	if err := s.guard.Check(ctx, claims.UserID, c.IP()); err != nil {
+		return lockedResponse(c, err)
+	}
+	secret, err := s.twoFactor.GetSecret(ctx, claims.UserID)
+	if errors.Is(err, sql.ErrNoRows) {
+		return c.Status(409).JSON(fiber.Map{"error": "enrollment_not_started"})
+	}
+	if err != nil {
+		return c.Status(500).JSON(fiber.Map{"error": "failed_confirm_2fa"})
+	}
+	if secret.Confirmed {
+		return c.Status(409).JSON(fiber.Map{"error": "two_factor_already_enabled"})
+	}
+
+	codes, ok, err := s.confirmEnrollment(ctx, secret, input.Code)
+	if err != nil {
+		return c.Status(500).JSON(fiber.Map{"error": "failed_confirm_2fa"})
+	}
+	if !ok {
+		s.guard.Failure(ctx, claims.UserID, c.IP())
+		return c.Status(400).JSON(fiber.Map{"error": "invalid_code"})
+	}
+	return c.JSON(fiber.Map{
+		"message":        "two_factor_enabled",
+		"recovery_codes": codes,
+	})
+}
+
+// POST /auth/2fa/recovery-codes
+// Ganti seluruh recovery code (yang lama tidak berlaku), butuh kode TOTP
+func (s *AuthService) RegenerateRecoveryCodesHandler(c *fiber.Ctx) error {
+	claims := c.Locals("claims").(*utils.Claims)
+	var input model.TwoFactorCodeRequest
+	if err := c.BodyParser(&input); err != nil || input.Code == "" {
+		return c.Status(400).JSON(fiber.Map{"error": "invalid_request"})
+	}
+	if err := s.guard.Check(c.Context(), claims.UserID, c.IP()); err != nil {
+		return lockedResponse(c, err)
+	}
+	if status, errCode := s.verifyUserCode(c, claims.UserID, input.Code); errCode != "" {
+		return c.Status(status).JSON(fiber.Map{"error": errCode})
+	}
+
+	codes, hashes, err := newRecoveryCodes()
+	if err != nil {
+		return c.Status(500).JSON(fiber.Map{"error": "failed_generate_recovery_codes"})
+	}
+	if err := s.twoFactor.ReplaceRecoveryCodes(c.Context(), claims.UserID, hashes); err != nil {
+		return c.Status(500).JSON(fiber.Map{"error": "failed_generate_recovery_codes"})
+	}
+	return c.JSON(fiber.Map{"recovery_codes": codes})
+}
+
+// DELETE /auth/2fa
+// Matikan 2FA (butuh kode TOTP); ditolak jika role mewajibkan 2FA
+func (s *AuthService) DisableTwoFactorHandler(c *fiber.Ctx) error {
+	claims := c.Locals("claims").(*utils.Claims)
+	var input model.TwoFactorCodeRequest
+	if err := c.BodyParser(&input); err != nil || input.Code == "" {
+		return c.Status(400).JSON(fiber.Map{"error": "invalid_request"})
+	}
+
+	status, err := s.twoFactor.Status(c.Context(), claims.UserID)
+	if err != nil {
+		return c.Status(500).JSON(fiber.Map{"error": "failed_disable_2fa"})
+	}
+	if status.Required {
+		return c.Status(409).JSON(fiber.Map{"error": "two_factor_required_by_role"})
+	}
+	if err := s.guard.Check(c.Context(), claims.UserID, c.IP()); err != nil {
+		return lockedResponse(c, err)
+	}
+	if code, errCode := s.verifyUserCode(c, claims.UserID, input.Code); errCode != "" {
+		return c.Status(code).JSON(fiber.Map{"error": errCode})
+	}
+
+	if err := s.twoFactor.Disable(c.Context(), claims.UserID); err != nil {
+		return c.Status(500).JSON(fiber.Map{"error": "failed_disable_2fa"})
+	}
+	return c.JSON(fiber.Map{"message": "two_factor_disabled"})
+}
+
+// loadChallenge: challenge dari hash token, atau status + kode error
+func (s *AuthService) loadChallenge(ctx context.Context, tokenHash string) (*model.LoginChallenge, int, string) {
+	ch, err := s.twoFactor.GetChallenge(ctx, tokenHash)
+	if err != nil {
+		if errors.Is(err, sql.ErrNoRows) {
+			return nil, 401, "invalid_challenge"
+		}
+		return nil, 500, "failed_check_challenge"
+	}
+	return ch, 0, ""
+}
+
+// failChallenge mencatat kode salah: per challenge (dibuang setelah
+// maxChallengeAttempts) dan lewat LoginGuard supaya akun ikut terkunci
+func (s *AuthService) failChallenge(c *fiber.Ctx, tokenHash string, userID int64) error {
+	ctx := c.Context()
+	attempts, err := s.twoFactor.FailChallenge(ctx, tokenHash)
+	if err == nil && attempts >= maxChallengeAttempts {
+		_, err = s.twoFactor.ConsumeChallenge(ctx, tokenHash)
+	}
+	if err != nil && !errors.Is(err, sql.ErrNoRows) {
+		log.Printf("2fa: fail challenge user %d: %v", userID, err)
+	}
+	s.guard.Failure(ctx, userID, c.IP())
+	return c.Status(401).JSON(fiber.Map{"error": "invalid_code"})
+}
+
+// verifyUserCode mengecek kode TOTP user yang sudah login (endpoint
+// protected); kode salah ikut dihitung LoginGuard. Cek lockout dulu di handler.
+func (s *AuthService) verifyUserCode(c *fiber.Ctx, userID int64, code string) (int, string) {
+	ctx := c.Context()
+	secret, err := s.twoFactor.GetSecret(ctx, userID)
+	if errors.Is(err, sql.ErrNoRows) || (err == nil && !secret.Confirmed) {
+		return 409, "two_factor_not_enabled"
+	}
+	if err != nil {
+		return 500, "failed_verify_2fa"
+	}
+	ok, err := s.checkSecondFactor(ctx, secret, code, "")
+	if err != nil {
+		return 500, "failed_verify_2fa"
+	}
+	if !ok {
+		s.guard.Failure(ctx, userID, c.IP())
+		return 400, "invalid_code"
+	}
+	return 0, ""
+}
+
+// checkSecondFactor: recovery code (sekali pakai) atau kode TOTP yang
+// langkahnya belum pernah dipakai
+func (s *AuthService) checkSecondFactor(ctx context.Context, secret *model.TOTPSecret, code, recoveryCode string) (bool, error) {
+	var err error
+	if recoveryCode != "" {
+		err = s.twoFactor.UseRecoveryCode(ctx, secret.UserID, hashRecoveryCode(recoveryCode))
+	} else {
+		step, ok := utils.ValidateTOTP(secret.Secret, code, time.Now())
+		if !ok {
+			return false, nil
+		}
+		err = s.twoFactor.UseStep(ctx, secret.UserID, step)
+	}
+	if errors.Is(err, sql.ErrNoRows) {
+		return false, nil
+	}
+	return err == nil, err
+}
+
+// confirmEnrollment mengaktifkan secret yang belum dikonfirmasi dengan code
+// dan membuat recovery code baru
+func (s *AuthService) confirmEnrollment(ctx context.Context, secret *model.TOTPSecret, code string) ([]string, bool, error) {
+	step, ok := utils.ValidateTOTP(secret.Secret, code, time.Now())
+	if !ok {
+		return nil, false, nil
+	}
+	codes, hashes, err := newRecoveryCodes()
+	if err != nil {
+		return nil, false, err
+	}
+	err = s.twoFactor.Confirm(ctx, secret.UserID, step, hashes)
+	if errors.Is(err, sql.ErrNoRows) {
+		return nil, false, nil
+	}
+	if err != nil {
+		return nil, false, err
+	}
+	return codes, true, nil
+}
+
+func (s *AuthService) startEnrollment(c *fiber.Ctx, user *model.User) error {
+	secret, err := utils.NewTOTPSecret()
+	if err != nil {
+		return c.Status(500).JSON(fiber.Map{"error": "failed_enroll_2fa"})
+	}
+	if err := s.twoFactor.SaveSecret(c.Context(), user.ID, secret); err != nil {
+		if errors.Is(err, sql.ErrNoRows) {
+			return c.Status(409).JSON(fiber.Map{"error": "two_factor_already_enabled"})
+		}
+		return c.Status(500).JSON(fiber.Map{"error": "failed_enroll_2fa"})
+	}
+	return c.JSON(model.TwoFactorEnrollment{
+		Secret:     secret,
+		OTPAuthURI: utils.TOTPURI(s.totpIssuer, user.Username, secret),
+	})
+}
+
+// newRecoveryCodes: kode untuk ditampilkan ke user + hash untuk disimpan
+func newRecoveryCodes() ([]string, []string, error) {
+	codes, err := utils.NewRecoveryCodes(recoveryCodeCount)
+	if err != nil {
+		return nil, nil, err
+	}
+	hashes := make([]string, len(codes))
+	for i, code := range codes {
+		hashes[i] = hashRecoveryCode(code)
+	}
+	return codes, hashes, nil
+}
+
+func hashRecoveryCode(code string) string {
+	return utils.HashToken(utils.NormalizeRecoveryCode(code))
+}
+
+// lockedResponse: 429 + Retry-After untuk *LockedError, selain itu 500
+func lockedResponse(c *fiber.Ctx, err error) error {
+	var locked *LockedError
+	if !errors.As(err, &locked) {
+		return c.Status(500).JSON(fiber.Map{"error": "failed_check_lockout"})
+	}
+	retry := int(locked.RetryAfter.Seconds()) + 1
+	c.Set(fiber.HeaderRetryAfter, strconv.Itoa(retry))
+	return c.Status(429).JSON(fiber.Map{"error": locked.Error(), "retry_after": retry})
+}
@@ -0,0 +1,219 @@
+package service
+
+import (
+	"database/sql"
+	"encoding/json"
+	"net/http"
+	"testing"
+	"time"
+
+	"uas/app/model"
+	"uas/app/repository/mocks"
+	"uas/utils"
+
+	"github.com/gofiber/fiber/v2"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/mock"
+)
+
+const (
+	testTOTPSecret     = "JBSWY3DPEHPK3PXP"
+	testChallengeToken = "challenge-token"
+)
+
+func setupTwoFactorApp(service *AuthService) *fiber.App {
+	app := setupAuthApp(service)
+	app.Post("/2fa/verify", service.VerifyTwoFactorHandler)
+	app.Post("/2fa/confirm", service.ConfirmTwoFactorHandler)
+	app.Delete("/2fa", service.DisableTwoFactorHandler)
+	return app
+}
+
+func currentTOTPCode() string {
+	code, _ := utils.TOTPCode(testTOTPSecret, utils.TOTPStep(time.Now()))
+	return code
+}
+
+// challenge aktif milik user 1 + user aktif yang bisa diambil
+func pendingChallenge(userRepo *mocks.MockUserRepository, twoFactor *mocks.MockTwoFactorRepository) {
+	twoFactor.On("GetChallenge", mock.Anything, utils.HashToken(testChallengeToken)).
+		Return(&model.LoginChallenge{UserID: 1, ExpiresAt: time.Now().Add(time.Minute)}, nil)
+	userRepo.On("FindCredentialsByID", mock.Anything, int64(1)).
+		Return(&model.User{ID: 1, Username: "admin", Role: model.RoleAdmin, IsActive: true}, nil)
+}
+
+func decodeBody(t *testing.T, resp *http.Response) map[string]interface{} {
+	var out map[string]interface{}
+	assert.NoError(t, json.NewDecoder(resp.Body).Decode(&out))
+	return out
+}
+
+func TestLoginHandler_TwoFactorReturnsChallenge(t *testing.T) {
+	userRepo := new(mocks.MockUserRepository)
+	tokenRepo := new(mocks.MockRefreshTokenRepository)
+	twoFactor := new(mocks.MockTwoFactorRepository)
+	app := setupAuthApp(NewAuthService(userRepo, tokenRepo, new(mocks.MockAccessTokenRepository),
+		twoFactor, testKeySet(), openLoginGuard(), "UAS"))
+
+	hash, _ := utils.HashPassword("secret")
+	userRepo.On("FindByUsernameOrEmail", "admin").
+		Return(&model.User{ID: 1, Username: "admin", PasswordHash: hash, IsActive: true}, nil)
+	twoFactor.On("Status", mock.Anything, int64(1)).
+		Return(&model.TwoFactorStatus{Required: true}, nil)
+	var savedHash string
+	twoFactor.On("CreateChallenge", mock.Anything, int64(1), mock.Anything, mock.Anything).
+		Run(func(args mock.Arguments) { savedHash = args.String(2) }).
+		Return(nil)
+
+	resp, err := app.Test(jsonRequest(http.MethodPost, "/login", LoginInput{Username: "admin", Password: "secret"}))
+	assert.NoError(t, err)
+	assert.Equal(t, 200, resp.StatusCode)
+
+	// belum ada JWT sebelum kode 2FA; role mewajibkan enroll
+	out := decodeBody(t, resp)
+	assert.Nil(t, out["access_token"])
+	assert.Equal(t, true, out["enrollment_required"])
+	token, _ := out["challenge_token"].(string)
+	assert.Equal(t, utils.HashToken(token), savedHash)
+
+	userRepo.AssertNotCalled(t, "GetPermissionsByUserID", mock.Anything)
+	tokenRepo.AssertNotCalled(t, "Save", mock.Anything, mock.Anything, mock.Anything, mock.Anything)
+}
+
+func TestVerifyTwoFactor_Success(t *testing.T) {
+	userRepo := new(mocks.MockUserRepository)
+	tokenRepo := new(mocks.MockRefreshTokenRepository)
+	twoFactor := new(mocks.MockTwoFactorRepository)
+	service := NewAuthService(userRepo, tokenRepo, new(mocks.MockAccessTokenRepository),
+		twoFactor, testKeySet(), openLoginGuard(), "UAS")
+	app := setupTwoFactorApp(service)
+
+	pendingChallenge(userRepo, twoFactor)
+	twoFactor.On("GetSecret", mock.Anything, int64(1)).
+		Return(&model.TOTPSecret{UserID: 1, Secret: testTOTPSecret, Confirmed: true}, nil)
+	twoFactor.On("UseStep", mock.Anything, int64(1), mock.Anything).Return(nil)
+	twoFactor.On("ConsumeChallenge", mock.Anything, utils.HashToken(testChallengeToken)).Return(int64(1), nil)
+	userRepo.On("GetPermissionsByUserID", int64(1)).Return([]string{model.PermUserManage}, nil)
+	tokenRepo.On("Save", mock.Anything, mock.Anything, mock.Anything, mock.Anything).Return(nil)
+
+	resp, err := app.Test(jsonRequest(http.MethodPost, "/2fa/verify", model.TwoFactorVerifyRequest{
+		ChallengeToken: testChallengeToken,
+		Code:           currentTOTPCode(),
+	}))
+	assert.NoError(t, err)
+	assert.Equal(t, 200, resp.StatusCode)
+
+	out := decodeBody(t, resp)
+	access, _ := out["access_token"].(string)
+	claims, err := service.keys.ParseToken(access)
+	assert.NoError(t, err)
+	assert.Equal(t, model.RoleAdmin, claims.Role)
+	assert.Nil(t, out["recovery_codes"])
+	twoFactor.AssertExpectations(t)
+}
+
+func TestVerifyTwoFactor_ReplayedCodeRejected(t *testing.T) {
+	userRepo := new(mocks.MockUserRepository)
+	twoFactor := new(mocks.MockTwoFactorRepository)
+	app := setupTwoFactorApp(NewAuthService(userRepo, new(mocks.MockRefreshTokenRepository),
+		new(mocks.MockAccessTokenRepository), twoFactor, testKeySet(), openLoginGuard(), "UAS"))
+
+	pendingChallenge(userRepo, twoFactor)
+	twoFactor.On("GetSecret", mock.Anything, int64(1)).
+		Return(&model.TOTPSecret{UserID: 1, Secret: testTOTPSecret, Confirmed: true}, nil)
+	// langkah ini sudah dipakai login sebelumnya
+	twoFactor.On("UseStep", mock.Anything, int64(1), mock.Anything).Return(sql.ErrNoRows)
+	twoFactor.On("FailChallenge", mock.Anything, utils.HashToken(testChallengeToken)).Return(1, nil)
+
+	resp, err := app.Test(jsonRequest(http.MethodPost, "/2fa/verify", model.TwoFactorVerifyRequest{
+		ChallengeToken: testChallengeToken,
+		Code:           currentTOTPCode(),
+	}))
+	assert.NoError(t, err)
+	assert.Equal(t, 401, resp.StatusCode)
+	twoFactor.AssertNotCalled(t, "ConsumeChallenge", mock.Anything, mock.Anything)
+	userRepo.AssertNotCalled(t, "GetPermissionsByUserID", mock.Anything)
+}
+
+func TestVerifyTwoFactor_ChallengeDroppedAfterMaxAttempts(t *testing.T) {
+	userRepo := new(mocks.MockUserRepository)
+	twoFactor := new(mocks.MockTwoFactorRepository)
+	app := setupTwoFactorApp(NewAuthService(userRepo, new(mocks.MockRefreshTokenRepository),
+		new(mocks.MockAccessTokenRepository), twoFactor, testKeySet(), openLoginGuard(), "UAS"))
+
+	pendingChallenge(userRepo, twoFactor)
+	twoFactor.On("GetSecret", mock.Anything, int64(1)).
+		Return(&model.TOTPSecret{UserID: 1, Secret: testTOTPSecret, Confirmed: true}, nil)
+	twoFactor.On("UseRecoveryCode", mock.Anything, int64(1), mock.Anything).Return(sql.ErrNoRows)
+	twoFactor.On("FailChallenge", mock.Anything, utils.HashToken(testChallengeToken)).Return(maxChallengeAttempts, nil)
+	twoFactor.On("ConsumeChallenge", mock.Anything, utils.HashToken(testChallengeToken)).Return(int64(1), nil)
+
+	resp, err := app.Test(jsonRequest(http.MethodPost, "/2fa/verify", model.TwoFactorVerifyRequest{
+		ChallengeToken: testChallengeToken,
+		RecoveryCode:   "abcd-efgh",
+	}))
+	assert.NoError(t, err)
+	assert.Equal(t, 401, resp.StatusCode)
+	twoFactor.AssertExpectations(t)
+}
+
+func TestVerifyTwoFactor_EnrollmentAtLoginReturnsRecoveryCodes(t *testing.T) {
+	userRepo := new(mocks.MockUserRepository)
+	tokenRepo := new(mocks.MockRefreshTokenRepository)
+	twoFactor := new(mocks.MockTwoFactorRepository)
+	app := setupTwoFactorApp(NewAuthService(userRepo, tokenRepo, new(mocks.MockAccessTokenRepository),
+		twoFactor, testKeySet(), openLoginGuard(), "UAS"))
+
+	pendingChallenge(userRepo, twoFactor)
+	twoFactor.On("GetSecret", mock.Anything, int64(1)).
+		Return(&model.TOTPSecret{UserID: 1, Secret: testTOTPSecret}, nil)
+	var savedHashes []string
+	twoFactor.On("Confirm", mock.Anything, int64(1), mock.Anything, mock.Anything).
+		Run(func(args mock.Arguments) { savedHashes = args.Get(3).([]string) }).
+		Return(nil)
+	twoFactor.On("ConsumeChallenge", mock.Anything, utils.HashToken(testChallengeToken)).Return(int64(1), nil)
+	userRepo.On("GetPermissionsByUserID", int64(1)).Return([]string{}, nil)
+	tokenRepo.On("Save", mock.Anything, mock.Anything, mock.Anything, mock.Anything).Return(nil)
+
+	resp, err := app.Test(jsonRequest(http.MethodPost, "/2fa/verify", model.TwoFactorVerifyRequest{
+		ChallengeToken: testChallengeToken,
+		Code:           currentTOTPCode(),
+	}))
+	assert.NoError(t, err)
+	assert.Equal(t, 200, resp.StatusCode)
+
+	// recovery code dikirim sekali, yang disimpan hanya hash-nya
+	out := decodeBody(t, resp)
+	codes, _ := out["recovery_codes"].([]interface{})
+	assert.Len(t, codes, recoveryCodeCount)
+	assert.Len(t, savedHashes, recoveryCodeCount)
+	assert.Equal(t, hashRecoveryCode(codes[0].(string)), savedHashes[0])
+}
+
+func TestConfirmTwoFactor_InvalidCode(t *testing.T) {
+	twoFactor := new(mocks.MockTwoFactorRepository)
+	app := setupTwoFactorApp(NewAuthService(new(mocks.MockUserRepository), new(mocks.MockRefreshTokenRepository),
+		new(mocks.MockAccessTokenRepository), twoFactor, testKeySet(), openLoginGuard(), "UAS"))
+
+	twoFactor.On("GetSecret", mock.Anything, int64(1)).
+		Return(&model.TOTPSecret{UserID: 1, Secret: testTOTPSecret}, nil)
+
+	resp, err := app.Test(jsonRequest(http.MethodPost, "/2fa/confirm", model.TwoFactorCodeRequest{Code: "000000x"}))
+	assert.NoError(t, err)
+	assert.Equal(t, 400, resp.StatusCode)
+	twoFactor.AssertNotCalled(t, "Confirm", mock.Anything, mock.Anything, mock.Anything, mock.Anything)
+}
+
+func TestDisableTwoFactor_RequiredByRole(t *testing.T) {
+	twoFactor := new(mocks.MockTwoFactorRepository)
+	app := setupTwoFactorApp(NewAuthService(new(mocks.MockUserRepository), new(mocks.MockRefreshTokenRepository),
+		new(mocks.MockAccessTokenRepository), twoFactor, testKeySet(), openLoginGuard(), "UAS"))
+
+	twoFactor.On("Status", mock.Anything, int64(1)).
+		Return(&model.TwoFactorStatus{Enabled: true, Required: true}, nil)
+
+	resp, err := app.Test(jsonRequest(http.MethodDelete, "/2fa", model.TwoFactorCodeRequest{Code: currentTOTPCode()}))
+	assert.NoError(t, err)
+	assert.Equal(t, 409, resp.StatusCode)
+	twoFactor.AssertNotCalled(t, "Disable", mock.Anything, mock.Anything)
+}
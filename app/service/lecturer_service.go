@@ -3,6 +3,7 @@ package service
 import (
 	"strconv"
 
+	"uas/app/model"
 	"uas/app/repository"
 	"uas/utils"
 
@@ -34,11 +35,6 @@ func (s *LecturerService) GetAll(c *fiber.Ctx) error {
 // DOSEN WALI: GET /lecturers/:id/advisees
 func (s *LecturerService) GetAdvisees(c *fiber.Ctx) error {
 	claims := c.Locals("claims").(*utils.Claims)
-	if claims.Role != "dosen wali" {
-		return c.Status(403).JSON(fiber.Map{
-			"error": "lecturer_only",
-		})
-	}
 	lecturerID, err := strconv.ParseInt(c.Params("id"), 10, 64)
 	if err != nil {
 		return c.Status(400).JSON(fiber.Map{
@@ -46,12 +42,14 @@ func (s *LecturerService) GetAdvisees(c *fiber.Ctx) error {
 		})
 	}
 
-	// cek apakah lecturerID milik dosen yang sedang login
-	myLecturerID, err := s.Repo.GetLecturerIDByUserID(c.Context(), claims.UserID)
-	if err != nil || myLecturerID != lecturerID {
-		return c.Status(403).JSON(fiber.Map{
-			"error": "forbidden",
-		})
+	// cek apakah lecturerID milik dosen yang sedang login (kecuali lecturer:read)
+	if !utils.HasPermission(c, model.PermLecturerRead) {
+		myLecturerID, err := s.Repo.GetLecturerIDByUserID(c.Context(), claims.UserID)
+		if err != nil || myLecturerID != lecturerID {
+			return c.Status(403).JSON(fiber.Map{
+				"error": "forbidden",
+			})
+		}
 	}
 	students, err := s.Repo.GetAdvisees(c.Context(), lecturerID)
 	if err != nil {
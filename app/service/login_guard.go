@@ -0,0 +1,194 @@
+package service
+
+import (
+	"context"
+	"fmt"
+	"log"
+	"strings"
+	"time"
+
+	"uas/app/model"
+	"uas/app/repository"
+)
+
+type LoginGuardOptions struct {
+	MaxFailures   int           // per akun sebelum dikunci
+	IPMaxFailures int           // per IP sebelum dikunci
+	LockoutBase   time.Duration // kunci pertama; berikutnya 2x, 4x, ...
+	LockoutMax    time.Duration
+	FailureWindow time.Duration // hitungan gagal direset setelah sekian lama tanpa gagal
+
+	// Permintaan reset password (lupa password) per email dan per IP
+	// sebelum dikunci; memakai window dan backoff yang sama
+	ResetMaxPerEmail int
+	ResetMaxPerIP    int
+}
+
+// LoginGuard membatasi tebakan password: hitungan gagal per akun dan per IP,
+// kunci sementara dengan backoff eksponensial, dan audit lockout / unlock.
+type LoginGuard struct {
+	repo  repository.LoginThrottleRepository
+	audit repository.AuditRepository
+	opts  LoginGuardOptions
+	now   func() time.Time
+}
+
+func NewLoginGuard(
+	repo repository.LoginThrottleRepository,
+	audit repository.AuditRepository,
+	opts LoginGuardOptions,
+) *LoginGuard {
+	return &LoginGuard{repo: repo, audit: audit, opts: opts, now: time.Now}
+}
+
+// LockedError: login ditolak sementara, coba lagi setelah RetryAfter
+type LockedError struct {
+	RetryAfter time.Duration
+}
+
+func (e *LockedError) Error() string {
+	return "too_many_attempts"
+}
+
+func userThrottleKey(userID int64) string { return fmt.Sprintf("user:%d", userID) }
+func ipThrottleKey(ip string) string      { return "ip:" + ip }
+
+func resetEmailThrottleKey(email string) string { return "reset:email:" + strings.ToLower(email) }
+func resetIPThrottleKey(ip string) string       { return "reset:ip:" + ip }
+
+// Check mengembalikan *LockedError jika IP atau akun (userID > 0) sedang dikunci
+func (g *LoginGuard) Check(ctx context.Context, userID int64, ip string) error {
+	keys := []string{ipThrottleKey(ip)}
+	if userID > 0 {
+		keys = append(keys, userThrottleKey(userID))
+	}
+	until, err := g.repo.LockedUntil(ctx, keys)
+	if err != nil {
+		return err
+	}
+	if wait := until.Sub(g.now()); wait > 0 {
+		return &LockedError{RetryAfter: wait}
+	}
+	return nil
+}
+
+// Failure mencatat login gagal dari IP, dan untuk akun jika userID > 0
+// (username tidak dikenal hanya dihitung per IP)
+func (g *LoginGuard) Failure(ctx context.Context, userID int64, ip string) {
+	g.recordFailure(ctx, ipThrottleKey(ip), g.opts.IPMaxFailures, model.AuditIPLocked, nil, ip)
+	if userID > 0 {
+		g.recordFailure(ctx, userThrottleKey(userID), g.opts.MaxFailures, model.AuditAccountLocked, &userID, ip)
+	}
+}
+
+// Success mereset hitungan akun. Hitungan IP dibiarkan turun sendiri supaya
+// login sukses ke akun milik penyerang tidak membuka kunci IP-nya.
+func (g *LoginGuard) Success(ctx context.Context, userID int64, ip string) {
+	wasLocked, err := g.repo.Reset(ctx, userThrottleKey(userID))
+	if err != nil {
+		log.Printf("login guard: reset user %d: %v", userID, err)
+		return
+	}
+	if wasLocked {
+		g.record(ctx, &model.AuditLog{
+			Event:     model.AuditAccountUnlocked,
+			UserID:    &userID,
+			IPAddress: ip,
+			Details:   map[string]interface{}{"reason": "login_success"},
+		})
+	}
+}
+
+// Unlock: admin membuka kunci akun sebelum waktunya
+func (g *LoginGuard) Unlock(ctx context.Context, userID, actorID int64, ip string) (bool, error) {
+	wasLocked, err := g.repo.Reset(ctx, userThrottleKey(userID))
+	if err != nil {
+		return false, err
+	}
+	g.record(ctx, &model.AuditLog{
+		Event:     model.AuditAccountUnlocked,
+		UserID:    &userID,
+		ActorID:   &actorID,
+		IPAddress: ip,
+		Details:   map[string]interface{}{"reason": "admin", "was_locked": wasLocked},
+	})
+	return wasLocked, nil
+}
+
+// ResetRequest membatasi permintaan lupa password per email dan per IP.
+// Dihitung sebelum email dicari supaya email terdaftar / tidak sama saja;
+// tanpa ini setiap request membuat token baru dan link yang lama hangus.
+func (g *LoginGuard) ResetRequest(ctx context.Context, email, ip string) error {
+	emailKey, ipKey := resetEmailThrottleKey(email), resetIPThrottleKey(ip)
+	until, err := g.repo.LockedUntil(ctx, []string{emailKey, ipKey})
+	if err != nil {
+		return err
+	}
+	if wait := until.Sub(g.now()); wait > 0 {
+		return &LockedError{RetryAfter: wait}
+	}
+	g.countRequest(ctx, emailKey, g.opts.ResetMaxPerEmail)
+	g.countRequest(ctx, ipKey, g.opts.ResetMaxPerIP)
+	return nil
+}
+
+// countRequest: seperti recordFailure tanpa audit; request ke-max masih
+// diproses, berikutnya ditolak sampai kunci habis
+func (g *LoginGuard) countRequest(ctx context.Context, key string, max int) {
+	count, err := g.repo.RecordFailure(ctx, key, g.opts.FailureWindow)
+	if err != nil {
+		log.Printf("login guard: record request %s: %v", key, err)
+		return
+	}
+	if max <= 0 || count < max {
+		return
+	}
+	until := g.now().Add(lockoutDuration(count-max, g.opts.LockoutBase, g.opts.LockoutMax))
+	if err := g.repo.Lock(ctx, key, until); err != nil {
+		log.Printf("login guard: lock %s: %v", key, err)
+	}
+}
+
+func (g *LoginGuard) recordFailure(ctx context.Context, key string, max int, event string, userID *int64, ip string) {
+	failures, err := g.repo.RecordFailure(ctx, key, g.opts.FailureWindow)
+	if err != nil {
+		log.Printf("login guard: record failure %s: %v", key, err)
+		return
+	}
+	if max <= 0 || failures < max {
+		return
+	}
+	duration := lockoutDuration(failures-max, g.opts.LockoutBase, g.opts.LockoutMax)
+	until := g.now().Add(duration)
+	if err := g.repo.Lock(ctx, key, until); err != nil {
+		log.Printf("login guard: lock %s: %v", key, err)
+		return
+	}
+	g.record(ctx, &model.AuditLog{
+		Event:     event,
+		UserID:    userID,
+		IPAddress: ip,
+		Details: map[string]interface{}{
+			"failures":     failures,
+			"locked_until": until.UTC().Format(time.RFC3339),
+		},
+	})
+}
+
+func (g *LoginGuard) record(ctx context.Context, entry *model.AuditLog) {
+	if err := g.audit.Record(ctx, entry); err != nil {
+		log.Printf("audit %s: %v", entry.Event, err)
+	}
+}
+
+// lockoutDuration: base * 2^n, maksimal max
+func lockoutDuration(n int, base, max time.Duration) time.Duration {
+	d := base
+	for i := 0; i < n && d < max; i++ {
+		d *= 2
+	}
+	if d > max {
+		return max
+	}
+	return d
+}
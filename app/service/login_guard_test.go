@@ -0,0 +1,185 @@
+package service
+
+import (
+	"context"
+	"errors"
+	"net/http"
+	"testing"
+	"time"
+
+	"uas/app/model"
+	"uas/app/repository/mocks"
+	"uas/utils"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/mock"
+)
+
+func TestLockoutDuration_Exponential(t *testing.T) {
+	base, max := 30*time.Second, 10*time.Minute
+	assert.Equal(t, 30*time.Second, lockoutDuration(0, base, max))
+	assert.Equal(t, time.Minute, lockoutDuration(1, base, max))
+	assert.Equal(t, 4*time.Minute, lockoutDuration(3, base, max))
+	assert.Equal(t, max, lockoutDuration(10, base, max))
+}
+
+// Gagal ke-3 (MaxFailures) mengunci akun dan mencatat audit
+func TestLoginGuard_LocksAccountAtThreshold(t *testing.T) {
+	throttle := new(mocks.MockLoginThrottleRepository)
+	audit := new(mocks.MockAuditRepository)
+	guard := NewLoginGuard(throttle, audit, testGuardOptions)
+
+	throttle.On("RecordFailure", mock.Anything, "ip:10.0.0.1", testGuardOptions.FailureWindow).Return(3, nil)
+	throttle.On("RecordFailure", mock.Anything, "user:7", testGuardOptions.FailureWindow).Return(3, nil)
+	throttle.On("Lock", mock.Anything, "user:7", mock.MatchedBy(func(until time.Time) bool {
+		return time.Until(until) > 25*time.Second && time.Until(until) <= 30*time.Second
+	})).Return(nil)
+	audit.On("Record", mock.Anything, mock.MatchedBy(func(e *model.AuditLog) bool {
+		return e.Event == model.AuditAccountLocked && *e.UserID == 7 && e.Details["failures"] == 3
+	})).Return(nil)
+
+	guard.Failure(context.Background(), 7, "10.0.0.1")
+
+	throttle.AssertExpectations(t)
+	audit.AssertExpectations(t)
+	// IP baru 3 dari 10 gagal, belum dikunci
+	throttle.AssertNotCalled(t, "Lock", mock.Anything, "ip:10.0.0.1", mock.Anything)
+}
+
+// fakeThrottle: aturan reset yang sama dengan RecordFailure di PostgreSQL
+// (window dihitung dari GREATEST(last_failure_at, locked_until))
+type fakeThrottle struct {
+	now     *time.Time
+	entries map[string]*fakeThrottleEntry
+	locks   map[string][]time.Duration // lama setiap kunci per key, berurutan
+}
+
+type fakeThrottleEntry struct {
+	failures    int
+	lastFailure time.Time
+	lockedUntil time.Time
+}
+
+func (f *fakeThrottle) LockedUntil(ctx context.Context, keys []string) (time.Time, error) {
+	var until time.Time
+	for _, k := range keys {
+		if e := f.entries[k]; e != nil && e.lockedUntil.After(*f.now) && e.lockedUntil.After(until) {
+			until = e.lockedUntil
+		}
+	}
+	return until, nil
+}
+
+func (f *fakeThrottle) RecordFailure(ctx context.Context, key string, window time.Duration) (int, error) {
+	e := f.entries[key]
+	if e == nil {
+		e = &fakeThrottleEntry{}
+		f.entries[key] = e
+	}
+	last := e.lastFailure
+	if e.lockedUntil.After(last) {
+		last = e.lockedUntil
+	}
+	if last.Before(f.now.Add(-window)) {
+		e.failures = 0
+	}
+	e.failures++
+	e.lastFailure = *f.now
+	return e.failures, nil
+}
+
+func (f *fakeThrottle) Lock(ctx context.Context, key string, until time.Time) error {
+	f.entries[key].lockedUntil = until
+	f.locks[key] = append(f.locks[key], until.Sub(*f.now))
+	return nil
+}
+
+func (f *fakeThrottle) Reset(ctx context.Context, key string) (bool, error) {
+	delete(f.entries, key)
+	return false, nil
+}
+
+// Penyerang menunggu setiap kunci habis lalu menebak lagi: kunci yang lebih
+// lama dari FailureWindow tidak boleh mereset backoff, jadi LockoutMax tercapai
+func TestLoginGuard_BackoffSurvivesLocksLongerThanWindow(t *testing.T) {
+	now := time.Now()
+	throttle := &fakeThrottle{now: &now, entries: map[string]*fakeThrottleEntry{}, locks: map[string][]time.Duration{}}
+	audit := new(mocks.MockAuditRepository)
+	audit.On("Record", mock.Anything, mock.Anything).Return(nil)
+	guard := NewLoginGuard(throttle, audit, testGuardOptions)
+	guard.now = func() time.Time { return now }
+	ctx := context.Background()
+
+	for len(throttle.locks["user:7"]) < 10 {
+		var locked *LockedError
+		if err := guard.Check(ctx, 7, "10.0.0.1"); errors.As(err, &locked) {
+			now = now.Add(locked.RetryAfter + time.Second)
+			continue
+		}
+		guard.Failure(ctx, 7, "10.0.0.1")
+		now = now.Add(time.Second)
+	}
+
+	// 30s, 1m, 2m, ... 16m, 32m, lalu tertahan di LockoutMax (1 jam)
+	locks := throttle.locks["user:7"]
+	assert.Equal(t, 30*time.Second, locks[0])
+	assert.Equal(t, 32*time.Minute, locks[6])
+	assert.Equal(t, testGuardOptions.LockoutMax, locks[len(locks)-1])
+}
+
+func TestLoginHandler_LockedReturns429(t *testing.T) {
+	userRepo := new(mocks.MockUserRepository)
+	throttle := new(mocks.MockLoginThrottleRepository)
+	guard := NewLoginGuard(throttle, new(mocks.MockAuditRepository), testGuardOptions)
+	app := setupAuthApp(NewAuthService(userRepo, new(mocks.MockRefreshTokenRepository),
+		new(mocks.MockAccessTokenRepository), nil, testKeySet(), guard, "UAS"))
+
+	hash, _ := utils.HashPassword("secret")
+	userRepo.On("FindByUsernameOrEmail", "test").
+		Return(&model.User{ID: 7, Username: "test", PasswordHash: hash, IsActive: true}, nil)
+	throttle.On("LockedUntil", mock.Anything, []string{"ip:0.0.0.0", "user:7"}).
+		Return(time.Now().Add(2*time.Minute), nil)
+
+	// password benar pun ditolak selama terkunci
+	resp, err := app.Test(jsonRequest(http.MethodPost, "/login", LoginInput{Username: "test", Password: "secret"}))
+	assert.NoError(t, err)
+	assert.Equal(t, 429, resp.StatusCode)
+	assert.NotEmpty(t, resp.Header.Get("Retry-After"))
+
+	userRepo.AssertNotCalled(t, "GetPermissionsByUserID", mock.Anything)
+	throttle.AssertNotCalled(t, "RecordFailure", mock.Anything, mock.Anything, mock.Anything)
+}
+
+func TestLoginGuard_AdminUnlockIsAudited(t *testing.T) {
+	throttle := new(mocks.MockLoginThrottleRepository)
+	audit := new(mocks.MockAuditRepository)
+	guard := NewLoginGuard(throttle, audit, testGuardOptions)
+
+	throttle.On("Reset", mock.Anything, "user:7").Return(true, nil)
+	audit.On("Record", mock.Anything, mock.MatchedBy(func(e *model.AuditLog) bool {
+		return e.Event == model.AuditAccountUnlocked && *e.UserID == 7 && *e.ActorID == 1
+	})).Return(nil)
+
+	wasLocked, err := guard.Unlock(context.Background(), 7, 1, "10.0.0.9")
+	assert.NoError(t, err)
+	assert.True(t, wasLocked)
+	audit.AssertExpectations(t)
+}
+
+// Permintaan reset ke-max (per email) masih diproses lalu key dikunci
+func TestLoginGuard_ResetRequestLocksEmailAtLimit(t *testing.T) {
+	throttle := new(mocks.MockLoginThrottleRepository)
+	opts := testGuardOptions
+	opts.ResetMaxPerEmail, opts.ResetMaxPerIP = 3, 10
+	guard := NewLoginGuard(throttle, new(mocks.MockAuditRepository), opts)
+
+	throttle.On("LockedUntil", mock.Anything, []string{"reset:email:budi@example.ac.id", "reset:ip:10.0.0.1"}).
+		Return(time.Time{}, nil)
+	throttle.On("RecordFailure", mock.Anything, "reset:email:budi@example.ac.id", opts.FailureWindow).Return(3, nil)
+	throttle.On("RecordFailure", mock.Anything, "reset:ip:10.0.0.1", opts.FailureWindow).Return(3, nil)
+	throttle.On("Lock", mock.Anything, "reset:email:budi@example.ac.id", mock.Anything).Return(nil)
+
+	assert.NoError(t, guard.ResetRequest(context.Background(), "BUDI@example.ac.id", "10.0.0.1"))
+	throttle.AssertExpectations(t)
+	throttle.AssertNotCalled(t, "Lock", mock.Anything, "reset:ip:10.0.0.1", mock.Anything)
+}
@@ -0,0 +1,360 @@
+package service
+
+import (
+	"context"
+	"crypto/subtle"
+	"database/sql"
+	"errors"
+	"log"
+	"strconv"
+	"strings"
+	"time"
+
+	"uas/app/model"
+	"uas/app/repository"
+	"uas/utils"
+
+	"github.com/gofiber/fiber/v2"
+	"github.com/golang-jwt/jwt/v5"
+)
+
+// Umur satu login SSO (redirect ke IdP sampai callback)
+const oidcStateTTL = 10 * time.Minute
+
+// Cookie berisi hash state: callback hanya diterima dari browser yang
+// memulai login (cegah login CSRF dengan code + state milik penyerang)
+const oidcStateCookie = "oidc_state"
+
+// password_hash untuk user hasil provisioning SSO: bukan hash bcrypt, jadi
+// login password selalu gagal sampai user memakai lupa password
+const ssoOnlyPasswordHash = "!sso"
+
+// OIDCClaimMapping: nama claim di ID token IdP kampus. Claim kosong = tidak dipakai.
+type OIDCClaimMapping struct {
+	Email        string
+	Username     string
+	Name         string
+	NIM          string
+	NIP          string
+	ProgramStudy string
+	Department   string
+}
+
+type OIDCOptions struct {
+	Claims OIDCClaimMapping
+
+	// Email hanya dipakai mencocokkan user jika email_verified = true
+	RequireVerifiedEmail bool
+
+	// Just-in-time provisioning: login SSO pertama tanpa user yang cocok
+	// membuat user baru (mahasiswa jika ada NIM, dosen wali jika ada NIP)
+	Provisioning bool
+}
+
+// OIDCService: login SSO (authorization code + PKCE) dengan IdP kampus.
+// Setelah akun IdP dipetakan ke user lokal, alurnya sama dengan login
+// password (2FA, sesi, refresh token) lewat AuthService.
+type OIDCService struct {
+	auth       *AuthService
+	provider   *utils.OIDCProvider
+	identities repository.IdentityRepository
+	audit      repository.AuditRepository
+	opts       OIDCOptions
+}
+
+// provider nil = SSO tidak dikonfigurasi (OIDC_ISSUER kosong)
+func NewOIDCService(
+	auth *AuthService,
+	provider *utils.OIDCProvider,
+	identities repository.IdentityRepository,
+	audit repository.AuditRepository,
+	opts OIDCOptions,
+) *OIDCService {
+	return &OIDCService{
+		auth:       auth,
+		provider:   provider,
+		identities: identities,
+		audit:      audit,
+		opts:       opts,
+	}
+}
+
+// GET /auth/oidc/login
+// Redirect ke halaman login IdP; state, nonce dan PKCE verifier disimpan
+// di server sampai callback, hash state juga di cookie browser
+func (s *OIDCService) LoginHandler(c *fiber.Ctx) error {
+	if s.provider == nil {
+		return c.Status(404).JSON(fiber.Map{"error": "sso_disabled"})
+	}
+	ctx := c.Context()
+
+	state, err := utils.NewOpaqueToken()
+	if err != nil {
+		return c.Status(500).JSON(fiber.Map{"error": "failed_start_sso"})
+	}
+	nonce, err := utils.NewOpaqueToken()
+	if err != nil {
+		return c.Status(500).JSON(fiber.Map{"error": "failed_start_sso"})
+	}
+	verifier, challenge, err := utils.NewPKCE()
+	if err != nil {
+		return c.Status(500).JSON(fiber.Map{"error": "failed_start_sso"})
+	}
+
+	// discovery dulu: IdP mati → tidak ada state yang tersimpan sia-sia
+	authURL, err := s.provider.AuthCodeURL(ctx, state, nonce, challenge)
+	if err != nil {
+		log.Printf("sso: %v", err)
+		return c.Status(502).JSON(fiber.Map{"error": "sso_unavailable"})
+	}
+	stateHash := utils.HashToken(state)
+	loginState := model.OIDCLoginState{CodeVerifier: verifier, Nonce: nonce}
+	err = s.identities.SaveLoginState(ctx, stateHash, loginState, time.Now().Add(oidcStateTTL))
+	if err != nil {
+		return c.Status(500).JSON(fiber.Map{"error": "failed_start_sso"})
+	}
+	setOIDCStateCookie(c, stateHash, time.Now().Add(oidcStateTTL))
+	return c.Redirect(authURL, fiber.StatusFound)
+}
+
+// GET / POST /auth/oidc/callback
+// code + state dari redirect IdP (query, atau body JSON jika frontend yang
+// meneruskan) ditukar dengan access / refresh token, atau challenge 2FA
+func (s *OIDCService) CallbackHandler(c *fiber.Ctx) error {
+	if s.provider == nil {
+		return c.Status(404).JSON(fiber.Map{"error": "sso_disabled"})
+	}
+	if reason := c.Query("error"); reason != "" {
+		return c.Status(401).JSON(fiber.Map{"error": "sso_denied", "reason": reason})
+	}
+	var input model.OIDCCallbackRequest
+	var err error
+	if c.Method() == fiber.MethodPost {
+		err = c.BodyParser(&input)
+	} else {
+		err = c.QueryParser(&input)
+	}
+	if err != nil || input.Code == "" || input.State == "" {
+		return c.Status(400).JSON(fiber.Map{"error": "invalid_request"})
+	}
+	ctx := c.Context()
+
+	// 1. State harus sama dengan cookie browser ini, baru dipakai (sekali
+	// pakai, cegah CSRF / replay callback)
+	stateHash := utils.HashToken(input.State)
+	cookie := c.Cookies(oidcStateCookie)
+	setOIDCStateCookie(c, "", time.Unix(0, 0))
+	if cookie == "" || subtle.ConstantTimeCompare([]byte(cookie), []byte(stateHash)) != 1 {
+		return c.Status(401).JSON(fiber.Map{"error": "invalid_state"})
+	}
+	state, err := s.identities.ConsumeLoginState(ctx, stateHash)
+	if err != nil {
+		if errors.Is(err, sql.ErrNoRows) {
+			return c.Status(401).JSON(fiber.Map{"error": "invalid_state"})
+		}
+		return c.Status(500).JSON(fiber.Map{"error": "failed_check_state"})
+	}
+
+	// 2. Tukar code (dengan PKCE verifier) lalu verifikasi ID token
+	tokens, err := s.provider.Exchange(ctx, input.Code, state.CodeVerifier)
+	if err != nil {
+		log.Printf("sso: %v", err)
+		return c.Status(401).JSON(fiber.Map{"error": "sso_exchange_failed"})
+	}
+	claims, err := s.provider.VerifyIDToken(ctx, tokens.IDToken, state.Nonce)
+	if err != nil {
+		log.Printf("sso: %v", err)
+		return c.Status(401).JSON(fiber.Map{"error": "invalid_id_token"})
+	}
+	identity := s.mapClaims(claims)
+
+	// 3. Akun IdP → user lokal (tautan, cocokkan, atau provisioning)
+	userID, status, errCode := s.resolveUser(c, identity)
+	if errCode != "" {
+		return c.Status(status).JSON(fiber.Map{"error": errCode})
+	}
+	user, err := s.auth.userRepo.FindCredentialsByID(ctx, userID)
+	if err != nil {
+		return c.Status(500).JSON(fiber.Map{"error": "failed_get_user"})
+	}
+	if !user.IsActive {
+		return c.Status(401).JSON(fiber.Map{"error": "user_inactive"})
+	}
+
+	// 4. Lanjut seperti login password: lockout, 2FA, token
+	if err := s.auth.guard.Check(ctx, user.ID, c.IP()); err != nil {
+		return lockedResponse(c, err)
+	}
+	output, err := s.auth.afterFirstFactor(ctx, user, c.IP())
+	if err != nil {
+		return c.Status(500).JSON(fiber.Map{"error": "token_failed"})
+	}
+	return s.auth.loginResult(c, output)
+}
+
+// Cookie state SSO; SameSite=Lax supaya ikut terkirim pada redirect GET
+// dari IdP. expires di masa lalu = hapus cookie.
+func setOIDCStateCookie(c *fiber.Ctx, value string, expires time.Time) {
+	c.Cookie(&fiber.Cookie{
+		Name:     oidcStateCookie,
+		Value:    value,
+		Path:     "/",
+		Expires:  expires,
+		HTTPOnly: true,
+		Secure:   c.Protocol() == "https",
+		SameSite: fiber.CookieSameSiteLaxMode,
+	})
+}
+
+// resolveUser: user_id untuk identity, atau status + kode error.
+// Urutan: akun IdP yang sudah terhubung, lalu email / NIM / NIP, lalu
+// provisioning (jika diaktifkan).
+func (s *OIDCService) resolveUser(c *fiber.Ctx, identity model.OIDCIdentity) (int64, int, string) {
+	ctx := c.Context()
+
+	userID, err := s.identities.FindUserID(ctx, identity.Issuer, identity.Subject)
+	if err == nil {
+		if err := s.identities.Link(ctx, userID, identity); err != nil {
+			return 0, 500, "failed_link_identity"
+		}
+		return userID, 0, ""
+	}
+	if !errors.Is(err, sql.ErrNoRows) {
+		return 0, 500, "failed_find_identity"
+	}
+
+	matches, err := s.identities.MatchUsers(ctx, identity.Email, identity.NIM, identity.NIP)
+	if err != nil {
+		return 0, 500, "failed_find_identity"
+	}
+	switch len(matches) {
+	case 0:
+		return s.provision(c, identity)
+	case 1:
+		// tautan dibuat sekali; login berikutnya cukup lewat (issuer, sub)
+		if err := s.identities.Link(ctx, matches[0], identity); err != nil {
+			if errors.Is(err, sql.ErrNoRows) {
+				return 0, 409, "identity_conflict"
+			}
+			return 0, 500, "failed_link_identity"
+		}
+		s.record(ctx, model.AuditSSOLinked, matches[0], c.IP(), identity)
+		return matches[0], 0, ""
+	default:
+		// email, NIM dan NIP menunjuk ke user berbeda: jangan menebak
+		return 0, 409, "identity_conflict"
+	}
+}
+
+// provision: user + profil baru dari claim IdP (just-in-time)
+func (s *OIDCService) provision(c *fiber.Ctx, identity model.OIDCIdentity) (int64, int, string) {
+	if !s.opts.Provisioning {
+		return 0, 403, "account_not_found"
+	}
+	var role string
+	switch {
+	case identity.NIM != "":
+		role = model.RoleStudent
+	case identity.NIP != "":
+		role = model.RoleLecturer
+	default:
+		return 0, 403, "account_not_found"
+	}
+	// users.email wajib dan unik; tanpa email terverifikasi tidak bisa dibuat
+	if identity.Email == "" {
+		return 0, 403, "verified_email_required"
+	}
+
+	ctx := c.Context()
+	roleID, err := s.auth.userRepo.GetRoleIDByName(role)
+	if err != nil {
+		return 0, 500, "role_not_found"
+	}
+	userID, err := s.identities.Provision(ctx, model.OIDCProvision{
+		Identity:     identity,
+		Username:     provisionUsername(identity),
+		RoleID:       roleID,
+		Role:         role,
+		PasswordHash: ssoOnlyPasswordHash,
+	})
+	if err != nil {
+		if errors.Is(err, repository.ErrProvisionConflict) {
+			return 0, 409, "identity_conflict"
+		}
+		return 0, 500, "failed_provision_user"
+	}
+	s.record(ctx, model.AuditSSOProvisioned, userID, c.IP(), identity)
+	return userID, 0, ""
+}
+
+// mapClaims memetakan claim ID token sesuai OIDCClaimMapping
+func (s *OIDCService) mapClaims(claims jwt.MapClaims) model.OIDCIdentity {
+	m := s.opts.Claims
+	sub, _ := claims.GetSubject()
+	identity := model.OIDCIdentity{
+		Issuer:       s.provider.Issuer(),
+		Subject:      sub,
+		Email:        strings.ToLower(claimString(claims, m.Email)),
+		Username:     claimString(claims, m.Username),
+		FullName:     claimString(claims, m.Name),
+		NIM:          claimString(claims, m.NIM),
+		NIP:          claimString(claims, m.NIP),
+		ProgramStudy: claimString(claims, m.ProgramStudy),
+		Department:   claimString(claims, m.Department),
+	}
+	if s.opts.RequireVerifiedEmail && claimString(claims, "email_verified") != "true" {
+		identity.Email = ""
+	}
+	if identity.FullName == "" {
+		identity.FullName = identity.Username
+	}
+	return identity
+}
+
+func (s *OIDCService) record(ctx context.Context, event string, userID int64, ip string, identity model.OIDCIdentity) {
+	err := s.audit.Record(ctx, &model.AuditLog{
+		Event:     event,
+		UserID:    &userID,
+		IPAddress: ip,
+		Details: map[string]interface{}{
+			"issuer":  identity.Issuer,
+			"subject": identity.Subject,
+			"email":   identity.Email,
+		},
+	})
+	if err != nil {
+		log.Printf("audit %s: %v", event, err)
+	}
+}
+
+// claimString: nilai claim sebagai string (NIM / NIP kadang dikirim sebagai angka)
+func claimString(claims jwt.MapClaims, name string) string {
+	if name == "" {
+		return ""
+	}
+	switch v := claims[name].(type) {
+	case string:
+		return strings.TrimSpace(v)
+	case float64:
+		return strconv.FormatFloat(v, 'f', -1, 64)
+	case bool:
+		return strconv.FormatBool(v)
+	default:
+		return ""
+	}
+}
+
+// provisionUsername: username dari IdP, atau NIM / NIP, atau bagian lokal email
+func provisionUsername(identity model.OIDCIdentity) string {
+	for _, candidate := range []string{
+		identity.Username,
+		identity.NIM,
+		identity.NIP,
+		strings.SplitN(identity.Email, "@", 2)[0],
+	} {
+		if candidate != "" {
+			return truncate(candidate, 50)
+		}
+	}
+	return identity.Subject
+}
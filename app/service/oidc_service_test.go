@@ -0,0 +1,267 @@
+package service
+
+import (
+	"database/sql"
+	"net/http"
+	"net/http/httptest"
+	"net/url"
+	"testing"
+
+	"uas/app/model"
+	"uas/app/repository"
+	"uas/app/repository/mocks"
+	"uas/utils"
+	"uas/utils/oidctest"
+
+	"github.com/gofiber/fiber/v2"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/mock"
+)
+
+const testRedirectURL = "http://localhost:3000/api/v1/auth/oidc/callback"
+
+var testClaimMapping = OIDCClaimMapping{
+	Email:        "email",
+	Username:     "preferred_username",
+	Name:         "name",
+	NIM:          "nim",
+	NIP:          "nip",
+	ProgramStudy: "program_study",
+	Department:   "department",
+}
+
+type oidcTestEnv struct {
+	app        *fiber.App
+	idp        *oidctest.IdP
+	userRepo   *mocks.MockUserRepository
+	tokenRepo  *mocks.MockRefreshTokenRepository
+	identities *mocks.MockIdentityRepository
+	audit      *mocks.MockAuditRepository
+}
+
+func setupOIDCApp(t *testing.T, provisioning bool) *oidcTestEnv {
+	env := &oidcTestEnv{
+		idp:        oidctest.NewIdP("uas"),
+		userRepo:   new(mocks.MockUserRepository),
+		tokenRepo:  new(mocks.MockRefreshTokenRepository),
+		identities: new(mocks.MockIdentityRepository),
+		audit:      new(mocks.MockAuditRepository),
+	}
+	t.Cleanup(env.idp.Close)
+
+	auth := NewAuthService(env.userRepo, env.tokenRepo, new(mocks.MockAccessTokenRepository),
+		noTwoFactor(), testKeySet(), openLoginGuard(), "UAS")
+	provider := utils.NewOIDCProvider(utils.OIDCOptions{
+		Issuer:      env.idp.Issuer,
+		ClientID:    "uas",
+		RedirectURL: testRedirectURL,
+		Scopes:      []string{"openid", "email", "profile"},
+	})
+	service := NewOIDCService(auth, provider, env.identities, env.audit, OIDCOptions{
+		Claims:               testClaimMapping,
+		RequireVerifiedEmail: true,
+		Provisioning:         provisioning,
+	})
+
+	env.app = fiber.New()
+	env.app.Get("/oidc/login", service.LoginHandler)
+	env.app.Get("/oidc/callback", service.CallbackHandler)
+	return env
+}
+
+// login lewat IdP tiruan: /oidc/login → IdP → /oidc/callback
+func (env *oidcTestEnv) login(t *testing.T) *http.Response {
+	var saved model.OIDCLoginState
+	var savedHash string
+	env.identities.On("SaveLoginState", mock.Anything, mock.Anything, mock.Anything, mock.Anything).
+		Run(func(args mock.Arguments) {
+			savedHash = args.String(1)
+			saved = args.Get(2).(model.OIDCLoginState)
+		}).Return(nil).Once()
+
+	resp, err := env.app.Test(httptest.NewRequest(http.MethodGet, "/oidc/login", nil))
+	assert.NoError(t, err)
+	assert.Equal(t, 302, resp.StatusCode)
+
+	code, state, err := env.idp.Authorize(resp.Header.Get("Location"))
+	assert.NoError(t, err)
+	assert.Equal(t, utils.HashToken(state), savedHash)
+	cookie := stateCookie(resp)
+	if assert.NotNil(t, cookie) {
+		assert.Equal(t, savedHash, cookie.Value)
+		assert.True(t, cookie.HttpOnly)
+		assert.Equal(t, http.SameSiteLaxMode, cookie.SameSite)
+	}
+	env.identities.On("ConsumeLoginState", mock.Anything, savedHash).Return(&saved, nil).Once()
+
+	q := url.Values{"code": {code}, "state": {state}}
+	req := httptest.NewRequest(http.MethodGet, "/oidc/callback?"+q.Encode(), nil)
+	if cookie != nil {
+		req.AddCookie(&http.Cookie{Name: cookie.Name, Value: cookie.Value})
+	}
+	resp, err = env.app.Test(req)
+	assert.NoError(t, err)
+	return resp
+}
+
+func stateCookie(resp *http.Response) *http.Cookie {
+	for _, c := range resp.Cookies() {
+		if c.Name == oidcStateCookie {
+			return c
+		}
+	}
+	return nil
+}
+
+// user aktif + token bisa diterbitkan
+func (env *oidcTestEnv) expectTokens(userID int64, role string) {
+	env.userRepo.On("FindCredentialsByID", mock.Anything, userID).
+		Return(&model.User{ID: userID, Username: "budi", Role: role, IsActive: true}, nil)
+	env.userRepo.On("GetPermissionsByUserID", userID).Return([]string{}, nil)
+	env.tokenRepo.On("Save", mock.Anything, mock.Anything, mock.Anything, mock.Anything).Return(nil)
+}
+
+func TestOIDCLogin_LinkedIdentity(t *testing.T) {
+	env := setupOIDCApp(t, false)
+	env.idp.SetUser(map[string]interface{}{"sub": "u-1", "email": "budi@kampus.ac.id", "email_verified": true})
+	env.identities.On("FindUserID", mock.Anything, env.idp.Issuer, "u-1").Return(int64(7), nil)
+	env.identities.On("Link", mock.Anything, int64(7), mock.Anything).Return(nil)
+	env.expectTokens(7, model.RoleStudent)
+
+	resp := env.login(t)
+	assert.Equal(t, 200, resp.StatusCode)
+	out := decodeBody(t, resp)
+	assert.NotEmpty(t, out["access_token"])
+	assert.NotEmpty(t, out["refresh_token"])
+	env.identities.AssertNotCalled(t, "MatchUsers", mock.Anything, mock.Anything, mock.Anything, mock.Anything)
+}
+
+func TestOIDCLogin_MatchByNIMLinksUser(t *testing.T) {
+	env := setupOIDCApp(t, false)
+	// NIM dikirim sebagai angka; email belum terverifikasi → tidak dipakai
+	env.idp.SetUser(map[string]interface{}{"sub": "u-2", "email": "budi@kampus.ac.id", "nim": 2201001})
+	env.identities.On("FindUserID", mock.Anything, mock.Anything, "u-2").Return(int64(0), sql.ErrNoRows)
+	env.identities.On("MatchUsers", mock.Anything, "", "2201001", "").Return([]int64{9}, nil)
+	env.identities.On("Link", mock.Anything, int64(9), mock.MatchedBy(func(i model.OIDCIdentity) bool {
+		return i.Subject == "u-2" && i.NIM == "2201001"
+	})).Return(nil)
+	env.audit.On("Record", mock.Anything, mock.MatchedBy(func(e *model.AuditLog) bool {
+		return e.Event == model.AuditSSOLinked
+	})).Return(nil)
+	env.expectTokens(9, model.RoleStudent)
+
+	resp := env.login(t)
+	assert.Equal(t, 200, resp.StatusCode)
+	env.audit.AssertExpectations(t)
+}
+
+func TestOIDCLogin_ConflictingMatches(t *testing.T) {
+	env := setupOIDCApp(t, true)
+	env.idp.SetUser(map[string]interface{}{"sub": "u-3", "email": "a@kampus.ac.id", "email_verified": true, "nip": "1987"})
+	env.identities.On("FindUserID", mock.Anything, mock.Anything, "u-3").Return(int64(0), sql.ErrNoRows)
+	env.identities.On("MatchUsers", mock.Anything, "a@kampus.ac.id", "", "1987").Return([]int64{3, 4}, nil)
+
+	resp := env.login(t)
+	assert.Equal(t, 409, resp.StatusCode)
+	env.identities.AssertNotCalled(t, "Link", mock.Anything, mock.Anything, mock.Anything)
+	env.identities.AssertNotCalled(t, "Provision", mock.Anything, mock.Anything)
+}
+
+func TestOIDCLogin_UnknownWithoutProvisioning(t *testing.T) {
+	env := setupOIDCApp(t, false)
+	env.idp.SetUser(map[string]interface{}{"sub": "u-4", "email": "baru@kampus.ac.id", "email_verified": true, "nim": "2301"})
+	env.identities.On("FindUserID", mock.Anything, mock.Anything, "u-4").Return(int64(0), sql.ErrNoRows)
+	env.identities.On("MatchUsers", mock.Anything, mock.Anything, mock.Anything, mock.Anything).Return([]int64{}, nil)
+
+	resp := env.login(t)
+	assert.Equal(t, 403, resp.StatusCode)
+	assert.Equal(t, "account_not_found", decodeBody(t, resp)["error"])
+}
+
+func TestOIDCLogin_ProvisionsStudent(t *testing.T) {
+	env := setupOIDCApp(t, true)
+	env.idp.SetUser(map[string]interface{}{
+		"sub":            "u-5",
+		"email":          "Siti@Kampus.ac.id",
+		"email_verified": true,
+		"name":           "Siti Aminah",
+		"nim":            "2301002",
+		"program_study":  "Informatika",
+	})
+	env.identities.On("FindUserID", mock.Anything, mock.Anything, "u-5").Return(int64(0), sql.ErrNoRows)
+	env.identities.On("MatchUsers", mock.Anything, "siti@kampus.ac.id", "2301002", "").Return([]int64{}, nil)
+	env.userRepo.On("GetRoleIDByName", model.RoleStudent).Return("role-mhs", nil)
+	var provisioned model.OIDCProvision
+	env.identities.On("Provision", mock.Anything, mock.Anything).
+		Run(func(args mock.Arguments) { provisioned = args.Get(1).(model.OIDCProvision) }).
+		Return(int64(11), nil)
+	env.audit.On("Record", mock.Anything, mock.Anything).Return(nil)
+	env.expectTokens(11, model.RoleStudent)
+
+	resp := env.login(t)
+	assert.Equal(t, 200, resp.StatusCode)
+	assert.Equal(t, "2301002", provisioned.Username)
+	assert.Equal(t, "role-mhs", provisioned.RoleID)
+	assert.Equal(t, model.RoleStudent, provisioned.Role)
+	assert.Equal(t, "Informatika", provisioned.Identity.ProgramStudy)
+	assert.Equal(t, "Siti Aminah", provisioned.Identity.FullName)
+	// bukan hash bcrypt: login password tidak mungkin berhasil
+	assert.False(t, utils.CheckPassword(provisioned.PasswordHash, ""))
+}
+
+func TestOIDCLogin_ProvisionConflict(t *testing.T) {
+	env := setupOIDCApp(t, true)
+	env.idp.SetUser(map[string]interface{}{"sub": "u-6", "email": "x@kampus.ac.id", "email_verified": true, "nip": "1990"})
+	env.identities.On("FindUserID", mock.Anything, mock.Anything, "u-6").Return(int64(0), sql.ErrNoRows)
+	env.identities.On("MatchUsers", mock.Anything, mock.Anything, mock.Anything, mock.Anything).Return([]int64{}, nil)
+	env.userRepo.On("GetRoleIDByName", model.RoleLecturer).Return("role-dosen", nil)
+	env.identities.On("Provision", mock.Anything, mock.Anything).Return(int64(0), repository.ErrProvisionConflict)
+
+	resp := env.login(t)
+	assert.Equal(t, 409, resp.StatusCode)
+}
+
+func TestOIDCCallback_InvalidState(t *testing.T) {
+	env := setupOIDCApp(t, false)
+	env.identities.On("ConsumeLoginState", mock.Anything, utils.HashToken("forged")).
+		Return(nil, sql.ErrNoRows)
+
+	req := httptest.NewRequest(http.MethodGet, "/oidc/callback?code=c&state=forged", nil)
+	req.AddCookie(&http.Cookie{Name: oidcStateCookie, Value: utils.HashToken("forged")})
+	resp, err := env.app.Test(req)
+	assert.NoError(t, err)
+	assert.Equal(t, 401, resp.StatusCode)
+	assert.Equal(t, "invalid_state", decodeBody(t, resp)["error"])
+}
+
+// Callback tanpa cookie state / cookie milik login lain (login CSRF):
+// ditolak sebelum state di server dipakai
+func TestOIDCCallback_StateCookieRequired(t *testing.T) {
+	env := setupOIDCApp(t, false)
+
+	cases := map[string]*http.Cookie{
+		"tanpa cookie": nil,
+		"cookie beda":  {Name: oidcStateCookie, Value: utils.HashToken("state-lain")},
+	}
+	for name, cookie := range cases {
+		req := httptest.NewRequest(http.MethodGet, "/oidc/callback?code=c&state=milik-penyerang", nil)
+		if cookie != nil {
+			req.AddCookie(cookie)
+		}
+		resp, err := env.app.Test(req)
+		assert.NoError(t, err)
+		assert.Equal(t, 401, resp.StatusCode, name)
+		assert.Equal(t, "invalid_state", decodeBody(t, resp)["error"], name)
+	}
+	env.identities.AssertNotCalled(t, "ConsumeLoginState", mock.Anything, mock.Anything)
+}
+
+func TestOIDCLogin_Disabled(t *testing.T) {
+	service := NewOIDCService(nil, nil, nil, nil, OIDCOptions{})
+	app := fiber.New()
+	app.Get("/oidc/login", service.LoginHandler)
+
+	resp, err := app.Test(httptest.NewRequest(http.MethodGet, "/oidc/login", nil))
+	assert.NoError(t, err)
+	assert.Equal(t, 404, resp.StatusCode)
+}
@@ -0,0 +1,275 @@
+package service
+
+import (
+	"context"
+	"database/sql"
+	"errors"
+	"fmt"
+	"log"
+	"strings"
+	"sync"
+	"time"
+
+	"uas/app/model"
+	"uas/app/repository"
+	"uas/utils"
+
+	"github.com/gofiber/fiber/v2"
+)
+
+type PasswordService struct {
+	userRepo  repository.UserRepository
+	tokenRepo repository.RefreshTokenRepository
+	resetRepo repository.PasswordResetRepository
+	mailer    utils.Mailer
+	guard     *LoginGuard
+	policy    *utils.PasswordPolicy
+	resetURL  string
+	resetTTL  time.Duration
+
+	// email lupa password dikirim di background (lihat ForgotPasswordHandler)
+	background sync.WaitGroup
+}
+
+// Batas waktu kirim email lupa password di background
+const forgotPasswordTimeout = 30 * time.Second
+
+func NewPasswordService(
+	userRepo repository.UserRepository,
+	tokenRepo repository.RefreshTokenRepository,
+	resetRepo repository.PasswordResetRepository,
+	mailer utils.Mailer,
+	guard *LoginGuard,
+	policy *utils.PasswordPolicy,
+	resetURL string,
+	resetTTL time.Duration,
+) *PasswordService {
+	return &PasswordService{
+		userRepo:  userRepo,
+		tokenRepo: tokenRepo,
+		resetRepo: resetRepo,
+		mailer:    mailer,
+		guard:     guard,
+		policy:    policy,
+		resetURL:  resetURL,
+		resetTTL:  resetTTL,
+	}
+}
+
+// POST /auth/password
+// Ganti password sendiri; semua sesi (termasuk yang sedang dipakai) dicabut.
+// Password lama dihitung seperti login gagal (lockout yang sama), supaya
+// access token curian tidak bisa dipakai menebak password tanpa batas.
+func (s *PasswordService) ChangePasswordHandler(c *fiber.Ctx) error {
+	claims := c.Locals("claims").(*utils.Claims)
+	var req model.ChangePasswordRequest
+	if err := c.BodyParser(&req); err != nil || req.CurrentPassword == "" {
+		return c.Status(400).JSON(fiber.Map{"error": "invalid_request"})
+	}
+	ctx := c.Context()
+
+	// 1. Akun / IP sedang dikunci?
+	if err := s.guard.Check(ctx, claims.UserID, c.IP()); err != nil {
+		return lockedResponse(c, err)
+	}
+
+	// 2. Cek password lama
+	user, err := s.userRepo.FindCredentialsByID(ctx, claims.UserID)
+	if err != nil {
+		return c.Status(404).JSON(fiber.Map{"error": "user_not_found"})
+	}
+	if !utils.CheckPassword(user.PasswordHash, req.CurrentPassword) {
+		s.guard.Failure(ctx, user.ID, c.IP())
+		return c.Status(400).JSON(fiber.Map{"error": "invalid_current_password"})
+	}
+
+	// 3. Validasi password baru
+	if fields := s.validateNewPassword(req.NewPassword, user); len(fields) > 0 {
+		return c.Status(422).JSON(fiber.Map{"error": "validation_failed", "fields": fields})
+	}
+	if req.NewPassword == req.CurrentPassword {
+		return c.Status(422).JSON(fiber.Map{
+			"error":  "validation_failed",
+			"fields": model.ValidationErrors{"new_password": "must differ from the current password"},
+		})
+	}
+
+	// 4. Simpan + cabut semua sesi
+	n, err := s.setPassword(ctx, user.ID, req.NewPassword)
+	if err != nil {
+		return c.Status(500).JSON(fiber.Map{"error": "failed_change_password"})
+	}
+	s.guard.Success(ctx, user.ID, c.IP())
+	return c.JSON(fiber.Map{
+		"message":  "password changed",
+		"sessions": n,
+	})
+}
+
+// POST /auth/password/forgot (public)
+// Respons selalu sama agar tidak bisa dipakai menebak email yang terdaftar:
+// pencarian user dan pengiriman email berjalan di background, jadi waktu
+// respons juga tidak bergantung pada ada / tidaknya akun
+func (s *PasswordService) ForgotPasswordHandler(c *fiber.Ctx) error {
+	var req model.ForgotPasswordRequest
+	if err := c.BodyParser(&req); err != nil || strings.TrimSpace(req.Email) == "" {
+		return c.Status(400).JSON(fiber.Map{"error": "invalid_request"})
+	}
+	email := strings.TrimSpace(req.Email)
+
+	if err := s.guard.ResetRequest(c.Context(), email, c.IP()); err != nil {
+		return lockedResponse(c, err)
+	}
+
+	s.background.Add(1)
+	go func() {
+		defer s.background.Done()
+		ctx, cancel := context.WithTimeout(context.Background(), forgotPasswordTimeout)
+		defer cancel()
+		s.sendForgotPassword(ctx, email)
+	}()
+
+	return c.JSON(fiber.Map{
+		"message": "if the account exists, a reset link has been sent",
+	})
+}
+
+// sendForgotPassword: hanya email (bukan username) yang dicari
+func (s *PasswordService) sendForgotPassword(ctx context.Context, email string) {
+	user, err := s.userRepo.FindCredentialsByEmail(ctx, email)
+	if err != nil {
+		if !errors.Is(err, sql.ErrNoRows) {
+			log.Printf("password reset: find user: %v", err)
+		}
+		return
+	}
+	if !user.IsActive {
+		return
+	}
+	if _, err := s.sendResetLink(ctx, user, nil); err != nil {
+		log.Printf("password reset: user %d: %v", user.ID, err)
+	}
+}
+
+// POST /auth/password/reset (public)
+// Tukar token dari email dengan password baru; token hanya berlaku sekali
+func (s *PasswordService) ResetPasswordHandler(c *fiber.Ctx) error {
+	var req model.ResetPasswordRequest
+	if err := c.BodyParser(&req); err != nil || req.Token == "" {
+		return c.Status(400).JSON(fiber.Map{"error": "invalid_request"})
+	}
+	ctx := c.Context()
+	tokenHash := utils.HashToken(req.Token)
+
+	// 1. Token masih berlaku?
+	userID, err := s.resetRepo.Lookup(ctx, tokenHash)
+	if errors.Is(err, sql.ErrNoRows) {
+		return c.Status(400).JSON(fiber.Map{"error": "invalid_reset_token"})
+	}
+	if err != nil {
+		return c.Status(500).JSON(fiber.Map{"error": "failed_reset_password"})
+	}
+	user, err := s.userRepo.FindCredentialsByID(ctx, userID)
+	if err != nil {
+		return c.Status(400).JSON(fiber.Map{"error": "invalid_reset_token"})
+	}
+
+	// 2. Validasi dulu supaya token tidak hangus karena password ditolak
+	if fields := s.validateNewPassword(req.NewPassword, user); len(fields) > 0 {
+		return c.Status(422).JSON(fiber.Map{"error": "validation_failed", "fields": fields})
+	}
+
+	// 3. Pakai token (atomic: request kedua dengan token sama gagal di sini)
+	if _, err := s.resetRepo.Consume(ctx, tokenHash); err != nil {
+		if errors.Is(err, sql.ErrNoRows) {
+			return c.Status(400).JSON(fiber.Map{"error": "invalid_reset_token"})
+		}
+		return c.Status(500).JSON(fiber.Map{"error": "failed_reset_password"})
+	}
+
+	n, err := s.setPassword(ctx, userID, req.NewPassword)
+	if err != nil {
+		return c.Status(500).JSON(fiber.Map{"error": "failed_reset_password"})
+	}
+	return c.JSON(fiber.Map{
+		"message":  "password reset",
+		"sessions": n,
+	})
+}
+
+// ADMIN: POST /admin/users/:id/password-reset
+// Kirim link reset ke email user; admin tidak pernah melihat password-nya
+func (s *PasswordService) AdminResetPasswordHandler(c *fiber.Ctx) error {
+	userID, err := c.ParamsInt("id")
+	if err != nil || userID <= 0 {
+		return c.Status(400).JSON(fiber.Map{"error": "invalid_user_id"})
+	}
+	claims := c.Locals("claims").(*utils.Claims)
+
+	user, err := s.userRepo.FindCredentialsByID(c.Context(), int64(userID))
+	if err != nil {
+		return c.Status(404).JSON(fiber.Map{"error": "user_not_found"})
+	}
+	if strings.TrimSpace(user.Email) == "" {
+		return c.Status(422).JSON(fiber.Map{"error": "user_has_no_email"})
+	}
+
+	expiresAt, err := s.sendResetLink(c.Context(), user, &claims.UserID)
+	if err != nil {
+		log.Printf("password reset: user %d: %v", user.ID, err)
+		return c.Status(500).JSON(fiber.Map{"error": "failed_send_reset"})
+	}
+	return c.JSON(fiber.Map{
+		"message":    "reset link sent",
+		"user_id":    user.ID,
+		"expires_at": expiresAt,
+	})
+}
+
+// sendResetLink membuat token baru (yang lama hangus) dan mengirimnya via mailer
+func (s *PasswordService) sendResetLink(ctx context.Context, user *model.User, requestedBy *int64) (time.Time, error) {
+	token, err := utils.NewOpaqueToken()
+	if err != nil {
+		return time.Time{}, err
+	}
+	expiresAt := time.Now().Add(s.resetTTL)
+	if err := s.resetRepo.Create(ctx, user.ID, utils.HashToken(token), expiresAt, requestedBy); err != nil {
+		return time.Time{}, err
+	}
+
+	body := fmt.Sprintf(
+		"Hello %s,\n\n"+
+			"A password reset was requested for your account (%s).\n"+
+			"Open the link below to choose a new password:\n\n%s%s\n\n"+
+			"The link expires in %s and can only be used once.\n"+
+			"If you did not request this, you can ignore this email.\n",
+		user.FullName, user.Username, s.resetURL, token, s.resetTTL,
+	)
+	err = s.mailer.Send(ctx, utils.Mail{
+		To:      user.Email,
+		Subject: "Password reset",
+		Body:    body,
+	})
+	return expiresAt, err
+}
+
+// setPassword menyimpan hash baru (token_version ikut naik → access token
+// lama ditolak) lalu mencabut semua refresh token / sesi
+func (s *PasswordService) setPassword(ctx context.Context, userID int64, password string) (int64, error) {
+	hash, err := utils.HashPassword(password)
+	if err != nil {
+		return 0, err
+	}
+	if err := s.userRepo.UpdatePassword(ctx, userID, hash); err != nil {
+		return 0, err
+	}
+	return s.tokenRepo.RevokeAllSessions(ctx, userID)
+}
+
+func (s *PasswordService) validateNewPassword(password string, user *model.User) model.ValidationErrors {
+	fields := model.ValidationErrors{}
+	if err := s.policy.Check(password, user.Username, user.Email); err != nil {
+		fields["new_password"] = err.Error()
+	}
+	return fields
+}
@@ -0,0 +1,253 @@
+package service
+
+import (
+	"context"
+	"database/sql"
+	"errors"
+	"net/http"
+	"strings"
+	"testing"
+	"time"
+
+	"uas/app/model"
+	"uas/app/repository/mocks"
+	"uas/utils"
+
+	"github.com/gofiber/fiber/v2"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/mock"
+)
+
+type recordingMailer struct {
+	sent []utils.Mail
+}
+
+func (m *recordingMailer) Send(ctx context.Context, mail utils.Mail) error {
+	m.sent = append(m.sent, mail)
+	return nil
+}
+
+type passwordTestDeps struct {
+	userRepo  *mocks.MockUserRepository
+	tokenRepo *mocks.MockRefreshTokenRepository
+	resetRepo *mocks.MockPasswordResetRepository
+	mailer    *recordingMailer
+	throttle  *mocks.MockLoginThrottleRepository
+	service   *PasswordService
+}
+
+// Tidak ada akun / IP / email yang dikunci (lupa password, ganti password)
+func (d passwordTestDeps) unlocked() {
+	d.throttle.On("LockedUntil", mock.Anything, mock.Anything).Return(time.Time{}, nil)
+	d.throttle.On("RecordFailure", mock.Anything, mock.Anything, mock.Anything).Return(1, nil)
+	d.throttle.On("Reset", mock.Anything, mock.Anything).Return(false, nil)
+}
+
+// Kirim POST /password/forgot lalu tunggu pengiriman di background selesai
+func (d passwordTestDeps) forgot(app *fiber.App, email string) *http.Response {
+	resp, _ := app.Test(jsonRequest(http.MethodPost, "/password/forgot", model.ForgotPasswordRequest{Email: email}))
+	d.service.background.Wait()
+	return resp
+}
+
+func setupPasswordApp() (*fiber.App, passwordTestDeps) {
+	d := passwordTestDeps{
+		userRepo:  new(mocks.MockUserRepository),
+		tokenRepo: new(mocks.MockRefreshTokenRepository),
+		resetRepo: new(mocks.MockPasswordResetRepository),
+		mailer:    &recordingMailer{},
+		throttle:  new(mocks.MockLoginThrottleRepository),
+	}
+	guard := NewLoginGuard(d.throttle, new(mocks.MockAuditRepository), testGuardOptions)
+	service := NewPasswordService(d.userRepo, d.tokenRepo, d.resetRepo, d.mailer, guard, testPasswordPolicy(),
+		"https://uas.example/reset?token=", 30*time.Minute)
+	d.service = service
+
+	app := fiber.New()
+	app.Post("/password/forgot", service.ForgotPasswordHandler)
+	app.Post("/password/reset", service.ResetPasswordHandler)
+	app.Use(func(c *fiber.Ctx) error {
+		c.Locals("claims", &utils.Claims{UserID: 1, Role: "admin"})
+		return c.Next()
+	})
+	app.Post("/password", service.ChangePasswordHandler)
+	app.Post("/users/:id/password-reset", service.AdminResetPasswordHandler)
+	return app, d
+}
+
+func credentialsFor(id int64, password string) *model.User {
+	hash, _ := utils.HashPassword(password)
+	return &model.User{ID: id, Username: "budi", FullName: "Budi", Email: "budi@example.ac.id", PasswordHash: hash, IsActive: true}
+}
+
+func TestChangePassword_RevokesAllSessions(t *testing.T) {
+	app, d := setupPasswordApp()
+	d.unlocked()
+	d.userRepo.On("FindCredentialsByID", mock.Anything, int64(1)).Return(credentialsFor(1, "Lama-123456"), nil)
+	d.userRepo.On("UpdatePassword", mock.Anything, int64(1), mock.Anything).
+		Run(func(args mock.Arguments) {
+			assert.True(t, utils.CheckPassword(args.String(2), "Baru-123456"))
+		}).
+		Return(nil)
+	d.tokenRepo.On("RevokeAllSessions", mock.Anything, int64(1)).Return(int64(2), nil)
+
+	resp, err := app.Test(jsonRequest(http.MethodPost, "/password", model.ChangePasswordRequest{
+		CurrentPassword: "Lama-123456",
+		NewPassword:     "Baru-123456",
+	}))
+	assert.NoError(t, err)
+	assert.Equal(t, 200, resp.StatusCode)
+	d.userRepo.AssertExpectations(t)
+	d.tokenRepo.AssertExpectations(t)
+	// berhasil: hitungan gagal akun direset
+	d.throttle.AssertCalled(t, "Reset", mock.Anything, "user:1")
+}
+
+func TestChangePassword_Rejected(t *testing.T) {
+	app, d := setupPasswordApp()
+	d.unlocked()
+	d.userRepo.On("FindCredentialsByID", mock.Anything, int64(1)).Return(credentialsFor(1, "Lama-123456"), nil)
+
+	cases := map[string]struct {
+		req  model.ChangePasswordRequest
+		want int
+	}{
+		"password lama salah": {model.ChangePasswordRequest{CurrentPassword: "salah", NewPassword: "Baru-123456"}, 400},
+		"terlalu pendek":      {model.ChangePasswordRequest{CurrentPassword: "Lama-123456", NewPassword: "pendek"}, 422},
+		"sama dengan lama":    {model.ChangePasswordRequest{CurrentPassword: "Lama-123456", NewPassword: "Lama-123456"}, 422},
+	}
+	for name, tc := range cases {
+		resp, err := app.Test(jsonRequest(http.MethodPost, "/password", tc.req))
+		assert.NoError(t, err)
+		assert.Equal(t, tc.want, resp.StatusCode, name)
+	}
+	d.userRepo.AssertNotCalled(t, "UpdatePassword", mock.Anything, mock.Anything, mock.Anything)
+	// hanya password lama yang salah dihitung sebagai gagal
+	d.throttle.AssertNumberOfCalls(t, "RecordFailure", 2) // ip + user
+	d.throttle.AssertCalled(t, "RecordFailure", mock.Anything, "user:1", testGuardOptions.FailureWindow)
+}
+
+// Akun terkunci (mis. karena tebakan password lama): 429 sebelum password dicek
+func TestChangePassword_Locked(t *testing.T) {
+	app, d := setupPasswordApp()
+	d.throttle.On("LockedUntil", mock.Anything, []string{"ip:0.0.0.0", "user:1"}).
+		Return(time.Now().Add(time.Minute), nil)
+
+	resp, err := app.Test(jsonRequest(http.MethodPost, "/password", model.ChangePasswordRequest{
+		CurrentPassword: "Lama-123456",
+		NewPassword:     "Baru-123456",
+	}))
+	assert.NoError(t, err)
+	assert.Equal(t, 429, resp.StatusCode)
+	assert.NotEmpty(t, resp.Header.Get("Retry-After"))
+	d.userRepo.AssertNotCalled(t, "FindCredentialsByID", mock.Anything, mock.Anything)
+	d.userRepo.AssertNotCalled(t, "UpdatePassword", mock.Anything, mock.Anything, mock.Anything)
+}
+
+func TestForgotPassword_SendsSingleUseLink(t *testing.T) {
+	app, d := setupPasswordApp()
+	d.unlocked()
+	d.userRepo.On("FindCredentialsByEmail", mock.Anything, "budi@example.ac.id").Return(credentialsFor(3, "x"), nil)
+	var storedHash string
+	d.resetRepo.On("Create", mock.Anything, int64(3), mock.Anything, mock.Anything, (*int64)(nil)).
+		Run(func(args mock.Arguments) { storedHash = args.String(2) }).
+		Return(nil)
+
+	resp := d.forgot(app, "budi@example.ac.id")
+	assert.Equal(t, 200, resp.StatusCode)
+
+	// email berisi token asli, database hanya hash-nya
+	if assert.Len(t, d.mailer.sent, 1) {
+		mail := d.mailer.sent[0]
+		assert.Equal(t, "budi@example.ac.id", mail.To)
+		i := strings.Index(mail.Body, "token=")
+		assert.True(t, i > 0)
+		token := strings.Fields(mail.Body[i+len("token="):])[0]
+		assert.Equal(t, utils.HashToken(token), storedHash)
+	}
+}
+
+// Email tidak terdaftar: respons sama, tidak ada email terkirim
+func TestForgotPassword_UnknownEmail(t *testing.T) {
+	app, d := setupPasswordApp()
+	d.unlocked()
+	d.userRepo.On("FindCredentialsByEmail", mock.Anything, "siapa@example.ac.id").Return(nil, sql.ErrNoRows)
+
+	resp := d.forgot(app, "siapa@example.ac.id")
+	assert.Equal(t, 200, resp.StatusCode)
+	assert.Empty(t, d.mailer.sent)
+	d.resetRepo.AssertNotCalled(t, "Create", mock.Anything, mock.Anything, mock.Anything, mock.Anything, mock.Anything)
+}
+
+// Username bukan email: tidak dicari sebagai username
+func TestForgotPassword_LooksUpByEmailOnly(t *testing.T) {
+	app, d := setupPasswordApp()
+	d.unlocked()
+	d.userRepo.On("FindCredentialsByEmail", mock.Anything, "budi").Return(nil, sql.ErrNoRows)
+
+	resp := d.forgot(app, "budi")
+	assert.Equal(t, 200, resp.StatusCode)
+	assert.Empty(t, d.mailer.sent)
+	d.userRepo.AssertNotCalled(t, "FindByUsernameOrEmail", mock.Anything)
+}
+
+// Email / IP yang sedang dikunci: 429, token lama tidak diganti
+func TestForgotPassword_Throttled(t *testing.T) {
+	app, d := setupPasswordApp()
+	d.throttle.On("LockedUntil", mock.Anything, []string{"reset:email:budi@example.ac.id", "reset:ip:0.0.0.0"}).
+		Return(time.Now().Add(time.Minute), nil)
+
+	resp := d.forgot(app, "Budi@example.ac.id")
+	assert.Equal(t, 429, resp.StatusCode)
+	assert.NotEmpty(t, resp.Header.Get("Retry-After"))
+	assert.Empty(t, d.mailer.sent)
+	d.userRepo.AssertNotCalled(t, "FindCredentialsByEmail", mock.Anything, mock.Anything)
+	d.resetRepo.AssertNotCalled(t, "Create", mock.Anything, mock.Anything, mock.Anything, mock.Anything, mock.Anything)
+}
+
+func TestResetPassword(t *testing.T) {
+	app, d := setupPasswordApp()
+	d.resetRepo.On("Lookup", mock.Anything, utils.HashToken("token-baik")).Return(int64(3), nil)
+	d.resetRepo.On("Lookup", mock.Anything, utils.HashToken("token-bekas")).Return(int64(0), sql.ErrNoRows)
+	d.resetRepo.On("Lookup", mock.Anything, utils.HashToken("token-lain")).Return(int64(3), nil)
+	d.resetRepo.On("Consume", mock.Anything, utils.HashToken("token-baik")).Return(int64(3), nil)
+	d.userRepo.On("FindCredentialsByID", mock.Anything, int64(3)).Return(credentialsFor(3, "x"), nil)
+	d.userRepo.On("UpdatePassword", mock.Anything, int64(3), mock.Anything).Return(nil)
+	d.tokenRepo.On("RevokeAllSessions", mock.Anything, int64(3)).Return(int64(1), nil)
+
+	resp, err := app.Test(jsonRequest(http.MethodPost, "/password/reset", model.ResetPasswordRequest{Token: "token-baik", NewPassword: "Baru-123456"}))
+	assert.NoError(t, err)
+	assert.Equal(t, 200, resp.StatusCode)
+
+	// token sudah dipakai / kedaluwarsa
+	resp, err = app.Test(jsonRequest(http.MethodPost, "/password/reset", model.ResetPasswordRequest{Token: "token-bekas", NewPassword: "Baru-123456"}))
+	assert.NoError(t, err)
+	assert.Equal(t, 400, resp.StatusCode)
+
+	// password ditolak policy (memuat username) → token tidak dikonsumsi
+	resp, err = app.Test(jsonRequest(http.MethodPost, "/password/reset", model.ResetPasswordRequest{Token: "token-lain", NewPassword: "Budi-2026x"}))
+	assert.NoError(t, err)
+	assert.Equal(t, 422, resp.StatusCode)
+	d.resetRepo.AssertNotCalled(t, "Consume", mock.Anything, utils.HashToken("token-lain"))
+
+	d.tokenRepo.AssertExpectations(t)
+}
+
+func TestAdminResetPassword_RecordsAdmin(t *testing.T) {
+	app, d := setupPasswordApp()
+	d.userRepo.On("FindCredentialsByID", mock.Anything, int64(3)).Return(credentialsFor(3, "x"), nil)
+	d.userRepo.On("FindCredentialsByID", mock.Anything, int64(9)).Return(nil, errors.New("not found"))
+	d.resetRepo.On("Create", mock.Anything, int64(3), mock.Anything, mock.Anything, mock.MatchedBy(func(by *int64) bool {
+		return by != nil && *by == 1
+	})).Return(nil)
+
+	resp, err := app.Test(jsonRequest(http.MethodPost, "/users/3/password-reset", nil))
+	assert.NoError(t, err)
+	assert.Equal(t, 200, resp.StatusCode)
+	assert.Len(t, d.mailer.sent, 1)
+
+	resp, err = app.Test(jsonRequest(http.MethodPost, "/users/9/password-reset", nil))
+	assert.NoError(t, err)
+	assert.Equal(t, 404, resp.StatusCode)
+	d.resetRepo.AssertExpectations(t)
+}
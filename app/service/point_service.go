@@ -0,0 +1,114 @@
+package service
+
+import (
+	"database/sql"
+
+	"uas/app/model"
+	"uas/app/repository"
+	"uas/utils"
+
+	"github.com/gofiber/fiber/v2"
+)
+
+type PointService struct {
+	Repo         repository.PointRepository
+	StudentRepo  repository.StudentRepository
+	LecturerRepo repository.LecturerRepository
+}
+
+func NewPointService(
+	repo repository.PointRepository,
+	studentRepo repository.StudentRepository,
+	lecturerRepo repository.LecturerRepository,
+) *PointService {
+	return &PointService{
+		Repo:         repo,
+		StudentRepo:  studentRepo,
+		LecturerRepo: lecturerRepo,
+	}
+}
+
+// GET /students/:id/points
+func (s *PointService) GetStudentPoints(c *fiber.Ctx) error {
+	claims := c.Locals("claims").(*utils.Claims)
+	ctx := c.Context()
+	studentID := c.Params("id")
+	if studentID == "" {
+		return c.Status(400).JSON(fiber.Map{
+			"error": "invalid_student_id",
+		})
+	}
+
+	// 1. Cakupan: student:read (semua), mahasiswa pemilik, atau dosen wali pembimbing
+	if !utils.HasPermission(c, model.PermStudentRead) {
+		if myStudentID, err := s.StudentRepo.GetStudentID(ctx, claims.UserID); err == nil {
+			if myStudentID != studentID {
+				return c.Status(403).JSON(fiber.Map{"error": "forbidden"})
+			}
+		} else {
+			lecturerID, err := s.LecturerRepo.GetLecturerIDByUserID(ctx, claims.UserID)
+			if err != nil {
+				return c.Status(403).JSON(fiber.Map{"error": "forbidden"})
+			}
+			advisees, err := s.StudentRepo.GetStudentsByAdvisor(ctx, lecturerID)
+			if err != nil || !containsString(advisees, studentID) {
+				return c.Status(403).JSON(fiber.Map{"error": "student_not_supervised"})
+			}
+		}
+	}
+
+	// 2. Saldo
+	balance, cached, err := s.Repo.GetBalance(ctx, studentID)
+	if err != nil {
+		if err == sql.ErrNoRows {
+			return c.Status(404).JSON(fiber.Map{"error": "student_not_found"})
+		}
+		return c.Status(500).JSON(fiber.Map{"error": "failed_get_points"})
+	}
+
+	// 3. Rincian transaksi
+	txs, err := s.Repo.GetTransactions(ctx, studentID)
+	if err != nil {
+		return c.Status(500).JSON(fiber.Map{"error": "failed_get_point_transactions"})
+	}
+
+	return c.JSON(fiber.Map{
+		"student_id":    studentID,
+		"balance":       balance,
+		"cached_points": cached,
+		"by_semester":   sumPointsBy(txs, func(t model.PointTransaction) string { return t.Semester }),
+		"by_reason":     sumPointsBy(txs, func(t model.PointTransaction) string { return string(t.Reason) }),
+		"transactions":  txs,
+	})
+}
+
+// ADMIN: POST /admin/points/recompute?dry_run=true
+func (s *PointService) RecomputeBalances(c *fiber.Ctx) error {
+	dryRun := c.QueryBool("dry_run", false)
+	drifts, err := s.Repo.RecomputeAll(c.Context(), !dryRun)
+	if err != nil {
+		return c.Status(500).JSON(fiber.Map{"error": "failed_recompute_points"})
+	}
+	return c.JSON(fiber.Map{
+		"dry_run":          dryRun,
+		"drifted_students": len(drifts),
+		"drifts":           drifts,
+	})
+}
+
+func sumPointsBy(txs []model.PointTransaction, key func(model.PointTransaction) string) map[string]float64 {
+	out := map[string]float64{}
+	for _, t := range txs {
+		out[key(t)] += t.Delta
+	}
+	return out
+}
+
+func containsString(list []string, v string) bool {
+	for _, item := range list {
+		if item == v {
+			return true
+		}
+	}
+	return false
+}
@@ -0,0 +1,136 @@
+package service
+
+import (
+	"encoding/json"
+	"errors"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+
+	"uas/app/model"
+	"uas/app/repository/mocks"
+	"uas/utils"
+
+	"github.com/gofiber/fiber/v2"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/mock"
+)
+
+// =======================
+// SETUP
+// =======================
+
+func setupPointApp(service *PointService, userID int64, role string) *fiber.App {
+	app := fiber.New()
+	app.Use(func(c *fiber.Ctx) error {
+		c.Locals("claims", &utils.Claims{UserID: userID, Role: role})
+		return c.Next()
+	})
+	app.Get("/students/:id/points", service.GetStudentPoints)
+	app.Post("/admin/points/recompute", service.RecomputeBalances)
+	return app
+}
+
+// =======================
+// GET STUDENT POINTS
+// =======================
+
+func TestPoints_GetStudentPoints_OwnerBreakdown(t *testing.T) {
+	pointRepo := new(mocks.MockPointRepository)
+	studentRepo := new(mocks.MockStudentRepository)
+	lecturerRepo := new(mocks.MockLecturerRepository)
+
+	service := NewPointService(pointRepo, studentRepo, lecturerRepo)
+	app := setupPointApp(service, 10, "mahasiswa")
+
+	studentRepo.On("GetStudentID", mock.Anything, int64(10)).
+		Return("student-1", nil)
+	pointRepo.On("GetBalance", mock.Anything, "student-1").
+		Return(15.0, 15.0, nil)
+	pointRepo.On("GetTransactions", mock.Anything, "student-1").
+		Return([]model.PointTransaction{
+			{ID: 3, StudentID: "student-1", Delta: -10, Reason: model.PointReasonRevoke, Semester: "2025/2026-2"},
+			{ID: 2, StudentID: "student-1", Delta: 10, Reason: model.PointReasonGrant, Semester: "2025/2026-1"},
+			{ID: 1, StudentID: "student-1", Delta: 15, Reason: model.PointReasonGrant, Semester: "2025/2026-1"},
+		}, nil)
+
+	req := httptest.NewRequest(http.MethodGet, "/students/student-1/points", nil)
+	resp, err := app.Test(req)
+	assert.NoError(t, err)
+	assert.Equal(t, 200, resp.StatusCode)
+
+	var body struct {
+		Balance    float64            `json:"balance"`
+		BySemester map[string]float64 `json:"by_semester"`
+		ByReason   map[string]float64 `json:"by_reason"`
+	}
+	assert.NoError(t, json.NewDecoder(resp.Body).Decode(&body))
+	assert.Equal(t, 15.0, body.Balance)
+	assert.Equal(t, 25.0, body.BySemester["2025/2026-1"])
+	assert.Equal(t, -10.0, body.BySemester["2025/2026-2"])
+	assert.Equal(t, 25.0, body.ByReason["grant"])
+
+	pointRepo.AssertExpectations(t)
+	studentRepo.AssertExpectations(t)
+}
+
+func TestPoints_GetStudentPoints_OtherStudentForbidden(t *testing.T) {
+	pointRepo := new(mocks.MockPointRepository)
+	studentRepo := new(mocks.MockStudentRepository)
+	lecturerRepo := new(mocks.MockLecturerRepository)
+
+	service := NewPointService(pointRepo, studentRepo, lecturerRepo)
+	app := setupPointApp(service, 10, "mahasiswa")
+
+	studentRepo.On("GetStudentID", mock.Anything, int64(10)).
+		Return("student-1", nil)
+
+	req := httptest.NewRequest(http.MethodGet, "/students/student-2/points", nil)
+	resp, err := app.Test(req)
+	assert.NoError(t, err)
+	assert.Equal(t, 403, resp.StatusCode)
+
+	pointRepo.AssertNotCalled(t, "GetBalance", mock.Anything, mock.Anything)
+}
+
+// =======================
+// RECOMPUTE
+// =======================
+
+func TestPoints_Recompute_DryRunDoesNotApply(t *testing.T) {
+	pointRepo := new(mocks.MockPointRepository)
+
+	service := NewPointService(pointRepo, nil, nil)
+	app := setupPointApp(service, 1, "admin")
+
+	pointRepo.On("RecomputeAll", mock.Anything, false).
+		Return([]model.PointDrift{
+			{StudentID: "student-1", CachedPoints: 30, LedgerPoints: 15, Drift: 15},
+		}, nil)
+
+	req := httptest.NewRequest(http.MethodPost, "/admin/points/recompute?dry_run=true", nil)
+	resp, err := app.Test(req)
+	assert.NoError(t, err)
+	assert.Equal(t, 200, resp.StatusCode)
+
+	var body map[string]interface{}
+	assert.NoError(t, json.NewDecoder(resp.Body).Decode(&body))
+	assert.Equal(t, float64(1), body["drifted_students"])
+
+	pointRepo.AssertExpectations(t)
+}
+
+func TestPoints_Recompute_Error(t *testing.T) {
+	pointRepo := new(mocks.MockPointRepository)
+
+	service := NewPointService(pointRepo, nil, nil)
+	app := setupPointApp(service, 1, "admin")
+
+	pointRepo.On("RecomputeAll", mock.Anything, true).
+		Return(nil, errors.New("db down"))
+
+	req := httptest.NewRequest(http.MethodPost, "/admin/points/recompute", nil)
+	resp, err := app.Test(req)
+	assert.NoError(t, err)
+	assert.Equal(t, 500, resp.StatusCode)
+}
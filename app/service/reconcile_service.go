@@ -0,0 +1,295 @@
+package service
+
+import (
+	"context"
+	"database/sql"
+	"errors"
+	"fmt"
+	"sort"
+	"time"
+
+	"uas/app/model"
+	"uas/app/repository"
+	"uas/utils"
+
+	"github.com/gofiber/fiber/v2"
+	"go.mongodb.org/mongo-driver/bson"
+	"go.mongodb.org/mongo-driver/bson/primitive"
+)
+
+// ReconcileService membandingkan achievement_references dengan dokumen Mongo,
+// melaporkan selisihnya dan (opsional) memperbaikinya.
+type ReconcileService struct {
+	Repo    repository.AchievementReferenceRepository
+	Docs    repository.AchievementDocumentStore
+	SyncOps repository.AchievementSyncRepository
+
+	// Dokumen Mongo yang lebih muda dari Grace tidak dilaporkan sebagai orphan:
+	// reference PostgreSQL-nya mungkin belum ter-commit (create sedang berjalan)
+	Grace time.Duration
+}
+
+func NewReconcileService(
+	repo repository.AchievementReferenceRepository,
+	docs repository.AchievementDocumentStore,
+	syncOps repository.AchievementSyncRepository,
+) *ReconcileService {
+	return &ReconcileService{
+		Repo:    repo,
+		Docs:    docs,
+		SyncOps: syncOps,
+		Grace:   syncGracePeriod,
+	}
+}
+
+type ReconcileOptions struct {
+	Repair bool
+	DryRun bool
+	Actor  repository.AchievementActor
+}
+
+// ADMIN: POST /admin/achievements/reconcile?repair=true&dry_run=true
+func (s *ReconcileService) Reconcile(c *fiber.Ctx) error {
+	claims := c.Locals("claims").(*utils.Claims)
+	report, err := s.Run(c.Context(), ReconcileOptions{
+		Repair: c.QueryBool("repair", false),
+		DryRun: c.QueryBool("dry_run", false),
+		Actor:  actorFromClaims(claims),
+	})
+	if err != nil {
+		return c.Status(500).JSON(fiber.Map{"error": err.Error()})
+	}
+	return c.JSON(report)
+}
+
+// Run memindai kedua store; perbaikan hanya dijalankan jika Repair && !DryRun
+func (s *ReconcileService) Run(ctx context.Context, opts ReconcileOptions) (*model.ReconcileReport, error) {
+	refs, err := s.Repo.ListReferenceStates(ctx)
+	if err != nil {
+		return nil, fmt.Errorf("failed to list references: %w", err)
+	}
+	docs, err := s.Docs.ListDocumentStates(ctx)
+	if err != nil {
+		return nil, fmt.Errorf("failed to list mongo documents: %w", err)
+	}
+	// Operasi dual-write yang sedang berjalan tidak dihitung sebagai selisih
+	pending, err := s.SyncOps.ListPending(ctx, time.Now(), 1000)
+	if err != nil {
+		return nil, fmt.Errorf("failed to list pending sync operations: %w", err)
+	}
+	inFlight := map[string]bool{}
+	for _, op := range pending {
+		inFlight[op.MongoAchievementID] = true
+	}
+
+	report := &model.ReconcileReport{
+		Repair:            opts.Repair,
+		DryRun:            opts.DryRun,
+		ScannedReferences: len(refs),
+		ScannedDocuments:  len(docs),
+		Counts:            map[string]int{},
+		Findings:          []model.ReconcileFinding{},
+	}
+
+	findings := findReconcileIssues(refs, docs, inFlight, s.cutoff(), report)
+	for i := range findings {
+		f := &findings[i]
+		report.Counts[f.Kind]++
+		if opts.Repair && !opts.DryRun && f.Action != model.ReconcileActionManualReview {
+			// scan di atas bukan snapshot: kondisi bisa berubah sejak dibaca
+			current, err := s.stillApplies(ctx, f)
+			switch {
+			case err != nil:
+				f.Error = err.Error()
+			case !current:
+				f.Error = "state changed since scan, skipped"
+			default:
+				if err := s.repair(ctx, f, opts.Actor); err != nil {
+					f.Error = err.Error()
+				} else {
+					f.Repaired = true
+					report.Repaired++
+				}
+			}
+		}
+	}
+	report.Findings = findings
+	return report, nil
+}
+
+func (s *ReconcileService) cutoff() time.Time {
+	return time.Now().Add(-s.Grace)
+}
+
+// stillApplies membaca ulang reference, dokumen dan operasi pending untuk
+// satu temuan lalu menghitung ulang; false jika temuan sudah tidak berlaku
+func (s *ReconcileService) stillApplies(ctx context.Context, f *model.ReconcileFinding) (bool, error) {
+	pending, err := s.SyncOps.HasPending(ctx, f.MongoID)
+	if err != nil || pending {
+		return false, err
+	}
+
+	var refs []model.ReferenceState
+	ref, err := s.Repo.GetReferenceState(ctx, f.MongoID)
+	switch {
+	case err == nil:
+		refs = append(refs, *ref)
+	case !errors.Is(err, sql.ErrNoRows):
+		return false, err
+	}
+
+	objID, err := primitive.ObjectIDFromHex(f.MongoID)
+	if err != nil {
+		return false, err
+	}
+	var docs []model.DocumentState
+	doc, err := s.Docs.GetDocumentState(ctx, objID)
+	switch {
+	case err == nil:
+		docs = append(docs, *doc)
+	case !errors.Is(err, repository.ErrDocumentNotFound):
+		return false, err
+	}
+
+	for _, again := range findReconcileIssues(refs, docs, nil, s.cutoff(), &model.ReconcileReport{}) {
+		if again.Kind == f.Kind && again.Action == f.Action && again.StudentID == f.StudentID {
+			return true, nil
+		}
+	}
+	return false, nil
+}
+
+// Bandingkan kedua store. PostgreSQL adalah sumber kebenaran untuk
+// kepemilikan (student) dan status hapus. Dokumen yang dibuat setelah
+// cutoff tidak dianggap orphan.
+func findReconcileIssues(
+	refs []model.ReferenceState,
+	docs []model.DocumentState,
+	inFlight map[string]bool,
+	cutoff time.Time,
+	report *model.ReconcileReport,
+) []model.ReconcileFinding {
+	docByID := map[string]model.DocumentState{}
+	for _, d := range docs {
+		docByID[d.MongoID] = d
+	}
+	refByMongoID := map[string]bool{}
+	findings := []model.ReconcileFinding{}
+
+	for _, ref := range refs {
+		refByMongoID[ref.MongoID] = true
+		if inFlight[ref.MongoID] {
+			report.SkippedInFlight++
+			continue
+		}
+		refID := ref.ID
+		doc, ok := docByID[ref.MongoID]
+		if !ok {
+			if ref.IsDeleted {
+				continue
+			}
+			action := model.ReconcileActionManualReview
+			if ref.Status == string(model.AchievementStatusDraft) || ref.Status == string(model.AchievementStatusRejected) {
+				action = model.ReconcileActionSoftDeleteReference
+			}
+			findings = append(findings, model.ReconcileFinding{
+				Kind:        model.ReconcileDanglingReference,
+				MongoID:     ref.MongoID,
+				ReferenceID: &refID,
+				Detail:      fmt.Sprintf("reference status %s points to a missing mongo document", ref.Status),
+				Action:      action,
+			})
+			continue
+		}
+		if doc.StudentID != ref.StudentID {
+			findings = append(findings, model.ReconcileFinding{
+				Kind:        model.ReconcileStudentMismatch,
+				MongoID:     ref.MongoID,
+				ReferenceID: &refID,
+				StudentID:   ref.StudentID,
+				Detail:      fmt.Sprintf("mongo studentId %q, reference student_uuid %q", doc.StudentID, ref.StudentID),
+				Action:      model.ReconcileActionSetDocumentStudent,
+			})
+		}
+		docDeleted := doc.DeletedAt != nil
+		if docDeleted != ref.IsDeleted {
+			action := model.ReconcileActionMarkDocumentDeleted
+			detail := "reference is deleted but mongo document is not"
+			if docDeleted {
+				action = model.ReconcileActionClearDocumentDelete
+				detail = "mongo document has deletedAt but reference is not deleted"
+			}
+			findings = append(findings, model.ReconcileFinding{
+				Kind:        model.ReconcileDeletedDivergence,
+				MongoID:     ref.MongoID,
+				ReferenceID: &refID,
+				Detail:      detail,
+				Action:      action,
+			})
+		}
+	}
+
+	for _, d := range docs {
+		if refByMongoID[d.MongoID] {
+			continue
+		}
+		if inFlight[d.MongoID] {
+			report.SkippedInFlight++
+			continue
+		}
+		if d.DeletedAt != nil {
+			continue
+		}
+		if createdAfter(d.MongoID, cutoff) {
+			report.SkippedRecent++
+			continue
+		}
+		findings = append(findings, model.ReconcileFinding{
+			Kind:    model.ReconcileOrphanDocument,
+			MongoID: d.MongoID,
+			Detail:  "mongo document has no achievement reference",
+			Action:  model.ReconcileActionSoftDeleteDocument,
+		})
+	}
+
+	sort.SliceStable(findings, func(i, j int) bool {
+		return findings[i].Kind < findings[j].Kind
+	})
+	return findings
+}
+
+// createdAfter: waktu pembuatan dokumen dari timestamp ObjectID
+func createdAfter(mongoID string, cutoff time.Time) bool {
+	id, err := primitive.ObjectIDFromHex(mongoID)
+	if err != nil {
+		return false
+	}
+	return id.Timestamp().After(cutoff)
+}
+
+func (s *ReconcileService) repair(ctx context.Context, f *model.ReconcileFinding, actor repository.AchievementActor) error {
+	if f.Action == model.ReconcileActionSoftDeleteReference {
+		return s.Repo.SoftDeleteDangling(ctx, *f.ReferenceID, actor)
+	}
+
+	objID, err := primitive.ObjectIDFromHex(f.MongoID)
+	if err != nil {
+		return err
+	}
+	filter := bson.M{"_id": objID}
+	var update bson.M
+	switch f.Action {
+	case model.ReconcileActionSoftDeleteDocument:
+		update = bson.M{"$set": bson.M{"deletedAt": syncTimestamp(), "orphaned": true}}
+	case model.ReconcileActionMarkDocumentDeleted:
+		update = bson.M{"$set": bson.M{"deletedAt": syncTimestamp()}}
+	case model.ReconcileActionClearDocumentDelete:
+		update = bson.M{"$unset": bson.M{"deletedAt": ""}}
+	case model.ReconcileActionSetDocumentStudent:
+		update = bson.M{"$set": bson.M{"studentId": f.StudentID, "updatedAt": syncTimestamp()}}
+	default:
+		return fmt.Errorf("unknown repair action %q", f.Action)
+	}
+	_, err = s.Docs.Update(ctx, filter, update)
+	return err
+}
@@ -0,0 +1,213 @@
+package service
+
+import (
+	"context"
+	"database/sql"
+	"database/sql/driver"
+	"encoding/json"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+	"time"
+
+	"uas/app/model"
+	"uas/app/repository"
+	"uas/app/repository/mocks"
+	"uas/utils"
+
+	"github.com/DATA-DOG/go-sqlmock"
+	"github.com/gofiber/fiber/v2"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/mock"
+	"go.mongodb.org/mongo-driver/bson"
+	"go.mongodb.org/mongo-driver/bson/primitive"
+)
+
+const (
+	reconcileDangling = "693a838b456e26eb7bec0001"
+	reconcileOrphan   = "693a838b456e26eb7bec0002"
+	reconcileMismatch = "693a838b456e26eb7bec0003"
+	reconcileDeleted  = "693a838b456e26eb7bec0004"
+	reconcileInFlight = "693a838b456e26eb7bec0005"
+	reconcileVerified = "693a838b456e26eb7bec0006"
+)
+
+// Satu contoh untuk setiap jenis temuan + satu operasi yang sedang berjalan
+func newReconcileTestService(t *testing.T, docs *fakeDocs) (*ReconcileService, sqlmock.Sqlmock) {
+	db, sqlMock, err := sqlmock.New()
+	assert.NoError(t, err)
+	t.Cleanup(func() { db.Close() })
+
+	sqlMock.ExpectQuery("SELECT id, mongo_achievement_id, student_uuid, status, is_deleted").
+		WillReturnRows(sqlmock.NewRows([]string{"id", "mongo_achievement_id", "student_uuid", "status", "is_deleted"}).
+			AddRow("ref-1", reconcileDangling, "student-1", "draft", false).
+			AddRow("ref-2", reconcileMismatch, "student-1", "verified", false).
+			AddRow("ref-3", reconcileDeleted, "student-1", "draft", true).
+			AddRow("ref-4", reconcileInFlight, "student-1", "draft", false).
+			AddRow("ref-5", reconcileVerified, "student-1", "verified", false))
+
+	deletedAt := time.Now()
+	docs.states = []model.DocumentState{
+		{MongoID: reconcileOrphan, StudentID: "student-2"},
+		{MongoID: reconcileMismatch, StudentID: "student-2"},
+		{MongoID: reconcileDeleted, StudentID: "student-1"},
+		{MongoID: reconcileInFlight, StudentID: "student-1", DeletedAt: &deletedAt},
+	}
+
+	syncOps := new(mocks.MockAchievementSyncRepository)
+	syncOps.On("ListPending", mock.Anything, mock.Anything, mock.Anything).
+		Return([]model.AchievementSyncOp{{ID: 9, Op: model.SyncOpUpdate, MongoAchievementID: reconcileInFlight}}, nil)
+	syncOps.On("HasPending", mock.Anything, mock.Anything).Return(false, nil)
+
+	return NewReconcileService(repository.NewAchievementRepository(db), docs, syncOps), sqlMock
+}
+
+// cek ulang reference sebelum repair; ref nil = reference tidak ada
+func expectReferenceState(sqlMock sqlmock.Sqlmock, mongoID string, ref []driver.Value) {
+	q := sqlMock.ExpectQuery("SELECT id, mongo_achievement_id, student_uuid, status, is_deleted").WithArgs(mongoID)
+	if ref == nil {
+		q.WillReturnError(sql.ErrNoRows)
+		return
+	}
+	q.WillReturnRows(sqlmock.NewRows([]string{"id", "mongo_achievement_id", "student_uuid", "status", "is_deleted"}).
+		AddRow(ref...))
+}
+
+func TestReconcile_ReportOnly(t *testing.T) {
+	docs := &fakeDocs{}
+	service, sqlMock := newReconcileTestService(t, docs)
+
+	report, err := service.Run(context.Background(), ReconcileOptions{})
+	assert.NoError(t, err)
+
+	assert.Equal(t, 5, report.ScannedReferences)
+	assert.Equal(t, 4, report.ScannedDocuments)
+	assert.Equal(t, 1, report.SkippedInFlight)
+	assert.Equal(t, map[string]int{
+		model.ReconcileDanglingReference: 2,
+		model.ReconcileOrphanDocument:    1,
+		model.ReconcileStudentMismatch:   1,
+		model.ReconcileDeletedDivergence: 1,
+	}, report.Counts)
+
+	actions := map[string]string{}
+	for _, f := range report.Findings {
+		actions[f.Kind+"/"+f.MongoID] = f.Action
+		assert.False(t, f.Repaired)
+	}
+	assert.Equal(t, model.ReconcileActionSoftDeleteReference, actions[model.ReconcileDanglingReference+"/"+reconcileDangling])
+	assert.Equal(t, model.ReconcileActionManualReview, actions[model.ReconcileDanglingReference+"/"+reconcileVerified])
+	assert.Equal(t, model.ReconcileActionSoftDeleteDocument, actions[model.ReconcileOrphanDocument+"/"+reconcileOrphan])
+	assert.Equal(t, model.ReconcileActionSetDocumentStudent, actions[model.ReconcileStudentMismatch+"/"+reconcileMismatch])
+	assert.Equal(t, model.ReconcileActionMarkDocumentDeleted, actions[model.ReconcileDeletedDivergence+"/"+reconcileDeleted])
+
+	assert.Empty(t, docs.updates)
+	assert.NoError(t, sqlMock.ExpectationsWereMet())
+}
+
+func TestReconcile_DryRunChangesNothing(t *testing.T) {
+	docs := &fakeDocs{}
+	service, sqlMock := newReconcileTestService(t, docs)
+
+	report, err := service.Run(context.Background(), ReconcileOptions{Repair: true, DryRun: true})
+	assert.NoError(t, err)
+	assert.Equal(t, 0, report.Repaired)
+	assert.Len(t, report.Findings, 5)
+	assert.Empty(t, docs.updates)
+	assert.NoError(t, sqlMock.ExpectationsWereMet())
+}
+
+func TestReconcile_Repair(t *testing.T) {
+	docs := &fakeDocs{}
+	service, sqlMock := newReconcileTestService(t, docs)
+
+	// urutan temuan: dangling, deleted_divergence, orphan, student_mismatch
+	expectReferenceState(sqlMock, reconcileDangling, []driver.Value{"ref-1", reconcileDangling, "student-1", "draft", false})
+	sqlMock.ExpectBegin()
+	sqlMock.ExpectQuery("UPDATE achievement_references").
+		WithArgs("ref-1").
+		WillReturnRows(sqlmock.NewRows([]string{"mongo_achievement_id", "status"}).AddRow(reconcileDangling, "draft"))
+	sqlMock.ExpectExec("INSERT INTO achievement_status_events").
+		WithArgs("ref-1", reconcileDangling, "draft", "deleted", int64(0), "system", "reconcile: mongo document missing").
+		WillReturnResult(sqlmock.NewResult(1, 1))
+	sqlMock.ExpectCommit()
+	expectReferenceState(sqlMock, reconcileDeleted, []driver.Value{"ref-3", reconcileDeleted, "student-1", "draft", true})
+	expectReferenceState(sqlMock, reconcileOrphan, nil)
+	expectReferenceState(sqlMock, reconcileMismatch, []driver.Value{"ref-2", reconcileMismatch, "student-1", "verified", false})
+
+	report, err := service.Run(context.Background(), ReconcileOptions{
+		Repair: true,
+		Actor:  repository.AchievementActor{Role: "system"},
+	})
+	assert.NoError(t, err)
+	// manual_review tidak diperbaiki otomatis
+	assert.Equal(t, 4, report.Repaired)
+	assert.NoError(t, sqlMock.ExpectationsWereMet())
+
+	assert.Len(t, docs.updates, 3)
+	var sawStudentFix bool
+	for _, u := range docs.updates {
+		if set, ok := u["$set"].(bson.M); ok && set["studentId"] == "student-1" {
+			sawStudentFix = true
+		}
+	}
+	assert.True(t, sawStudentFix)
+}
+
+func TestReconcile_SkipsRecentDocuments(t *testing.T) {
+	docs := &fakeDocs{}
+	service, sqlMock := newReconcileTestService(t, docs)
+	// dibuat barusan: reference-nya mungkin belum ter-commit
+	docs.states = append(docs.states, model.DocumentState{MongoID: primitive.NewObjectID().Hex(), StudentID: "student-3"})
+
+	report, err := service.Run(context.Background(), ReconcileOptions{})
+	assert.NoError(t, err)
+	assert.Equal(t, 1, report.SkippedRecent)
+	assert.Equal(t, 1, report.Counts[model.ReconcileOrphanDocument])
+	assert.NoError(t, sqlMock.ExpectationsWereMet())
+}
+
+func TestReconcile_RepairSkipsChangedState(t *testing.T) {
+	docs := &fakeDocs{}
+	service, sqlMock := newReconcileTestService(t, docs)
+
+	// setiap temuan sudah beres saat dicek ulang
+	sqlMock.MatchExpectationsInOrder(false)
+	expectReferenceState(sqlMock, reconcileDangling, []driver.Value{"ref-1", reconcileDangling, "student-1", "draft", true})
+	expectReferenceState(sqlMock, reconcileDeleted, []driver.Value{"ref-3", reconcileDeleted, "student-1", "draft", false})
+	expectReferenceState(sqlMock, reconcileOrphan, []driver.Value{"ref-9", reconcileOrphan, "student-2", "draft", false})
+	expectReferenceState(sqlMock, reconcileMismatch, []driver.Value{"ref-2", reconcileMismatch, "student-2", "verified", false})
+
+	report, err := service.Run(context.Background(), ReconcileOptions{Repair: true})
+	assert.NoError(t, err)
+	assert.Equal(t, 0, report.Repaired)
+	assert.Empty(t, docs.updates)
+	for _, f := range report.Findings {
+		if f.Action != model.ReconcileActionManualReview {
+			assert.Equal(t, "state changed since scan, skipped", f.Error, f.Kind)
+		}
+	}
+	assert.NoError(t, sqlMock.ExpectationsWereMet())
+}
+
+func TestReconcile_Handler(t *testing.T) {
+	service, _ := newReconcileTestService(t, &fakeDocs{})
+
+	app := fiber.New()
+	app.Use(func(c *fiber.Ctx) error {
+		c.Locals("claims", &utils.Claims{UserID: 1, Role: "admin"})
+		return c.Next()
+	})
+	app.Post("/admin/achievements/reconcile", service.Reconcile)
+
+	req := httptest.NewRequest(http.MethodPost, "/admin/achievements/reconcile?repair=true&dry_run=true", nil)
+	resp, err := app.Test(req)
+	assert.NoError(t, err)
+	assert.Equal(t, 200, resp.StatusCode)
+
+	var report model.ReconcileReport
+	assert.NoError(t, json.NewDecoder(resp.Body).Decode(&report))
+	assert.True(t, report.Repair)
+	assert.True(t, report.DryRun)
+	assert.Equal(t, 0, report.Repaired)
+}
@@ -2,14 +2,15 @@ package service
 
 import (
 	"uas/app/repository"
+	"uas/utils"
 
 	"github.com/gofiber/fiber/v2"
 	"go.mongodb.org/mongo-driver/bson/primitive"
 )
 
 type ReportService struct {
-	AchievementRepo      *repository.AchievementRepository
-	MongoAchievementRepo *repository.MongoAchievementRepository
+	AchievementRepo      repository.AchievementReferenceRepository
+	MongoAchievementRepo repository.AchievementDocumentStore
 }
 
 func (s *ReportService) GetStatistics(c *fiber.Ctx) error {
@@ -38,12 +39,21 @@ func (s *ReportService) GetStatistics(c *fiber.Ctx) error {
 		mongoIDs = append(mongoIDs, objID)
 	}
 
+	// Achievement yang dicabut tidak masuk agregasi, hanya dihitung
+	totalRevoked, err := s.AchievementRepo.CountRevoked(ctx)
+	if err != nil {
+		return c.Status(500).JSON(fiber.Map{
+			"error": "failed_count_revoked",
+		})
+	}
+
 	if len(mongoIDs) == 0 {
 		return c.JSON(fiber.Map{
 			"total_by_type":         map[string]int{},
 			"total_by_year":         map[int]int{},
 			"distribution_by_level": map[string]int{},
 			"top_students":          []fiber.Map{},
+			"total_revoked":         totalRevoked,
 		})
 	}
 
@@ -65,9 +75,9 @@ func (s *ReportService) GetStatistics(c *fiber.Ctx) error {
 	distributionByLevel := map[string]int{}
 
 	for _, d := range docs {
-	totalByType[d.AchievementType]++
-	totalByYear[d.Details.Year]++
-	distributionByLevel[d.Details.CompetitionLevel]++
+		totalByType[d.AchievementType]++
+		totalByYear[d.Details.Year]++
+		distributionByLevel[d.Details.CompetitionLevel]++
 	}
 
 	// ================================
@@ -88,14 +98,22 @@ func (s *ReportService) GetStatistics(c *fiber.Ctx) error {
 		"total_by_year":         totalByYear,
 		"distribution_by_level": distributionByLevel,
 		"top_students":          topStudents,
+		"total_revoked":         totalRevoked,
 	})
 }
 
-// ADMIN: GET /reports/student/:id
+// GET /reports/student/:id
+// Cakupan sama dengan achievement: admin (achievement:read_all) semua,
+// mahasiswa hanya dirinya, dosen wali hanya mahasiswa bimbingannya
 func (s *ReportService) GetStudentReport(c *fiber.Ctx) error {
+	claims := c.Locals("claims").(*utils.Claims)
 	ctx := c.Context()
 	studentID := c.Params("id")
 
+	if code := studentAccessError(c, s.AchievementRepo, claims.UserID, studentID); code != "" {
+		return c.Status(403).JSON(fiber.Map{"error": code})
+	}
+
 	// 1️⃣ Ambil references dari PostgreSQL
 	refs, err := s.AchievementRepo.GetByStudentUUID(ctx, studentID)
 	if err != nil {
@@ -138,23 +156,46 @@ func (s *ReportService) GetStudentReport(c *fiber.Ctx) error {
 	byYear := map[int]int{}
 	var achievements []fiber.Map
 
+	refByMongoID := map[string]map[string]interface{}{}
+	for _, r := range refs {
+		refByMongoID[r["mongo_id"].(string)] = r
+	}
+
+	var totalClaimed, totalApproved float64
 	for _, d := range docs {
-    // by type
-    byType[d.AchievementType]++
-    // by year
-    byYear[d.Details.Year]++
-    achievements = append(achievements, fiber.Map{
-        "type":  d.AchievementType,
-        "year":  d.Details.Year,
-        "level": d.Details.CompetitionLevel,
-    })
-}
+		// by type
+		byType[d.AchievementType]++
+		// by year
+		byYear[d.Details.Year]++
+
+		item := fiber.Map{
+			"type":  d.AchievementType,
+			"year":  d.Details.Year,
+			"level": d.Details.CompetitionLevel,
+		}
+		// poin klaim vs poin yang disetujui dosen wali
+		if ref, ok := refByMongoID[d.ID.Hex()]; ok {
+			item["id"] = d.ID.Hex()
+			item["status"] = ref["status"]
+			item["claimed_points"] = ref["claimed_points"]
+			item["approved_points"] = ref["approved_points"]
+			if p, ok := ref["claimed_points"].(*float64); ok && p != nil {
+				totalClaimed += *p
+			}
+			if p, ok := ref["approved_points"].(*float64); ok && p != nil {
+				totalApproved += *p
+			}
+		}
+		achievements = append(achievements, item)
+	}
 
 	return c.JSON(fiber.Map{
-		"student_id":         studentID,
-		"total_achievements": len(achievements),
-		"by_type":            byType,
-		"by_year":            byYear,
-		"achievements":       achievements,
+		"student_id":            studentID,
+		"total_achievements":    len(achievements),
+		"total_claimed_points":  totalClaimed,
+		"total_approved_points": totalApproved,
+		"by_type":               byType,
+		"by_year":               byYear,
+		"achievements":          achievements,
 	})
 }
@@ -1,15 +1,24 @@
 package service
 
 import (
+	"context"
+	"database/sql"
+	"encoding/json"
 	"net/http"
 	"net/http/httptest"
 	"testing"
 
+	"uas/app/model"
 	"uas/app/repository"
+	"uas/app/repository/mocks"
+	"uas/utils"
 
 	"github.com/DATA-DOG/go-sqlmock"
 	"github.com/gofiber/fiber/v2"
 	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/mock"
+	"go.mongodb.org/mongo-driver/bson"
+	"go.mongodb.org/mongo-driver/bson/primitive"
 )
 
 func setupReportApp(service *ReportService) *fiber.App {
@@ -18,6 +27,18 @@ func setupReportApp(service *ReportService) *fiber.App {
 	return app
 }
 
+// Laporan per mahasiswa seperti route: claims + permission dari RequirePermission
+func setupStudentReportApp(service *ReportService, userID int64, perms ...string) *fiber.App {
+	app := fiber.New()
+	app.Use(func(c *fiber.Ctx) error {
+		c.Locals("claims", &utils.Claims{UserID: userID})
+		return c.Next()
+	})
+	app.Use(withPermissions(perms...))
+	app.Get("/reports/student/:id", service.GetStudentReport)
+	return app
+}
+
 func TestReport_GetStatistics_EmptyData(t *testing.T) {
 	db, mock, err := sqlmock.New()
 	assert.NoError(t, err)
@@ -36,6 +57,8 @@ func TestReport_GetStatistics_EmptyData(t *testing.T) {
 	rows := sqlmock.NewRows([]string{"mongo_id"})
 	mock.ExpectQuery("SELECT").
 		WillReturnRows(rows)
+	mock.ExpectQuery("status = 'revoked'").
+		WillReturnRows(sqlmock.NewRows([]string{"count"}).AddRow(0))
 
 	app := setupReportApp(service)
 
@@ -50,3 +73,76 @@ func TestReport_GetStatistics_EmptyData(t *testing.T) {
 	assert.NoError(t, err)
 	assert.Equal(t, 200, resp.StatusCode)
 }
+
+func TestReport_GetStudentReport_WithMockRepository(t *testing.T) {
+	ctx := context.Background()
+	docs := repository.NewMemoryAchievementStore()
+	verifiedID := primitive.NewObjectID()
+	draftID := primitive.NewObjectID()
+	assert.NoError(t, docs.Insert(ctx, bson.M{
+		"_id": verifiedID, "achievementType": "competition",
+		"details": bson.M{"year": 2024, "competitionLevel": "national"},
+	}))
+	assert.NoError(t, docs.Insert(ctx, bson.M{
+		"_id": draftID, "achievementType": "publication",
+		"details": bson.M{"year": 2025},
+	}))
+
+	claimed, approved := 50.0, 40.0
+	repo := new(mocks.MockAchievementRepository)
+	repo.On("GetByStudentUUID", mock.Anything, "student-1").Return([]map[string]interface{}{
+		{"mongo_id": verifiedID.Hex(), "status": "verified", "claimed_points": &claimed, "approved_points": &approved},
+		{"mongo_id": draftID.Hex(), "status": "draft", "claimed_points": (*float64)(nil), "approved_points": (*float64)(nil)},
+	}, nil)
+
+	service := &ReportService{AchievementRepo: repo, MongoAchievementRepo: docs}
+	app := setupStudentReportApp(service, 1, model.PermReportStudent, model.PermAchievementReadAll)
+
+	resp, err := app.Test(httptest.NewRequest(http.MethodGet, "/reports/student/student-1", nil))
+	assert.NoError(t, err)
+	assert.Equal(t, 200, resp.StatusCode)
+
+	var out struct {
+		TotalAchievements   int            `json:"total_achievements"`
+		TotalClaimedPoints  float64        `json:"total_claimed_points"`
+		TotalApprovedPoints float64        `json:"total_approved_points"`
+		ByType              map[string]int `json:"by_type"`
+	}
+	assert.NoError(t, json.NewDecoder(resp.Body).Decode(&out))
+	assert.Equal(t, 2, out.TotalAchievements)
+	assert.Equal(t, 50.0, out.TotalClaimedPoints)
+	assert.Equal(t, 40.0, out.TotalApprovedPoints)
+	assert.Equal(t, map[string]int{"competition": 1, "publication": 1}, out.ByType)
+	repo.AssertExpectations(t)
+}
+
+// Tanpa achievement:read_all: mahasiswa hanya laporannya sendiri, dosen wali
+// hanya mahasiswa bimbingannya
+func TestReport_GetStudentReport_Scope(t *testing.T) {
+	repo := new(mocks.MockAchievementRepository)
+	repo.On("GetStudentID", mock.Anything, int64(10)).Return("student-1", nil)
+	repo.On("GetStudentID", mock.Anything, int64(20)).Return("", sql.ErrNoRows)
+	repo.On("GetLecturerID", mock.Anything, int64(20)).Return(int64(5), nil)
+	repo.On("IsStudentSupervised", mock.Anything, int64(5), "student-1").Return(true, nil)
+	repo.On("IsStudentSupervised", mock.Anything, int64(5), "student-2").Return(false, nil)
+	repo.On("GetByStudentUUID", mock.Anything, mock.Anything).Return([]map[string]interface{}{}, nil)
+	service := &ReportService{AchievementRepo: repo, MongoAchievementRepo: repository.NewMemoryAchievementStore()}
+
+	cases := []struct {
+		userID  int64
+		student string
+		want    int
+	}{
+		{10, "student-1", 200}, // diri sendiri
+		{10, "student-2", 403}, // mahasiswa lain
+		{20, "student-1", 200}, // bimbingan
+		{20, "student-2", 403}, // bukan bimbingan
+	}
+	for _, tc := range cases {
+		app := setupStudentReportApp(service, tc.userID, model.PermReportStudent)
+		resp, err := app.Test(httptest.NewRequest(http.MethodGet, "/reports/student/"+tc.student, nil))
+		assert.NoError(t, err)
+		assert.Equal(t, tc.want, resp.StatusCode, "user %d → %s", tc.userID, tc.student)
+	}
+	repo.AssertNotCalled(t, "GetByStudentUUID", mock.Anything, "student-2")
+}
@@ -0,0 +1,422 @@
+package service
+
+import (
+	"database/sql"
+	"errors"
+	"fmt"
+	"regexp"
+	"strings"
+
+	"uas/app/model"
+	"uas/app/repository"
+
+	"github.com/gofiber/fiber/v2"
+	"github.com/google/uuid"
+)
+
+// PermissionInvalidator: cache permission yang harus dikosongkan setiap
+// role / permission / grant berubah (middleware.PermissionCache)
+type PermissionInvalidator interface {
+	Invalidate()
+}
+
+type RoleService struct {
+	Repo  repository.RoleRepository
+	Perms PermissionInvalidator
+}
+
+func NewRoleService(repo repository.RoleRepository, perms PermissionInvalidator) *RoleService {
+	return &RoleService{Repo: repo, Perms: perms}
+}
+
+var permissionNamePattern = regexp.MustCompile(`^[a-z_]+:[a-z_]+$`)
+
+// ADMIN: GET /admin/roles
+func (s *RoleService) ListRoles(c *fiber.Ctx) error {
+	roles, err := s.Repo.ListRoles(c.Context())
+	if err != nil {
+		return c.Status(500).JSON(fiber.Map{"error": "failed_get_roles"})
+	}
+	return c.JSON(fiber.Map{"data": roles})
+}
+
+// ADMIN: GET /admin/roles/:id
+func (s *RoleService) GetRole(c *fiber.Ctx) error {
+	role, status, errCode := s.loadRole(c)
+	if role == nil {
+		return c.Status(status).JSON(fiber.Map{"error": errCode})
+	}
+	return c.JSON(role)
+}
+
+// ADMIN: POST /admin/roles
+func (s *RoleService) CreateRole(c *fiber.Ctx) error {
+	var input model.RoleRequest
+	if err := c.BodyParser(&input); err != nil {
+		return c.Status(400).JSON(fiber.Map{"error": "invalid_request"})
+	}
+	input.Name = strings.TrimSpace(input.Name)
+	if verrs := validateRoleRequest(input); verrs != nil {
+		return c.Status(422).JSON(fiber.Map{"error": "validation_failed", "fields": verrs})
+	}
+	if _, err := s.Repo.GetRoleIDByName(c.Context(), input.Name); err == nil {
+		return c.Status(409).JSON(fiber.Map{"error": "role_exists"})
+	}
+
+	role, err := s.Repo.CreateRole(c.Context(), input.Name, input.Description)
+	if err != nil {
+		return c.Status(500).JSON(fiber.Map{"error": "failed_create_role"})
+	}
+	return c.Status(201).JSON(model.RoleDetail{Role: *role, Permissions: []string{}})
+}
+
+// ADMIN: PUT /admin/roles/:id
+// Role bawaan hanya boleh diubah deskripsinya.
+func (s *RoleService) UpdateRole(c *fiber.Ctx) error {
+	role, status, errCode := s.loadRole(c)
+	if role == nil {
+		return c.Status(status).JSON(fiber.Map{"error": errCode})
+	}
+	var input model.RoleRequest
+	if err := c.BodyParser(&input); err != nil {
+		return c.Status(400).JSON(fiber.Map{"error": "invalid_request"})
+	}
+	input.Name = strings.TrimSpace(input.Name)
+	if verrs := validateRoleRequest(input); verrs != nil {
+		return c.Status(422).JSON(fiber.Map{"error": "validation_failed", "fields": verrs})
+	}
+
+	if input.Name != role.Name {
+		if role.IsBuiltin {
+			return c.Status(409).JSON(fiber.Map{"error": "builtin_role_immutable"})
+		}
+		if _, err := s.Repo.GetRoleIDByName(c.Context(), input.Name); err == nil {
+			return c.Status(409).JSON(fiber.Map{"error": "role_exists"})
+		}
+	}
+
+	if err := s.Repo.UpdateRole(c.Context(), role.ID, input.Name, input.Description); err != nil {
+		return c.Status(500).JSON(fiber.Map{"error": "failed_update_role"})
+	}
+	// cache permission di-key dengan nama role; access token dengan nama
+	// lama sudah dicabut repository (token_version naik)
+	s.Perms.Invalidate()
+
+	role.Name = input.Name
+	role.Description = input.Description
+	return c.JSON(role)
+}
+
+// ADMIN: DELETE /admin/roles/:id
+func (s *RoleService) DeleteRole(c *fiber.Ctx) error {
+	role, status, errCode := s.loadRole(c)
+	if role == nil {
+		return c.Status(status).JSON(fiber.Map{"error": errCode})
+	}
+	// 1. Role bawaan dipakai kode, tidak boleh dihapus
+	if role.IsBuiltin {
+		return c.Status(409).JSON(fiber.Map{"error": "builtin_role_immutable"})
+	}
+	// 2. Masih dipakai user
+	if role.UserCount > 0 {
+		return c.Status(409).JSON(fiber.Map{
+			"error":      "role_in_use",
+			"user_count": role.UserCount,
+		})
+	}
+	// 3. Jangan sampai tidak ada lagi role yang bisa mengelola role
+	if hasPermission(role.Permissions, model.PermRoleManage) {
+		if status, errCode := s.checkOtherAdminRole(c, role.ID); errCode != "" {
+			return c.Status(status).JSON(fiber.Map{"error": errCode})
+		}
+	}
+
+	if err := s.Repo.DeleteRole(c.Context(), role.ID); err != nil {
+		if errors.Is(err, sql.ErrNoRows) {
+			return c.Status(404).JSON(fiber.Map{"error": "role_not_found"})
+		}
+		return c.Status(500).JSON(fiber.Map{"error": "failed_delete_role"})
+	}
+	s.Perms.Invalidate()
+
+	return c.JSON(fiber.Map{
+		"message": "role deleted",
+		"id":      role.ID,
+	})
+}
+
+// ADMIN: PUT /admin/roles/:id/permissions
+// Body berisi daftar lengkap nama permission, menggantikan grant sebelumnya.
+func (s *RoleService) SetRolePermissions(c *fiber.Ctx) error {
+	role, status, errCode := s.loadRole(c)
+	if role == nil {
+		return c.Status(status).JSON(fiber.Map{"error": errCode})
+	}
+	var input model.RolePermissionsRequest
+	if err := c.BodyParser(&input); err != nil {
+		return c.Status(400).JSON(fiber.Map{"error": "invalid_request"})
+	}
+
+	// 1. Petakan nama → id, nama yang tidak dikenal ditolak
+	all, err := s.Repo.ListPermissions(c.Context())
+	if err != nil {
+		return c.Status(500).JSON(fiber.Map{"error": "failed_get_permissions"})
+	}
+	idByName := map[string]string{}
+	for _, p := range all {
+		idByName[p.Name] = p.ID
+	}
+	verrs := model.ValidationErrors{}
+	ids := []string{}
+	names := []string{}
+	seen := map[string]bool{}
+	for i, name := range input.Permissions {
+		id, ok := idByName[name]
+		if !ok {
+			verrs[fmt.Sprintf("permissions[%d]", i)] = "unknown permission"
+			continue
+		}
+		if seen[name] {
+			continue
+		}
+		seen[name] = true
+		ids = append(ids, id)
+		names = append(names, name)
+	}
+	if len(verrs) > 0 {
+		return c.Status(422).JSON(fiber.Map{"error": "validation_failed", "fields": verrs})
+	}
+
+	// 2. Mencabut role:manage dari role admin terakhir dilarang
+	if hasPermission(role.Permissions, model.PermRoleManage) && !seen[model.PermRoleManage] {
+		if status, errCode := s.checkOtherAdminRole(c, role.ID); errCode != "" {
+			return c.Status(status).JSON(fiber.Map{"error": errCode})
+		}
+	}
+
+	// 3. Simpan lalu kosongkan cache supaya langsung berlaku
+	if err := s.Repo.SetRolePermissions(c.Context(), role.ID, ids); err != nil {
+		return c.Status(500).JSON(fiber.Map{"error": "failed_update_role_permissions"})
+	}
+	s.Perms.Invalidate()
+
+	role.Permissions = names
+	return c.JSON(role)
+}
+
+// ADMIN: PUT /admin/roles/:id/two-factor
+// User di role ini harus memakai 2FA; yang belum enroll diminta enroll saat login.
+func (s *RoleService) SetRoleTwoFactor(c *fiber.Ctx) error {
+	role, status, errCode := s.loadRole(c)
+	if role == nil {
+		return c.Status(status).JSON(fiber.Map{"error": errCode})
+	}
+	var input model.RoleTwoFactorRequest
+	if err := c.BodyParser(&input); err != nil {
+		return c.Status(400).JSON(fiber.Map{"error": "invalid_request"})
+	}
+	if input.Required == nil {
+		return c.Status(422).JSON(fiber.Map{
+			"error":  "validation_failed",
+			"fields": model.ValidationErrors{"required": "is required"},
+		})
+	}
+
+	if err := s.Repo.SetRequire2FA(c.Context(), role.ID, *input.Required); err != nil {
+		if errors.Is(err, sql.ErrNoRows) {
+			return c.Status(404).JSON(fiber.Map{"error": "role_not_found"})
+		}
+		return c.Status(500).JSON(fiber.Map{"error": "failed_update_role"})
+	}
+
+	role.Require2FA = *input.Required
+	return c.JSON(role)
+}
+
+// ADMIN: GET /admin/permissions
+func (s *RoleService) ListPermissions(c *fiber.Ctx) error {
+	perms, err := s.Repo.ListPermissions(c.Context())
+	if err != nil {
+		return c.Status(500).JSON(fiber.Map{"error": "failed_get_permissions"})
+	}
+	return c.JSON(fiber.Map{"data": perms})
+}
+
+// ADMIN: POST /admin/permissions
+func (s *RoleService) CreatePermission(c *fiber.Ctx) error {
+	var input model.PermissionRequest
+	if err := c.BodyParser(&input); err != nil {
+		return c.Status(400).JSON(fiber.Map{"error": "invalid_request"})
+	}
+	p, verrs := buildPermission(input)
+	if verrs != nil {
+		return c.Status(422).JSON(fiber.Map{"error": "validation_failed", "fields": verrs})
+	}
+	if exists, err := s.permissionExists(c, p.Name); err != nil {
+		return c.Status(500).JSON(fiber.Map{"error": "failed_get_permissions"})
+	} else if exists {
+		return c.Status(409).JSON(fiber.Map{"error": "permission_exists"})
+	}
+
+	created, err := s.Repo.CreatePermission(c.Context(), p)
+	if err != nil {
+		return c.Status(500).JSON(fiber.Map{"error": "failed_create_permission"})
+	}
+	return c.Status(201).JSON(created)
+}
+
+// ADMIN: PUT /admin/permissions/:id
+// Permission bawaan dicek oleh route, hanya deskripsinya yang boleh diubah.
+func (s *RoleService) UpdatePermission(c *fiber.Ctx) error {
+	current, status, errCode := s.loadPermission(c)
+	if current == nil {
+		return c.Status(status).JSON(fiber.Map{"error": errCode})
+	}
+	var input model.PermissionRequest
+	if err := c.BodyParser(&input); err != nil {
+		return c.Status(400).JSON(fiber.Map{"error": "invalid_request"})
+	}
+	p, verrs := buildPermission(input)
+	if verrs != nil {
+		return c.Status(422).JSON(fiber.Map{"error": "validation_failed", "fields": verrs})
+	}
+	p.ID = current.ID
+
+	if p.Name != current.Name {
+		if model.IsBuiltinPermission(current.Name) {
+			return c.Status(409).JSON(fiber.Map{"error": "builtin_permission_immutable"})
+		}
+		if exists, err := s.permissionExists(c, p.Name); err != nil {
+			return c.Status(500).JSON(fiber.Map{"error": "failed_get_permissions"})
+		} else if exists {
+			return c.Status(409).JSON(fiber.Map{"error": "permission_exists"})
+		}
+	}
+
+	if err := s.Repo.UpdatePermission(c.Context(), p); err != nil {
+		return c.Status(500).JSON(fiber.Map{"error": "failed_update_permission"})
+	}
+	s.Perms.Invalidate()
+	return c.JSON(p)
+}
+
+// ADMIN: DELETE /admin/permissions/:id
+func (s *RoleService) DeletePermission(c *fiber.Ctx) error {
+	current, status, errCode := s.loadPermission(c)
+	if current == nil {
+		return c.Status(status).JSON(fiber.Map{"error": errCode})
+	}
+	if model.IsBuiltinPermission(current.Name) {
+		return c.Status(409).JSON(fiber.Map{"error": "builtin_permission_immutable"})
+	}
+
+	if err := s.Repo.DeletePermission(c.Context(), current.ID); err != nil {
+		if errors.Is(err, sql.ErrNoRows) {
+			return c.Status(404).JSON(fiber.Map{"error": "permission_not_found"})
+		}
+		return c.Status(500).JSON(fiber.Map{"error": "failed_delete_permission"})
+	}
+	s.Perms.Invalidate()
+
+	return c.JSON(fiber.Map{
+		"message": "permission deleted",
+		"id":      current.ID,
+	})
+}
+
+// loadRole: role dari :id, atau status + kode error
+func (s *RoleService) loadRole(c *fiber.Ctx) (*model.RoleDetail, int, string) {
+	id := c.Params("id")
+	if _, err := uuid.Parse(id); err != nil {
+		return nil, 400, "invalid_role_id"
+	}
+	role, err := s.Repo.GetRole(c.Context(), id)
+	if err != nil {
+		if errors.Is(err, sql.ErrNoRows) {
+			return nil, 404, "role_not_found"
+		}
+		return nil, 500, "failed_get_role"
+	}
+	return role, 0, ""
+}
+
+func (s *RoleService) loadPermission(c *fiber.Ctx) (*model.Permission, int, string) {
+	id := c.Params("id")
+	if _, err := uuid.Parse(id); err != nil {
+		return nil, 400, "invalid_permission_id"
+	}
+	p, err := s.Repo.GetPermission(c.Context(), id)
+	if err != nil {
+		if errors.Is(err, sql.ErrNoRows) {
+			return nil, 404, "permission_not_found"
+		}
+		return nil, 500, "failed_get_permission"
+	}
+	return p, 0, ""
+}
+
+// checkOtherAdminRole memastikan masih ada role lain yang memegang role:manage
+// dan dipakai minimal satu user aktif
+func (s *RoleService) checkOtherAdminRole(c *fiber.Ctx, roleID string) (int, string) {
+	others, err := s.Repo.CountActiveRolesWithPermission(c.Context(), model.PermRoleManage, roleID)
+	if err != nil {
+		return 500, "failed_check_admin_roles"
+	}
+	if others == 0 {
+		return 409, "last_admin_role"
+	}
+	return 0, ""
+}
+
+func (s *RoleService) permissionExists(c *fiber.Ctx, name string) (bool, error) {
+	perms, err := s.Repo.ListPermissions(c.Context())
+	if err != nil {
+		return false, err
+	}
+	return hasPermission(permissionNames(perms), name), nil
+}
+
+func validateRoleRequest(input model.RoleRequest) model.ValidationErrors {
+	errs := model.ValidationErrors{}
+	if len(input.Name) < 2 || len(input.Name) > 50 {
+		errs["name"] = "must be 2-50 characters"
+	}
+	if len(errs) > 0 {
+		return errs
+	}
+	return nil
+}
+
+// buildPermission memecah "resource:action" menjadi kolom resource / action
+func buildPermission(input model.PermissionRequest) (model.Permission, model.ValidationErrors) {
+	name := strings.TrimSpace(input.Name)
+	if !permissionNamePattern.MatchString(name) || len(name) > 100 {
+		return model.Permission{}, model.ValidationErrors{
+			"name": "must be resource:action (lowercase letters or underscore)",
+		}
+	}
+	resource, action, _ := strings.Cut(name, ":")
+	return model.Permission{
+		Name:        name,
+		Resource:    resource,
+		Action:      action,
+		Description: input.Description,
+	}, nil
+}
+
+func permissionNames(perms []model.Permission) []string {
+	names := make([]string, 0, len(perms))
+	for _, p := range perms {
+		names = append(names, p.Name)
+	}
+	return names
+}
+
+func hasPermission(names []string, name string) bool {
+	for _, n := range names {
+		if n == name {
+			return true
+		}
+	}
+	return false
+}
@@ -0,0 +1,286 @@
+package service
+
+import (
+	"bytes"
+	"context"
+	"encoding/json"
+	"errors"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+
+	"uas/app/model"
+	"uas/app/repository"
+	"uas/app/repository/mocks"
+
+	"github.com/DATA-DOG/go-sqlmock"
+	"github.com/gofiber/fiber/v2"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/mock"
+)
+
+const (
+	adminRoleID  = "11111111-1111-1111-1111-111111111111"
+	customRoleID = "22222222-2222-2222-2222-222222222222"
+)
+
+type countingInvalidator struct{ calls int }
+
+func (c *countingInvalidator) Invalidate() { c.calls++ }
+
+func setupRoleApp(service *RoleService) *fiber.App {
+	app := fiber.New()
+	app.Post("/admin/roles", service.CreateRole)
+	app.Put("/admin/roles/:id", service.UpdateRole)
+	app.Delete("/admin/roles/:id", service.DeleteRole)
+	app.Put("/admin/roles/:id/permissions", service.SetRolePermissions)
+	app.Post("/admin/permissions", service.CreatePermission)
+	return app
+}
+
+func jsonRequest(method, url string, body interface{}) *http.Request {
+	b, _ := json.Marshal(body)
+	req := httptest.NewRequest(method, url, bytes.NewBuffer(b))
+	req.Header.Set("Content-Type", "application/json")
+	return req
+}
+
+func TestCreateRole_Success(t *testing.T) {
+	repo := new(mocks.MockRoleRepository)
+	repo.On("GetRoleIDByName", mock.Anything, "staf kemahasiswaan").Return("", errors.New("role_not_found"))
+	repo.On("CreateRole", mock.Anything, "staf kemahasiswaan", "Staf").
+		Return(&model.Role{ID: customRoleID, Name: "staf kemahasiswaan", Description: "Staf"}, nil)
+
+	app := setupRoleApp(NewRoleService(repo, &countingInvalidator{}))
+	resp, err := app.Test(jsonRequest(http.MethodPost, "/admin/roles",
+		model.RoleRequest{Name: " staf kemahasiswaan ", Description: "Staf"}))
+	assert.NoError(t, err)
+	assert.Equal(t, 201, resp.StatusCode)
+	repo.AssertExpectations(t)
+}
+
+func TestCreateRole_Duplicate(t *testing.T) {
+	repo := new(mocks.MockRoleRepository)
+	repo.On("GetRoleIDByName", mock.Anything, "admin").Return(adminRoleID, nil)
+
+	app := setupRoleApp(NewRoleService(repo, &countingInvalidator{}))
+	resp, err := app.Test(jsonRequest(http.MethodPost, "/admin/roles", model.RoleRequest{Name: "admin"}))
+	assert.NoError(t, err)
+	assert.Equal(t, 409, resp.StatusCode)
+	repo.AssertNotCalled(t, "CreateRole", mock.Anything, mock.Anything, mock.Anything)
+}
+
+func TestUpdateRole_BuiltinCannotBeRenamed(t *testing.T) {
+	repo := new(mocks.MockRoleRepository)
+	repo.On("GetRole", mock.Anything, adminRoleID).Return(&model.RoleDetail{
+		Role:      model.Role{ID: adminRoleID, Name: model.RoleAdmin},
+		IsBuiltin: true,
+	}, nil)
+
+	app := setupRoleApp(NewRoleService(repo, &countingInvalidator{}))
+	resp, err := app.Test(jsonRequest(http.MethodPut, "/admin/roles/"+adminRoleID, model.RoleRequest{Name: "superadmin"}))
+	assert.NoError(t, err)
+	assert.Equal(t, 409, resp.StatusCode)
+	repo.AssertNotCalled(t, "UpdateRole", mock.Anything, mock.Anything, mock.Anything, mock.Anything)
+}
+
+func TestDeleteRole_InUse(t *testing.T) {
+	repo := new(mocks.MockRoleRepository)
+	repo.On("GetRole", mock.Anything, customRoleID).Return(&model.RoleDetail{
+		Role:      model.Role{ID: customRoleID, Name: "staf"},
+		UserCount: 3,
+	}, nil)
+
+	app := setupRoleApp(NewRoleService(repo, &countingInvalidator{}))
+	resp, err := app.Test(httptest.NewRequest(http.MethodDelete, "/admin/roles/"+customRoleID, nil))
+	assert.NoError(t, err)
+	assert.Equal(t, 409, resp.StatusCode)
+	repo.AssertNotCalled(t, "DeleteRole", mock.Anything, mock.Anything)
+}
+
+func TestDeleteRole_LastAdminRole(t *testing.T) {
+	repo := new(mocks.MockRoleRepository)
+	repo.On("GetRole", mock.Anything, customRoleID).Return(&model.RoleDetail{
+		Role:        model.Role{ID: customRoleID, Name: "super"},
+		Permissions: []string{model.PermRoleManage},
+	}, nil)
+	repo.On("CountActiveRolesWithPermission", mock.Anything, model.PermRoleManage, customRoleID).Return(0, nil)
+
+	app := setupRoleApp(NewRoleService(repo, &countingInvalidator{}))
+	resp, err := app.Test(httptest.NewRequest(http.MethodDelete, "/admin/roles/"+customRoleID, nil))
+	assert.NoError(t, err)
+	assert.Equal(t, 409, resp.StatusCode)
+
+	var out map[string]interface{}
+	assert.NoError(t, json.NewDecoder(resp.Body).Decode(&out))
+	assert.Equal(t, "last_admin_role", out["error"])
+	repo.AssertNotCalled(t, "DeleteRole", mock.Anything, mock.Anything)
+}
+
+// Role kosong dengan role:manage tidak dihitung sebagai "role admin lain"
+func TestCountActiveRolesWithPermission_RequiresActiveUser(t *testing.T) {
+	db, sqlMock, err := sqlmock.New()
+	assert.NoError(t, err)
+	defer db.Close()
+
+	sqlMock.ExpectQuery(`JOIN users u ON u.role_id = rp.role_id AND u.is_active = TRUE`).
+		WithArgs(model.PermRoleManage, adminRoleID).
+		WillReturnRows(sqlmock.NewRows([]string{"count"}).AddRow(0))
+
+	n, err := repository.NewRoleRepository(db).
+		CountActiveRolesWithPermission(context.Background(), model.PermRoleManage, adminRoleID)
+	assert.NoError(t, err)
+	assert.Equal(t, 0, n)
+	assert.NoError(t, sqlMock.ExpectationsWereMet())
+}
+
+// Rename role mencabut access token user-nya (nama role lama di claims)
+func TestRoleRepository_UpdateRole_RenameBumpsTokenVersion(t *testing.T) {
+	db, sqlMock, err := sqlmock.New()
+	assert.NoError(t, err)
+	defer db.Close()
+	repo := repository.NewRoleRepository(db)
+
+	sqlMock.ExpectBegin()
+	sqlMock.ExpectQuery("SELECT name FROM roles").WithArgs(customRoleID).
+		WillReturnRows(sqlmock.NewRows([]string{"name"}).AddRow("staf"))
+	sqlMock.ExpectExec("UPDATE roles SET name").WithArgs("staf kemahasiswaan", "Staf", customRoleID).
+		WillReturnResult(sqlmock.NewResult(0, 1))
+	sqlMock.ExpectExec("UPDATE users SET token_version = token_version \\+ 1 WHERE role_id").
+		WithArgs(customRoleID).
+		WillReturnResult(sqlmock.NewResult(0, 3))
+	sqlMock.ExpectCommit()
+	assert.NoError(t, repo.UpdateRole(context.Background(), customRoleID, "staf kemahasiswaan", "Staf"))
+
+	// hanya deskripsi berubah: token tetap berlaku
+	sqlMock.ExpectBegin()
+	sqlMock.ExpectQuery("SELECT name FROM roles").WithArgs(customRoleID).
+		WillReturnRows(sqlmock.NewRows([]string{"name"}).AddRow("staf kemahasiswaan"))
+	sqlMock.ExpectExec("UPDATE roles SET name").WillReturnResult(sqlmock.NewResult(0, 1))
+	sqlMock.ExpectCommit()
+	assert.NoError(t, repo.UpdateRole(context.Background(), customRoleID, "staf kemahasiswaan", "Staf baru"))
+
+	assert.NoError(t, sqlMock.ExpectationsWereMet())
+}
+
+func TestDeleteRole_Success(t *testing.T) {
+	repo := new(mocks.MockRoleRepository)
+	perms := &countingInvalidator{}
+	repo.On("GetRole", mock.Anything, customRoleID).Return(&model.RoleDetail{
+		Role:        model.Role{ID: customRoleID, Name: "super"},
+		Permissions: []string{model.PermRoleManage},
+	}, nil)
+	repo.On("CountActiveRolesWithPermission", mock.Anything, model.PermRoleManage, customRoleID).Return(1, nil)
+	repo.On("DeleteRole", mock.Anything, customRoleID).Return(nil)
+
+	app := setupRoleApp(NewRoleService(repo, perms))
+	resp, err := app.Test(httptest.NewRequest(http.MethodDelete, "/admin/roles/"+customRoleID, nil))
+	assert.NoError(t, err)
+	assert.Equal(t, 200, resp.StatusCode)
+	assert.Equal(t, 1, perms.calls)
+	repo.AssertExpectations(t)
+}
+
+func TestSetRolePermissions_CannotDropLastRoleManage(t *testing.T) {
+	repo := new(mocks.MockRoleRepository)
+	repo.On("GetRole", mock.Anything, adminRoleID).Return(&model.RoleDetail{
+		Role:        model.Role{ID: adminRoleID, Name: model.RoleAdmin},
+		Permissions: []string{model.PermRoleManage, model.PermUserManage},
+		IsBuiltin:   true,
+	}, nil)
+	repo.On("ListPermissions", mock.Anything).Return([]model.Permission{
+		{ID: "p-role", Name: model.PermRoleManage},
+		{ID: "p-user", Name: model.PermUserManage},
+	}, nil)
+	repo.On("CountActiveRolesWithPermission", mock.Anything, model.PermRoleManage, adminRoleID).Return(0, nil)
+
+	app := setupRoleApp(NewRoleService(repo, &countingInvalidator{}))
+	resp, err := app.Test(jsonRequest(http.MethodPut, "/admin/roles/"+adminRoleID+"/permissions",
+		model.RolePermissionsRequest{Permissions: []string{model.PermUserManage}}))
+	assert.NoError(t, err)
+	assert.Equal(t, 409, resp.StatusCode)
+	repo.AssertNotCalled(t, "SetRolePermissions", mock.Anything, mock.Anything, mock.Anything)
+}
+
+func TestSetRolePermissions_Success(t *testing.T) {
+	repo := new(mocks.MockRoleRepository)
+	perms := &countingInvalidator{}
+	repo.On("GetRole", mock.Anything, customRoleID).Return(&model.RoleDetail{
+		Role: model.Role{ID: customRoleID, Name: "staf"},
+	}, nil)
+	repo.On("ListPermissions", mock.Anything).Return([]model.Permission{
+		{ID: "p-student", Name: model.PermStudentRead},
+		{ID: "p-report", Name: model.PermReportStatistics},
+	}, nil)
+	repo.On("SetRolePermissions", mock.Anything, customRoleID, []string{"p-student", "p-report"}).Return(nil)
+
+	app := setupRoleApp(NewRoleService(repo, perms))
+	resp, err := app.Test(jsonRequest(http.MethodPut, "/admin/roles/"+customRoleID+"/permissions",
+		model.RolePermissionsRequest{Permissions: []string{
+			model.PermStudentRead, model.PermReportStatistics, model.PermStudentRead,
+		}}))
+	assert.NoError(t, err)
+	assert.Equal(t, 200, resp.StatusCode)
+	assert.Equal(t, 1, perms.calls)
+	repo.AssertExpectations(t)
+}
+
+func TestSetRolePermissions_UnknownPermission(t *testing.T) {
+	repo := new(mocks.MockRoleRepository)
+	repo.On("GetRole", mock.Anything, customRoleID).Return(&model.RoleDetail{
+		Role: model.Role{ID: customRoleID, Name: "staf"},
+	}, nil)
+	repo.On("ListPermissions", mock.Anything).Return([]model.Permission{}, nil)
+
+	app := setupRoleApp(NewRoleService(repo, &countingInvalidator{}))
+	resp, err := app.Test(jsonRequest(http.MethodPut, "/admin/roles/"+customRoleID+"/permissions",
+		model.RolePermissionsRequest{Permissions: []string{"nope:nope"}}))
+	assert.NoError(t, err)
+	assert.Equal(t, 422, resp.StatusCode)
+}
+
+func TestCreatePermission_SplitsResourceAction(t *testing.T) {
+	repo := new(mocks.MockRoleRepository)
+	repo.On("ListPermissions", mock.Anything).Return([]model.Permission{}, nil)
+	repo.On("CreatePermission", mock.Anything, model.Permission{
+		Name: "report:export", Resource: "report", Action: "export", Description: "Export",
+	}).Return(&model.Permission{ID: "p-1", Name: "report:export", Resource: "report", Action: "export"}, nil)
+
+	app := setupRoleApp(NewRoleService(repo, &countingInvalidator{}))
+	resp, err := app.Test(jsonRequest(http.MethodPost, "/admin/permissions",
+		model.PermissionRequest{Name: "report:export", Description: "Export"}))
+	assert.NoError(t, err)
+	assert.Equal(t, 201, resp.StatusCode)
+	repo.AssertExpectations(t)
+}
+
+func TestCreatePermission_InvalidName(t *testing.T) {
+	repo := new(mocks.MockRoleRepository)
+	app := setupRoleApp(NewRoleService(repo, &countingInvalidator{}))
+
+	resp, err := app.Test(jsonRequest(http.MethodPost, "/admin/permissions", model.PermissionRequest{Name: "Report Export"}))
+	assert.NoError(t, err)
+	assert.Equal(t, 422, resp.StatusCode)
+}
+
+func TestSetRoleTwoFactor(t *testing.T) {
+	repo := new(mocks.MockRoleRepository)
+	repo.On("GetRole", mock.Anything, customRoleID).Return(&model.RoleDetail{
+		Role: model.Role{ID: customRoleID, Name: "staf kemahasiswaan"},
+	}, nil)
+	repo.On("SetRequire2FA", mock.Anything, customRoleID, true).Return(nil)
+
+	app := fiber.New()
+	app.Put("/admin/roles/:id/two-factor", NewRoleService(repo, &countingInvalidator{}).SetRoleTwoFactor)
+
+	required := true
+	resp, err := app.Test(jsonRequest(http.MethodPut, "/admin/roles/"+customRoleID+"/two-factor",
+		model.RoleTwoFactorRequest{Required: &required}))
+	assert.NoError(t, err)
+	assert.Equal(t, 200, resp.StatusCode)
+
+	var out model.RoleDetail
+	assert.NoError(t, json.NewDecoder(resp.Body).Decode(&out))
+	assert.True(t, out.Require2FA)
+	repo.AssertExpectations(t)
+}
@@ -0,0 +1,213 @@
+package service
+
+import (
+	"context"
+	"database/sql"
+	"errors"
+	"fmt"
+	"regexp"
+	"strings"
+
+	"uas/app/model"
+	"uas/app/repository"
+
+	"github.com/gofiber/fiber/v2"
+	"go.mongodb.org/mongo-driver/bson"
+)
+
+// Rubrik tidak pernah diubah di tempat: poin yang sudah dihitung menyimpan
+// label versinya (rubricVersion), jadi isi versi itu harus tetap sama.
+type RubricService struct {
+	Repo repository.RubricRepository
+	Docs repository.AchievementDocumentStore
+}
+
+func NewRubricService(repo repository.RubricRepository, docs repository.AchievementDocumentStore) *RubricService {
+	return &RubricService{Repo: repo, Docs: docs}
+}
+
+var academicYearPattern = regexp.MustCompile(`^\d{4}/\d{4}$`)
+
+// ADMIN: GET /admin/rubrics
+func (s *RubricService) List(c *fiber.Ctx) error {
+	rubrics, err := s.Repo.List(c.Context())
+	if err != nil {
+		return c.Status(500).JSON(fiber.Map{"error": "failed_get_rubrics"})
+	}
+	return c.JSON(fiber.Map{"data": rubrics})
+}
+
+// ADMIN: GET /admin/rubrics/:id
+func (s *RubricService) Get(c *fiber.Ctx) error {
+	rubric, err := s.Repo.GetByID(c.Context(), c.Params("id"))
+	if err != nil {
+		if err == sql.ErrNoRows {
+			return c.Status(404).JSON(fiber.Map{"error": "rubric_not_found"})
+		}
+		return c.Status(500).JSON(fiber.Map{"error": "failed_get_rubric"})
+	}
+	return c.JSON(rubric)
+}
+
+// ADMIN: POST /admin/rubrics
+func (s *RubricService) Create(c *fiber.Ctx) error {
+	var input model.PointRubricRequest
+	if err := c.BodyParser(&input); err != nil {
+		return c.Status(400).JSON(fiber.Map{"error": "invalid_request"})
+	}
+	if err := validateRubricRequest(input); err != nil {
+		return c.Status(422).JSON(fiber.Map{"error": err.Error()})
+	}
+	rubric, err := s.Repo.Create(c.Context(), input)
+	if err != nil {
+		return c.Status(500).JSON(fiber.Map{"error": "failed_create_rubric"})
+	}
+	return c.Status(201).JSON(rubric)
+}
+
+// ADMIN: PUT /admin/rubrics/:id
+// Perubahan disimpan sebagai versi baru (tahun akademik sama, versi + 1);
+// versi lama tetap utuh untuk poin yang sudah dihitung dengannya
+func (s *RubricService) Update(c *fiber.Ctx) error {
+	var input model.PointRubricRequest
+	if err := c.BodyParser(&input); err != nil {
+		return c.Status(400).JSON(fiber.Map{"error": "invalid_request"})
+	}
+	// academic_year tidak bisa diubah; tahun lain dibuat lewat POST
+	existing, err := s.Repo.GetByID(c.Context(), c.Params("id"))
+	if err != nil {
+		if err == sql.ErrNoRows {
+			return c.Status(404).JSON(fiber.Map{"error": "rubric_not_found"})
+		}
+		return c.Status(500).JSON(fiber.Map{"error": "failed_get_rubric"})
+	}
+	input.AcademicYear = existing.AcademicYear
+	if err := validateRubricRequest(input); err != nil {
+		return c.Status(422).JSON(fiber.Map{"error": err.Error()})
+	}
+	rubric, err := s.Repo.Create(c.Context(), input)
+	if err != nil {
+		return c.Status(500).JSON(fiber.Map{"error": "failed_update_rubric"})
+	}
+	return c.Status(201).JSON(fiber.Map{
+		"supersedes": existing.ID,
+		"rubric":     rubric,
+	})
+}
+
+// ADMIN: DELETE /admin/rubrics/:id
+// Versi yang aktif atau sudah dipakai menghitung poin tidak bisa dihapus
+func (s *RubricService) Delete(c *fiber.Ctx) error {
+	ctx := c.Context()
+	rubric, err := s.Repo.GetByID(ctx, c.Params("id"))
+	if err != nil {
+		if err == sql.ErrNoRows {
+			return c.Status(404).JSON(fiber.Map{"error": "rubric_not_found"})
+		}
+		return c.Status(500).JSON(fiber.Map{"error": "failed_get_rubric"})
+	}
+	inUse, err := s.inUse(ctx, rubric)
+	if err != nil {
+		return c.Status(500).JSON(fiber.Map{"error": "failed_delete_rubric"})
+	}
+	if inUse {
+		return c.Status(409).JSON(fiber.Map{"error": "rubric_in_use"})
+	}
+	if err := s.Repo.Delete(ctx, rubric.ID); err != nil {
+		if err == sql.ErrNoRows {
+			return c.Status(404).JSON(fiber.Map{"error": "rubric_not_found"})
+		}
+		return c.Status(500).JSON(fiber.Map{"error": "failed_delete_rubric"})
+	}
+	return c.JSON(fiber.Map{
+		"message": "rubric deleted",
+		"id":      rubric.ID,
+	})
+}
+
+// inUse: rubrik aktif, atau ada achievement (termasuk yang dihapus) yang
+// poinnya dihitung dengan versi ini
+func (s *RubricService) inUse(ctx context.Context, rubric *model.PointRubric) (bool, error) {
+	if rubric.IsActive {
+		return true, nil
+	}
+	_, err := s.Docs.FindOne(ctx, bson.M{"rubricVersion": rubric.Label()})
+	if errors.Is(err, repository.ErrDocumentNotFound) {
+		return false, nil
+	}
+	return err == nil, err
+}
+
+func validateRubricRequest(input model.PointRubricRequest) error {
+	if !academicYearPattern.MatchString(input.AcademicYear) {
+		return fmt.Errorf("invalid_academic_year")
+	}
+	if len(input.Entries) == 0 {
+		return fmt.Errorf("entries_required")
+	}
+	seen := map[string]bool{}
+	for _, e := range input.Entries {
+		if strings.TrimSpace(e.AchievementType) == "" {
+			return fmt.Errorf("entry_achievement_type_required")
+		}
+		if e.Points < 0 {
+			return fmt.Errorf("entry_points_must_not_be_negative")
+		}
+		key := strings.ToLower(e.AchievementType + "|" + e.Level + "|" + e.Rank)
+		if seen[key] {
+			return fmt.Errorf("duplicate_entry: %s/%s/%s", e.AchievementType, e.Level, e.Rank)
+		}
+		seen[key] = true
+	}
+	return nil
+}
+
+// Hitung poin saran dari rubrik berdasarkan achievementType dan details.
+// Entry yang paling spesifik (level + rank) menang atas entry wildcard.
+func suggestPoints(
+	rubric *model.PointRubric,
+	achievementType string,
+	details map[string]interface{},
+) (float64, bool) {
+	if rubric == nil {
+		return 0, false
+	}
+	level := detailString(details, "competitionLevel", "level")
+	rank := detailString(details, "rank", "role", "position")
+
+	bestScore := -1
+	var best float64
+	for _, e := range rubric.Entries {
+		if !strings.EqualFold(e.AchievementType, achievementType) {
+			continue
+		}
+		score := 0
+		if e.Level != "" {
+			if !strings.EqualFold(e.Level, level) {
+				continue
+			}
+			score += 2
+		}
+		if e.Rank != "" {
+			if !strings.EqualFold(e.Rank, rank) {
+				continue
+			}
+			score++
+		}
+		if score > bestScore {
+			bestScore = score
+			best = e.Points
+		}
+	}
+	return best, bestScore >= 0
+}
+
+// Ambil nilai string pertama yang ada dari beberapa key details
+func detailString(details map[string]interface{}, keys ...string) string {
+	for _, k := range keys {
+		if v, ok := details[k].(string); ok && v != "" {
+			return v
+		}
+	}
+	return ""
+}
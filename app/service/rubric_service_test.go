@@ -0,0 +1,232 @@
+package service
+
+import (
+	"bytes"
+	"context"
+	"database/sql"
+	"encoding/json"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+
+	"uas/app/model"
+	"uas/app/repository"
+	"uas/app/repository/mocks"
+
+	"github.com/gofiber/fiber/v2"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/mock"
+	"go.mongodb.org/mongo-driver/bson"
+)
+
+// =======================
+// SETUP
+// =======================
+
+func setupRubricApp(service *RubricService) *fiber.App {
+	app := fiber.New()
+	app.Post("/rubrics", service.Create)
+	app.Put("/rubrics/:id", service.Update)
+	app.Delete("/rubrics/:id", service.Delete)
+	return app
+}
+
+func sampleRubric() *model.PointRubric {
+	return &model.PointRubric{
+		ID:           "rubric-1",
+		AcademicYear: "2025/2026",
+		Version:      2,
+		IsActive:     true,
+		Entries: []model.PointRubricEntry{
+			{AchievementType: "competition", Points: 5},
+			{AchievementType: "competition", Level: "national", Points: 20},
+			{AchievementType: "competition", Level: "national", Rank: "1", Points: 40},
+			{AchievementType: "competition", Rank: "1", Points: 10},
+			{AchievementType: "publication", Points: 30},
+		},
+	}
+}
+
+// =======================
+// SUGGEST POINTS
+// =======================
+
+func TestSuggestPoints_MostSpecificEntryWins(t *testing.T) {
+	rubric := sampleRubric()
+
+	points, ok := suggestPoints(rubric, "competition", map[string]interface{}{
+		"competitionLevel": "National",
+		"rank":             "1",
+	})
+	assert.True(t, ok)
+	assert.Equal(t, 40.0, points)
+
+	points, ok = suggestPoints(rubric, "competition", map[string]interface{}{
+		"competitionLevel": "national",
+		"rank":             "3",
+	})
+	assert.True(t, ok)
+	assert.Equal(t, 20.0, points)
+
+	points, ok = suggestPoints(rubric, "competition", map[string]interface{}{
+		"competitionLevel": "regional",
+	})
+	assert.True(t, ok)
+	assert.Equal(t, 5.0, points)
+}
+
+func TestSuggestPoints_UnknownTypeOrNoRubric(t *testing.T) {
+	_, ok := suggestPoints(sampleRubric(), "internship", nil)
+	assert.False(t, ok)
+
+	points, ok := suggestPoints(nil, "competition", nil)
+	assert.False(t, ok)
+	assert.Equal(t, 0.0, points)
+}
+
+func TestRubricLabel(t *testing.T) {
+	assert.Equal(t, "2025/2026#2", sampleRubric().Label())
+}
+
+// =======================
+// CRUD HANDLERS
+// =======================
+
+func TestRubric_Create_Success(t *testing.T) {
+	repo := new(mocks.MockRubricRepository)
+	app := setupRubricApp(NewRubricService(repo, repository.NewMemoryAchievementStore()))
+
+	input := model.PointRubricRequest{
+		AcademicYear: "2025/2026",
+		IsActive:     true,
+		Entries: []model.PointRubricEntry{
+			{AchievementType: "competition", Level: "national", Points: 20},
+		},
+	}
+	repo.On("Create", mock.Anything, input).Return(sampleRubric(), nil)
+
+	body, _ := json.Marshal(input)
+	req := httptest.NewRequest(http.MethodPost, "/rubrics", bytes.NewBuffer(body))
+	req.Header.Set("Content-Type", "application/json")
+
+	resp, err := app.Test(req)
+	assert.NoError(t, err)
+	assert.Equal(t, 201, resp.StatusCode)
+	repo.AssertExpectations(t)
+}
+
+func TestRubric_Create_InvalidAcademicYear(t *testing.T) {
+	repo := new(mocks.MockRubricRepository)
+	app := setupRubricApp(NewRubricService(repo, repository.NewMemoryAchievementStore()))
+
+	body, _ := json.Marshal(model.PointRubricRequest{
+		AcademicYear: "2025",
+		Entries:      []model.PointRubricEntry{{AchievementType: "competition", Points: 1}},
+	})
+	req := httptest.NewRequest(http.MethodPost, "/rubrics", bytes.NewBuffer(body))
+	req.Header.Set("Content-Type", "application/json")
+
+	resp, err := app.Test(req)
+	assert.NoError(t, err)
+	assert.Equal(t, 422, resp.StatusCode)
+	repo.AssertNotCalled(t, "Create", mock.Anything, mock.Anything)
+}
+
+func TestRubric_Create_DuplicateEntry(t *testing.T) {
+	repo := new(mocks.MockRubricRepository)
+	app := setupRubricApp(NewRubricService(repo, repository.NewMemoryAchievementStore()))
+
+	body, _ := json.Marshal(model.PointRubricRequest{
+		AcademicYear: "2025/2026",
+		Entries: []model.PointRubricEntry{
+			{AchievementType: "competition", Level: "national", Points: 20},
+			{AchievementType: "Competition", Level: "National", Points: 25},
+		},
+	})
+	req := httptest.NewRequest(http.MethodPost, "/rubrics", bytes.NewBuffer(body))
+	req.Header.Set("Content-Type", "application/json")
+
+	resp, err := app.Test(req)
+	assert.NoError(t, err)
+	assert.Equal(t, 422, resp.StatusCode)
+}
+
+func TestRubric_Update_CreatesNewVersion(t *testing.T) {
+	repo := new(mocks.MockRubricRepository)
+	app := setupRubricApp(NewRubricService(repo, repository.NewMemoryAchievementStore()))
+
+	repo.On("GetByID", mock.Anything, "rubric-1").Return(sampleRubric(), nil)
+	newVersion := sampleRubric()
+	newVersion.ID, newVersion.Version = "rubric-2", 3
+	// academic_year tetap; isi versi lama tidak disentuh
+	repo.On("Create", mock.Anything, mock.MatchedBy(func(req model.PointRubricRequest) bool {
+		return req.AcademicYear == "2025/2026"
+	})).Return(newVersion, nil)
+
+	body, _ := json.Marshal(model.PointRubricRequest{
+		AcademicYear: "1999/2000",
+		Entries:      []model.PointRubricEntry{{AchievementType: "competition", Points: 1}},
+	})
+	req := httptest.NewRequest(http.MethodPut, "/rubrics/rubric-1", bytes.NewBuffer(body))
+	req.Header.Set("Content-Type", "application/json")
+
+	resp, err := app.Test(req)
+	assert.NoError(t, err)
+	assert.Equal(t, 201, resp.StatusCode)
+	var out struct {
+		Supersedes string            `json:"supersedes"`
+		Rubric     model.PointRubric `json:"rubric"`
+	}
+	assert.NoError(t, json.NewDecoder(resp.Body).Decode(&out))
+	assert.Equal(t, "rubric-1", out.Supersedes)
+	assert.Equal(t, 3, out.Rubric.Version)
+	repo.AssertExpectations(t)
+}
+
+func TestRubric_Delete_NotFound(t *testing.T) {
+	repo := new(mocks.MockRubricRepository)
+	app := setupRubricApp(NewRubricService(repo, repository.NewMemoryAchievementStore()))
+
+	repo.On("GetByID", mock.Anything, "missing").Return(nil, sql.ErrNoRows)
+
+	req := httptest.NewRequest(http.MethodDelete, "/rubrics/missing", nil)
+	resp, err := app.Test(req)
+	assert.NoError(t, err)
+	assert.Equal(t, 404, resp.StatusCode)
+}
+
+func TestRubric_Delete_InUse(t *testing.T) {
+	repo := new(mocks.MockRubricRepository)
+	docs := repository.NewMemoryAchievementStore()
+	app := setupRubricApp(NewRubricService(repo, docs))
+
+	used := sampleRubric()
+	used.IsActive = false
+	repo.On("GetByID", mock.Anything, "rubric-1").Return(used, nil)
+	active := sampleRubric()
+	active.ID = "rubric-active"
+	repo.On("GetByID", mock.Anything, "rubric-active").Return(active, nil)
+	assert.NoError(t, docs.Insert(context.Background(), bson.M{"rubricVersion": used.Label(), "points": 40.0}))
+
+	for _, id := range []string{"rubric-1", "rubric-active"} {
+		resp, err := app.Test(httptest.NewRequest(http.MethodDelete, "/rubrics/"+id, nil))
+		assert.NoError(t, err)
+		assert.Equal(t, 409, resp.StatusCode, id)
+	}
+	repo.AssertNotCalled(t, "Delete", mock.Anything, mock.Anything)
+}
+
+func TestRubric_Delete_Unused(t *testing.T) {
+	repo := new(mocks.MockRubricRepository)
+	app := setupRubricApp(NewRubricService(repo, repository.NewMemoryAchievementStore()))
+
+	unused := sampleRubric()
+	unused.IsActive = false
+	repo.On("GetByID", mock.Anything, "rubric-1").Return(unused, nil)
+	repo.On("Delete", mock.Anything, "rubric-1").Return(nil)
+
+	resp, err := app.Test(httptest.NewRequest(http.MethodDelete, "/rubrics/rubric-1", nil))
+	assert.NoError(t, err)
+	assert.Equal(t, 200, resp.StatusCode)
+	repo.AssertExpectations(t)
+}
@@ -0,0 +1,293 @@
+package service
+
+import (
+	"context"
+	"errors"
+	"fmt"
+
+	"uas/app/model"
+	"uas/app/repository"
+	"uas/utils"
+
+	"go.mongodb.org/mongo-driver/bson"
+	"go.mongodb.org/mongo-driver/bson/primitive"
+)
+
+// SeedService mengisi database kosong: matriks role/permission, admin awal
+// dan (opsional) data demo. Aman dijalankan berulang kali.
+type SeedService struct {
+	Repo repository.SeedRepository
+	Docs repository.AchievementDocumentStore
+}
+
+func NewSeedService(repo repository.SeedRepository, docs repository.AchievementDocumentStore) *SeedService {
+	return &SeedService{Repo: repo, Docs: docs}
+}
+
+type SeedOptions struct {
+	AdminUsername string
+	AdminEmail    string
+	AdminFullName string
+	// Kosong = dibuat acak dan ditampilkan sekali
+	AdminPassword string
+
+	Demo         bool
+	DemoPassword string
+}
+
+// SeedUser: Password hanya diisi jika user baru dibuat dengan password acak
+type SeedUser struct {
+	Username string
+	Role     string
+	Created  bool
+	Password string
+}
+
+type SeedResult struct {
+	RolesSeeded       int
+	PermissionsSeeded int
+	GrantsAdded       int
+	Admin             SeedUser
+	DemoUsers         []SeedUser
+	DemoAchievements  int
+}
+
+type demoUser struct {
+	Username string
+	Email    string
+	FullName string
+	Role     string
+	// dosen wali
+	NIP        string
+	Department string
+	// mahasiswa
+	NIM          string
+	ProgramStudy string
+	AcademicYear string
+}
+
+// Data demo untuk dev lokal; mahasiswa dibimbing oleh dosen demo pertama
+var demoUsers = []demoUser{
+	{
+		Username: "dosen.demo", Email: "dosen.demo@example.ac.id", FullName: "Dosen Demo",
+		Role: model.RoleLecturer, NIP: "198001012005011001", Department: "Teknik Informatika",
+	},
+	{
+		Username: "mhs.demo1", Email: "mhs.demo1@example.ac.id", FullName: "Mahasiswa Demo Satu",
+		Role: model.RoleStudent, NIM: "434231901", ProgramStudy: "D4 Teknik Informatika", AcademicYear: "2023",
+	},
+	{
+		Username: "mhs.demo2", Email: "mhs.demo2@example.ac.id", FullName: "Mahasiswa Demo Dua",
+		Role: model.RoleStudent, NIM: "434231902", ProgramStudy: "D4 Teknik Informatika", AcademicYear: "2023",
+	},
+}
+
+type demoAchievement struct {
+	Status          string
+	AchievementType string
+	Title           string
+	Details         bson.M
+	Points          float64
+}
+
+// Setiap mahasiswa demo mendapat satu draft dan satu yang menunggu verifikasi
+var demoAchievements = []demoAchievement{
+	{
+		Status:          string(model.AchievementStatusDraft),
+		AchievementType: model.AchievementTypeCompetition,
+		Title:           "Juara 2 Lomba Pemrograman Nasional",
+		Details: bson.M{
+			"year": 2024, "competitionName": "Gemastik", "competitionLevel": "national", "rank": "second",
+		},
+		Points: 50,
+	},
+	{
+		Status:          string(model.AchievementStatusSubmitted),
+		AchievementType: model.AchievementTypeCertification,
+		Title:           "Sertifikasi Cloud Practitioner",
+		Details: bson.M{
+			"year": 2024, "certificationName": "Cloud Practitioner", "issuedBy": "AWS",
+		},
+		Points: 20,
+	},
+}
+
+func (s *SeedService) Run(ctx context.Context, opts SeedOptions) (*SeedResult, error) {
+	if opts.AdminUsername == "" || opts.AdminEmail == "" {
+		return nil, errors.New("admin username and email are required")
+	}
+	result := &SeedResult{}
+
+	// 1. Permission
+	permIDs := map[string]string{}
+	for _, p := range model.DefaultPermissions {
+		id, err := s.Repo.EnsurePermission(ctx, p.Name, p.Resource, p.Action, p.Description)
+		if err != nil {
+			return nil, fmt.Errorf("seed permission %s: %w", p.Name, err)
+		}
+		permIDs[p.Name] = id
+		result.PermissionsSeeded++
+	}
+
+	// 2. Role + matriks permission (grant yang sudah ada dibiarkan)
+	roleIDs := map[string]string{}
+	for _, role := range model.DefaultRoles {
+		id, err := s.Repo.EnsureRole(ctx, role.Name, role.Description, role.Require2FA)
+		if err != nil {
+			return nil, fmt.Errorf("seed role %s: %w", role.Name, err)
+		}
+		roleIDs[role.Name] = id
+		result.RolesSeeded++
+
+		for _, perm := range role.Permissions {
+			added, err := s.Repo.GrantPermission(ctx, id, permIDs[perm])
+			if err != nil {
+				return nil, fmt.Errorf("grant %s to %s: %w", perm, role.Name, err)
+			}
+			if added {
+				result.GrantsAdded++
+			}
+		}
+	}
+
+	// 3. Admin awal
+	fullName := opts.AdminFullName
+	if fullName == "" {
+		fullName = "Administrator"
+	}
+	admin, _, err := s.ensureUser(ctx, opts.AdminUsername, opts.AdminEmail, fullName,
+		model.RoleAdmin, roleIDs[model.RoleAdmin], opts.AdminPassword)
+	if err != nil {
+		return nil, fmt.Errorf("seed admin: %w", err)
+	}
+	result.Admin = *admin
+
+	// 4. Data demo (opsional)
+	if opts.Demo {
+		if err := s.seedDemo(ctx, opts.DemoPassword, roleIDs, result); err != nil {
+			return nil, fmt.Errorf("seed demo data: %w", err)
+		}
+	}
+	return result, nil
+}
+
+func (s *SeedService) ensureUser(
+	ctx context.Context,
+	username, email, fullName, role, roleID, password string,
+) (*SeedUser, int64, error) {
+	generated := password == ""
+	if generated {
+		var err error
+		if password, err = utils.GeneratePassword(12); err != nil {
+			return nil, 0, err
+		}
+	}
+	hash, err := utils.HashPassword(password)
+	if err != nil {
+		return nil, 0, err
+	}
+	id, created, err := s.Repo.EnsureUser(ctx, username, email, fullName, hash, roleID)
+	if err != nil {
+		return nil, 0, err
+	}
+
+	user := &SeedUser{Username: username, Role: role, Created: created}
+	if created && generated {
+		user.Password = password
+	}
+	return user, id, nil
+}
+
+func (s *SeedService) seedDemo(
+	ctx context.Context,
+	password string,
+	roleIDs map[string]string,
+	result *SeedResult,
+) error {
+	if s.Docs == nil {
+		return errors.New("achievement document store is required for demo data")
+	}
+	// Semua user demo memakai password yang sama supaya mudah dicoba
+	if password == "" {
+		var err error
+		if password, err = utils.GeneratePassword(9); err != nil {
+			return err
+		}
+	}
+
+	var advisorID int64
+	for _, u := range demoUsers {
+		user, userID, err := s.ensureUser(ctx, u.Username, u.Email, u.FullName, u.Role, roleIDs[u.Role], password)
+		if err != nil {
+			return fmt.Errorf("user %s: %w", u.Username, err)
+		}
+		if user.Created {
+			user.Password = password
+		}
+		result.DemoUsers = append(result.DemoUsers, *user)
+
+		switch u.Role {
+		case model.RoleLecturer:
+			lecturerID, err := s.Repo.EnsureLecturer(ctx, userID, u.NIP, u.Department)
+			if err != nil {
+				return fmt.Errorf("lecturer %s: %w", u.Username, err)
+			}
+			if advisorID == 0 {
+				advisorID = lecturerID
+			}
+		case model.RoleStudent:
+			studentID, err := s.Repo.EnsureStudent(ctx, userID, u.NIM, u.ProgramStudy, u.AcademicYear, advisorID)
+			if err != nil {
+				return fmt.Errorf("student %s: %w", u.Username, err)
+			}
+			n, err := s.seedDemoAchievements(ctx, studentID, repository.AchievementActor{UserID: userID, Role: u.Role})
+			if err != nil {
+				return fmt.Errorf("achievements for %s: %w", u.Username, err)
+			}
+			result.DemoAchievements += n
+		}
+	}
+	return nil
+}
+
+// Achievement demo hanya dibuat untuk mahasiswa yang belum punya achievement
+func (s *SeedService) seedDemoAchievements(
+	ctx context.Context,
+	studentID string,
+	actor repository.AchievementActor,
+) (int, error) {
+	existing, err := s.Repo.CountReferences(ctx, studentID)
+	if err != nil {
+		return 0, err
+	}
+	if existing > 0 {
+		return 0, nil
+	}
+
+	for _, a := range demoAchievements {
+		objID := primitive.NewObjectID()
+		now := syncTimestamp()
+		doc := bson.M{
+			"_id":             objID,
+			"studentId":       studentID,
+			"achievementType": a.AchievementType,
+			"title":           a.Title,
+			"description":     "Data demo dari perintah seed",
+			"details":         a.Details,
+			"tags":            []string{"demo"},
+			"points":          a.Points,
+			"rubricVersion":   nil,
+			"createdAt":       now,
+			"updatedAt":       now,
+		}
+		if err := s.Docs.Insert(ctx, doc); err != nil {
+			return 0, err
+		}
+		// Mongo dulu; jika PostgreSQL gagal dokumen dihapus lagi
+		if err := s.Repo.InsertSeedReference(ctx, studentID, objID.Hex(), a.Status, actor); err != nil {
+			s.Docs.Delete(ctx, bson.M{"_id": objID})
+			return 0, err
+		}
+	}
+	return len(demoAchievements), nil
+}
@@ -0,0 +1,121 @@
+package service
+
+import (
+	"context"
+	"testing"
+
+	"uas/app/model"
+	"uas/app/repository"
+	"uas/app/repository/mocks"
+	"uas/utils"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/mock"
+	"go.mongodb.org/mongo-driver/bson"
+)
+
+// Role/permission selalu di-"ensure"; ID = nama supaya mudah dicek
+func expectSeedMatrix(repo *mocks.MockSeedRepository, grantAdded bool) {
+	for _, p := range model.DefaultPermissions {
+		repo.On("EnsurePermission", mock.Anything, p.Name, p.Resource, p.Action, p.Description).Return("perm-"+p.Name, nil)
+	}
+	for _, r := range model.DefaultRoles {
+		repo.On("EnsureRole", mock.Anything, r.Name, r.Description, r.Require2FA).Return("role-"+r.Name, nil)
+	}
+	repo.On("GrantPermission", mock.Anything, mock.Anything, mock.Anything).Return(grantAdded, nil)
+}
+
+func TestSeed_FreshDatabaseWithDemo(t *testing.T) {
+	repo := new(mocks.MockSeedRepository)
+	docs := repository.NewMemoryAchievementStore()
+	expectSeedMatrix(repo, true)
+
+	var adminHash string
+	repo.On("EnsureUser", mock.Anything, "root", "root@example.ac.id", "Administrator", mock.Anything, "role-admin").
+		Run(func(args mock.Arguments) { adminHash = args.String(4) }).
+		Return(int64(1), true, nil)
+	repo.On("EnsureUser", mock.Anything, "dosen.demo", mock.Anything, mock.Anything, mock.Anything, "role-dosen wali").
+		Return(int64(2), true, nil)
+	repo.On("EnsureUser", mock.Anything, "mhs.demo1", mock.Anything, mock.Anything, mock.Anything, "role-mahasiswa").
+		Return(int64(3), true, nil)
+	repo.On("EnsureUser", mock.Anything, "mhs.demo2", mock.Anything, mock.Anything, mock.Anything, "role-mahasiswa").
+		Return(int64(4), true, nil)
+	repo.On("EnsureLecturer", mock.Anything, int64(2), mock.Anything, mock.Anything).Return(int64(10), nil)
+	repo.On("EnsureStudent", mock.Anything, int64(3), mock.Anything, mock.Anything, mock.Anything, int64(10)).Return("student-1", nil)
+	repo.On("EnsureStudent", mock.Anything, int64(4), mock.Anything, mock.Anything, mock.Anything, int64(10)).Return("student-2", nil)
+	repo.On("CountReferences", mock.Anything, mock.Anything).Return(0, nil)
+	repo.On("InsertSeedReference", mock.Anything, mock.Anything, mock.Anything, mock.Anything, mock.Anything).Return(nil)
+
+	result, err := NewSeedService(repo, docs).Run(context.Background(), SeedOptions{
+		AdminUsername: "root",
+		AdminEmail:    "root@example.ac.id",
+		Demo:          true,
+		DemoPassword:  "demo-pass",
+	})
+	assert.NoError(t, err)
+
+	assert.Equal(t, len(model.DefaultRoles), result.RolesSeeded)
+	assert.Equal(t, len(model.DefaultPermissions), result.PermissionsSeeded)
+	assert.Greater(t, result.GrantsAdded, 0)
+
+	// Password admin acak, ditampilkan sekali dan cocok dengan hash yang disimpan
+	assert.True(t, result.Admin.Created)
+	assert.NotEmpty(t, result.Admin.Password)
+	assert.True(t, utils.CheckPassword(adminHash, result.Admin.Password))
+
+	assert.Len(t, result.DemoUsers, 3)
+	assert.Equal(t, 4, result.DemoAchievements)
+	repo.AssertCalled(t, "InsertSeedReference", mock.Anything, "student-1", mock.Anything, "submitted",
+		repository.AchievementActor{UserID: 3, Role: model.RoleStudent})
+
+	states, err := docs.ListDocumentStates(context.Background())
+	assert.NoError(t, err)
+	assert.Len(t, states, 4)
+}
+
+func TestSeed_RerunIsIdempotent(t *testing.T) {
+	repo := new(mocks.MockSeedRepository)
+	docs := repository.NewMemoryAchievementStore()
+	expectSeedMatrix(repo, false)
+
+	repo.On("EnsureUser", mock.Anything, mock.Anything, mock.Anything, mock.Anything, mock.Anything, mock.Anything).
+		Return(int64(7), false, nil)
+	repo.On("EnsureLecturer", mock.Anything, int64(7), mock.Anything, mock.Anything).Return(int64(10), nil)
+	repo.On("EnsureStudent", mock.Anything, int64(7), mock.Anything, mock.Anything, mock.Anything, int64(10)).Return("student-1", nil)
+	repo.On("CountReferences", mock.Anything, "student-1").Return(2, nil)
+
+	result, err := NewSeedService(repo, docs).Run(context.Background(), SeedOptions{
+		AdminUsername: "admin",
+		AdminEmail:    "admin@example.ac.id",
+		Demo:          true,
+	})
+	assert.NoError(t, err)
+
+	assert.Equal(t, 0, result.GrantsAdded)
+	assert.False(t, result.Admin.Created)
+	assert.Empty(t, result.Admin.Password)
+	for _, u := range result.DemoUsers {
+		assert.False(t, u.Created)
+		assert.Empty(t, u.Password)
+	}
+	assert.Equal(t, 0, result.DemoAchievements)
+	repo.AssertNotCalled(t, "InsertSeedReference", mock.Anything, mock.Anything, mock.Anything, mock.Anything, mock.Anything)
+
+	_, err = docs.FindOne(context.Background(), bson.M{"studentId": "student-1"})
+	assert.ErrorIs(t, err, repository.ErrDocumentNotFound)
+}
+
+func TestSeed_DemoRequiresDocumentStore(t *testing.T) {
+	repo := new(mocks.MockSeedRepository)
+	expectSeedMatrix(repo, true)
+	repo.On("EnsureUser", mock.Anything, "admin", mock.Anything, mock.Anything, mock.Anything, mock.Anything).
+		Return(int64(1), true, nil)
+
+	_, err := NewSeedService(repo, nil).Run(context.Background(), SeedOptions{
+		AdminUsername: "admin",
+		AdminEmail:    "admin@example.ac.id",
+		AdminPassword: "s3cret-password",
+		Demo:          true,
+	})
+	assert.ErrorContains(t, err, "document store")
+}
@@ -0,0 +1,219 @@
+package main
+
+import (
+	"context"
+	"database/sql"
+	"flag"
+	"fmt"
+
+	"uas/app/repository"
+	"uas/app/service"
+	"uas/config"
+	"uas/database"
+)
+
+// =========================
+// CLI SUBCOMMANDS
+// =========================
+//
+//	go run . recompute-points [-dry-run]
+//	go run . reconcile [-repair] [-dry-run]
+//	go run . migrate up|down [-steps N]|status
+//	go run . seed [-admin-username U] [-admin-email E] [-admin-password P] [-demo] [-demo-password P]
+func runCommand(cfg *config.Config, db *sql.DB, args []string) error {
+	switch args[0] {
+	case "recompute-points":
+		return recomputePointsCommand(db, args[1:])
+	case "reconcile":
+		return reconcileCommand(cfg, db, args[1:])
+	case "migrate":
+		return migrateCommand(db, args[1:])
+	case "seed":
+		return seedCommand(cfg, db, args[1:])
+	default:
+		return fmt.Errorf("unknown command %q", args[0])
+	}
+}
+
+// Hitung ulang students.points dari ledger point_transactions dan laporkan drift
+func recomputePointsCommand(db *sql.DB, args []string) error {
+	fs := flag.NewFlagSet("recompute-points", flag.ContinueOnError)
+	dryRun := fs.Bool("dry-run", false, "only report drift, do not update students.points")
+	if err := fs.Parse(args); err != nil {
+		return err
+	}
+
+	repo := repository.NewPointRepository(db)
+	drifts, err := repo.RecomputeAll(context.Background(), !*dryRun)
+	if err != nil {
+		return err
+	}
+
+	for _, d := range drifts {
+		fmt.Printf("%s\tcached=%.2f\tledger=%.2f\tdrift=%+.2f\n",
+			d.StudentID, d.CachedPoints, d.LedgerPoints, d.Drift)
+	}
+	if *dryRun {
+		fmt.Printf("%d student(s) drifted (dry run, nothing changed)\n", len(drifts))
+	} else {
+		fmt.Printf("%d student(s) recomputed from ledger\n", len(drifts))
+	}
+	return nil
+}
+
+// Bandingkan achievement_references dengan dokumen Mongo dan (opsional) perbaiki
+func reconcileCommand(cfg *config.Config, db *sql.DB, args []string) error {
+	fs := flag.NewFlagSet("reconcile", flag.ContinueOnError)
+	repair := fs.Bool("repair", false, "repair the findings (PostgreSQL wins)")
+	dryRun := fs.Bool("dry-run", false, "with -repair: only show what would be repaired")
+	if err := fs.Parse(args); err != nil {
+		return err
+	}
+
+	docs, closeDocs, err := openAchievementStore(cfg)
+	if err != nil {
+		return err
+	}
+	defer closeDocs()
+
+	reconciler := service.NewReconcileService(
+		repository.NewAchievementRepository(db),
+		docs,
+		repository.NewAchievementSyncRepository(db),
+	)
+	report, err := reconciler.Run(context.Background(), service.ReconcileOptions{
+		Repair: *repair,
+		DryRun: *dryRun,
+		Actor:  repository.AchievementActor{Role: "system"},
+	})
+	if err != nil {
+		return err
+	}
+
+	for _, f := range report.Findings {
+		status := ""
+		if f.Repaired {
+			status = "\trepaired"
+		} else if f.Error != "" {
+			status = "\terror=" + f.Error
+		}
+		fmt.Printf("%s\t%s\t%s\taction=%s%s\n", f.Kind, f.MongoID, f.Detail, f.Action, status)
+	}
+	fmt.Printf("scanned %d reference(s), %d document(s); %d in-flight, %d recent skipped; %d finding(s)\n",
+		report.ScannedReferences, report.ScannedDocuments, report.SkippedInFlight, report.SkippedRecent, len(report.Findings))
+	switch {
+	case *repair && *dryRun:
+		fmt.Println("dry run, nothing changed")
+	case *repair:
+		fmt.Printf("%d finding(s) repaired\n", report.Repaired)
+	}
+	return nil
+}
+
+// Jalankan / rollback / tampilkan status migrasi skema yang di-embed
+func migrateCommand(db *sql.DB, args []string) error {
+	if len(args) == 0 {
+		return fmt.Errorf("usage: migrate up|down [-steps N]|status")
+	}
+	migrator, err := database.NewMigrator(db)
+	if err != nil {
+		return err
+	}
+	ctx := context.Background()
+
+	switch args[0] {
+	case "up":
+		applied, err := migrator.Up(ctx)
+		for _, m := range applied {
+			fmt.Printf("applied\t%04d_%s\n", m.Version, m.Name)
+		}
+		if err != nil {
+			return err
+		}
+		fmt.Printf("%d migration(s) applied\n", len(applied))
+	case "down":
+		fs := flag.NewFlagSet("migrate down", flag.ContinueOnError)
+		steps := fs.Int("steps", 1, "number of migrations to roll back")
+		if err := fs.Parse(args[1:]); err != nil {
+			return err
+		}
+		if *steps < 1 {
+			return fmt.Errorf("-steps must be at least 1")
+		}
+		reverted, err := migrator.Down(ctx, *steps)
+		for _, m := range reverted {
+			fmt.Printf("reverted\t%04d_%s\n", m.Version, m.Name)
+		}
+		if err != nil {
+			return err
+		}
+		fmt.Printf("%d migration(s) reverted\n", len(reverted))
+	case "status":
+		statuses, err := migrator.Status(ctx)
+		if err != nil {
+			return err
+		}
+		for _, st := range statuses {
+			appliedAt := "pending"
+			if st.AppliedAt != nil {
+				appliedAt = st.AppliedAt.Format("2006-01-02 15:04:05")
+			}
+			fmt.Printf("%04d_%s\t%s\n", st.Version, st.Name, appliedAt)
+		}
+	default:
+		return fmt.Errorf("unknown migrate command %q (want up, down or status)", args[0])
+	}
+	return nil
+}
+
+// Isi role/permission, admin awal dan (opsional) data demo. Idempoten.
+func seedCommand(cfg *config.Config, db *sql.DB, args []string) error {
+	fs := flag.NewFlagSet("seed", flag.ContinueOnError)
+	adminUsername := fs.String("admin-username", cfg.SeedAdminUsername, "bootstrap admin username")
+	adminEmail := fs.String("admin-email", cfg.SeedAdminEmail, "bootstrap admin email")
+	adminPassword := fs.String("admin-password", cfg.SeedAdminPassword, "bootstrap admin password (empty = generate)")
+	demo := fs.Bool("demo", false, "also create demo lecturer, students and achievements")
+	demoPassword := fs.String("demo-password", "", "password for demo users (empty = generate)")
+	if err := fs.Parse(args); err != nil {
+		return err
+	}
+
+	var docs repository.AchievementDocumentStore
+	if *demo {
+		store, closeDocs, err := openAchievementStore(cfg)
+		if err != nil {
+			return err
+		}
+		defer closeDocs()
+		docs = store
+	}
+
+	seeder := service.NewSeedService(repository.NewSeedRepository(db), docs)
+	result, err := seeder.Run(context.Background(), service.SeedOptions{
+		AdminUsername: *adminUsername,
+		AdminEmail:    *adminEmail,
+		AdminPassword: *adminPassword,
+		Demo:          *demo,
+		DemoPassword:  *demoPassword,
+	})
+	if err != nil {
+		return err
+	}
+
+	fmt.Printf("%d role(s), %d permission(s) ensured; %d grant(s) added\n",
+		result.RolesSeeded, result.PermissionsSeeded, result.GrantsAdded)
+	for _, u := range append([]service.SeedUser{result.Admin}, result.DemoUsers...) {
+		switch {
+		case !u.Created:
+			fmt.Printf("%s\t%s\talready exists\n", u.Role, u.Username)
+		case u.Password != "":
+			fmt.Printf("%s\t%s\tcreated, password: %s\n", u.Role, u.Username, u.Password)
+		default:
+			fmt.Printf("%s\t%s\tcreated\n", u.Role, u.Username)
+		}
+	}
+	if *demo {
+		fmt.Printf("%d demo achievement(s) created\n", result.DemoAchievements)
+	}
+	return nil
+}
@@ -2,6 +2,7 @@ package config
 
 import (
 	"log"
+	"time"
 
 	"github.com/joho/godotenv"
 	"github.com/caarlos0/env/v9"
@@ -9,10 +10,106 @@ import (
 
 type Config struct {
 	AppPort     string `env:"APP_PORT" envDefault:"3000"`
+	AppEnv      string `env:"APP_ENV" envDefault:"production"` // "development" untuk dev lokal
 	PostgresDSN string `env:"POSTGRES_DSN"`
 	MongoURI    string `env:"MONGO_URI" envDefault:"mongodb://localhost:27017"`
-	MongoDB     string `env:"MONGO_DB" envDefault:"uas_db"`
+	MongoDB     string `env:"MONGO_DB" envDefault:"uas"`
 	JWTSecret   string `env:"JWT_SECRET" envDefault:"changeme"`
+
+	// Kunci JWT: HS256 (JWT_SECRET, min. 32 byte dan bukan "changeme" di luar
+	// APP_ENV=development), RS256 / EdDSA (JWT_PRIVATE_KEY_FILE, PEM).
+	// JWT_KEYS_FILE (JSON) untuk beberapa kunci / rotasi, lihat utils.LoadKeySet.
+	JWTAlgorithm      string        `env:"JWT_ALGORITHM" envDefault:"HS256"`
+	JWTKeyID          string        `env:"JWT_KEY_ID" envDefault:"default"`
+	JWTPrivateKeyFile string        `env:"JWT_PRIVATE_KEY_FILE"`
+	JWTKeysFile       string        `env:"JWT_KEYS_FILE"`
+	JWTIssuer         string        `env:"JWT_ISSUER" envDefault:"uas"`
+	JWTAudience       []string      `env:"JWT_AUDIENCE" envDefault:"uas-api" envSeparator:","`
+	AccessTokenTTL    time.Duration `env:"ACCESS_TOKEN_TTL" envDefault:"15m"` // pendek; sesi panjang lewat refresh token
+
+	// Penyimpanan dokumen achievement: "mongo" atau "memory" (dev lokal, tidak persisten)
+	AchievementStore           string `env:"ACHIEVEMENT_STORE" envDefault:"mongo"`
+	MongoAchievementCollection string `env:"MONGO_ACHIEVEMENT_COLLECTION" envDefault:"achievements"`
+
+	// Jalankan `migrate up` otomatis sebelum server start
+	MigrateOnStart bool `env:"MIGRATE_ON_START" envDefault:"false"`
+
+	// Lama cache role → permission di middleware.RequirePermission
+	PermissionCacheTTL time.Duration `env:"PERMISSION_CACHE_TTL" envDefault:"1m"`
+
+	// Email keluar (reset password): MAIL_DRIVER console | file | smtp.
+	// Wajib di luar APP_ENV=development; console di luar dev tidak menulis isi email.
+	MailDriver   string `env:"MAIL_DRIVER"`
+	MailFrom     string `env:"MAIL_FROM" envDefault:"no-reply@example.ac.id"`
+	MailDir      string `env:"MAIL_DIR" envDefault:"./tmp/mail"`
+	SMTPHost     string `env:"SMTP_HOST"`
+	SMTPPort     int    `env:"SMTP_PORT" envDefault:"587"`
+	SMTPUsername string `env:"SMTP_USERNAME"`
+	SMTPPassword string `env:"SMTP_PASSWORD"`
+
+	// Link di email reset password; token ditambahkan di akhir URL
+	PasswordResetURL string        `env:"PASSWORD_RESET_URL" envDefault:"http://localhost:3000/reset-password?token="`
+	PasswordResetTTL time.Duration `env:"PASSWORD_RESET_TTL" envDefault:"30m"`
+
+	// Batas permintaan lupa password per email / per IP dalam LOGIN_FAILURE_WINDOW;
+	// setiap permintaan membuat link baru dan yang lama hangus
+	PasswordResetMaxPerEmail int `env:"PASSWORD_RESET_MAX_PER_EMAIL" envDefault:"3"`
+	PasswordResetMaxPerIP    int `env:"PASSWORD_RESET_MAX_PER_IP" envDefault:"10"`
+
+	// Aturan password baru. PASSWORD_BREACHED_FILE: daftar password bocor
+	// (satu per baris atau SHA-1 format HIBP), kosong = tidak dicek
+	PasswordMinLength     int    `env:"PASSWORD_MIN_LENGTH" envDefault:"8"`
+	PasswordRequireUpper  bool   `env:"PASSWORD_REQUIRE_UPPER" envDefault:"true"`
+	PasswordRequireLower  bool   `env:"PASSWORD_REQUIRE_LOWER" envDefault:"true"`
+	PasswordRequireDigit  bool   `env:"PASSWORD_REQUIRE_DIGIT" envDefault:"true"`
+	PasswordRequireSymbol bool   `env:"PASSWORD_REQUIRE_SYMBOL" envDefault:"false"`
+	PasswordBreachedFile  string `env:"PASSWORD_BREACHED_FILE"`
+
+	// Throttling login: setelah N gagal, kunci sementara dengan backoff
+	// eksponensial (base, 2x, 4x, ... maks LOGIN_LOCKOUT_MAX). Hitungan gagal
+	// mulai dari nol lagi jika tidak ada gagal selama LOGIN_FAILURE_WINDOW.
+	LoginMaxFailures   int           `env:"LOGIN_MAX_FAILURES" envDefault:"5"`
+	LoginIPMaxFailures int           `env:"LOGIN_IP_MAX_FAILURES" envDefault:"20"`
+	LoginLockoutBase   time.Duration `env:"LOGIN_LOCKOUT_BASE" envDefault:"30s"`
+	LoginLockoutMax    time.Duration `env:"LOGIN_LOCKOUT_MAX" envDefault:"1h"`
+	LoginFailureWindow time.Duration `env:"LOGIN_FAILURE_WINDOW" envDefault:"15m"`
+
+	// Reverse proxy di depan API. IP klien (throttling per IP, audit) diambil
+	// dari PROXY_HEADER hanya untuk request dari TRUSTED_PROXIES (IP / CIDR);
+	// kosong = alamat koneksi langsung. Proxy harus menimpa header ini,
+	// bukan menambahkannya (mis. nginx: proxy_set_header X-Real-IP $remote_addr).
+	TrustedProxies []string `env:"TRUSTED_PROXIES" envSeparator:","`
+	ProxyHeader    string   `env:"PROXY_HEADER" envDefault:"X-Real-IP"`
+
+	// Nama layanan yang tampil di authenticator app (2FA TOTP)
+	TOTPIssuer string `env:"TOTP_ISSUER" envDefault:"UAS"`
+
+	// SSO OpenID Connect dengan IdP kampus; OIDC_ISSUER kosong = SSO mati.
+	// OIDC_CLIENT_SECRET kosong = public client (PKCE saja).
+	OIDCIssuer       string   `env:"OIDC_ISSUER"`
+	OIDCClientID     string   `env:"OIDC_CLIENT_ID"`
+	OIDCClientSecret string   `env:"OIDC_CLIENT_SECRET"`
+	OIDCRedirectURL  string   `env:"OIDC_REDIRECT_URL" envDefault:"http://localhost:3000/api/v1/auth/oidc/callback"`
+	OIDCScopes       []string `env:"OIDC_SCOPES" envDefault:"openid,email,profile" envSeparator:","`
+
+	// Nama claim ID token yang dipetakan ke user / profil (kosong = tidak dipakai)
+	OIDCClaimEmail        string `env:"OIDC_CLAIM_EMAIL" envDefault:"email"`
+	OIDCClaimUsername     string `env:"OIDC_CLAIM_USERNAME" envDefault:"preferred_username"`
+	OIDCClaimName         string `env:"OIDC_CLAIM_NAME" envDefault:"name"`
+	OIDCClaimNIM          string `env:"OIDC_CLAIM_NIM" envDefault:"nim"`
+	OIDCClaimNIP          string `env:"OIDC_CLAIM_NIP" envDefault:"nip"`
+	OIDCClaimProgramStudy string `env:"OIDC_CLAIM_PROGRAM_STUDY" envDefault:"program_study"`
+	OIDCClaimDepartment   string `env:"OIDC_CLAIM_DEPARTMENT" envDefault:"department"`
+
+	// Email dari IdP hanya dipercaya jika email_verified = true.
+	// OIDC_JIT_PROVISIONING: buat user mahasiswa / dosen wali saat login SSO pertama.
+	OIDCRequireVerifiedEmail bool `env:"OIDC_REQUIRE_VERIFIED_EMAIL" envDefault:"true"`
+	OIDCJITProvisioning      bool `env:"OIDC_JIT_PROVISIONING" envDefault:"false"`
+
+	// Admin awal untuk perintah `seed` (password kosong = dibuat acak)
+	SeedAdminUsername string `env:"SEED_ADMIN_USERNAME" envDefault:"admin"`
+	SeedAdminEmail    string `env:"SEED_ADMIN_EMAIL" envDefault:"admin@example.ac.id"`
+	SeedAdminPassword string `env:"SEED_ADMIN_PASSWORD"`
 }
 
 func LoadConfig() *Config {
@@ -28,3 +125,7 @@ func LoadConfig() *Config {
 
 	return &cfg
 }
+
+func (c *Config) IsDevelopment() bool {
+	return c.AppEnv == "development"
+}
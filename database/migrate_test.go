@@ -0,0 +1,64 @@
+package database
+
+import (
+	"context"
+	"regexp"
+	"testing"
+	"testing/fstest"
+	"time"
+
+	"github.com/DATA-DOG/go-sqlmock"
+	"github.com/stretchr/testify/assert"
+)
+
+func TestLoadMigrations_EmbeddedSchema(t *testing.T) {
+	migrations, err := LoadMigrations(migrationFiles, "migrations")
+	assert.NoError(t, err)
+	assert.NotEmpty(t, migrations)
+
+	for i, m := range migrations {
+		// versi berurutan tanpa lubang
+		assert.Equal(t, int64(i+1), m.Version)
+		assert.NotEmpty(t, m.Up)
+		assert.NotEmpty(t, m.Down)
+	}
+}
+
+func TestLoadMigrations_RejectsMissingDown(t *testing.T) {
+	fsys := fstest.MapFS{
+		"m/0001_init.up.sql":   {Data: []byte("CREATE TABLE a (id INT);")},
+		"m/0001_init.down.sql": {Data: []byte("DROP TABLE a;")},
+		"m/0002_more.up.sql":   {Data: []byte("CREATE TABLE b (id INT);")},
+	}
+	_, err := LoadMigrations(fsys, "m")
+	assert.ErrorContains(t, err, "0002_more")
+}
+
+func TestMigrator_UpAppliesOnlyPending(t *testing.T) {
+	db, sqlMock, err := sqlmock.New()
+	assert.NoError(t, err)
+	defer db.Close()
+
+	migrator := &Migrator{DB: db, Migrations: []Migration{
+		{Version: 1, Name: "init", Up: "CREATE TABLE a (id INT)", Down: "DROP TABLE a"},
+		{Version: 2, Name: "more", Up: "CREATE TABLE b (id INT)", Down: "DROP TABLE b"},
+	}}
+
+	sqlMock.ExpectExec("SELECT pg_advisory_lock").WillReturnResult(sqlmock.NewResult(0, 0))
+	sqlMock.ExpectExec("CREATE TABLE IF NOT EXISTS schema_migrations").WillReturnResult(sqlmock.NewResult(0, 0))
+	sqlMock.ExpectQuery("SELECT version, applied_at FROM schema_migrations").
+		WillReturnRows(sqlmock.NewRows([]string{"version", "applied_at"}).AddRow(int64(1), time.Now()))
+	sqlMock.ExpectBegin()
+	sqlMock.ExpectExec(regexp.QuoteMeta("CREATE TABLE b (id INT)")).WillReturnResult(sqlmock.NewResult(0, 0))
+	sqlMock.ExpectExec("INSERT INTO schema_migrations").
+		WithArgs(int64(2), "more").
+		WillReturnResult(sqlmock.NewResult(1, 1))
+	sqlMock.ExpectCommit()
+	sqlMock.ExpectExec("SELECT pg_advisory_unlock").WillReturnResult(sqlmock.NewResult(0, 0))
+
+	applied, err := migrator.Up(context.Background())
+	assert.NoError(t, err)
+	assert.Len(t, applied, 1)
+	assert.Equal(t, int64(2), applied[0].Version)
+	assert.NoError(t, sqlMock.ExpectationsWereMet())
+}
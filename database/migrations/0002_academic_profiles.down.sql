@@ -0,0 +1,2 @@
+DROP TABLE IF EXISTS students;
+DROP TABLE IF EXISTS lecturers;
@@ -0,0 +1,22 @@
+-- Profil dosen wali dan mahasiswa (1:1 dengan users)
+CREATE TABLE IF NOT EXISTS lecturers (
+    id         BIGSERIAL PRIMARY KEY,
+    user_id    BIGINT NOT NULL UNIQUE REFERENCES users(id) ON DELETE CASCADE,
+    nip        VARCHAR(20) UNIQUE,
+    department VARCHAR(100),
+    created_at TIMESTAMPTZ NOT NULL DEFAULT NOW()
+);
+
+CREATE TABLE IF NOT EXISTS students (
+    id            UUID PRIMARY KEY DEFAULT gen_random_uuid(),
+    user_id       BIGINT NOT NULL UNIQUE REFERENCES users(id) ON DELETE CASCADE,
+    nim           VARCHAR(20) UNIQUE,
+    program_study VARCHAR(100),
+    academic_year VARCHAR(10),
+    advisor_id    BIGINT REFERENCES lecturers(id) ON DELETE SET NULL,
+    -- cache dari SUM(point_transactions.delta)
+    points        NUMERIC(10, 2) NOT NULL DEFAULT 0,
+    created_at    TIMESTAMPTZ NOT NULL DEFAULT NOW()
+);
+
+CREATE INDEX IF NOT EXISTS idx_students_advisor_id ON students (advisor_id);
@@ -0,0 +1,2 @@
+DROP TABLE IF EXISTS achievement_status_events;
+DROP TABLE IF EXISTS achievement_references;
@@ -0,0 +1,44 @@
+-- Referensi PostgreSQL untuk dokumen achievement di Mongo + log transisi status
+CREATE TABLE IF NOT EXISTS achievement_references (
+    id                   UUID PRIMARY KEY DEFAULT gen_random_uuid(),
+    student_uuid         UUID NOT NULL REFERENCES students(id) ON DELETE CASCADE,
+    mongo_achievement_id VARCHAR(24) NOT NULL UNIQUE,
+    status               VARCHAR(20) NOT NULL DEFAULT 'draft'
+        CHECK (status IN ('draft', 'submitted', 'verified', 'rejected', 'revoked')),
+    submitted_at         TIMESTAMPTZ,
+    verified_at          TIMESTAMPTZ,
+    verified_by          BIGINT REFERENCES lecturers(id) ON DELETE SET NULL,
+    rejection_note       TEXT,
+    revision_round       INT NOT NULL DEFAULT 1,
+    claimed_points       NUMERIC(10, 2),
+    approved_points      NUMERIC(10, 2),
+    verification_remarks TEXT,
+    revoked_at           TIMESTAMPTZ,
+    revoked_by           BIGINT REFERENCES users(id) ON DELETE SET NULL,
+    revocation_reason    TEXT,
+    is_deleted           BOOLEAN NOT NULL DEFAULT FALSE,
+    created_at           TIMESTAMPTZ NOT NULL DEFAULT NOW(),
+    updated_at           TIMESTAMPTZ NOT NULL DEFAULT NOW()
+);
+
+CREATE INDEX IF NOT EXISTS idx_achievement_references_student
+    ON achievement_references (student_uuid, created_at DESC);
+CREATE INDEX IF NOT EXISTS idx_achievement_references_status
+    ON achievement_references (status) WHERE is_deleted = FALSE;
+
+-- Append-only: satu baris per transisi status
+CREATE TABLE IF NOT EXISTS achievement_status_events (
+    id                   BIGSERIAL PRIMARY KEY,
+    achievement_ref_id   UUID NOT NULL REFERENCES achievement_references(id) ON DELETE CASCADE,
+    mongo_achievement_id VARCHAR(24) NOT NULL,
+    round                INT NOT NULL DEFAULT 1,
+    from_status          VARCHAR(20),
+    to_status            VARCHAR(20) NOT NULL,
+    actor_user_id        BIGINT REFERENCES users(id) ON DELETE SET NULL,
+    actor_role           VARCHAR(50),
+    note                 TEXT,
+    created_at           TIMESTAMPTZ NOT NULL DEFAULT NOW()
+);
+
+CREATE INDEX IF NOT EXISTS idx_achievement_status_events_mongo
+    ON achievement_status_events (mongo_achievement_id, created_at, id);
@@ -0,0 +1,17 @@
+-- Ledger poin mahasiswa; students.points adalah cache dari SUM(delta)
+CREATE TABLE IF NOT EXISTS point_transactions (
+    id                   BIGSERIAL PRIMARY KEY,
+    achievement_ref_id   UUID REFERENCES achievement_references(id) ON DELETE SET NULL,
+    mongo_achievement_id VARCHAR(24),
+    student_uuid         UUID NOT NULL REFERENCES students(id) ON DELETE CASCADE,
+    delta                NUMERIC(10, 2) NOT NULL,
+    reason               VARCHAR(20) NOT NULL,
+    actor_user_id        BIGINT REFERENCES users(id) ON DELETE SET NULL,
+    semester             VARCHAR(20) NOT NULL,
+    created_at           TIMESTAMPTZ NOT NULL DEFAULT NOW(),
+    -- verifikasi / revoke yang diulang tidak menggandakan poin
+    UNIQUE (achievement_ref_id, reason)
+);
+
+CREATE INDEX IF NOT EXISTS idx_point_transactions_student
+    ON point_transactions (student_uuid, created_at DESC);
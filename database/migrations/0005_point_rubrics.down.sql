@@ -0,0 +1,2 @@
+DROP TABLE IF EXISTS point_rubric_entries;
+DROP TABLE IF EXISTS point_rubrics;
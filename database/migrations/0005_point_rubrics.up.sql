@@ -0,0 +1,25 @@
+-- Rubrik poin per tahun akademik (berversi, hanya satu yang aktif)
+CREATE TABLE IF NOT EXISTS point_rubrics (
+    id            UUID PRIMARY KEY DEFAULT gen_random_uuid(),
+    academic_year VARCHAR(9) NOT NULL,
+    version       INT NOT NULL,
+    description   TEXT NOT NULL DEFAULT '',
+    is_active     BOOLEAN NOT NULL DEFAULT FALSE,
+    created_at    TIMESTAMPTZ NOT NULL DEFAULT NOW(),
+    updated_at    TIMESTAMPTZ NOT NULL DEFAULT NOW(),
+    UNIQUE (academic_year, version)
+);
+
+CREATE UNIQUE INDEX IF NOT EXISTS idx_point_rubrics_single_active
+    ON point_rubrics (is_active) WHERE is_active;
+
+CREATE TABLE IF NOT EXISTS point_rubric_entries (
+    id               BIGSERIAL PRIMARY KEY,
+    rubric_id        UUID NOT NULL REFERENCES point_rubrics(id) ON DELETE CASCADE,
+    achievement_type VARCHAR(50) NOT NULL,
+    level            VARCHAR(50) NOT NULL DEFAULT '',
+    rank             VARCHAR(50) NOT NULL DEFAULT '',
+    points           NUMERIC(10, 2) NOT NULL
+);
+
+CREATE INDEX IF NOT EXISTS idx_point_rubric_entries_rubric ON point_rubric_entries (rubric_id);
@@ -0,0 +1,12 @@
+-- Katalog kategori achievement (menimpa kategori bawaan dengan key sama)
+CREATE TABLE IF NOT EXISTS achievement_types (
+    key                  VARCHAR(50) PRIMARY KEY,
+    label_id             VARCHAR(100) NOT NULL,
+    label_en             VARCHAR(100) NOT NULL,
+    details_schema       JSONB NOT NULL,
+    required_attachments JSONB NOT NULL DEFAULT '[]',
+    default_rubric       JSONB NOT NULL DEFAULT '[]',
+    is_active            BOOLEAN NOT NULL DEFAULT TRUE,
+    created_at           TIMESTAMPTZ NOT NULL DEFAULT NOW(),
+    updated_at           TIMESTAMPTZ NOT NULL DEFAULT NOW()
+);
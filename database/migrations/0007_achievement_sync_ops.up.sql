@@ -0,0 +1,17 @@
+-- Outbox dual-write Mongo/PostgreSQL
+CREATE TABLE IF NOT EXISTS achievement_sync_ops (
+    id                   BIGSERIAL PRIMARY KEY,
+    op                   VARCHAR(20) NOT NULL,
+    mongo_achievement_id VARCHAR(24) NOT NULL,
+    payload              JSONB NOT NULL DEFAULT '{}',
+    status               VARCHAR(20) NOT NULL DEFAULT 'pending',
+    attempts             INT NOT NULL DEFAULT 0,
+    last_error           TEXT,
+    created_at           TIMESTAMPTZ NOT NULL DEFAULT NOW(),
+    updated_at           TIMESTAMPTZ NOT NULL DEFAULT NOW()
+);
+
+CREATE INDEX IF NOT EXISTS idx_achievement_sync_ops_pending
+    ON achievement_sync_ops (created_at) WHERE status = 'pending';
+CREATE INDEX IF NOT EXISTS idx_achievement_sync_ops_mongo
+    ON achievement_sync_ops (mongo_achievement_id);
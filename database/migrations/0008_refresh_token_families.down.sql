@@ -0,0 +1,11 @@
+-- Hash tidak bisa dikembalikan ke token asli: semua sesi harus login ulang
+DELETE FROM refresh_tokens;
+
+DROP INDEX IF EXISTS idx_refresh_tokens_family_id;
+
+ALTER TABLE refresh_tokens
+    DROP COLUMN IF EXISTS revoked_at,
+    DROP COLUMN IF EXISTS rotated_at,
+    DROP COLUMN IF EXISTS family_id;
+
+ALTER TABLE refresh_tokens RENAME COLUMN token_hash TO token;
@@ -0,0 +1,14 @@
+-- Refresh token disimpan sebagai hash SHA-256 dan dikelompokkan per family
+-- (satu family = satu login). Token lama yang sudah di-rotate tetap disimpan
+-- supaya pemakaian ulang bisa dideteksi.
+ALTER TABLE refresh_tokens RENAME COLUMN token TO token_hash;
+
+-- Token plaintext yang sudah ada di-hash supaya sesi aktif tidak putus
+UPDATE refresh_tokens SET token_hash = encode(digest(token_hash, 'sha256'), 'hex');
+
+ALTER TABLE refresh_tokens
+    ADD COLUMN family_id  UUID NOT NULL DEFAULT gen_random_uuid(),
+    ADD COLUMN rotated_at TIMESTAMPTZ,
+    ADD COLUMN revoked_at TIMESTAMPTZ;
+
+CREATE INDEX IF NOT EXISTS idx_refresh_tokens_family_id ON refresh_tokens (family_id);
@@ -0,0 +1,25 @@
+-- Satu sesi = satu login di satu perangkat = satu family refresh token
+CREATE TABLE IF NOT EXISTS auth_sessions (
+    id           UUID PRIMARY KEY,
+    user_id      BIGINT NOT NULL REFERENCES users(id) ON DELETE CASCADE,
+    user_agent   TEXT NOT NULL DEFAULT '',
+    ip_address   VARCHAR(45) NOT NULL DEFAULT '',
+    created_at   TIMESTAMPTZ NOT NULL DEFAULT NOW(),
+    last_used_at TIMESTAMPTZ NOT NULL DEFAULT NOW(),
+    revoked_at   TIMESTAMPTZ
+);
+
+CREATE INDEX IF NOT EXISTS idx_auth_sessions_user_id ON auth_sessions (user_id) WHERE revoked_at IS NULL;
+
+-- Family yang sudah ada menjadi sesi tanpa metadata perangkat
+INSERT INTO auth_sessions (id, user_id, created_at, last_used_at, revoked_at)
+SELECT family_id, MIN(user_id), MIN(created_at), MAX(created_at),
+       CASE WHEN BOOL_AND(revoked_at IS NOT NULL) THEN MAX(revoked_at) END
+FROM refresh_tokens
+GROUP BY family_id
+ON CONFLICT (id) DO NOTHING;
+
+ALTER TABLE refresh_tokens ALTER COLUMN family_id DROP DEFAULT;
+ALTER TABLE refresh_tokens
+    ADD CONSTRAINT refresh_tokens_family_id_fkey
+    FOREIGN KEY (family_id) REFERENCES auth_sessions (id) ON DELETE CASCADE;
@@ -0,0 +1,14 @@
+-- Versi token per user: access token dengan klaim ver lebih kecil ditolak.
+-- Dinaikkan saat role berubah, akun dinonaktifkan atau password diganti.
+ALTER TABLE users ADD COLUMN IF NOT EXISTS token_version BIGINT NOT NULL DEFAULT 0;
+
+-- Denylist access token (jti) yang dicabut sebelum kedaluwarsa, mis. saat logout.
+-- Baris boleh dihapus setelah expires_at karena token sudah tidak valid.
+CREATE TABLE IF NOT EXISTS revoked_access_tokens (
+    jti        UUID PRIMARY KEY,
+    user_id    BIGINT NOT NULL REFERENCES users(id) ON DELETE CASCADE,
+    expires_at TIMESTAMPTZ NOT NULL,
+    revoked_at TIMESTAMPTZ NOT NULL DEFAULT NOW()
+);
+
+CREATE INDEX IF NOT EXISTS idx_revoked_access_tokens_expires_at ON revoked_access_tokens (expires_at);
@@ -0,0 +1,12 @@
+-- Token reset password: sekali pakai, kedaluwarsa, hanya hash yang disimpan
+CREATE TABLE IF NOT EXISTS password_reset_tokens (
+    id           BIGSERIAL PRIMARY KEY,
+    user_id      BIGINT NOT NULL REFERENCES users(id) ON DELETE CASCADE,
+    token_hash   TEXT NOT NULL UNIQUE,
+    expires_at   TIMESTAMPTZ NOT NULL,
+    used_at      TIMESTAMPTZ,
+    requested_by BIGINT REFERENCES users(id) ON DELETE SET NULL, -- admin; NULL = lupa password
+    created_at   TIMESTAMPTZ NOT NULL DEFAULT NOW()
+);
+
+CREATE INDEX IF NOT EXISTS idx_password_reset_tokens_user_id ON password_reset_tokens (user_id);
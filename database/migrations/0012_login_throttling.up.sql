@@ -0,0 +1,21 @@
+-- Penghitung login gagal per akun ("user:<id>") dan per IP ("ip:<alamat>")
+CREATE TABLE IF NOT EXISTS login_throttles (
+    key             TEXT PRIMARY KEY,
+    failures        INTEGER NOT NULL DEFAULT 0,
+    last_failure_at TIMESTAMPTZ NOT NULL DEFAULT NOW(),
+    locked_until    TIMESTAMPTZ
+);
+
+-- Jejak audit kejadian keamanan akun (lockout, unlock, ...)
+CREATE TABLE IF NOT EXISTS audit_logs (
+    id         BIGSERIAL PRIMARY KEY,
+    event      VARCHAR(50) NOT NULL,
+    user_id    BIGINT REFERENCES users(id) ON DELETE SET NULL,
+    actor_id   BIGINT REFERENCES users(id) ON DELETE SET NULL,
+    ip_address VARCHAR(45) NOT NULL DEFAULT '',
+    details    JSONB NOT NULL DEFAULT '{}',
+    created_at TIMESTAMPTZ NOT NULL DEFAULT NOW()
+);
+
+CREATE INDEX IF NOT EXISTS idx_audit_logs_user_id ON audit_logs (user_id, created_at DESC);
+CREATE INDEX IF NOT EXISTS idx_audit_logs_event ON audit_logs (event, created_at DESC);
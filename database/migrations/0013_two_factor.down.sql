@@ -0,0 +1,5 @@
+ALTER TABLE roles DROP COLUMN IF EXISTS require_2fa;
+
+DROP TABLE IF EXISTS login_challenges;
+DROP TABLE IF EXISTS user_recovery_codes;
+DROP TABLE IF EXISTS user_totp;
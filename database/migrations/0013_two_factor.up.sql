@@ -0,0 +1,34 @@
+-- TOTP (RFC 6238) per user. confirmed_at NULL = enrollment belum dikonfirmasi.
+-- last_used_step mencegah kode yang sama dipakai dua kali.
+CREATE TABLE IF NOT EXISTS user_totp (
+    user_id        BIGINT PRIMARY KEY REFERENCES users(id) ON DELETE CASCADE,
+    secret         TEXT NOT NULL,
+    confirmed_at   TIMESTAMPTZ,
+    last_used_step BIGINT NOT NULL DEFAULT 0,
+    created_at     TIMESTAMPTZ NOT NULL DEFAULT NOW()
+);
+
+-- Kode pemulihan sekali pakai (hash SHA-256)
+CREATE TABLE IF NOT EXISTS user_recovery_codes (
+    id         BIGSERIAL PRIMARY KEY,
+    user_id    BIGINT NOT NULL REFERENCES users(id) ON DELETE CASCADE,
+    code_hash  TEXT NOT NULL,
+    used_at    TIMESTAMPTZ,
+    created_at TIMESTAMPTZ NOT NULL DEFAULT NOW(),
+    UNIQUE (user_id, code_hash)
+);
+
+-- Token tahap kedua login: password sudah benar, menunggu kode TOTP
+CREATE TABLE IF NOT EXISTS login_challenges (
+    token_hash TEXT PRIMARY KEY,
+    user_id    BIGINT NOT NULL REFERENCES users(id) ON DELETE CASCADE,
+    attempts   INTEGER NOT NULL DEFAULT 0,
+    expires_at TIMESTAMPTZ NOT NULL,
+    created_at TIMESTAMPTZ NOT NULL DEFAULT NOW()
+);
+
+CREATE INDEX IF NOT EXISTS idx_login_challenges_expires_at ON login_challenges (expires_at);
+
+-- Kebijakan per role: 2FA wajib (default untuk admin)
+ALTER TABLE roles ADD COLUMN IF NOT EXISTS require_2fa BOOLEAN NOT NULL DEFAULT FALSE;
+UPDATE roles SET require_2fa = TRUE WHERE name = 'admin';
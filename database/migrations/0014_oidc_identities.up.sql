@@ -0,0 +1,24 @@
+-- Akun IdP kampus (OIDC) yang terhubung ke user lokal. (issuer, subject)
+-- adalah identitas tetap di IdP; email hanya dicatat untuk informasi.
+CREATE TABLE IF NOT EXISTS user_identities (
+    issuer        TEXT NOT NULL,
+    subject       TEXT NOT NULL,
+    user_id       BIGINT NOT NULL REFERENCES users(id) ON DELETE CASCADE,
+    email         VARCHAR(100),
+    created_at    TIMESTAMPTZ NOT NULL DEFAULT NOW(),
+    last_login_at TIMESTAMPTZ NOT NULL DEFAULT NOW(),
+    PRIMARY KEY (issuer, subject)
+);
+
+CREATE INDEX IF NOT EXISTS idx_user_identities_user_id ON user_identities (user_id);
+
+-- Login SSO yang sedang berjalan: state (hash) → PKCE verifier + nonce
+CREATE TABLE IF NOT EXISTS oidc_login_states (
+    state_hash    TEXT PRIMARY KEY,
+    code_verifier TEXT NOT NULL,
+    nonce         TEXT NOT NULL,
+    expires_at    TIMESTAMPTZ NOT NULL,
+    created_at    TIMESTAMPTZ NOT NULL DEFAULT NOW()
+);
+
+CREATE INDEX IF NOT EXISTS idx_oidc_login_states_expires_at ON oidc_login_states (expires_at);
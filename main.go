@@ -1,7 +1,9 @@
 package main
 
 import (
+	"context"
 	"log"
+	"os"
 
 	"uas/app/repository"
 	"uas/app/service"
@@ -9,6 +11,7 @@ import (
 	"uas/database"
 	"uas/middleware"
 	routes "uas/route"
+	"uas/utils"
 
 	"github.com/gofiber/fiber/v2"
 )
@@ -29,14 +32,38 @@ func main() {
 	defer db.Close()
 
 	// =========================
-	// CONNECT MONGO
+	// CLI SUBCOMMAND (opsional)
 	// =========================
-	mongoClient, err := database.ConnectMongo(cfg.MongoURI)
+	if len(os.Args) > 1 {
+		if err := runCommand(cfg, db, os.Args[1:]); err != nil {
+			log.Fatalf("%s: %v", os.Args[1], err)
+		}
+		return
+	}
+
+	// =========================
+	// MIGRASI SKEMA (opsional, MIGRATE_ON_START=true)
+	// =========================
+	if cfg.MigrateOnStart {
+		migrator, err := database.NewMigrator(db)
+		if err != nil {
+			log.Fatalf("migrate error: %v", err)
+		}
+		applied, err := migrator.Up(context.Background())
+		if err != nil {
+			log.Fatalf("migrate error: %v", err)
+		}
+		log.Printf("migrate: %d migration(s) applied", len(applied))
+	}
+
+	// =========================
+	// CONNECT MONGO (atau store memori untuk dev lokal)
+	// =========================
+	achievementDocs, closeDocs, err := openAchievementStore(cfg)
 	if err != nil {
 		log.Fatalf("mongo connect error: %v", err)
 	}
-
-	mongoDB := mongoClient.Database("uas")
+	defer closeDocs()
 
 	// =========================
 	// INIT REPOSITORIES
@@ -45,31 +72,135 @@ func main() {
 	studentRepo := repository.NewStudentRepository(db)
 	lecturerRepo := repository.NewLecturerRepository(db)
 	tokenRepo := repository.NewRefreshTokenRepository(db)
+	accessTokenRepo := repository.NewAccessTokenRepository(db)
+	passwordResetRepo := repository.NewPasswordResetRepository(db)
+	loginThrottleRepo := repository.NewLoginThrottleRepository(db)
+	auditRepo := repository.NewAuditRepository(db)
+	twoFactorRepo := repository.NewTwoFactorRepository(db)
+	identityRepo := repository.NewIdentityRepository(db)
 	achievementRepo := repository.NewAchievementRepository(db)
+	pointRepo := repository.NewPointRepository(db)
+	rubricRepo := repository.NewRubricRepository(db)
+	achievementTypeRepo := repository.NewAchievementTypeRepository(db)
+	syncRepo := repository.NewAchievementSyncRepository(db)
+	roleRepo := repository.NewRoleRepository(db)
 
-	mongoAchievementRepo := repository.NewMongoAchievementRepository(
-		mongoDB.Collection("achievements"),
-	)
+	// Kunci JWT (server tidak start jika kunci salah konfigurasi)
+	jwtKeys, err := loadJWTKeys(cfg)
+	if err != nil {
+		log.Fatalf("jwt keys error: %v", err)
+	}
+
+	mailer, err := utils.NewMailer(utils.MailerOptions{
+		Driver:       cfg.MailDriver,
+		From:         cfg.MailFrom,
+		Dir:          cfg.MailDir,
+		SMTPHost:     cfg.SMTPHost,
+		SMTPPort:     cfg.SMTPPort,
+		SMTPUsername: cfg.SMTPUsername,
+		SMTPPassword: cfg.SMTPPassword,
+		Dev:          cfg.IsDevelopment(),
+	})
+	if err != nil {
+		log.Fatalf("mailer error: %v", err)
+	}
+
+	passwordPolicy, err := utils.LoadPasswordPolicy(utils.PasswordPolicyOptions{
+		MinLength:     cfg.PasswordMinLength,
+		RequireUpper:  cfg.PasswordRequireUpper,
+		RequireLower:  cfg.PasswordRequireLower,
+		RequireDigit:  cfg.PasswordRequireDigit,
+		RequireSymbol: cfg.PasswordRequireSymbol,
+		BreachedFile:  cfg.PasswordBreachedFile,
+	})
+	if err != nil {
+		log.Fatalf("password policy error: %v", err)
+	}
 
 	// =========================
 	// INIT SERVICES
 	// =========================
 	userService := service.NewUserService(userRepo)
-	authService := service.NewAuthService(userRepo, tokenRepo)
-	achievementService := service.NewAchievementService(achievementRepo, mongoClient)
-	adminService := service.NewAdminService(userRepo, studentRepo, lecturerRepo)
+	loginGuard := service.NewLoginGuard(loginThrottleRepo, auditRepo, service.LoginGuardOptions{
+		MaxFailures:   cfg.LoginMaxFailures,
+		IPMaxFailures: cfg.LoginIPMaxFailures,
+		LockoutBase:   cfg.LoginLockoutBase,
+		LockoutMax:    cfg.LoginLockoutMax,
+		FailureWindow: cfg.LoginFailureWindow,
+
+		ResetMaxPerEmail: cfg.PasswordResetMaxPerEmail,
+		ResetMaxPerIP:    cfg.PasswordResetMaxPerIP,
+	})
+	authService := service.NewAuthService(
+		userRepo,
+		tokenRepo,
+		accessTokenRepo,
+		twoFactorRepo,
+		jwtKeys,
+		loginGuard,
+		cfg.TOTPIssuer,
+	)
+	oidcService := service.NewOIDCService(
+		authService,
+		newOIDCProvider(cfg),
+		identityRepo,
+		auditRepo,
+		service.OIDCOptions{
+			Claims: service.OIDCClaimMapping{
+				Email:        cfg.OIDCClaimEmail,
+				Username:     cfg.OIDCClaimUsername,
+				Name:         cfg.OIDCClaimName,
+				NIM:          cfg.OIDCClaimNIM,
+				NIP:          cfg.OIDCClaimNIP,
+				ProgramStudy: cfg.OIDCClaimProgramStudy,
+				Department:   cfg.OIDCClaimDepartment,
+			},
+			RequireVerifiedEmail: cfg.OIDCRequireVerifiedEmail,
+			Provisioning:         cfg.OIDCJITProvisioning,
+		},
+	)
+	achievementService := service.NewAchievementService(
+		achievementRepo,
+		achievementDocs,
+		syncRepo,
+		rubricRepo,
+		achievementTypeRepo,
+	)
+	adminService := service.NewAdminService(userRepo, studentRepo, lecturerRepo, passwordPolicy)
+	passwordService := service.NewPasswordService(
+		userRepo,
+		tokenRepo,
+		passwordResetRepo,
+		mailer,
+		loginGuard,
+		passwordPolicy,
+		cfg.PasswordResetURL,
+		cfg.PasswordResetTTL,
+	)
 	studentService := service.NewStudentService(studentRepo)
 	lecturerService := service.NewLecturerService(lecturerRepo)
+	pointService := service.NewPointService(pointRepo, studentRepo, lecturerRepo)
+	rubricService := service.NewRubricService(rubricRepo, achievementDocs)
+	achievementTypeService := service.NewAchievementTypeService(achievementTypeRepo)
+	reconcileService := service.NewReconcileService(achievementRepo, achievementDocs, syncRepo)
 
 	reportService := &service.ReportService{
 		AchievementRepo:      achievementRepo,
-		MongoAchievementRepo: mongoAchievementRepo,
+		MongoAchievementRepo: achievementDocs,
 	}
 
+	// Worker kompensasi dual-write Mongo/PostgreSQL
+	syncWorker := service.NewAchievementSyncWorker(syncRepo, achievementDocs)
+	go syncWorker.Run(context.Background())
+
+	// Cache role → permission untuk RequirePermission
+	permissionCache := middleware.NewPermissionCache(roleRepo, cfg.PermissionCacheTTL)
+	roleService := service.NewRoleService(roleRepo, permissionCache)
+
 	// =========================
 	// INIT APP
 	// =========================
-	app := fiber.New()
+	app := fiber.New(serverConfig(cfg))
 
 	app.Static("/docs", "./docs")
 
@@ -82,13 +213,80 @@ func main() {
 		authService,
 		achievementService,
 		adminService,
-		middleware.AuthRequired([]byte(cfg.JWTSecret)),
+		middleware.AuthRequired(jwtKeys, accessTokenRepo),
 		studentService,
 		lecturerService,
 		reportService,
+		pointService,
+		rubricService,
+		achievementTypeService,
+		reconcileService,
+		roleService,
+		passwordService,
+		oidcService,
+		permissionCache,
 	)
 
 	// START SERVER
 	// =========================
 	log.Fatal(app.Listen(":" + cfg.AppPort))
 }
+
+// Store dokumen achievement sesuai config. ACHIEVEMENT_STORE=memory dipakai
+// untuk dev lokal tanpa Mongo (data hilang saat proses berhenti).
+func openAchievementStore(cfg *config.Config) (repository.AchievementDocumentStore, func(), error) {
+	if cfg.AchievementStore == "memory" {
+		log.Println("WARNING: using in-memory achievement store, documents are not persisted")
+		return repository.NewMemoryAchievementStore(), func() {}, nil
+	}
+	client, err := database.ConnectMongo(cfg.MongoURI)
+	if err != nil {
+		return nil, nil, err
+	}
+	store := repository.NewMongoAchievementStore(client, cfg.MongoDB, cfg.MongoAchievementCollection)
+	return store, func() { client.Disconnect(context.Background()) }, nil
+}
+
+func loadJWTKeys(cfg *config.Config) (*utils.KeySet, error) {
+	return utils.LoadKeySet(utils.KeyOptions{
+		Issuer:         cfg.JWTIssuer,
+		Audience:       cfg.JWTAudience,
+		TTL:            cfg.AccessTokenTTL,
+		Algorithm:      cfg.JWTAlgorithm,
+		KeyID:          cfg.JWTKeyID,
+		Secret:         cfg.JWTSecret,
+		PrivateKeyFile: cfg.JWTPrivateKeyFile,
+		KeysFile:       cfg.JWTKeysFile,
+		Dev:            cfg.IsDevelopment(),
+	})
+}
+
+// SSO OIDC hanya aktif jika OIDC_ISSUER diisi; discovery IdP baru diambil
+// saat login SSO pertama
+func newOIDCProvider(cfg *config.Config) *utils.OIDCProvider {
+	if cfg.OIDCIssuer == "" {
+		return nil
+	}
+	return utils.NewOIDCProvider(utils.OIDCOptions{
+		Issuer:       cfg.OIDCIssuer,
+		ClientID:     cfg.OIDCClientID,
+		ClientSecret: cfg.OIDCClientSecret,
+		RedirectURL:  cfg.OIDCRedirectURL,
+		Scopes:       cfg.OIDCScopes,
+	})
+}
+
+// Di belakang reverse proxy c.IP() memakai PROXY_HEADER, tetapi hanya untuk
+// request dari TRUSTED_PROXIES; tanpa ini semua klien berbagi IP proxy
+// (satu penyerang bisa mengunci login per IP untuk semua orang)
+func serverConfig(cfg *config.Config) fiber.Config {
+	if len(cfg.TrustedProxies) == 0 {
+		return fiber.Config{}
+	}
+	return fiber.Config{
+		ProxyHeader:             cfg.ProxyHeader,
+		EnableTrustedProxyCheck: true,
+		TrustedProxies:          cfg.TrustedProxies,
+		EnableIPValidation:      true,
+	}
+}
@@ -1,28 +1,55 @@
 package middleware
 
 import (
+	"context"
+	"database/sql"
+	"errors"
 	"strings"
+
+	"uas/app/model"
 	"uas/utils"
 
 	"github.com/gofiber/fiber/v2"
 )
 
-func AuthRequired(secret []byte) fiber.Handler {
+// TokenStateSource: status pencabutan access token (denylist jti,
+// token_version user, sesi asal token)
+type TokenStateSource interface {
+	State(ctx context.Context, userID int64, jti, sessionID string) (*model.AccessTokenState, error)
+}
+
+// AuthRequired memverifikasi access token dengan KeySet (kid, algoritma, iss, aud),
+// lalu memastikan token belum dicabut: jti tidak di denylist, klaim ver sama
+// dengan token_version user, user masih ada dan aktif, sesi belum logout.
+func AuthRequired(keys *utils.KeySet, tokens TokenStateSource) fiber.Handler {
 	return func(c *fiber.Ctx) error {
 
 		auth := c.Get("Authorization")
 		if auth == "" {
-			return c.Status(401).JSON(fiber.Map{"error":"missing token"})
+			return c.Status(401).JSON(fiber.Map{"error": "missing token"})
 		}
 
 		parts := strings.SplitN(auth, " ", 2)
 		if len(parts) != 2 {
-			return c.Status(401).JSON(fiber.Map{"error":"bad auth header"})
+			return c.Status(401).JSON(fiber.Map{"error": "bad auth header"})
+		}
+
+		claims, err := keys.ParseToken(parts[1])
+		if err != nil || claims.ID == "" {
+			// token tanpa jti tidak bisa dicabut, anggap tidak valid
+			return c.Status(401).JSON(fiber.Map{"error": "invalid token"})
 		}
 
-		claims, err := utils.ParseToken(parts[1], secret)
+		state, err := tokens.State(c.Context(), claims.UserID, claims.ID, claims.SessionID)
+		if errors.Is(err, sql.ErrNoRows) {
+			return c.Status(401).JSON(fiber.Map{"error": "token revoked"})
+		}
 		if err != nil {
-			return c.Status(401).JSON(fiber.Map{"error":"invalid token"})
+			return c.Status(500).JSON(fiber.Map{"error": "failed_check_token"})
+		}
+		if state.Revoked || state.SessionRevoked || !state.IsActive ||
+			state.TokenVersion != claims.TokenVersion {
+			return c.Status(401).JSON(fiber.Map{"error": "token revoked"})
 		}
 
 		// SIMPAN INFORMASI JWT KE CONTEXT (WAJIB)
@@ -34,4 +61,3 @@ func AuthRequired(secret []byte) fiber.Handler {
 		return c.Next()
 	}
 }
-
@@ -0,0 +1,88 @@
+package middleware
+
+import (
+	"context"
+	"database/sql"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+	"time"
+
+	"uas/app/model"
+	"uas/utils"
+
+	"github.com/gofiber/fiber/v2"
+	"github.com/golang-jwt/jwt/v5"
+	"github.com/stretchr/testify/assert"
+)
+
+type fakeTokenState struct {
+	state *model.AccessTokenState
+	err   error
+}
+
+func (f *fakeTokenState) State(ctx context.Context, userID int64, jti, sessionID string) (*model.AccessTokenState, error) {
+	return f.state, f.err
+}
+
+func authTestKeys(t *testing.T) *utils.KeySet {
+	keys := utils.NewKeySet("uas", []string{"uas-api"}, 15*time.Minute)
+	key, err := utils.NewSigningKey("test", utils.AlgHS256, []byte("test-secret"), nil, nil)
+	assert.NoError(t, err)
+	assert.NoError(t, keys.Add(key, true))
+	return keys
+}
+
+func authStatus(t *testing.T, keys *utils.KeySet, source TokenStateSource, token string) int {
+	app := fiber.New()
+	app.Get("/me", AuthRequired(keys, source), func(c *fiber.Ctx) error {
+		return c.SendStatus(200)
+	})
+	req := httptest.NewRequest(http.MethodGet, "/me", nil)
+	req.Header.Set("Authorization", "Bearer "+token)
+	resp, err := app.Test(req)
+	assert.NoError(t, err)
+	return resp.StatusCode
+}
+
+func TestAuthRequired_RevocationChecks(t *testing.T) {
+	keys := authTestKeys(t)
+	token, err := keys.GenerateToken(utils.Claims{UserID: 7, Role: "mahasiswa", SessionID: "s-1", TokenVersion: 2})
+	assert.NoError(t, err)
+
+	cases := map[string]struct {
+		source *fakeTokenState
+		want   int
+	}{
+		"valid":           {&fakeTokenState{state: &model.AccessTokenState{TokenVersion: 2, IsActive: true}}, 200},
+		"jti di denylist": {&fakeTokenState{state: &model.AccessTokenState{TokenVersion: 2, IsActive: true, Revoked: true}}, 401},
+		"role berubah":    {&fakeTokenState{state: &model.AccessTokenState{TokenVersion: 3, IsActive: true}}, 401},
+		"akun nonaktif":   {&fakeTokenState{state: &model.AccessTokenState{TokenVersion: 3, IsActive: false}}, 401},
+		"sesi logout":     {&fakeTokenState{state: &model.AccessTokenState{TokenVersion: 2, IsActive: true, SessionRevoked: true}}, 401},
+		"user dihapus":    {&fakeTokenState{err: sql.ErrNoRows}, 401},
+		"database error":  {&fakeTokenState{err: sql.ErrConnDone}, 500},
+	}
+	for name, tc := range cases {
+		assert.Equal(t, tc.want, authStatus(t, keys, tc.source, token), name)
+	}
+}
+
+// Token tanpa jti tidak bisa dicabut sehingga ditolak
+func TestAuthRequired_RejectsTokenWithoutJTI(t *testing.T) {
+	keys := authTestKeys(t)
+	legacy := jwt.NewWithClaims(jwt.SigningMethodHS256, utils.Claims{
+		UserID: 7,
+		RegisteredClaims: jwt.RegisteredClaims{
+			Issuer:    "uas",
+			Audience:  jwt.ClaimStrings{"uas-api"},
+			IssuedAt:  jwt.NewNumericDate(time.Now()),
+			ExpiresAt: jwt.NewNumericDate(time.Now().Add(time.Hour)),
+		},
+	})
+	legacy.Header["kid"] = "test"
+	token, err := legacy.SignedString([]byte("test-secret"))
+	assert.NoError(t, err)
+
+	source := &fakeTokenState{state: &model.AccessTokenState{IsActive: true}}
+	assert.Equal(t, 401, authStatus(t, keys, source, token))
+}
@@ -0,0 +1,97 @@
+package middleware
+
+import (
+	"context"
+	"sync"
+	"time"
+
+	"uas/utils"
+
+	"github.com/gofiber/fiber/v2"
+)
+
+// PermissionSource: daftar permission milik sebuah role (role_permissions)
+type PermissionSource interface {
+	GetPermissionsByRole(ctx context.Context, role string) ([]string, error)
+}
+
+type permissionEntry struct {
+	perms     utils.PermissionSet
+	expiresAt time.Time
+}
+
+// PermissionCache menyimpan role → permission selama ttl supaya setiap
+// request tidak perlu query role_permissions. Panggil Invalidate setelah
+// role atau permission diubah.
+type PermissionCache struct {
+	source  PermissionSource
+	ttl     time.Duration
+	mu      sync.RWMutex
+	entries map[string]permissionEntry
+}
+
+func NewPermissionCache(source PermissionSource, ttl time.Duration) *PermissionCache {
+	return &PermissionCache{
+		source:  source,
+		ttl:     ttl,
+		entries: map[string]permissionEntry{},
+	}
+}
+
+func (p *PermissionCache) Permissions(ctx context.Context, role string) (utils.PermissionSet, error) {
+	p.mu.RLock()
+	entry, ok := p.entries[role]
+	p.mu.RUnlock()
+	if ok && time.Now().Before(entry.expiresAt) {
+		return entry.perms, nil
+	}
+
+	names, err := p.source.GetPermissionsByRole(ctx, role)
+	if err != nil {
+		return nil, err
+	}
+	perms := utils.PermissionSet{}
+	for _, name := range names {
+		perms[name] = true
+	}
+
+	p.mu.Lock()
+	p.entries[role] = permissionEntry{perms: perms, expiresAt: time.Now().Add(p.ttl)}
+	p.mu.Unlock()
+	return perms, nil
+}
+
+// Invalidate mengosongkan cache semua role
+func (p *PermissionCache) Invalidate() {
+	p.mu.Lock()
+	p.entries = map[string]permissionEntry{}
+	p.mu.Unlock()
+}
+
+// RequirePermission: lanjut hanya jika role user punya SEMUA permission.
+// Dipasang setelah AuthRequired.
+func (p *PermissionCache) RequirePermission(names ...string) fiber.Handler {
+	return func(c *fiber.Ctx) error {
+		claims, ok := c.Locals("claims").(*utils.Claims)
+		if !ok || claims == nil {
+			return c.Status(401).JSON(fiber.Map{"error": "unauthorized"})
+		}
+
+		perms, err := p.Permissions(c.Context(), claims.Role)
+		if err != nil {
+			return c.Status(500).JSON(fiber.Map{"error": "failed_load_permissions"})
+		}
+		for _, name := range names {
+			if !perms[name] {
+				return c.Status(403).JSON(fiber.Map{
+					"error":      "permission_denied",
+					"permission": name,
+				})
+			}
+		}
+
+		// handler membaca lewat utils.HasPermission
+		c.Locals(utils.PermissionsLocal, perms)
+		return c.Next()
+	}
+}
@@ -0,0 +1,72 @@
+package middleware
+
+import (
+	"context"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+	"time"
+
+	"uas/utils"
+
+	"github.com/gofiber/fiber/v2"
+	"github.com/stretchr/testify/assert"
+)
+
+type countingSource struct {
+	perms map[string][]string
+	calls int
+}
+
+func (s *countingSource) GetPermissionsByRole(ctx context.Context, role string) ([]string, error) {
+	s.calls++
+	return s.perms[role], nil
+}
+
+func TestPermissionCache_CachesUntilInvalidated(t *testing.T) {
+	source := &countingSource{perms: map[string][]string{"dosen wali": {"achievement:verify"}}}
+	cache := NewPermissionCache(source, time.Minute)
+
+	for i := 0; i < 3; i++ {
+		perms, err := cache.Permissions(context.Background(), "dosen wali")
+		assert.NoError(t, err)
+		assert.True(t, perms["achievement:verify"])
+	}
+	assert.Equal(t, 1, source.calls)
+
+	// grant baru terlihat setelah Invalidate
+	source.perms["dosen wali"] = append(source.perms["dosen wali"], "achievement:revoke")
+	cache.Invalidate()
+	perms, err := cache.Permissions(context.Background(), "dosen wali")
+	assert.NoError(t, err)
+	assert.True(t, perms["achievement:revoke"])
+	assert.Equal(t, 2, source.calls)
+}
+
+func TestRequirePermission(t *testing.T) {
+	source := &countingSource{perms: map[string][]string{"dosen wali": {"achievement:verify"}}}
+	cache := NewPermissionCache(source, time.Minute)
+
+	app := fiber.New()
+	app.Use(func(c *fiber.Ctx) error {
+		if role := c.Get("X-Role"); role != "" {
+			c.Locals("claims", &utils.Claims{UserID: 1, Role: role})
+		}
+		return c.Next()
+	})
+	app.Post("/verify", cache.RequirePermission("achievement:verify"), func(c *fiber.Ctx) error {
+		if !utils.HasPermission(c, "achievement:verify") {
+			return c.SendStatus(500)
+		}
+		return c.SendStatus(200)
+	})
+
+	cases := map[string]int{"dosen wali": 200, "mahasiswa": 403, "": 401}
+	for role, want := range cases {
+		req := httptest.NewRequest(http.MethodPost, "/verify", nil)
+		req.Header.Set("X-Role", role)
+		resp, err := app.Test(req)
+		assert.NoError(t, err)
+		assert.Equal(t, want, resp.StatusCode, "role %q", role)
+	}
+}
@@ -1,6 +1,7 @@
 package routes
 
 import (
+	"uas/app/model"
 	"uas/app/service"
 	"uas/middleware"
 
@@ -18,13 +19,32 @@ func SetupRoutes(
 	studentService *service.StudentService,
 	lecturerService *service.LecturerService,
 	reportService *service.ReportService,
+	pointService *service.PointService,
+	rubricService *service.RubricService,
+	achievementTypeService *service.AchievementTypeService,
+	reconcileService *service.ReconcileService,
+	roleService *service.RoleService,
+	passwordService *service.PasswordService,
+	oidcService *service.OIDCService,
+	perms *middleware.PermissionCache,
 ) {
+	can := perms.RequirePermission
+
+	// PUBLIC: kunci publik JWT untuk layanan kampus lain
+	app.Get("/.well-known/jwks.json", authService.JWKSHandler)
 
 	// PUBLIC AUTH (NO TOKEN)
 	auth := app.Group("/api/v1/auth")
 
 	auth.Post("/login", authService.LoginHandler)
 	auth.Post("/refresh", authService.RefreshHandler)
+	auth.Post("/password/forgot", passwordService.ForgotPasswordHandler)
+	auth.Post("/password/reset", passwordService.ResetPasswordHandler)
+	auth.Post("/2fa/verify", authService.VerifyTwoFactorHandler)
+	auth.Post("/2fa/setup", authService.SetupTwoFactorHandler)
+	auth.Get("/oidc/login", oidcService.LoginHandler)
+	auth.Get("/oidc/callback", oidcService.CallbackHandler)
+	auth.Post("/oidc/callback", oidcService.CallbackHandler)
 
 	// PROTECTED ROUTES (JWT)
 	api := app.Group("/api/v1", authMiddleware)
@@ -32,47 +52,97 @@ func SetupRoutes(
 	// AUTH PROTECTED
 	api.Get("/auth/profile", authService.ProfileHandler)
 	api.Post("/auth/logout", authService.LogoutHandler)
+	api.Post("/auth/password", passwordService.ChangePasswordHandler)
+	api.Get("/auth/sessions", authService.ListSessionsHandler)
+	api.Delete("/auth/sessions", authService.RevokeAllSessionsHandler)
+	api.Delete("/auth/sessions/:id", authService.RevokeSessionHandler)
+	api.Get("/auth/2fa", authService.TwoFactorStatusHandler)
+	api.Post("/auth/2fa/enroll", authService.EnrollTwoFactorHandler)
+	api.Post("/auth/2fa/confirm", authService.ConfirmTwoFactorHandler)
+	api.Post("/auth/2fa/recovery-codes", authService.RegenerateRecoveryCodesHandler)
+	api.Delete("/auth/2fa", authService.DisableTwoFactorHandler)
 
-	// ADMIN PROTECTED
-	admin := api.Group("/admin", middleware.AdminOnly)
+	// ADMIN (setiap route dijaga permission, bukan nama role)
+	admin := api.Group("/admin")
 
 	// ADMIN: USER CRUD
-	admin.Post("/users", adminService.CreateUser)
-	admin.Put("/users/:id", adminService.UpdateUser)
-	admin.Delete("/users/:id", adminService.DeleteUser)
-	admin.Get("/users", adminService.GetAllUsers)
-	admin.Get("/users/:id", adminService.GetUserByID)
-	admin.Put("/users/:id/role", adminService.UpdateUserRole)
+	admin.Post("/users", can(model.PermUserManage), adminService.CreateUser)
+	admin.Put("/users/:id", can(model.PermUserManage), adminService.UpdateUser)
+	admin.Delete("/users/:id", can(model.PermUserManage), adminService.DeleteUser)
+	admin.Get("/users", can(model.PermUserManage), adminService.GetAllUsers)
+	admin.Get("/users/:id", can(model.PermUserManage), adminService.GetUserByID)
+	admin.Put("/users/:id/role", can(model.PermUserManage), adminService.UpdateUserRole)
+	admin.Put("/users/:id/status", can(model.PermUserManage), adminService.UpdateUserStatus)
+	admin.Delete("/users/:id/sessions", can(model.PermUserManage), authService.AdminRevokeUserSessionsHandler)
+	admin.Delete("/users/:id/lockout", can(model.PermUserManage), authService.AdminUnlockUserHandler)
+	admin.Post("/users/:id/password-reset", can(model.PermUserManage), passwordService.AdminResetPasswordHandler)
+
+	// ADMIN: ROLE & PERMISSION
+	admin.Get("/roles", can(model.PermRoleManage), roleService.ListRoles)
+	admin.Post("/roles", can(model.PermRoleManage), roleService.CreateRole)
+	admin.Get("/roles/:id", can(model.PermRoleManage), roleService.GetRole)
+	admin.Put("/roles/:id", can(model.PermRoleManage), roleService.UpdateRole)
+	admin.Delete("/roles/:id", can(model.PermRoleManage), roleService.DeleteRole)
+	admin.Put("/roles/:id/permissions", can(model.PermRoleManage), roleService.SetRolePermissions)
+	admin.Put("/roles/:id/two-factor", can(model.PermRoleManage), roleService.SetRoleTwoFactor)
+	admin.Get("/permissions", can(model.PermRoleManage), roleService.ListPermissions)
+	admin.Post("/permissions", can(model.PermRoleManage), roleService.CreatePermission)
+	admin.Put("/permissions/:id", can(model.PermRoleManage), roleService.UpdatePermission)
+	admin.Delete("/permissions/:id", can(model.PermRoleManage), roleService.DeletePermission)
 
 	// ADMIN: STUDENT
-	admin.Get("/students", studentService.GetAll)
-	admin.Get("/students/:id", studentService.GetByID)
-	admin.Put("/students/:id/advisor", studentService.AssignAdvisor)
-	admin.Get("/students/:id/achievements", studentService.GetAchievements)
+	admin.Get("/students", can(model.PermStudentRead), studentService.GetAll)
+	admin.Get("/students/:id", can(model.PermStudentRead), studentService.GetByID)
+	admin.Put("/students/:id/advisor", can(model.PermStudentManage), studentService.AssignAdvisor)
+	admin.Get("/students/:id/achievements", can(model.PermStudentRead), studentService.GetAchievements)
+
+	// ADMIN: POINT LEDGER
+	admin.Post("/points/recompute", can(model.PermPointRecompute), pointService.RecomputeBalances)
+
+	// ADMIN: POINTS RUBRIC
+	admin.Get("/rubrics", can(model.PermRubricManage), rubricService.List)
+	admin.Post("/rubrics", can(model.PermRubricManage), rubricService.Create)
+	admin.Get("/rubrics/:id", can(model.PermRubricManage), rubricService.Get)
+	admin.Put("/rubrics/:id", can(model.PermRubricManage), rubricService.Update)
+	admin.Delete("/rubrics/:id", can(model.PermRubricManage), rubricService.Delete)
+
+	// ADMIN: ACHIEVEMENT TYPE CATALOG
+	admin.Post("/achievement-types", can(model.PermAchievementTypeManage), achievementTypeService.Create)
+	admin.Put("/achievement-types/:key", can(model.PermAchievementTypeManage), achievementTypeService.Update)
+	admin.Delete("/achievement-types/:key", can(model.PermAchievementTypeManage), achievementTypeService.Delete)
 
 	// ADMIN — VIEW ALL ACHIEVEMENTS
-	admin.Get("/achievements", achievementService.AdminListAchievements)
+	admin.Get("/achievements", can(model.PermAchievementReadAll), achievementService.AdminListAchievements)
+
+	// ADMIN — RECONCILE MONGO / POSTGRES
+	admin.Post("/achievements/reconcile", can(model.PermAchievementReconcile), reconcileService.Reconcile)
 
 	// ADMIN: LECTURER
-	admin.Get("/lecturers", lecturerService.GetAll)
-	api.Get("/lecturers/:id/advisees", lecturerService.GetAdvisees)
+	admin.Get("/lecturers", can(model.PermLecturerRead), lecturerService.GetAll)
+	api.Get("/lecturers/:id/advisees", can(model.PermLecturerAdvisees), lecturerService.GetAdvisees)
 
 	// ADMIN: REPORTS statistics
-	admin.Get("/reports/statistics", reportService.GetStatistics)
+	admin.Get("/reports/statistics", can(model.PermReportStatistics), reportService.GetStatistics)
+
+	// ACHIEVEMENT TYPES (katalog untuk client)
+	api.Get("/achievement-types", can(model.PermAchievementTypeRead), achievementTypeService.List)
 
 	// ACHIEVEMENTS
-	api.Post("/achievements", achievementService.CreateHandler)
-	api.Post("/achievements/:id/submit", achievementService.Submit)
-	api.Delete("/achievements/:id", achievementService.DeleteHandler)
-	api.Get("/achievements/me", achievementService.GetMyAchievements)
-	api.Get("/achievements/supervised", achievementService.GetSupervisedAchievements)
-	api.Post("/achievements/:id/verify", achievementService.Verify)
-	api.Post("/achievements/:id/reject", achievementService.Reject)
-	api.Get("/achievements/:id", achievementService.GetDetail)
-	api.Put("/achievements/:id", achievementService.UpdateDraft)
-	api.Get("/achievements/:id/history", achievementService.GetHistory)
-	api.Post("/achievements/:id/attachments", achievementService.UploadAttachment)
-	api.Get("/reports/student/:id", reportService.GetStudentReport)
+	api.Post("/achievements", can(model.PermAchievementCreate), achievementService.CreateHandler)
+	api.Post("/achievements/:id/submit", can(model.PermAchievementSubmit), achievementService.Submit)
+	api.Delete("/achievements/:id", can(model.PermAchievementDelete), achievementService.DeleteHandler)
+	api.Get("/achievements/me", can(model.PermAchievementRead), achievementService.GetMyAchievements)
+	api.Get("/achievements/supervised", can(model.PermAchievementSupervise), achievementService.GetSupervisedAchievements)
+	api.Post("/achievements/:id/verify", can(model.PermAchievementVerify), achievementService.Verify)
+	api.Post("/achievements/:id/reject", can(model.PermAchievementVerify), achievementService.Reject)
+	api.Post("/achievements/:id/revise", can(model.PermAchievementUpdate), achievementService.Revise)
+	api.Post("/achievements/:id/revoke", can(model.PermAchievementRevoke), achievementService.Revoke)
+	api.Get("/achievements/:id", can(model.PermAchievementRead), achievementService.GetDetail)
+	api.Put("/achievements/:id", can(model.PermAchievementUpdate), achievementService.UpdateDraft)
+	api.Get("/achievements/:id/history", can(model.PermAchievementRead), achievementService.GetHistory)
+	api.Post("/achievements/:id/attachments", can(model.PermAchievementUpdate), achievementService.UploadAttachment)
+	api.Get("/reports/student/:id", can(model.PermReportStudent), reportService.GetStudentReport)
+	api.Get("/students/:id/points", can(model.PermPointRead), pointService.GetStudentPoints)
 
 	// SWAGGER
 	app.Get("/swagger/*", swagger.New(swagger.Config{
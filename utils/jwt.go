@@ -1,35 +1,160 @@
 package utils
 
 import (
+	"crypto"
+	"errors"
 	"time"
+
 	"github.com/golang-jwt/jwt/v5"
+	"github.com/google/uuid"
 )
 
 type Claims struct {
 	UserID   int64  `json:"id"`
 	Username string `json:"username"`
 	Role     string `json:"role"`
+	// SessionID = auth_sessions.id (family refresh token) asal token ini
+	SessionID string `json:"sid,omitempty"`
+	// TokenVersion = users.token_version saat token terbit; lebih kecil dari
+	// versi sekarang berarti token sudah dicabut
+	TokenVersion int64 `json:"ver"`
 	jwt.RegisteredClaims
 }
 
-func GenerateToken(id int64, username string, role string, secret []byte) (string, error) {
-	claims := jwt.MapClaims{
-		"id":       id,
-		"username": username,
-		"role":     role,
-		"exp":      time.Now().Add(time.Hour * 24).Unix(),
+var (
+	ErrUnknownKeyID      = errors.New("unknown signing key id")
+	ErrAlgorithmMismatch = errors.New("token algorithm does not match key")
+	ErrNoSigningKey      = errors.New("no active signing key")
+)
+
+// SigningKey adalah satu kunci JWT yang dikenali lewat header kid.
+// Kunci tanpa private key hanya dipakai verifikasi (kunci lama saat rotasi).
+type SigningKey struct {
+	ID        string
+	Algorithm string
+	sign      interface{}
+	verify    interface{}
+}
+
+func (k *SigningKey) CanSign() bool {
+	return k.sign != nil
+}
+
+// KeySet menandatangani token dengan kunci aktif dan memverifikasi token
+// dengan semua kunci yang terdaftar. Algoritma dipatok per kid, iss dan aud
+// selalu dicek.
+type KeySet struct {
+	Issuer   string
+	Audience []string
+	TTL      time.Duration
+
+	active *SigningKey
+	keys   map[string]*SigningKey
+	order  []string
+}
+
+func NewKeySet(issuer string, audience []string, ttl time.Duration) *KeySet {
+	return &KeySet{
+		Issuer:   issuer,
+		Audience: audience,
+		TTL:      ttl,
+		keys:     map[string]*SigningKey{},
+	}
+}
+
+// Add mendaftarkan kunci; active=true menjadikannya kunci penandatangan
+func (k *KeySet) Add(key *SigningKey, active bool) error {
+	if key.ID == "" {
+		return errors.New("signing key requires a kid")
+	}
+	if _, exists := k.keys[key.ID]; exists {
+		return errors.New("duplicate signing key id " + key.ID)
+	}
+	if active {
+		if !key.CanSign() {
+			return errors.New("active signing key " + key.ID + " has no private key")
+		}
+		k.active = key
+	}
+	k.keys[key.ID] = key
+	k.order = append(k.order, key.ID)
+	return nil
+}
+
+func (k *KeySet) ActiveKeyID() string {
+	if k.active == nil {
+		return ""
+	}
+	return k.active.ID
+}
+
+// GenerateToken menandatangani claims milik aplikasi (id, username, role, sid, ver);
+// iss, aud, iat dan exp selalu diisi dari KeySet, jti selalu baru (untuk denylist).
+func (k *KeySet) GenerateToken(claims Claims) (string, error) {
+	if k.active == nil {
+		return "", ErrNoSigningKey
+	}
+	now := time.Now()
+	claims.RegisteredClaims = jwt.RegisteredClaims{
+		ID:        uuid.New().String(),
+		Issuer:    k.Issuer,
+		Audience:  k.Audience,
+		IssuedAt:  jwt.NewNumericDate(now),
+		ExpiresAt: jwt.NewNumericDate(now.Add(k.TTL)),
 	}
-	token := jwt.NewWithClaims(jwt.SigningMethodHS256, claims)
-	return token.SignedString(secret)
+	token := jwt.NewWithClaims(jwt.GetSigningMethod(k.active.Algorithm), claims)
+	token.Header["kid"] = k.active.ID
+	return token.SignedString(k.active.sign)
 }
 
-func ParseToken(tokenString string, secret []byte) (*Claims, error) {
+func (k *KeySet) ParseToken(tokenString string) (*Claims, error) {
+	opts := []jwt.ParserOption{
+		jwt.WithValidMethods(k.algorithms()),
+		jwt.WithIssuer(k.Issuer),
+		jwt.WithExpirationRequired(),
+		jwt.WithIssuedAt(),
+	}
+	if len(k.Audience) > 0 {
+		opts = append(opts, jwt.WithAudience(k.Audience...))
+	}
+
 	token, err := jwt.ParseWithClaims(tokenString, &Claims{}, func(t *jwt.Token) (interface{}, error) {
-		return secret, nil
-	})
-	if err != nil { return nil, err }
+		kid, _ := t.Header["kid"].(string)
+		key, ok := k.keys[kid]
+		if !ok {
+			return nil, ErrUnknownKeyID
+		}
+		// Patok algoritma ke kid: token HS256 tidak bisa memakai public key RSA sebagai secret
+		if t.Method.Alg() != key.Algorithm {
+			return nil, ErrAlgorithmMismatch
+		}
+		return key.verify, nil
+	}, opts...)
+	if err != nil {
+		return nil, err
+	}
 	if claims, ok := token.Claims.(*Claims); ok && token.Valid {
 		return claims, nil
 	}
 	return nil, jwt.ErrTokenInvalidClaims
 }
+
+func (k *KeySet) algorithms() []string {
+	seen := map[string]bool{}
+	algs := []string{}
+	for _, id := range k.order {
+		alg := k.keys[id].Algorithm
+		if !seen[alg] {
+			seen[alg] = true
+			algs = append(algs, alg)
+		}
+	}
+	return algs
+}
+
+func publicOf(private interface{}) interface{} {
+	if signer, ok := private.(crypto.Signer); ok {
+		return signer.Public()
+	}
+	return private
+}
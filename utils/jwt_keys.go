@@ -0,0 +1,298 @@
+package utils
+
+import (
+	"crypto/ecdsa"
+	"crypto/ed25519"
+	"crypto/elliptic"
+	"crypto/rsa"
+	"encoding/base64"
+	"encoding/json"
+	"fmt"
+	"math/big"
+	"os"
+	"path/filepath"
+	"time"
+
+	"github.com/golang-jwt/jwt/v5"
+)
+
+const (
+	AlgHS256 = "HS256"
+	AlgRS256 = "RS256"
+	AlgEdDSA = "EdDSA"
+)
+
+// KeyOptions: sumber kunci JWT dari konfigurasi.
+// Jika KeysFile diisi, Algorithm/KeyID/Secret/PrivateKeyFile diabaikan.
+type KeyOptions struct {
+	Issuer         string
+	Audience       []string
+	TTL            time.Duration
+	Algorithm      string
+	KeyID          string
+	Secret         string
+	PrivateKeyFile string
+	KeysFile       string
+
+	// Dev (APP_ENV=development): secret HS256 lemah / bawaan tetap diterima
+	Dev bool
+}
+
+// Secret HS256 minimal 32 byte di luar dev; "changeme" adalah default
+// JWT_SECRET yang publik, token yang ditandatanganinya bisa dipalsukan siapa saja
+const (
+	minHMACSecretLen  = 32
+	defaultHMACSecret = "changeme"
+)
+
+// Format JWT_KEYS_FILE (path relatif terhadap folder file ini):
+//
+//	{
+//	  "active": "2026-10",
+//	  "keys": [
+//	    {"kid": "2026-10", "alg": "EdDSA", "private_key_file": "2026-10.pem"},
+//	    {"kid": "2026-04", "alg": "RS256", "public_key_file": "2026-04.pub.pem"}
+//	  ]
+//	}
+type keysFile struct {
+	Active string         `json:"active"`
+	Keys   []keyFileEntry `json:"keys"`
+}
+
+type keyFileEntry struct {
+	KID            string `json:"kid"`
+	Alg            string `json:"alg"`
+	Secret         string `json:"secret"`
+	SecretFile     string `json:"secret_file"`
+	PrivateKeyFile string `json:"private_key_file"`
+	PublicKeyFile  string `json:"public_key_file"`
+}
+
+func LoadKeySet(opts KeyOptions) (*KeySet, error) {
+	ks := NewKeySet(opts.Issuer, opts.Audience, opts.TTL)
+
+	if opts.KeysFile == "" {
+		var pem []byte
+		if opts.PrivateKeyFile != "" {
+			b, err := os.ReadFile(opts.PrivateKeyFile)
+			if err != nil {
+				return nil, fmt.Errorf("read jwt private key: %w", err)
+			}
+			pem = b
+		}
+		if err := checkHMACSecret(opts.KeyID, opts.Algorithm, []byte(opts.Secret), opts.Dev); err != nil {
+			return nil, err
+		}
+		key, err := NewSigningKey(opts.KeyID, opts.Algorithm, []byte(opts.Secret), pem, nil)
+		if err != nil {
+			return nil, err
+		}
+		if err := ks.Add(key, true); err != nil {
+			return nil, err
+		}
+		return ks, nil
+	}
+
+	raw, err := os.ReadFile(opts.KeysFile)
+	if err != nil {
+		return nil, fmt.Errorf("read jwt keys file: %w", err)
+	}
+	var file keysFile
+	if err := json.Unmarshal(raw, &file); err != nil {
+		return nil, fmt.Errorf("parse jwt keys file: %w", err)
+	}
+	dir := filepath.Dir(opts.KeysFile)
+	read := func(name string) ([]byte, error) {
+		if name == "" {
+			return nil, nil
+		}
+		if !filepath.IsAbs(name) {
+			name = filepath.Join(dir, name)
+		}
+		return os.ReadFile(name)
+	}
+
+	for _, e := range file.Keys {
+		secret := []byte(e.Secret)
+		if e.SecretFile != "" {
+			if secret, err = read(e.SecretFile); err != nil {
+				return nil, fmt.Errorf("jwt key %s: %w", e.KID, err)
+			}
+		}
+		private, err := read(e.PrivateKeyFile)
+		if err != nil {
+			return nil, fmt.Errorf("jwt key %s: %w", e.KID, err)
+		}
+		public, err := read(e.PublicKeyFile)
+		if err != nil {
+			return nil, fmt.Errorf("jwt key %s: %w", e.KID, err)
+		}
+		if err := checkHMACSecret(e.KID, e.Alg, secret, opts.Dev); err != nil {
+			return nil, err
+		}
+		key, err := NewSigningKey(e.KID, e.Alg, secret, private, public)
+		if err != nil {
+			return nil, err
+		}
+		if err := ks.Add(key, e.KID == file.Active); err != nil {
+			return nil, err
+		}
+	}
+	if ks.active == nil {
+		return nil, fmt.Errorf("jwt keys file: active key %q not found", file.Active)
+	}
+	return ks, nil
+}
+
+// checkHMACSecret menolak secret HS256 bawaan / terlalu pendek di luar dev
+func checkHMACSecret(kid, alg string, secret []byte, dev bool) error {
+	if alg != AlgHS256 || dev || len(secret) == 0 {
+		return nil
+	}
+	if string(secret) == defaultHMACSecret {
+		return fmt.Errorf("jwt key %s: JWT_SECRET is the default %q, set a random secret", kid, defaultHMACSecret)
+	}
+	if len(secret) < minHMACSecretLen {
+		return fmt.Errorf("jwt key %s: HS256 secret must be at least %d bytes", kid, minHMACSecretLen)
+	}
+	return nil
+}
+
+// NewSigningKey membuat kunci dari secret (HS256) atau PEM (RS256 / EdDSA).
+// Untuk kunci asimetris cukup salah satu: private PEM (sign + verify) atau
+// public PEM (verify saja).
+func NewSigningKey(kid, alg string, secret, privatePEM, publicPEM []byte) (*SigningKey, error) {
+	key := &SigningKey{ID: kid, Algorithm: alg}
+	switch alg {
+	case AlgHS256:
+		if len(secret) == 0 {
+			return nil, fmt.Errorf("jwt key %s: HS256 requires a secret", kid)
+		}
+		key.sign, key.verify = secret, secret
+	case AlgRS256:
+		if len(privatePEM) > 0 {
+			private, err := jwt.ParseRSAPrivateKeyFromPEM(privatePEM)
+			if err != nil {
+				return nil, fmt.Errorf("jwt key %s: %w", kid, err)
+			}
+			key.sign, key.verify = private, &private.PublicKey
+		} else if len(publicPEM) > 0 {
+			public, err := jwt.ParseRSAPublicKeyFromPEM(publicPEM)
+			if err != nil {
+				return nil, fmt.Errorf("jwt key %s: %w", kid, err)
+			}
+			key.verify = public
+		}
+	case AlgEdDSA:
+		if len(privatePEM) > 0 {
+			private, err := jwt.ParseEdPrivateKeyFromPEM(privatePEM)
+			if err != nil {
+				return nil, fmt.Errorf("jwt key %s: %w", kid, err)
+			}
+			key.sign, key.verify = private, publicOf(private)
+		} else if len(publicPEM) > 0 {
+			public, err := jwt.ParseEdPublicKeyFromPEM(publicPEM)
+			if err != nil {
+				return nil, fmt.Errorf("jwt key %s: %w", kid, err)
+			}
+			key.verify = public
+		}
+	default:
+		return nil, fmt.Errorf("jwt key %s: unsupported algorithm %q", kid, alg)
+	}
+	if key.verify == nil {
+		return nil, fmt.Errorf("jwt key %s: %s requires a private or public key", kid, alg)
+	}
+	return key, nil
+}
+
+// JWK (RFC 7517) untuk kunci publik
+type JWK struct {
+	Kty string `json:"kty"`
+	Use string `json:"use"`
+	Alg string `json:"alg"`
+	Kid string `json:"kid"`
+	N   string `json:"n,omitempty"`
+	E   string `json:"e,omitempty"`
+	Crv string `json:"crv,omitempty"`
+	X   string `json:"x,omitempty"`
+	Y   string `json:"y,omitempty"`
+}
+
+// PublicKey mengubah JWK menjadi kunci untuk verifikasi (RSA, EC P-256 atau Ed25519)
+func (j JWK) PublicKey() (interface{}, error) {
+	b64 := base64.RawURLEncoding
+	switch j.Kty {
+	case "RSA":
+		n, err := b64.DecodeString(j.N)
+		if err != nil {
+			return nil, fmt.Errorf("jwk %s: n: %w", j.Kid, err)
+		}
+		e, err := b64.DecodeString(j.E)
+		if err != nil {
+			return nil, fmt.Errorf("jwk %s: e: %w", j.Kid, err)
+		}
+		return &rsa.PublicKey{N: new(big.Int).SetBytes(n), E: int(new(big.Int).SetBytes(e).Int64())}, nil
+	case "EC":
+		if j.Crv != "P-256" {
+			return nil, fmt.Errorf("jwk %s: unsupported curve %q", j.Kid, j.Crv)
+		}
+		x, err := b64.DecodeString(j.X)
+		if err != nil {
+			return nil, fmt.Errorf("jwk %s: x: %w", j.Kid, err)
+		}
+		y, err := b64.DecodeString(j.Y)
+		if err != nil {
+			return nil, fmt.Errorf("jwk %s: y: %w", j.Kid, err)
+		}
+		// titik tidak terkompresi: 0x04 || X || Y
+		point := append([]byte{4}, append(leftPad(x, 32), leftPad(y, 32)...)...)
+		return ecdsa.ParseUncompressedPublicKey(elliptic.P256(), point)
+	case "OKP":
+		if j.Crv != "Ed25519" {
+			return nil, fmt.Errorf("jwk %s: unsupported curve %q", j.Kid, j.Crv)
+		}
+		x, err := b64.DecodeString(j.X)
+		if err != nil || len(x) != ed25519.PublicKeySize {
+			return nil, fmt.Errorf("jwk %s: invalid ed25519 key", j.Kid)
+		}
+		return ed25519.PublicKey(x), nil
+	default:
+		return nil, fmt.Errorf("jwk %s: unsupported key type %q", j.Kid, j.Kty)
+	}
+}
+
+func leftPad(b []byte, size int) []byte {
+	if len(b) >= size {
+		return b
+	}
+	return append(make([]byte, size-len(b)), b...)
+}
+
+type JWKS struct {
+	Keys []JWK `json:"keys"`
+}
+
+// JWKS berisi semua kunci publik (aktif dan lama); secret HS256 tidak pernah dipublikasi
+func (k *KeySet) JWKS() JWKS {
+	out := JWKS{Keys: []JWK{}}
+	b64 := base64.RawURLEncoding
+	for _, id := range k.order {
+		key := k.keys[id]
+		switch pub := key.verify.(type) {
+		case *rsa.PublicKey:
+			out.Keys = append(out.Keys, JWK{
+				Kty: "RSA", Use: "sig", Alg: key.Algorithm, Kid: key.ID,
+				N: b64.EncodeToString(pub.N.Bytes()),
+				E: b64.EncodeToString(big.NewInt(int64(pub.E)).Bytes()),
+			})
+		case ed25519.PublicKey:
+			out.Keys = append(out.Keys, JWK{
+				Kty: "OKP", Use: "sig", Alg: key.Algorithm, Kid: key.ID,
+				Crv: "Ed25519",
+				X:   b64.EncodeToString(pub),
+			})
+		}
+	}
+	return out
+}
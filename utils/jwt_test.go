@@ -0,0 +1,216 @@
+package utils
+
+import (
+	"crypto/ed25519"
+	"crypto/rand"
+	"crypto/rsa"
+	"crypto/x509"
+	"encoding/json"
+	"encoding/pem"
+	"os"
+	"path/filepath"
+	"testing"
+	"time"
+
+	"github.com/golang-jwt/jwt/v5"
+	"github.com/stretchr/testify/assert"
+)
+
+func rsaPEM(t *testing.T) (private, public []byte) {
+	key, err := rsa.GenerateKey(rand.Reader, 2048)
+	assert.NoError(t, err)
+	pub, err := x509.MarshalPKIXPublicKey(&key.PublicKey)
+	assert.NoError(t, err)
+	return pem.EncodeToMemory(&pem.Block{Type: "RSA PRIVATE KEY", Bytes: x509.MarshalPKCS1PrivateKey(key)}),
+		pem.EncodeToMemory(&pem.Block{Type: "PUBLIC KEY", Bytes: pub})
+}
+
+func edPEM(t *testing.T) []byte {
+	_, key, err := ed25519.GenerateKey(rand.Reader)
+	assert.NoError(t, err)
+	der, err := x509.MarshalPKCS8PrivateKey(key)
+	assert.NoError(t, err)
+	return pem.EncodeToMemory(&pem.Block{Type: "PRIVATE KEY", Bytes: der})
+}
+
+func TestKeySet_SignAndVerifyEachAlgorithm(t *testing.T) {
+	rsaPrivate, _ := rsaPEM(t)
+	cases := map[string]func() (*SigningKey, error){
+		AlgHS256: func() (*SigningKey, error) { return NewSigningKey("hs", AlgHS256, []byte("secret"), nil, nil) },
+		AlgRS256: func() (*SigningKey, error) { return NewSigningKey("rs", AlgRS256, nil, rsaPrivate, nil) },
+		AlgEdDSA: func() (*SigningKey, error) { return NewSigningKey("ed", AlgEdDSA, nil, edPEM(t), nil) },
+	}
+	for alg, build := range cases {
+		key, err := build()
+		assert.NoError(t, err, alg)
+		ks := NewKeySet("uas", []string{"uas-api"}, time.Hour)
+		assert.NoError(t, ks.Add(key, true))
+
+		token, err := ks.GenerateToken(Claims{UserID: 7, Username: "budi", Role: "mahasiswa"})
+		assert.NoError(t, err, alg)
+
+		parsed, _, err := jwt.NewParser().ParseUnverified(token, &Claims{})
+		assert.NoError(t, err)
+		assert.Equal(t, alg, parsed.Method.Alg())
+		assert.Equal(t, key.ID, parsed.Header["kid"])
+
+		claims, err := ks.ParseToken(token)
+		assert.NoError(t, err, alg)
+		assert.Equal(t, int64(7), claims.UserID)
+		assert.Equal(t, "mahasiswa", claims.Role)
+
+		// setiap token punya jti sendiri
+		again, err := ks.GenerateToken(Claims{UserID: 7, Username: "budi", Role: "mahasiswa"})
+		assert.NoError(t, err)
+		other, err := ks.ParseToken(again)
+		assert.NoError(t, err)
+		assert.NotEmpty(t, claims.ID)
+		assert.NotEqual(t, claims.ID, other.ID)
+	}
+}
+
+// Kunci lama tetap memverifikasi token yang sudah beredar setelah rotasi
+func TestKeySet_Rotation(t *testing.T) {
+	oldPrivate, oldPublic := rsaPEM(t)
+	oldKey, err := NewSigningKey("2026-04", AlgRS256, nil, oldPrivate, nil)
+	assert.NoError(t, err)
+	before := NewKeySet("uas", []string{"uas-api"}, time.Hour)
+	assert.NoError(t, before.Add(oldKey, true))
+	oldToken, err := before.GenerateToken(Claims{UserID: 1, Username: "admin", Role: "admin"})
+	assert.NoError(t, err)
+
+	newKey, err := NewSigningKey("2026-10", AlgEdDSA, nil, edPEM(t), nil)
+	assert.NoError(t, err)
+	verifyOnly, err := NewSigningKey("2026-04", AlgRS256, nil, nil, oldPublic)
+	assert.NoError(t, err)
+	assert.False(t, verifyOnly.CanSign())
+
+	after := NewKeySet("uas", []string{"uas-api"}, time.Hour)
+	assert.NoError(t, after.Add(newKey, true))
+	assert.NoError(t, after.Add(verifyOnly, false))
+
+	_, err = after.ParseToken(oldToken)
+	assert.NoError(t, err)
+
+	newToken, err := after.GenerateToken(Claims{UserID: 1, Username: "admin", Role: "admin"})
+	assert.NoError(t, err)
+	_, err = after.ParseToken(newToken)
+	assert.NoError(t, err)
+
+	// Setelah kunci lama dicabut, token lama ditolak
+	_, err = keySetWith(t, newKey).ParseToken(oldToken)
+	assert.Error(t, err)
+}
+
+// KeySet dengan kunci pertama sebagai kunci aktif
+func keySetWith(t *testing.T, keys ...*SigningKey) *KeySet {
+	ks := NewKeySet("uas", []string{"uas-api"}, time.Hour)
+	for i, k := range keys {
+		assert.NoError(t, ks.Add(k, i == 0))
+	}
+	return ks
+}
+
+// Serangan klasik: token HS256 yang di-"sign" dengan public key RSA
+func TestKeySet_RejectsAlgorithmConfusion(t *testing.T) {
+	rsaPrivate, rsaPublic := rsaPEM(t)
+	key, err := NewSigningKey("rs", AlgRS256, nil, rsaPrivate, nil)
+	assert.NoError(t, err)
+	ks := keySetWith(t, key)
+
+	forged := jwt.NewWithClaims(jwt.SigningMethodHS256, Claims{
+		UserID: 1, Role: "admin",
+		RegisteredClaims: jwt.RegisteredClaims{
+			Issuer:    "uas",
+			Audience:  jwt.ClaimStrings{"uas-api"},
+			ExpiresAt: jwt.NewNumericDate(time.Now().Add(time.Hour)),
+		},
+	})
+	forged.Header["kid"] = "rs"
+	signed, err := forged.SignedString(rsaPublic)
+	assert.NoError(t, err)
+
+	_, err = ks.ParseToken(signed)
+	assert.Error(t, err)
+
+	// alg none juga ditolak
+	none := jwt.NewWithClaims(jwt.SigningMethodNone, forged.Claims)
+	none.Header["kid"] = "rs"
+	unsigned, err := none.SignedString(jwt.UnsafeAllowNoneSignatureType)
+	assert.NoError(t, err)
+	_, err = ks.ParseToken(unsigned)
+	assert.Error(t, err)
+}
+
+func TestKeySet_ValidatesIssuerAndAudience(t *testing.T) {
+	key, err := NewSigningKey("hs", AlgHS256, []byte("secret"), nil, nil)
+	assert.NoError(t, err)
+
+	other := NewKeySet("layanan-lain", []string{"uas-api"}, time.Hour)
+	assert.NoError(t, other.Add(key, true))
+	token, err := other.GenerateToken(Claims{UserID: 1, Username: "a", Role: "admin"})
+	assert.NoError(t, err)
+	_, err = keySetWith(t, key).ParseToken(token)
+	assert.ErrorIs(t, err, jwt.ErrTokenInvalidIssuer)
+
+	otherAud := NewKeySet("uas", []string{"perpustakaan"}, time.Hour)
+	assert.NoError(t, otherAud.Add(key, true))
+	token, err = otherAud.GenerateToken(Claims{UserID: 1, Username: "a", Role: "admin"})
+	assert.NoError(t, err)
+	_, err = keySetWith(t, key).ParseToken(token)
+	assert.ErrorIs(t, err, jwt.ErrTokenInvalidAudience)
+}
+
+func TestLoadKeySet_KeysFileAndJWKS(t *testing.T) {
+	dir := t.TempDir()
+	_, oldPublic := rsaPEM(t)
+	assert.NoError(t, os.WriteFile(filepath.Join(dir, "new.pem"), edPEM(t), 0o600))
+	assert.NoError(t, os.WriteFile(filepath.Join(dir, "old.pub.pem"), oldPublic, 0o600))
+	file, _ := json.Marshal(map[string]interface{}{
+		"active": "new",
+		"keys": []map[string]string{
+			{"kid": "new", "alg": AlgEdDSA, "private_key_file": "new.pem"},
+			{"kid": "old", "alg": AlgRS256, "public_key_file": "old.pub.pem"},
+			{"kid": "legacy", "alg": AlgHS256, "secret": "legacy-secret-0123456789abcdefghij"},
+		},
+	})
+	keysPath := filepath.Join(dir, "keys.json")
+	assert.NoError(t, os.WriteFile(keysPath, file, 0o600))
+
+	ks, err := LoadKeySet(KeyOptions{Issuer: "uas", Audience: []string{"uas-api"}, TTL: time.Hour, KeysFile: keysPath})
+	assert.NoError(t, err)
+	assert.Equal(t, "new", ks.ActiveKeyID())
+
+	jwks := ks.JWKS()
+	assert.Len(t, jwks.Keys, 2, "HS256 secret must never be published")
+	assert.Equal(t, JWK{Kty: "OKP", Use: "sig", Alg: AlgEdDSA, Kid: "new", Crv: "Ed25519", X: jwks.Keys[0].X}, jwks.Keys[0])
+	assert.Equal(t, "RSA", jwks.Keys[1].Kty)
+	assert.Equal(t, "AQAB", jwks.Keys[1].E)
+
+	// kunci aktif harus punya private key
+	file, _ = json.Marshal(map[string]interface{}{
+		"active": "old",
+		"keys":   []map[string]string{{"kid": "old", "alg": AlgRS256, "public_key_file": "old.pub.pem"}},
+	})
+	assert.NoError(t, os.WriteFile(keysPath, file, 0o600))
+	_, err = LoadKeySet(KeyOptions{KeysFile: keysPath})
+	assert.Error(t, err)
+}
+
+// Secret HS256 bawaan / pendek hanya diterima di mode dev
+func TestLoadKeySet_RejectsWeakHMACSecretOutsideDev(t *testing.T) {
+	opts := KeyOptions{Issuer: "uas", TTL: time.Hour, Algorithm: AlgHS256, KeyID: "default"}
+	for _, secret := range []string{"changeme", "short-secret"} {
+		opts.Secret, opts.Dev = secret, false
+		_, err := LoadKeySet(opts)
+		assert.Error(t, err, secret)
+
+		opts.Dev = true
+		_, err = LoadKeySet(opts)
+		assert.NoError(t, err, secret)
+	}
+
+	opts.Secret, opts.Dev = "0123456789abcdef0123456789abcdef", false
+	_, err := LoadKeySet(opts)
+	assert.NoError(t, err)
+}
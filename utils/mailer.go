@@ -0,0 +1,144 @@
+package utils
+
+import (
+	"context"
+	"fmt"
+	"io"
+	"net"
+	"net/smtp"
+	"os"
+	"path/filepath"
+	"strings"
+	"sync"
+	"time"
+)
+
+// Mail: email teks biasa
+type Mail struct {
+	To      string
+	Subject string
+	Body    string
+}
+
+// Mailer mengirim email. Implementasi dipilih lewat MAIL_DRIVER:
+// console (log ke stdout), file (satu .eml per email) atau smtp.
+// Tanpa MAIL_DRIVER hanya boleh di mode dev (console); di luar dev server
+// tidak start, supaya link reset berisi token tidak berakhir di log produksi.
+type Mailer interface {
+	Send(ctx context.Context, mail Mail) error
+}
+
+type MailerOptions struct {
+	Driver       string
+	From         string
+	Dir          string
+	SMTPHost     string
+	SMTPPort     int
+	SMTPUsername string
+	SMTPPassword string
+
+	// Dev (APP_ENV=development): boleh tanpa driver dan isi email console
+	// ditampilkan apa adanya
+	Dev bool
+}
+
+func NewMailer(opts MailerOptions) (Mailer, error) {
+	switch opts.Driver {
+	case "":
+		if !opts.Dev {
+			return nil, fmt.Errorf("mailer: MAIL_DRIVER is required (console | file | smtp)")
+		}
+		return &ConsoleMailer{From: opts.From, Out: os.Stdout}, nil
+	case "console":
+		return &ConsoleMailer{From: opts.From, Out: os.Stdout, Redact: !opts.Dev}, nil
+	case "file":
+		if opts.Dir == "" {
+			return nil, fmt.Errorf("mailer: file driver requires MAIL_DIR")
+		}
+		return &FileMailer{From: opts.From, Dir: opts.Dir}, nil
+	case "smtp":
+		if opts.SMTPHost == "" {
+			return nil, fmt.Errorf("mailer: smtp driver requires SMTP_HOST")
+		}
+		return &SMTPMailer{
+			From:     opts.From,
+			Addr:     net.JoinHostPort(opts.SMTPHost, fmt.Sprint(opts.SMTPPort)),
+			Host:     opts.SMTPHost,
+			Username: opts.SMTPUsername,
+			Password: opts.SMTPPassword,
+		}, nil
+	default:
+		return nil, fmt.Errorf("mailer: unknown driver %q", opts.Driver)
+	}
+}
+
+// ConsoleMailer menulis email ke Out, untuk dev lokal. Redact: isi email
+// (link berisi token) tidak ditulis, hanya header
+type ConsoleMailer struct {
+	From   string
+	Out    io.Writer
+	Redact bool
+	mu     sync.Mutex
+}
+
+func (m *ConsoleMailer) Send(ctx context.Context, mail Mail) error {
+	m.mu.Lock()
+	defer m.mu.Unlock()
+	if m.Redact {
+		mail.Body = fmt.Sprintf("[body redacted, %d bytes]", len(mail.Body))
+	}
+	_, err := fmt.Fprintf(m.Out, "----- mail -----\n%s-----\n", formatMail(m.From, mail))
+	return err
+}
+
+// FileMailer menyimpan setiap email sebagai file .eml di Dir
+type FileMailer struct {
+	From string
+	Dir  string
+}
+
+func (m *FileMailer) Send(ctx context.Context, mail Mail) error {
+	if err := os.MkdirAll(m.Dir, 0o700); err != nil {
+		return err
+	}
+	suffix, err := GeneratePassword(6)
+	if err != nil {
+		return err
+	}
+	name := fmt.Sprintf("%s-%s.eml", time.Now().Format("20060102-150405"), suffix)
+	return os.WriteFile(filepath.Join(m.Dir, name), []byte(formatMail(m.From, mail)), 0o600)
+}
+
+// SMTPMailer mengirim lewat server SMTP (STARTTLS jika server mendukung)
+type SMTPMailer struct {
+	From     string
+	Addr     string
+	Host     string
+	Username string
+	Password string
+}
+
+func (m *SMTPMailer) Send(ctx context.Context, mail Mail) error {
+	var auth smtp.Auth
+	if m.Username != "" {
+		auth = smtp.PlainAuth("", m.Username, m.Password, m.Host)
+	}
+	return smtp.SendMail(m.Addr, auth, m.From, []string{mail.To}, []byte(formatMail(m.From, mail)))
+}
+
+func formatMail(from string, mail Mail) string {
+	// header tidak boleh berisi baris baru (header injection)
+	clean := func(s string) string {
+		return strings.NewReplacer("\r", "", "\n", "").Replace(s)
+	}
+	var b strings.Builder
+	fmt.Fprintf(&b, "From: %s\r\n", clean(from))
+	fmt.Fprintf(&b, "To: %s\r\n", clean(mail.To))
+	fmt.Fprintf(&b, "Subject: %s\r\n", clean(mail.Subject))
+	fmt.Fprintf(&b, "Date: %s\r\n", time.Now().Format(time.RFC1123Z))
+	b.WriteString("MIME-Version: 1.0\r\n")
+	b.WriteString("Content-Type: text/plain; charset=UTF-8\r\n\r\n")
+	b.WriteString(mail.Body)
+	b.WriteString("\r\n")
+	return b.String()
+}
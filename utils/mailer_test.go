@@ -0,0 +1,66 @@
+package utils
+
+import (
+	"bytes"
+	"context"
+	"os"
+	"strings"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+)
+
+func TestFileMailer_WritesEML(t *testing.T) {
+	dir := t.TempDir()
+	mailer, err := NewMailer(MailerOptions{Driver: "file", From: "no-reply@uas", Dir: dir})
+	assert.NoError(t, err)
+
+	err = mailer.Send(context.Background(), Mail{
+		To:      "budi@example.ac.id",
+		Subject: "Reset\r\nBcc: penyusup@example.com",
+		Body:    "isi email",
+	})
+	assert.NoError(t, err)
+
+	files, _ := os.ReadDir(dir)
+	if assert.Len(t, files, 1) {
+		raw, _ := os.ReadFile(dir + "/" + files[0].Name())
+		eml := string(raw)
+		assert.Contains(t, eml, "To: budi@example.ac.id\r\n")
+		assert.Contains(t, eml, "isi email")
+		// baris baru di header dibuang, tidak bisa menyisipkan header Bcc
+		assert.NotContains(t, eml, "\r\nBcc:")
+	}
+}
+
+func TestConsoleMailer(t *testing.T) {
+	var out bytes.Buffer
+	mailer := &ConsoleMailer{From: "no-reply@uas", Out: &out}
+	assert.NoError(t, mailer.Send(context.Background(), Mail{To: "a@b.c", Subject: "Hai", Body: "link"}))
+	assert.True(t, strings.Contains(out.String(), "Subject: Hai"))
+
+	_, err := NewMailer(MailerOptions{Driver: "pigeon"})
+	assert.Error(t, err)
+}
+
+func TestConsoleMailer_RedactsBody(t *testing.T) {
+	var out bytes.Buffer
+	mailer := &ConsoleMailer{From: "no-reply@uas", Out: &out, Redact: true}
+	assert.NoError(t, mailer.Send(context.Background(), Mail{To: "a@b.c", Subject: "Reset", Body: "https://x/reset?token=rahasia"}))
+	assert.Contains(t, out.String(), "Subject: Reset")
+	assert.NotContains(t, out.String(), "rahasia")
+}
+
+// Tanpa MAIL_DRIVER server hanya boleh start di mode dev
+func TestNewMailer_DriverRequiredOutsideDev(t *testing.T) {
+	_, err := NewMailer(MailerOptions{})
+	assert.Error(t, err)
+
+	mailer, err := NewMailer(MailerOptions{Dev: true})
+	assert.NoError(t, err)
+	assert.False(t, mailer.(*ConsoleMailer).Redact)
+
+	mailer, err = NewMailer(MailerOptions{Driver: "console"})
+	assert.NoError(t, err)
+	assert.True(t, mailer.(*ConsoleMailer).Redact)
+}
@@ -0,0 +1,301 @@
+package utils
+
+import (
+	"context"
+	"crypto/rand"
+	"crypto/sha256"
+	"encoding/base64"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"io"
+	"net/http"
+	"net/url"
+	"strings"
+	"sync"
+	"time"
+
+	"github.com/golang-jwt/jwt/v5"
+)
+
+// OIDCOptions: client OpenID Connect untuk IdP kampus
+// (authorization code + PKCE, RFC 7636)
+type OIDCOptions struct {
+	Issuer       string
+	ClientID     string
+	ClientSecret string // kosong = public client (PKCE saja)
+	RedirectURL  string
+	Scopes       []string
+	HTTPClient   *http.Client
+}
+
+// OIDCProvider: discovery document dan JWKS IdP diambil saat pertama dipakai
+// lalu di-cache, supaya server tetap bisa start walau IdP sedang tidak bisa
+// dihubungi
+type OIDCProvider struct {
+	opts OIDCOptions
+
+	mu          sync.Mutex
+	meta        *oidcMetadata
+	keys        map[string]interface{}
+	keysFetched time.Time
+}
+
+type oidcMetadata struct {
+	Issuer                string `json:"issuer"`
+	AuthorizationEndpoint string `json:"authorization_endpoint"`
+	TokenEndpoint         string `json:"token_endpoint"`
+	JWKSURI               string `json:"jwks_uri"`
+}
+
+// OIDCTokens: respons token endpoint
+type OIDCTokens struct {
+	AccessToken string `json:"access_token"`
+	IDToken     string `json:"id_token"`
+	TokenType   string `json:"token_type"`
+}
+
+var (
+	ErrOIDCNonce   = errors.New("oidc: nonce mismatch")
+	ErrOIDCNoToken = errors.New("oidc: token response without id_token")
+)
+
+// JWKS IdP diambil ulang jika kid tidak dikenal (rotasi kunci), paling
+// sering sekali per interval ini
+const oidcJWKSRefreshInterval = time.Minute
+
+// Algoritma tanda tangan ID token yang diterima (tidak pernah "none" / HMAC)
+var oidcSigningMethods = []string{"RS256", "RS384", "RS512", "ES256", "EdDSA"}
+
+func NewOIDCProvider(opts OIDCOptions) *OIDCProvider {
+	if opts.HTTPClient == nil {
+		opts.HTTPClient = &http.Client{Timeout: 10 * time.Second}
+	}
+	if len(opts.Scopes) == 0 {
+		opts.Scopes = []string{"openid"}
+	}
+	return &OIDCProvider{opts: opts}
+}
+
+func (p *OIDCProvider) Issuer() string {
+	return p.opts.Issuer
+}
+
+// NewPKCE: code_verifier acak dan code_challenge S256-nya
+func NewPKCE() (verifier, challenge string, err error) {
+	b := make([]byte, 32)
+	if _, err := rand.Read(b); err != nil {
+		return "", "", err
+	}
+	verifier = base64.RawURLEncoding.EncodeToString(b)
+	return verifier, PKCEChallenge(verifier), nil
+}
+
+// PKCEChallenge: BASE64URL(SHA256(verifier))
+func PKCEChallenge(verifier string) string {
+	sum := sha256.Sum256([]byte(verifier))
+	return base64.RawURLEncoding.EncodeToString(sum[:])
+}
+
+// AuthCodeURL: URL login IdP untuk di-redirect browser
+func (p *OIDCProvider) AuthCodeURL(ctx context.Context, state, nonce, codeChallenge string) (string, error) {
+	meta, err := p.metadata(ctx)
+	if err != nil {
+		return "", err
+	}
+	q := url.Values{}
+	q.Set("response_type", "code")
+	q.Set("client_id", p.opts.ClientID)
+	q.Set("redirect_uri", p.opts.RedirectURL)
+	q.Set("scope", strings.Join(p.opts.Scopes, " "))
+	q.Set("state", state)
+	q.Set("nonce", nonce)
+	q.Set("code_challenge", codeChallenge)
+	q.Set("code_challenge_method", "S256")
+
+	sep := "?"
+	if strings.Contains(meta.AuthorizationEndpoint, "?") {
+		sep = "&"
+	}
+	return meta.AuthorizationEndpoint + sep + q.Encode(), nil
+}
+
+// Exchange menukar authorization code (dengan code_verifier) ke token endpoint
+func (p *OIDCProvider) Exchange(ctx context.Context, code, codeVerifier string) (*OIDCTokens, error) {
+	meta, err := p.metadata(ctx)
+	if err != nil {
+		return nil, err
+	}
+	form := url.Values{}
+	form.Set("grant_type", "authorization_code")
+	form.Set("code", code)
+	form.Set("redirect_uri", p.opts.RedirectURL)
+	form.Set("code_verifier", codeVerifier)
+	form.Set("client_id", p.opts.ClientID)
+
+	req, err := http.NewRequestWithContext(ctx, http.MethodPost, meta.TokenEndpoint, strings.NewReader(form.Encode()))
+	if err != nil {
+		return nil, err
+	}
+	req.Header.Set("Content-Type", "application/x-www-form-urlencoded")
+	req.Header.Set("Accept", "application/json")
+	if p.opts.ClientSecret != "" {
+		// client_secret_basic (RFC 6749 2.3.1)
+		req.SetBasicAuth(url.QueryEscape(p.opts.ClientID), url.QueryEscape(p.opts.ClientSecret))
+	}
+
+	resp, err := p.opts.HTTPClient.Do(req)
+	if err != nil {
+		return nil, fmt.Errorf("oidc: token request: %w", err)
+	}
+	defer resp.Body.Close()
+	body, err := io.ReadAll(io.LimitReader(resp.Body, 1<<20))
+	if err != nil {
+		return nil, err
+	}
+	if resp.StatusCode != http.StatusOK {
+		var e struct {
+			Error       string `json:"error"`
+			Description string `json:"error_description"`
+		}
+		json.Unmarshal(body, &e)
+		return nil, fmt.Errorf("oidc: token endpoint %d: %s %s", resp.StatusCode, e.Error, e.Description)
+	}
+
+	var tokens OIDCTokens
+	if err := json.Unmarshal(body, &tokens); err != nil {
+		return nil, fmt.Errorf("oidc: token response: %w", err)
+	}
+	if tokens.IDToken == "" {
+		return nil, ErrOIDCNoToken
+	}
+	return &tokens, nil
+}
+
+// VerifyIDToken memeriksa tanda tangan (JWKS IdP), iss, aud, exp dan nonce,
+// lalu mengembalikan semua claim
+func (p *OIDCProvider) VerifyIDToken(ctx context.Context, rawIDToken, nonce string) (jwt.MapClaims, error) {
+	meta, err := p.metadata(ctx)
+	if err != nil {
+		return nil, err
+	}
+	claims := jwt.MapClaims{}
+	_, err = jwt.ParseWithClaims(rawIDToken, claims,
+		func(t *jwt.Token) (interface{}, error) {
+			kid, _ := t.Header["kid"].(string)
+			return p.key(ctx, kid)
+		},
+		jwt.WithValidMethods(oidcSigningMethods),
+		jwt.WithIssuer(meta.Issuer),
+		jwt.WithAudience(p.opts.ClientID),
+		jwt.WithExpirationRequired(),
+		jwt.WithIssuedAt(),
+		jwt.WithLeeway(time.Minute),
+	)
+	if err != nil {
+		return nil, fmt.Errorf("oidc: id token: %w", err)
+	}
+
+	// aud berisi client lain → azp harus client ini (OIDC Core 3.1.3.7)
+	if aud, _ := claims.GetAudience(); len(aud) > 1 {
+		if azp, _ := claims["azp"].(string); azp != p.opts.ClientID {
+			return nil, fmt.Errorf("oidc: id token: azp %q is not this client", azp)
+		}
+	}
+	if got, _ := claims["nonce"].(string); got != nonce {
+		return nil, ErrOIDCNonce
+	}
+	if sub, _ := claims.GetSubject(); sub == "" {
+		return nil, fmt.Errorf("oidc: id token without sub")
+	}
+	return claims, nil
+}
+
+// metadata: discovery document (/.well-known/openid-configuration), di-cache
+// setelah berhasil diambil
+func (p *OIDCProvider) metadata(ctx context.Context) (*oidcMetadata, error) {
+	p.mu.Lock()
+	defer p.mu.Unlock()
+	if p.meta != nil {
+		return p.meta, nil
+	}
+
+	issuer := strings.TrimSuffix(p.opts.Issuer, "/")
+	var meta oidcMetadata
+	if err := p.getJSON(ctx, issuer+"/.well-known/openid-configuration", &meta); err != nil {
+		return nil, fmt.Errorf("oidc: discovery: %w", err)
+	}
+	// issuer di dokumen harus sama persis dengan yang dikonfigurasi (OIDC Discovery 4.3)
+	if strings.TrimSuffix(meta.Issuer, "/") != issuer {
+		return nil, fmt.Errorf("oidc: discovery: issuer %q does not match %q", meta.Issuer, p.opts.Issuer)
+	}
+	if meta.AuthorizationEndpoint == "" || meta.TokenEndpoint == "" || meta.JWKSURI == "" {
+		return nil, fmt.Errorf("oidc: discovery: missing endpoints")
+	}
+	p.meta = &meta
+	return p.meta, nil
+}
+
+// key: kunci publik IdP untuk kid; JWKS diambil ulang jika kid belum dikenal
+func (p *OIDCProvider) key(ctx context.Context, kid string) (interface{}, error) {
+	p.mu.Lock()
+	defer p.mu.Unlock()
+
+	if k, ok := p.lookupKey(kid); ok {
+		return k, nil
+	}
+	if time.Since(p.keysFetched) < oidcJWKSRefreshInterval && p.keys != nil {
+		return nil, fmt.Errorf("unknown key id %q", kid)
+	}
+
+	var set JWKS
+	if err := p.getJSON(ctx, p.meta.JWKSURI, &set); err != nil {
+		return nil, fmt.Errorf("fetch jwks: %w", err)
+	}
+	keys := map[string]interface{}{}
+	for _, jwk := range set.Keys {
+		if jwk.Use != "" && jwk.Use != "sig" {
+			continue
+		}
+		k, err := jwk.PublicKey()
+		if err != nil {
+			continue // kunci jenis lain tidak menghalangi kunci yang valid
+		}
+		keys[jwk.Kid] = k
+	}
+	p.keys = keys
+	p.keysFetched = time.Now()
+
+	if k, ok := p.lookupKey(kid); ok {
+		return k, nil
+	}
+	return nil, fmt.Errorf("unknown key id %q", kid)
+}
+
+// lookupKey: tanpa kid hanya boleh jika IdP punya tepat satu kunci
+func (p *OIDCProvider) lookupKey(kid string) (interface{}, bool) {
+	if kid == "" && len(p.keys) == 1 {
+		for _, k := range p.keys {
+			return k, true
+		}
+	}
+	k, ok := p.keys[kid]
+	return k, ok
+}
+
+func (p *OIDCProvider) getJSON(ctx context.Context, url string, out interface{}) error {
+	req, err := http.NewRequestWithContext(ctx, http.MethodGet, url, nil)
+	if err != nil {
+		return err
+	}
+	req.Header.Set("Accept", "application/json")
+	resp, err := p.opts.HTTPClient.Do(req)
+	if err != nil {
+		return err
+	}
+	defer resp.Body.Close()
+	if resp.StatusCode != http.StatusOK {
+		return fmt.Errorf("GET %s: status %d", url, resp.StatusCode)
+	}
+	return json.NewDecoder(io.LimitReader(resp.Body, 1<<20)).Decode(out)
+}
@@ -0,0 +1,125 @@
+package utils_test
+
+import (
+	"context"
+	"net/url"
+	"testing"
+	"time"
+
+	"uas/utils"
+	"uas/utils/oidctest"
+
+	"github.com/golang-jwt/jwt/v5"
+	"github.com/stretchr/testify/assert"
+)
+
+// paket terpisah: oidctest sendiri memakai utils
+
+func newTestProvider(idp *oidctest.IdP) *utils.OIDCProvider {
+	return utils.NewOIDCProvider(utils.OIDCOptions{
+		Issuer:      idp.Issuer,
+		ClientID:    idp.ClientID,
+		RedirectURL: "http://localhost:3000/api/v1/auth/oidc/callback",
+		Scopes:      []string{"openid", "email"},
+	})
+}
+
+func TestOIDC_CodeFlowWithPKCE(t *testing.T) {
+	idp := oidctest.NewIdP("uas")
+	defer idp.Close()
+	idp.SetUser(map[string]interface{}{"sub": "u-1", "email": "budi@kampus.ac.id"})
+	provider := newTestProvider(idp)
+	ctx := context.Background()
+
+	verifier, challenge, err := utils.NewPKCE()
+	assert.NoError(t, err)
+	authURL, err := provider.AuthCodeURL(ctx, "state-1", "nonce-1", challenge)
+	assert.NoError(t, err)
+
+	u, _ := url.Parse(authURL)
+	assert.Equal(t, "S256", u.Query().Get("code_challenge_method"))
+	assert.Equal(t, "openid email", u.Query().Get("scope"))
+
+	code, state, err := idp.Authorize(authURL)
+	assert.NoError(t, err)
+	assert.Equal(t, "state-1", state)
+
+	tokens, err := provider.Exchange(ctx, code, verifier)
+	assert.NoError(t, err)
+	claims, err := provider.VerifyIDToken(ctx, tokens.IDToken, "nonce-1")
+	assert.NoError(t, err)
+	assert.Equal(t, "budi@kampus.ac.id", claims["email"])
+
+	// code sekali pakai
+	_, err = provider.Exchange(ctx, code, verifier)
+	assert.Error(t, err)
+}
+
+func TestOIDC_ExchangeRejectsWrongVerifier(t *testing.T) {
+	idp := oidctest.NewIdP("uas")
+	defer idp.Close()
+	idp.SetUser(map[string]interface{}{"sub": "u-1"})
+	provider := newTestProvider(idp)
+	ctx := context.Background()
+
+	_, challenge, _ := utils.NewPKCE()
+	authURL, _ := provider.AuthCodeURL(ctx, "s", "n", challenge)
+	code, _, _ := idp.Authorize(authURL)
+
+	other, _, _ := utils.NewPKCE()
+	_, err := provider.Exchange(ctx, code, other)
+	assert.ErrorContains(t, err, "invalid_grant")
+}
+
+func TestOIDC_VerifyIDTokenChecks(t *testing.T) {
+	idp := oidctest.NewIdP("uas")
+	defer idp.Close()
+	provider := newTestProvider(idp)
+	ctx := context.Background()
+
+	valid := func() jwt.MapClaims {
+		return jwt.MapClaims{
+			"iss":   idp.Issuer,
+			"aud":   "uas",
+			"sub":   "u-1",
+			"nonce": "n",
+			"iat":   time.Now().Unix(),
+			"exp":   time.Now().Add(time.Minute).Unix(),
+		}
+	}
+	_, err := provider.VerifyIDToken(ctx, idp.SignIDToken(valid()), "n")
+	assert.NoError(t, err)
+
+	cases := map[string]func(c jwt.MapClaims){
+		"wrong nonce":    func(c jwt.MapClaims) { c["nonce"] = "other" },
+		"wrong audience": func(c jwt.MapClaims) { c["aud"] = "other-client" },
+		"wrong issuer":   func(c jwt.MapClaims) { c["iss"] = "https://evil.example" },
+		"expired":        func(c jwt.MapClaims) { c["exp"] = time.Now().Add(-time.Hour).Unix() },
+		"no sub":         func(c jwt.MapClaims) { delete(c, "sub") },
+		"foreign azp": func(c jwt.MapClaims) {
+			c["aud"] = []string{"uas", "other-client"}
+			c["azp"] = "other-client"
+		},
+	}
+	for name, mutate := range cases {
+		claims := valid()
+		mutate(claims)
+		_, err := provider.VerifyIDToken(ctx, idp.SignIDToken(claims), "n")
+		assert.Error(t, err, name)
+	}
+
+	// tanda tangan kunci lain
+	other := oidctest.NewIdP("uas")
+	defer other.Close()
+	_, err = provider.VerifyIDToken(ctx, other.SignIDToken(valid()), "n")
+	assert.Error(t, err)
+}
+
+func TestOIDC_DiscoveryIssuerMismatch(t *testing.T) {
+	idp := oidctest.NewIdP("uas")
+	defer idp.Close()
+	provider := utils.NewOIDCProvider(utils.OIDCOptions{Issuer: idp.Issuer + "/realms/other", ClientID: "uas"})
+
+	_, err := provider.AuthCodeURL(context.Background(), "s", "n", "c")
+	assert.Error(t, err)
+}
@@ -0,0 +1,203 @@
+// Package oidctest: IdP OpenID Connect tiruan (httptest) untuk menguji login
+// SSO tanpa IdP kampus sungguhan. Mendukung discovery, JWKS, authorization
+// code + PKCE (S256) dan ID token RS256.
+package oidctest
+
+import (
+	"crypto/rand"
+	"crypto/rsa"
+	"encoding/base64"
+	"encoding/json"
+	"math/big"
+	"net/http"
+	"net/http/httptest"
+	"net/url"
+	"sync"
+	"time"
+
+	"uas/utils"
+
+	"github.com/golang-jwt/jwt/v5"
+)
+
+const keyID = "oidctest"
+
+type IdP struct {
+	Server   *httptest.Server
+	Issuer   string
+	ClientID string
+	Key      *rsa.PrivateKey
+
+	mu     sync.Mutex
+	claims map[string]interface{}
+	codes  map[string]authRequest
+}
+
+type authRequest struct {
+	clientID    string
+	redirectURI string
+	challenge   string
+	nonce       string
+	claims      map[string]interface{}
+}
+
+// NewIdP menjalankan IdP untuk clientID; tutup dengan Close
+func NewIdP(clientID string) *IdP {
+	key, err := rsa.GenerateKey(rand.Reader, 2048)
+	if err != nil {
+		panic(err)
+	}
+	idp := &IdP{
+		ClientID: clientID,
+		Key:      key,
+		claims:   map[string]interface{}{},
+		codes:    map[string]authRequest{},
+	}
+	mux := http.NewServeMux()
+	mux.HandleFunc("/.well-known/openid-configuration", idp.discovery)
+	mux.HandleFunc("/jwks", idp.jwks)
+	mux.HandleFunc("/authorize", idp.authorize)
+	mux.HandleFunc("/token", idp.token)
+	idp.Server = httptest.NewServer(mux)
+	idp.Issuer = idp.Server.URL
+	return idp
+}
+
+func (idp *IdP) Close() {
+	idp.Server.Close()
+}
+
+// SetUser: claim (sub, email, nim, ...) untuk login berikutnya
+func (idp *IdP) SetUser(claims map[string]interface{}) {
+	idp.mu.Lock()
+	defer idp.mu.Unlock()
+	idp.claims = claims
+}
+
+// Authorize mensimulasikan user login di IdP untuk authURL (hasil
+// OIDCProvider.AuthCodeURL): mengembalikan code dan state untuk callback
+func (idp *IdP) Authorize(authURL string) (code, state string, err error) {
+	u, err := url.Parse(authURL)
+	if err != nil {
+		return "", "", err
+	}
+	q := u.Query()
+	code = utils.HashToken(q.Get("state") + time.Now().String())[:32]
+
+	idp.mu.Lock()
+	defer idp.mu.Unlock()
+	idp.codes[code] = authRequest{
+		clientID:    q.Get("client_id"),
+		redirectURI: q.Get("redirect_uri"),
+		challenge:   q.Get("code_challenge"),
+		nonce:       q.Get("nonce"),
+		claims:      idp.claims,
+	}
+	return code, q.Get("state"), nil
+}
+
+// SignIDToken menandatangani claim apa adanya (untuk menguji token rusak)
+func (idp *IdP) SignIDToken(claims jwt.MapClaims) string {
+	token := jwt.NewWithClaims(jwt.SigningMethodRS256, claims)
+	token.Header["kid"] = keyID
+	signed, err := token.SignedString(idp.Key)
+	if err != nil {
+		panic(err)
+	}
+	return signed
+}
+
+func (idp *IdP) discovery(w http.ResponseWriter, r *http.Request) {
+	writeJSON(w, 200, map[string]interface{}{
+		"issuer":                                idp.Issuer,
+		"authorization_endpoint":                idp.Issuer + "/authorize",
+		"token_endpoint":                        idp.Issuer + "/token",
+		"jwks_uri":                              idp.Issuer + "/jwks",
+		"response_types_supported":              []string{"code"},
+		"code_challenge_methods_supported":      []string{"S256"},
+		"id_token_signing_alg_values_supported": []string{"RS256"},
+	})
+}
+
+func (idp *IdP) jwks(w http.ResponseWriter, r *http.Request) {
+	b64 := base64.RawURLEncoding
+	pub := idp.Key.PublicKey
+	writeJSON(w, 200, utils.JWKS{Keys: []utils.JWK{{
+		Kty: "RSA", Use: "sig", Alg: "RS256", Kid: keyID,
+		N: b64.EncodeToString(pub.N.Bytes()),
+		E: b64.EncodeToString(big.NewInt(int64(pub.E)).Bytes()),
+	}}})
+}
+
+// GET /authorize: langsung "login" dengan claim dari SetUser lalu redirect
+// ke redirect_uri (untuk mencoba alur lewat browser)
+func (idp *IdP) authorize(w http.ResponseWriter, r *http.Request) {
+	code, state, err := idp.Authorize(idp.Issuer + r.URL.RequestURI())
+	if err != nil {
+		http.Error(w, err.Error(), 400)
+		return
+	}
+	target, err := url.Parse(r.URL.Query().Get("redirect_uri"))
+	if err != nil {
+		http.Error(w, "invalid redirect_uri", 400)
+		return
+	}
+	q := target.Query()
+	q.Set("code", code)
+	q.Set("state", state)
+	target.RawQuery = q.Encode()
+	http.Redirect(w, r, target.String(), http.StatusFound)
+}
+
+func (idp *IdP) token(w http.ResponseWriter, r *http.Request) {
+	if err := r.ParseForm(); err != nil || r.PostForm.Get("grant_type") != "authorization_code" {
+		writeJSON(w, 400, map[string]string{"error": "unsupported_grant_type"})
+		return
+	}
+	code := r.PostForm.Get("code")
+
+	idp.mu.Lock()
+	req, ok := idp.codes[code]
+	delete(idp.codes, code) // code sekali pakai
+	idp.mu.Unlock()
+
+	clientID := r.PostForm.Get("client_id")
+	if user, _, hasBasic := r.BasicAuth(); hasBasic {
+		clientID = user
+	}
+	switch {
+	case !ok:
+		writeJSON(w, 400, map[string]string{"error": "invalid_grant"})
+		return
+	case clientID != req.clientID || r.PostForm.Get("redirect_uri") != req.redirectURI:
+		writeJSON(w, 400, map[string]string{"error": "invalid_grant"})
+		return
+	case utils.PKCEChallenge(r.PostForm.Get("code_verifier")) != req.challenge:
+		writeJSON(w, 400, map[string]string{"error": "invalid_grant", "error_description": "PKCE verification failed"})
+		return
+	}
+
+	now := time.Now()
+	claims := jwt.MapClaims{
+		"iss":   idp.Issuer,
+		"aud":   idp.ClientID,
+		"iat":   now.Unix(),
+		"exp":   now.Add(5 * time.Minute).Unix(),
+		"nonce": req.nonce,
+	}
+	for k, v := range req.claims {
+		claims[k] = v
+	}
+	writeJSON(w, 200, map[string]interface{}{
+		"access_token": "idp-access-token",
+		"token_type":   "Bearer",
+		"expires_in":   300,
+		"id_token":     idp.SignIDToken(claims),
+	})
+}
+
+func writeJSON(w http.ResponseWriter, status int, v interface{}) {
+	w.Header().Set("Content-Type", "application/json")
+	w.WriteHeader(status)
+	json.NewEncoder(w).Encode(v)
+}
@@ -0,0 +1,151 @@
+package utils
+
+import (
+	"bufio"
+	"crypto/sha1"
+	"encoding/hex"
+	"errors"
+	"fmt"
+	"os"
+	"regexp"
+	"strings"
+	"unicode"
+)
+
+// PasswordPolicy: aturan password baru (buat user, ganti / reset password).
+// Daftar password bocor disimpan sebagai SHA-1 supaya file format HIBP
+// ("HASH:count") bisa dipakai langsung.
+type PasswordPolicy struct {
+	MinLength     int
+	MaxLength     int
+	RequireUpper  bool
+	RequireLower  bool
+	RequireDigit  bool
+	RequireSymbol bool
+
+	breached map[string]struct{}
+}
+
+type PasswordPolicyOptions struct {
+	MinLength     int
+	RequireUpper  bool
+	RequireLower  bool
+	RequireDigit  bool
+	RequireSymbol bool
+	// BreachedFile: satu password per baris, atau SHA-1 hex (opsional ":count")
+	BreachedFile string
+}
+
+// Batas bcrypt: byte setelah 72 diabaikan
+const maxBcryptPasswordLength = 72
+
+var sha1Line = regexp.MustCompile(`^[0-9A-Fa-f]{40}(:\d+)?$`)
+
+func LoadPasswordPolicy(opts PasswordPolicyOptions) (*PasswordPolicy, error) {
+	p := &PasswordPolicy{
+		MinLength:     opts.MinLength,
+		MaxLength:     maxBcryptPasswordLength,
+		RequireUpper:  opts.RequireUpper,
+		RequireLower:  opts.RequireLower,
+		RequireDigit:  opts.RequireDigit,
+		RequireSymbol: opts.RequireSymbol,
+		breached:      map[string]struct{}{},
+	}
+	if p.MinLength > p.MaxLength {
+		return nil, fmt.Errorf("password policy: min length %d exceeds %d", p.MinLength, p.MaxLength)
+	}
+	if opts.BreachedFile == "" {
+		return p, nil
+	}
+
+	f, err := os.Open(opts.BreachedFile)
+	if err != nil {
+		return nil, fmt.Errorf("read breached password list: %w", err)
+	}
+	defer f.Close()
+	scanner := bufio.NewScanner(f)
+	for scanner.Scan() {
+		line := strings.TrimSpace(scanner.Text())
+		if line == "" || strings.HasPrefix(line, "#") {
+			continue
+		}
+		if sha1Line.MatchString(line) {
+			p.breached[strings.ToUpper(line[:40])] = struct{}{}
+		} else {
+			p.breached[passwordSHA1(line)] = struct{}{}
+		}
+	}
+	if err := scanner.Err(); err != nil {
+		return nil, fmt.Errorf("read breached password list: %w", err)
+	}
+	return p, nil
+}
+
+// BreachedCount: jumlah entri daftar password bocor yang dimuat
+func (p *PasswordPolicy) BreachedCount() int {
+	return len(p.breached)
+}
+
+// Check mengembalikan error berisi semua aturan yang dilanggar.
+// personal: data user (username, email) yang tidak boleh dipakai sebagai password.
+func (p *PasswordPolicy) Check(password string, personal ...string) error {
+	var problems []string
+	if len([]rune(password)) < p.MinLength {
+		problems = append(problems, fmt.Sprintf("must be at least %d characters", p.MinLength))
+	}
+	if len(password) > p.MaxLength {
+		problems = append(problems, fmt.Sprintf("must be at most %d bytes", p.MaxLength))
+	}
+
+	var upper, lower, digit, symbol bool
+	for _, r := range password {
+		switch {
+		case unicode.IsUpper(r):
+			upper = true
+		case unicode.IsLower(r):
+			lower = true
+		case unicode.IsDigit(r):
+			digit = true
+		case unicode.IsPunct(r) || unicode.IsSymbol(r) || unicode.IsSpace(r):
+			symbol = true
+		}
+	}
+	if p.RequireUpper && !upper {
+		problems = append(problems, "must contain an uppercase letter")
+	}
+	if p.RequireLower && !lower {
+		problems = append(problems, "must contain a lowercase letter")
+	}
+	if p.RequireDigit && !digit {
+		problems = append(problems, "must contain a digit")
+	}
+	if p.RequireSymbol && !symbol {
+		problems = append(problems, "must contain a symbol")
+	}
+
+	lowered := strings.ToLower(password)
+	for _, info := range personal {
+		info = strings.ToLower(strings.TrimSpace(info))
+		if name, _, ok := strings.Cut(info, "@"); ok {
+			info = name
+		}
+		if len(info) >= 3 && strings.Contains(lowered, info) {
+			problems = append(problems, "must not contain your username or email")
+			break
+		}
+	}
+
+	if _, ok := p.breached[passwordSHA1(password)]; ok {
+		problems = append(problems, "appears in a list of breached passwords")
+	}
+
+	if len(problems) > 0 {
+		return errors.New(strings.Join(problems, "; "))
+	}
+	return nil
+}
+
+func passwordSHA1(password string) string {
+	sum := sha1.Sum([]byte(password))
+	return strings.ToUpper(hex.EncodeToString(sum[:]))
+}
@@ -0,0 +1,50 @@
+package utils
+
+import (
+	"os"
+	"path/filepath"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+)
+
+func TestPasswordPolicy_Check(t *testing.T) {
+	policy, err := LoadPasswordPolicy(PasswordPolicyOptions{
+		MinLength:    10,
+		RequireUpper: true,
+		RequireLower: true,
+		RequireDigit: true,
+	})
+	assert.NoError(t, err)
+
+	assert.NoError(t, policy.Check("Kampus-Merdeka-7", "budi", "budi@example.ac.id"))
+
+	err = policy.Check("")
+	if assert.Error(t, err) {
+		assert.Contains(t, err.Error(), "at least 10 characters")
+		assert.Contains(t, err.Error(), "uppercase")
+		assert.Contains(t, err.Error(), "digit")
+	}
+	assert.Error(t, policy.Check("sangatpanjang123"), "tanpa huruf besar")
+	assert.Error(t, policy.Check("Budisantoso-99", "budisantoso"), "memuat username")
+	assert.Error(t, policy.Check("Xsiti.aminah99", "x", "siti.aminah@example.ac.id"), "memuat nama email")
+}
+
+func TestPasswordPolicy_BreachedList(t *testing.T) {
+	path := filepath.Join(t.TempDir(), "breached.txt")
+	list := "# top passwords\nPassword123\n" +
+		// SHA-1 "Rahasia-2026" format HIBP
+		passwordSHA1("Rahasia-2026") + ":42\n"
+	assert.NoError(t, os.WriteFile(path, []byte(list), 0o600))
+
+	policy, err := LoadPasswordPolicy(PasswordPolicyOptions{MinLength: 8, BreachedFile: path})
+	assert.NoError(t, err)
+	assert.Equal(t, 2, policy.BreachedCount())
+
+	assert.ErrorContains(t, policy.Check("Password123"), "breached")
+	assert.ErrorContains(t, policy.Check("Rahasia-2026"), "breached")
+	assert.NoError(t, policy.Check("Rahasia-2027"))
+
+	_, err = LoadPasswordPolicy(PasswordPolicyOptions{BreachedFile: filepath.Join(t.TempDir(), "tidak-ada.txt")})
+	assert.Error(t, err)
+}
@@ -0,0 +1,16 @@
+package utils
+
+import "github.com/gofiber/fiber/v2"
+
+// Key c.Locals untuk permission user yang sedang login (PermissionSet),
+// diisi middleware RequirePermission
+const PermissionsLocal = "permissions"
+
+type PermissionSet map[string]bool
+
+// HasPermission dipakai handler untuk cakupan data (mis. admin melihat semua).
+// Hanya terisi pada route yang melewati RequirePermission.
+func HasPermission(c *fiber.Ctx, name string) bool {
+	perms, ok := c.Locals(PermissionsLocal).(PermissionSet)
+	return ok && perms[name]
+}
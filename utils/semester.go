@@ -0,0 +1,20 @@
+package utils
+
+import (
+	"fmt"
+	"time"
+)
+
+// SemesterOf mengembalikan semester akademik untuk waktu t, contoh "2025/2026-1".
+// Ganjil (1) = Agustus–Januari, Genap (2) = Februari–Juli.
+func SemesterOf(t time.Time) string {
+	year := t.Year()
+	switch {
+	case t.Month() >= time.August:
+		return fmt.Sprintf("%d/%d-1", year, year+1)
+	case t.Month() == time.January:
+		return fmt.Sprintf("%d/%d-1", year-1, year)
+	default:
+		return fmt.Sprintf("%d/%d-2", year-1, year)
+	}
+}
@@ -0,0 +1,18 @@
+package utils
+
+import (
+	"crypto/sha256"
+	"encoding/hex"
+)
+
+// NewOpaqueToken: token acak 32 byte (base64 URL-safe), mis. refresh token
+func NewOpaqueToken() (string, error) {
+	return GeneratePassword(32)
+}
+
+// HashToken: SHA-256 (hex) dari token. Database hanya menyimpan hash ini,
+// token asli hanya pernah dipegang client.
+func HashToken(token string) string {
+	sum := sha256.Sum256([]byte(token))
+	return hex.EncodeToString(sum[:])
+}
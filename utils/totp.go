@@ -0,0 +1,135 @@
+package utils
+
+import (
+	"crypto/hmac"
+	"crypto/rand"
+	"crypto/sha1"
+	"crypto/subtle"
+	"encoding/base32"
+	"encoding/binary"
+	"fmt"
+	"net/url"
+	"strings"
+	"time"
+)
+
+// TOTP (RFC 6238) dengan parameter default authenticator app:
+// HMAC-SHA1, 6 digit, periode 30 detik.
+const (
+	TOTPDigits = 6
+	TOTPPeriod = 30 * time.Second
+	// toleransi jam HP yang meleset: satu langkah sebelum / sesudah
+	totpSkew = 1
+)
+
+var totpEncoding = base32.StdEncoding.WithPadding(base32.NoPadding)
+
+// NewTOTPSecret: 160 bit acak, base32 tanpa padding (format authenticator app)
+func NewTOTPSecret() (string, error) {
+	b := make([]byte, 20)
+	if _, err := rand.Read(b); err != nil {
+		return "", err
+	}
+	return totpEncoding.EncodeToString(b), nil
+}
+
+// TOTPStep: nomor langkah waktu (counter RFC 4226) untuk t
+func TOTPStep(t time.Time) int64 {
+	return t.Unix() / int64(TOTPPeriod/time.Second)
+}
+
+// TOTPCode menghitung kode untuk secret base32 pada langkah step
+func TOTPCode(secret string, step int64) (string, error) {
+	key, err := decodeTOTPSecret(secret)
+	if err != nil {
+		return "", err
+	}
+	return hotp(key, uint64(step), TOTPDigits), nil
+}
+
+// ValidateTOTP mencocokkan code dengan langkah sekarang ±1. Mengembalikan
+// langkah yang cocok supaya pemanggil bisa menolak kode yang dipakai ulang.
+func ValidateTOTP(secret, code string, now time.Time) (int64, bool) {
+	code = strings.TrimSpace(code)
+	if len(code) != TOTPDigits {
+		return 0, false
+	}
+	key, err := decodeTOTPSecret(secret)
+	if err != nil {
+		return 0, false
+	}
+	current := TOTPStep(now)
+	for step := current - totpSkew; step <= current+totpSkew; step++ {
+		expected := hotp(key, uint64(step), TOTPDigits)
+		if subtle.ConstantTimeCompare([]byte(expected), []byte(code)) == 1 {
+			return step, true
+		}
+	}
+	return 0, false
+}
+
+// TOTPURI: otpauth:// untuk di-render sebagai QR code oleh frontend
+func TOTPURI(issuer, account, secret string) string {
+	label := url.PathEscape(issuer) + ":" + url.PathEscape(account)
+	q := url.Values{}
+	q.Set("secret", secret)
+	q.Set("issuer", issuer)
+	q.Set("algorithm", "SHA1")
+	q.Set("digits", fmt.Sprint(TOTPDigits))
+	q.Set("period", fmt.Sprint(int(TOTPPeriod/time.Second)))
+	return "otpauth://totp/" + label + "?" + q.Encode()
+}
+
+// hotp: RFC 4226 bagian 5.3 (dynamic truncation)
+func hotp(key []byte, counter uint64, digits int) string {
+	var msg [8]byte
+	binary.BigEndian.PutUint64(msg[:], counter)
+	mac := hmac.New(sha1.New, key)
+	mac.Write(msg[:])
+	sum := mac.Sum(nil)
+
+	offset := sum[len(sum)-1] & 0x0f
+	value := binary.BigEndian.Uint32(sum[offset:offset+4]) & 0x7fffffff
+
+	mod := uint32(1)
+	for i := 0; i < digits; i++ {
+		mod *= 10
+	}
+	return fmt.Sprintf("%0*d", digits, value%mod)
+}
+
+func decodeTOTPSecret(secret string) ([]byte, error) {
+	secret = strings.ToUpper(strings.ReplaceAll(strings.TrimSpace(secret), " ", ""))
+	return totpEncoding.DecodeString(strings.TrimRight(secret, "="))
+}
+
+// NewRecoveryCodes: n kode sekali pakai format "xxxx-xxxx"
+func NewRecoveryCodes(n int) ([]string, error) {
+	const alphabet = "abcdefghjkmnpqrstuvwxyz23456789" // tanpa 0/o, 1/l/i
+	// byte >= limit dibuang supaya setiap huruf berpeluang sama
+	limit := byte(256 - 256%len(alphabet))
+	codes := make([]string, 0, n)
+	buf := make([]byte, 1)
+	for i := 0; i < n; i++ {
+		var b strings.Builder
+		for b.Len() < 9 {
+			if b.Len() == 4 {
+				b.WriteByte('-')
+				continue
+			}
+			if _, err := rand.Read(buf); err != nil {
+				return nil, err
+			}
+			if buf[0] < limit {
+				b.WriteByte(alphabet[int(buf[0])%len(alphabet)])
+			}
+		}
+		codes = append(codes, b.String())
+	}
+	return codes, nil
+}
+
+// NormalizeRecoveryCode: huruf kecil, tanpa spasi / tanda hubung (sebelum di-hash)
+func NormalizeRecoveryCode(code string) string {
+	return strings.NewReplacer("-", "", " ", "").Replace(strings.ToLower(strings.TrimSpace(code)))
+}